@@ -9,6 +9,7 @@ import (
 
 	"bisonai.com/miko/node/pkg/reporter"
 	"bisonai.com/miko/node/pkg/utils/loginit"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog/log"
 )
 
@@ -43,6 +44,9 @@ func main() {
 			}
 		})
 
+		http.Handle("GET /metrics", promhttp.Handler())
+		reporter.RegisterTransactionRoutes(http.DefaultServeMux)
+
 		if err := http.ListenAndServe(":"+port, nil); err != nil {
 			log.Fatal().Err(err).Msg("failed to start http server")
 		}
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS rest_calls_api_key_timestamp_idx;
+
+ALTER TABLE keys
+    DROP COLUMN IF EXISTS rate_limit,
+    DROP COLUMN IF EXISTS max_connections,
+    DROP COLUMN IF EXISTS allowed_symbols,
+    DROP COLUMN IF EXISTS monthly_quota;
@@ -0,0 +1,7 @@
+ALTER TABLE keys
+    ADD COLUMN IF NOT EXISTS rate_limit DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS max_connections INT,
+    ADD COLUMN IF NOT EXISTS allowed_symbols TEXT[],
+    ADD COLUMN IF NOT EXISTS monthly_quota BIGINT;
+
+CREATE INDEX IF NOT EXISTS rest_calls_api_key_timestamp_idx ON rest_calls(api_key, timestamp);
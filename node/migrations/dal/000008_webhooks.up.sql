@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS webhooks (
+    id SERIAL PRIMARY KEY,
+    api_key TEXT NOT NULL REFERENCES keys(key) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    symbols TEXT[] NOT NULL,
+    deviation DOUBLE PRECISION,
+    heartbeat INT,
+    secret TEXT NOT NULL,
+    timestamp TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS webhook_dead_letters (
+    id SERIAL PRIMARY KEY,
+    webhook_id INT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    payload JSONB NOT NULL,
+    error TEXT NOT NULL,
+    attempts INT NOT NULL,
+    timestamp TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS webhooks_api_key_idx ON webhooks(api_key);
+CREATE INDEX IF NOT EXISTS webhook_dead_letters_webhook_id_idx ON webhook_dead_letters(webhook_id);
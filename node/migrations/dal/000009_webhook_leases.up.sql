@@ -0,0 +1,2 @@
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS lease_owner TEXT;
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMPTZ;
@@ -0,0 +1,2 @@
+ALTER TABLE configs DROP COLUMN IF EXISTS aggregation_params;
+ALTER TABLE configs DROP COLUMN IF EXISTS aggregation_strategy;
@@ -0,0 +1,2 @@
+ALTER TABLE configs ADD COLUMN IF NOT EXISTS aggregation_strategy TEXT;
+ALTER TABLE configs ADD COLUMN IF NOT EXISTS aggregation_params JSONB;
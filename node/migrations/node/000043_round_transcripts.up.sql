@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS round_transcripts (
+    config_id INT4 NOT NULL,
+    round INT4 NOT NULL,
+    peer TEXT NOT NULL,
+    value INT8,
+    latency_ms INT4,
+    proof BYTEA,
+    median INT8,
+    status TEXT NOT NULL DEFAULT 'completed',
+    reason TEXT,
+    timestamp TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT round_transcripts_config_id_fkey
+        FOREIGN KEY(config_id)
+        REFERENCES configs(id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS round_transcripts_config_id_round_idx ON round_transcripts (config_id, round);
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS raft_states (
+    key TEXT PRIMARY KEY,
+    term INT4 NOT NULL DEFAULT 0,
+    voted_for TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
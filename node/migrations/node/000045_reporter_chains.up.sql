@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS reporter_chains (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    chain_type TEXT NOT NULL DEFAULT 'kaia',
+    provider_urls TEXT[] NOT NULL DEFAULT '{}',
+    contract_address TEXT NOT NULL,
+    reporter_pk_secret TEXT,
+    submit_interval INT4,
+    deviation_threshold FLOAT8,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE
+);
@@ -0,0 +1,2 @@
+ALTER TABLE configs ADD COLUMN IF NOT EXISTS deviation_threshold FLOAT8;
+ALTER TABLE configs ADD COLUMN IF NOT EXISTS heartbeat INTEGER;
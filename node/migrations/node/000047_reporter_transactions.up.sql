@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS reporter_transactions (
+    hash TEXT PRIMARY KEY,
+    chain TEXT NOT NULL,
+    nonce INT8 NOT NULL,
+    gas_price TEXT NOT NULL,
+    status TEXT NOT NULL,
+    pairs TEXT[] NOT NULL DEFAULT '{}',
+    replaced_by TEXT,
+    block_number INT8,
+    gas_used INT8,
+    submitted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    mined_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS reporter_transactions_submitted_at_idx ON reporter_transactions (submitted_at DESC);
+CREATE INDEX IF NOT EXISTS reporter_transactions_pairs_idx ON reporter_transactions USING GIN (pairs);
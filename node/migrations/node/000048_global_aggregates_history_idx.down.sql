@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS global_aggregates_config_id_round_idx;
+DROP INDEX IF EXISTS global_aggregates_config_id_timestamp_idx;
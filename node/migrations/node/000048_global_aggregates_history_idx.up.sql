@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS global_aggregates_config_id_timestamp_idx ON global_aggregates (config_id, timestamp);
+CREATE INDEX IF NOT EXISTS global_aggregates_config_id_round_idx ON global_aggregates (config_id, round);
@@ -15,12 +15,14 @@ import (
 
 	"bisonai.com/miko/node/pkg/admin/utils"
 	"bisonai.com/miko/node/pkg/bus"
+	localFetcher "bisonai.com/miko/node/pkg/fetcher"
 	"github.com/gofiber/fiber/v2"
 	"github.com/rs/zerolog/log"
 )
 
 func Run(ctx context.Context, bus *bus.MessageBus) error {
 	log.Debug().Msg("Starting admin server")
+	config.SetAggregationValidator(validateAggregation)
 	app, err := utils.Setup(ctx, utils.SetupInfo{
 		Version: "0.1.0",
 		Bus:     bus,
@@ -58,5 +60,15 @@ func Run(ctx context.Context, bus *bus.MessageBus) error {
 }
 
 func SyncMikoConfig(ctx context.Context) error {
+	config.SetAggregationValidator(validateAggregation)
 	return config.InitSyncDb(ctx)
 }
+
+func validateAggregation(c config.ConfigInsertModel) error {
+	_, err := localFetcher.NewAggregationStrategy(localFetcher.Config{
+		Name:                c.Name,
+		AggregationStrategy: c.AggregationStrategy,
+		AggregationParams:   c.AggregationParams,
+	})
+	return err
+}
@@ -1,14 +1,45 @@
 package aggregator
 
 import (
+	"encoding/hex"
 	"strconv"
+	"time"
 
 	"bisonai.com/miko/node/pkg/admin/utils"
 	"bisonai.com/miko/node/pkg/bus"
+	"bisonai.com/miko/node/pkg/db"
 	"github.com/gofiber/fiber/v2"
 	"github.com/rs/zerolog/log"
 )
 
+type RoundTranscriptRowModel struct {
+	Peer      string    `db:"peer"`
+	Value     *int64    `db:"value"`
+	LatencyMs *int32    `db:"latency_ms"`
+	Proof     []byte    `db:"proof"`
+	Median    *int64    `db:"median"`
+	Status    string    `db:"status"`
+	Reason    *string   `db:"reason"`
+	Timestamp time.Time `db:"timestamp"`
+}
+
+type RoundSubmissionModel struct {
+	Peer      string `json:"peer"`
+	Value     *int64 `json:"value"`
+	LatencyMs *int32 `json:"latencyMs"`
+	Proof     string `json:"proof"`
+}
+
+type RoundTranscriptModel struct {
+	ConfigID    int32                  `json:"configId"`
+	Round       int32                  `json:"round"`
+	Median      *int64                 `json:"median"`
+	Status      string                 `json:"status"`
+	Reason      *string                `json:"reason,omitempty"`
+	Timestamp   time.Time              `json:"timestamp"`
+	Submissions []RoundSubmissionModel `json:"submissions"`
+}
+
 func start(c *fiber.Ctx) error {
 	msg, err := utils.SendMessage(c, bus.AGGREGATOR, bus.START_AGGREGATOR_APP, nil)
 	if err != nil {
@@ -122,3 +153,51 @@ func getSigner(c *fiber.Ctx) error {
 	}
 	return c.JSON(resp.Args)
 }
+
+func getRound(c *fiber.Ctx) error {
+	configId, err := strconv.ParseInt(c.Params("configId"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("invalid config id: " + err.Error())
+	}
+	round, err := strconv.ParseInt(c.Params("round"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("invalid round: " + err.Error())
+	}
+
+	rows, err := db.QueryRows[RoundTranscriptRowModel](c.Context(), SelectRoundTranscriptQuery, map[string]any{"config_id": configId, "round": round})
+	if err != nil {
+		log.Error().Err(err).Str("Player", "Admin").Msg("failed to execute round transcript query")
+		return c.Status(fiber.StatusInternalServerError).SendString("failed to execute round transcript query: " + err.Error())
+	}
+	if len(rows) == 0 {
+		return c.Status(fiber.StatusNotFound).SendString("round transcript not found")
+	}
+
+	result := RoundTranscriptModel{
+		ConfigID:    int32(configId),
+		Round:       int32(round),
+		Median:      rows[0].Median,
+		Status:      rows[0].Status,
+		Reason:      rows[0].Reason,
+		Timestamp:   rows[0].Timestamp,
+		Submissions: make([]RoundSubmissionModel, 0, len(rows)),
+	}
+	for _, row := range rows {
+		// a round that timed out can still complete later, the completed
+		// transcript is sorted first and shown on its own
+		if row.Status != result.Status {
+			continue
+		}
+		// a row without a peer only records a round without submissions
+		if row.Peer == "" {
+			continue
+		}
+		submission := RoundSubmissionModel{Peer: row.Peer, Value: row.Value, LatencyMs: row.LatencyMs}
+		if len(row.Proof) > 0 {
+			submission.Proof = "0x" + hex.EncodeToString(row.Proof)
+		}
+		result.Submissions = append(result.Submissions, submission)
+	}
+
+	return c.JSON(result)
+}
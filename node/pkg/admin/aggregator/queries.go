@@ -0,0 +1,5 @@
+package aggregator
+
+const (
+	SelectRoundTranscriptQuery = `SELECT peer, value, latency_ms, proof, median, status, reason, timestamp FROM round_transcripts WHERE config_id = @config_id AND round = @round ORDER BY status = 'completed' DESC, peer`
+)
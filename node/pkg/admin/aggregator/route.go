@@ -14,4 +14,5 @@ func Routes(router fiber.Router) {
 	aggregator.Post("/deactivate/:id", deactivate)
 	aggregator.Post("/renew-signer", renewSigner)
 	aggregator.Get("/signer", getSigner)
+	aggregator.Get("/rounds/:configId/:round", getRound)
 }
@@ -3,6 +3,7 @@ package config
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -10,6 +11,7 @@ import (
 
 	"bisonai.com/miko/node/pkg/admin/feed"
 	"bisonai.com/miko/node/pkg/db"
+	errorSentinel "bisonai.com/miko/node/pkg/error"
 	"bisonai.com/miko/node/pkg/utils/request"
 	"github.com/gofiber/fiber/v2"
 	"github.com/rs/zerolog/log"
@@ -34,19 +36,39 @@ type ConfigInsertModel struct {
 	FeedDataFreshness    *int              `db:"feed_data_freshness" json:"feedDataFreshness"`
 	MultiplyBy           *string           `db:"multiply_by" json:"multiplyBy"`
 	MultiplyByReciprocal bool              `db:"multiply_by_reciprocal" json:"multiplyByReciprocal"`
+	AggregationStrategy  *string           `db:"aggregation_strategy" json:"aggregationStrategy"`
+	AggregationParams    json.RawMessage   `db:"aggregation_params" json:"aggregationParams"`
+	DeviationThreshold   *float64          `db:"deviation_threshold" json:"deviationThreshold"`
+	Heartbeat            *int              `db:"heartbeat" json:"heartbeat"`
 	Feeds                []FeedInsertModel `json:"feeds"`
 }
 
 type ConfigModel struct {
-	ID                   int32   `db:"id" json:"id"`
-	Name                 string  `db:"name" json:"name"`
-	FetchInterval        *int    `db:"fetch_interval" json:"fetchInterval"`
-	AggregateInterval    *int    `db:"aggregate_interval" json:"aggregateInterval"`
-	SubmitInterval       *int    `db:"submit_interval" json:"submitInterval"`
-	Decimals             *int    `db:"decimals" json:"decimals"`
-	FeedDataFreshness    *int    `db:"feed_data_freshness" json:"feedDataFreshness"`
-	MultiplyBy           *string `db:"multiply_by" json:"multiplyBy"`
-	MultiplyByReciprocal bool    `db:"multiply_by_reciprocal" json:"multiplyByReciprocal"`
+	ID                   int32           `db:"id" json:"id"`
+	Name                 string          `db:"name" json:"name"`
+	FetchInterval        *int            `db:"fetch_interval" json:"fetchInterval"`
+	AggregateInterval    *int            `db:"aggregate_interval" json:"aggregateInterval"`
+	SubmitInterval       *int            `db:"submit_interval" json:"submitInterval"`
+	Decimals             *int            `db:"decimals" json:"decimals"`
+	FeedDataFreshness    *int            `db:"feed_data_freshness" json:"feedDataFreshness"`
+	MultiplyBy           *string         `db:"multiply_by" json:"multiplyBy"`
+	MultiplyByReciprocal bool            `db:"multiply_by_reciprocal" json:"multiplyByReciprocal"`
+	AggregationStrategy  *string         `db:"aggregation_strategy" json:"aggregationStrategy"`
+	AggregationParams    json.RawMessage `db:"aggregation_params" json:"aggregationParams"`
+	DeviationThreshold   *float64        `db:"deviation_threshold" json:"deviationThreshold"`
+	Heartbeat            *int            `db:"heartbeat" json:"heartbeat"`
+}
+
+// AggregationValidator fails if the aggregation strategy or params of config
+// can't be resolved.
+type AggregationValidator func(config ConfigInsertModel) error
+
+// validateAggregation is replaced by the admin server with the fetcher's
+// strategy resolution, which this package can't import without a cycle.
+var validateAggregation AggregationValidator = func(ConfigInsertModel) error { return nil }
+
+func SetAggregationValidator(validator AggregationValidator) {
+	validateAggregation = validator
 }
 
 type ConfigNameIdModel struct {
@@ -59,7 +81,11 @@ func InitSyncDb(ctx context.Context) error {
 }
 
 func Sync(c *fiber.Ctx) error {
-	return sync(c.Context())
+	err := sync(c.Context())
+	if errors.Is(err, errorSentinel.ErrAdminInvalidAggregation) {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	return err
 }
 
 func sync(ctx context.Context) error {
@@ -72,6 +98,10 @@ func sync(ctx context.Context) error {
 	loadedConfigMap := map[string]ConfigInsertModel{}
 	loadedFeedMap := map[string]FeedInsertModel{}
 	for _, config := range loadedConfigs {
+		if err = validateAggregation(config); err != nil {
+			log.Error().Err(err).Str("Player", "Admin").Str("Config", config.Name).Msg("invalid aggregation strategy in loaded config")
+			return fmt.Errorf("%w: %s: %w", errorSentinel.ErrAdminInvalidAggregation, config.Name, err)
+		}
 		loadedConfigMap[config.Name] = config
 		for _, feed := range config.Feeds {
 			loadedFeedMap[feed.Name] = feed
@@ -172,17 +202,25 @@ func Insert(c *fiber.Ctx) error {
 		return err
 	}
 
+	if err := validateAggregation(*config); err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("invalid aggregation strategy: " + err.Error())
+	}
+
 	setDefaultValues(config)
 
 	result, err := db.QueryRow[ConfigModel](c.Context(), InsertConfigQuery, map[string]any{
-		"name":                    config.Name,
-		"fetch_interval":          config.FetchInterval,
-		"aggregate_interval":      config.AggregateInterval,
-		"submit_interval":         config.SubmitInterval,
-		"decimals":                config.Decimals,
-		"feed_data_freshness":     config.FeedDataFreshness,
-		"multiply_by":             config.MultiplyBy,
-		"multiply_by_reciprocal":  config.MultiplyByReciprocal,
+		"name":                   config.Name,
+		"fetch_interval":         config.FetchInterval,
+		"aggregate_interval":     config.AggregateInterval,
+		"submit_interval":        config.SubmitInterval,
+		"decimals":               config.Decimals,
+		"feed_data_freshness":    config.FeedDataFreshness,
+		"multiply_by":            config.MultiplyBy,
+		"multiply_by_reciprocal": config.MultiplyByReciprocal,
+		"aggregation_strategy":   config.AggregationStrategy,
+		"aggregation_params":     config.AggregationParams,
+		"deviation_threshold":    config.DeviationThreshold,
+		"heartbeat":              config.Heartbeat,
 	})
 	if err != nil {
 		log.Error().Err(err).Str("Player", "Admin").Msg("failed to insert config")
@@ -242,10 +280,10 @@ func getConfigUrl() string {
 func bulkUpsertConfigs(ctx context.Context, configs []ConfigInsertModel) error {
 	upsertRows := make([][]any, 0, len(configs))
 	for _, config := range configs {
-		upsertRows = append(upsertRows, []any{config.Name, config.FetchInterval, config.AggregateInterval, config.SubmitInterval, config.Decimals, config.MultiplyBy, config.MultiplyByReciprocal})
+		upsertRows = append(upsertRows, []any{config.Name, config.FetchInterval, config.AggregateInterval, config.SubmitInterval, config.Decimals, config.MultiplyBy, config.MultiplyByReciprocal, config.AggregationStrategy, config.AggregationParams, config.DeviationThreshold, config.Heartbeat})
 	}
 
-	return db.BulkUpsert(ctx, "configs", []string{"name", "fetch_interval", "aggregate_interval", "submit_interval", "decimals", "multiply_by", "multiply_by_reciprocal"}, upsertRows, []string{"name"}, []string{"fetch_interval", "aggregate_interval", "submit_interval", "decimals", "multiply_by", "multiply_by_reciprocal"})
+	return db.BulkUpsert(ctx, "configs", []string{"name", "fetch_interval", "aggregate_interval", "submit_interval", "decimals", "multiply_by", "multiply_by_reciprocal", "aggregation_strategy", "aggregation_params", "deviation_threshold", "heartbeat"}, upsertRows, []string{"name"}, []string{"fetch_interval", "aggregate_interval", "submit_interval", "decimals", "multiply_by", "multiply_by_reciprocal", "aggregation_strategy", "aggregation_params", "deviation_threshold", "heartbeat"})
 }
 
 func setDefaultValues(config *ConfigInsertModel) {
@@ -52,3 +52,22 @@ func refresh(c *fiber.Ctx) error {
 	}
 	return c.SendString("fetcher refreshed: " + strconv.FormatBool(resp.Success))
 }
+
+// getFeedStatus reports the websocket feeds as the watchdog sees them, stale
+// feeds first.
+func getFeedStatus(c *fiber.Ctx) error {
+	msg, err := utils.SendMessage(c, bus.FETCHER, bus.GET_FEED_STATUS, nil)
+	if err != nil {
+		log.Error().Err(err).Str("Player", "Admin").Msg("failed to send message to fetcher")
+		return c.Status(fiber.StatusInternalServerError).SendString("failed to get feed status: " + err.Error())
+	}
+	resp := <-msg.Response
+	if !resp.Success {
+		errMsg := "unknown error"
+		if e, ok := resp.Args["error"].(string); ok {
+			errMsg = e
+		}
+		return c.Status(fiber.StatusInternalServerError).SendString("failed to get feed status: " + errMsg)
+	}
+	return c.JSON(resp.Args)
+}
@@ -10,4 +10,5 @@ func Routes(router fiber.Router) {
 	fetcher.Post("/start", start)
 	fetcher.Post("/stop", stop)
 	fetcher.Post("/refresh", refresh)
+	fetcher.Get("/watchdog", getFeedStatus)
 }
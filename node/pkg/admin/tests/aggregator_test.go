@@ -6,7 +6,9 @@ import (
 	"strconv"
 	"testing"
 
+	"bisonai.com/miko/node/pkg/admin/aggregator"
 	"bisonai.com/miko/node/pkg/bus"
+	"bisonai.com/miko/node/pkg/db"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -139,3 +141,31 @@ func TestAggregatorGetSigner(t *testing.T) {
 	assert.False(t, res.Rotating)
 	assert.Equal(t, int64(0), res.ExpiresAt)
 }
+
+func TestAggregatorGetRound(t *testing.T) {
+	ctx := context.Background()
+	cleanup, testItems, err := setup(ctx)
+	if err != nil {
+		t.Fatalf("error setting up test: %v", err)
+	}
+	defer cleanup()
+
+	configId := testItems.tmpData.config.ID
+	err = db.QueryWithoutResult(ctx, "INSERT INTO round_transcripts (config_id, round, peer, value, latency_ms, proof, median) VALUES (@config_id, 1, 'peer-a', 100, 5, '\\x01', 100), (@config_id, 1, 'peer-b', -1, 7, NULL, 100)", map[string]any{"config_id": configId})
+	if err != nil {
+		t.Fatalf("error inserting round transcript: %v", err)
+	}
+
+	res, err := GetRequest[aggregator.RoundTranscriptModel](testItems.app, "/api/v1/aggregator/rounds/"+strconv.Itoa(int(configId))+"/1", nil)
+	if err != nil {
+		t.Fatalf("error getting round transcript: %v", err)
+	}
+
+	assert.Equal(t, configId, res.ConfigID)
+	assert.Equal(t, int64(100), *res.Median)
+	assert.Equal(t, "completed", res.Status)
+	assert.Equal(t, 2, len(res.Submissions))
+	assert.Equal(t, "peer-a", res.Submissions[0].Peer)
+	assert.Equal(t, "0x01", res.Submissions[0].Proof)
+	assert.Equal(t, int64(-1), *res.Submissions[1].Value)
+}
@@ -8,6 +8,7 @@ import (
 
 	"bisonai.com/miko/node/pkg/admin/config"
 	"bisonai.com/miko/node/pkg/admin/feed"
+	localFetcher "bisonai.com/miko/node/pkg/fetcher"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -145,3 +146,51 @@ func TestConfigDeleteById(t *testing.T) {
 	assert.Equal(t, 0, len(readResult))
 
 }
+
+func TestConfigInsertInvalidAggregation(t *testing.T) {
+	ctx := context.Background()
+	cleanup, testItems, err := setup(ctx)
+	if err != nil {
+		t.Fatalf("error setting up test: %v", err)
+	}
+	defer func() {
+		err = cleanup()
+		if err != nil {
+			t.Logf("Cleanup failed: %v", err)
+		}
+	}()
+
+	config.SetAggregationValidator(func(c config.ConfigInsertModel) error {
+		_, err := localFetcher.NewAggregationStrategy(localFetcher.Config{Name: c.Name, AggregationStrategy: c.AggregationStrategy, AggregationParams: c.AggregationParams})
+		return err
+	})
+
+	strategy := "unknown"
+	result, err := RawPostRequest(testItems.app, "/api/v1/config", config.ConfigInsertModel{
+		Name:                "test-invalid-aggregation",
+		AggregationStrategy: &strategy,
+	})
+	if err != nil {
+		t.Fatalf("error inserting config: %v", err)
+	}
+	assert.Contains(t, string(result), "invalid aggregation strategy")
+
+	trimmedMean := "trimmed_mean"
+	result, err = RawPostRequest(testItems.app, "/api/v1/config", config.ConfigInsertModel{
+		Name:                "test-invalid-aggregation",
+		AggregationStrategy: &trimmedMean,
+		AggregationParams:   json.RawMessage(`{"trimRatio":0.6}`),
+	})
+	if err != nil {
+		t.Fatalf("error inserting config: %v", err)
+	}
+	assert.Contains(t, string(result), "invalid aggregation strategy")
+
+	readResult, err := GetRequest[[]config.ConfigModel](testItems.app, "/api/v1/config", nil)
+	if err != nil {
+		t.Fatalf("error getting config: %v", err)
+	}
+	for _, c := range readResult {
+		assert.NotEqual(t, "test-invalid-aggregation", c.Name)
+	}
+}
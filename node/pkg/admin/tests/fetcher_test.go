@@ -65,3 +65,25 @@ func TestFetcherRefresh(t *testing.T) {
 
 	assert.Equal(t, string(result), "fetcher refreshed: true")
 }
+
+func TestFetcherWatchdog(t *testing.T) {
+	ctx := context.Background()
+	cleanup, testItems, err := setup(ctx)
+	if err != nil {
+		t.Fatalf("error setting up test: %v", err)
+	}
+	defer cleanup()
+
+	channel := testItems.mb.Subscribe(bus.FETCHER)
+	waitForMessageWithResponse(t, channel, bus.ADMIN, bus.FETCHER, bus.GET_FEED_STATUS, map[string]any{
+		"feeds": []map[string]any{{"feedId": 1, "provider": "binance", "stale": true}},
+	})
+
+	result, err := GetRequest[map[string][]map[string]any](testItems.app, "/api/v1/fetcher/watchdog", nil)
+	if err != nil {
+		t.Fatalf("error getting feed status: %v", err)
+	}
+
+	assert.Len(t, result["feeds"], 1)
+	assert.Equal(t, true, result["feeds"][0]["stale"])
+}
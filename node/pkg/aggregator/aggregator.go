@@ -4,13 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-
+	"errors"
+	"os"
 	"time"
 
 	"bisonai.com/miko/node/pkg/chain/helper"
+	"bisonai.com/miko/node/pkg/common/keys"
 	errorSentinel "bisonai.com/miko/node/pkg/error"
 	"bisonai.com/miko/node/pkg/raft"
-	"bisonai.com/miko/node/pkg/utils/calculator"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/rs/zerolog/log"
@@ -39,9 +40,10 @@ func NewAggregator(h host.Host, ps *pubsub.PubSub, topicString string, config Co
 			locked: map[int32]bool{},
 		},
 		roundPrices: &RoundPrices{
-			prices:  map[int32][]int64{},
-			senders: map[int32][]string{},
-			locked:  map[int32]bool{},
+			prices:    map[int32][]int64{},
+			senders:   map[int32][]string{},
+			latencies: map[int32][]time.Duration{},
+			locked:    map[int32]bool{},
 		},
 		roundPriceFixes: &RoundPriceFixes{
 			locked: map[int32]bool{},
@@ -52,10 +54,13 @@ func NewAggregator(h host.Host, ps *pubsub.PubSub, topicString string, config Co
 			locked:  map[int32]bool{},
 		},
 
-		RoundID:               1,
-		Signer:                signHelper,
-		LatestLocalAggregates: latestLocalAggregates,
+		RoundID:                1,
+		Signer:                 signHelper,
+		ConsensusPolicy:        LoadConsensusPolicy(),
+		RequireConsensusRecord: os.Getenv("AGGREGATOR_REQUIRE_CONSENSUS_RECORD") == "true",
+		LatestLocalAggregates:  latestLocalAggregates,
 	}
+	aggregator.Raft.Store = raft.NewPgsqlStateStore(topicString)
 	aggregator.Raft.LeaderJob = aggregator.LeaderJob
 	aggregator.Raft.HandleCustomMessage = aggregator.HandleCustomMessage
 
@@ -70,6 +75,17 @@ func (n *Aggregator) Run(ctx context.Context) {
 		n.RoundID = latestRoundId + 1
 	}
 
+	submissionTopic, err := n.Raft.Ps.Join(keys.SubmissionDataTopic(n.Name))
+	if err != nil {
+		log.Error().Str("Player", "Aggregator").Err(err).Msg("failed to join submission topic, publishing to redis only")
+	} else {
+		n.submissionTopic.Store(submissionTopic)
+		defer func() {
+			n.submissionTopic.Store(nil)
+			submissionTopic.Close()
+		}()
+	}
+
 	n.Raft.Run(ctx)
 }
 
@@ -172,7 +188,7 @@ func (n *Aggregator) HandlePriceDataMessage(ctx context.Context, msg raft.Messag
 		return nil
 	}
 
-	n.storeRoundPriceData(priceDataMessage.RoundID, priceDataMessage.PriceData, msg.SentFrom)
+	n.storeRoundPriceData(priceDataMessage.RoundID, priceDataMessage.PriceData, msg.SentFrom, time.Since(priceDataMessage.Timestamp))
 
 	if len(n.roundPrices.prices[priceDataMessage.RoundID]) == n.Raft.SubscribersCount()+1 {
 		// if all messsages received for the round
@@ -187,13 +203,15 @@ func (n *Aggregator) HandlePriceDataMessage(ctx context.Context, msg raft.Messag
 	return nil
 }
 
-func (n *Aggregator) storeRoundPriceData(roundID int32, priceData int64, sender string) {
+func (n *Aggregator) storeRoundPriceData(roundID int32, priceData int64, sender string, latency time.Duration) {
 	if prices, ok := n.roundPrices.prices[roundID]; ok {
 		n.roundPrices.prices[roundID] = append(prices, priceData)
 		n.roundPrices.senders[roundID] = append(n.roundPrices.senders[roundID], sender)
+		n.roundPrices.latencies[roundID] = append(n.roundPrices.latencies[roundID], latency)
 	} else {
 		n.roundPrices.prices[roundID] = []int64{priceData}
 		n.roundPrices.senders[roundID] = []string{sender}
+		n.roundPrices.latencies[roundID] = []time.Duration{latency}
 	}
 }
 
@@ -206,12 +224,17 @@ func (n *Aggregator) startPriceCollectionTimeout(ctx context.Context, roundID in
 		n.roundPrices.mu.Lock()
 		defer n.roundPrices.mu.Unlock()
 
-		if !n.roundPrices.locked[roundID] && len(n.roundPrices.prices[roundID]) >= (n.Raft.SubscribersCount()+1)/2 {
-			log.Debug().Str("Player", "Aggregator").Int32("roundId", roundID).Msg("timeout reached, processing available prices")
-			err := n.processCollectedPrices(ctx, roundID, timestamp)
-			if err != nil {
-				log.Error().Err(err).Int32("roundId", roundID).Msg("failed to process collected prices")
-			}
+		if n.roundPrices.locked[roundID] {
+			return
+		}
+		if len(n.roundPrices.prices[roundID]) < n.ConsensusPolicy.RequiredQuorum(n.Raft.SubscribersCount()+1) {
+			n.recordTranscript(roundID, nil, timestamp, TranscriptStatusTimeout, "price quorum not reached", nil, nil)
+			return
+		}
+		log.Debug().Str("Player", "Aggregator").Int32("roundId", roundID).Msg("timeout reached, processing available prices")
+		err := n.processCollectedPrices(ctx, roundID, timestamp)
+		if err != nil {
+			log.Error().Err(err).Int32("roundId", roundID).Msg("failed to process collected prices")
 		}
 	case <-ctx.Done():
 		return
@@ -225,21 +248,40 @@ func (n *Aggregator) processCollectedPrices(ctx context.Context, roundID int32,
 	}
 
 	prices := n.roundPrices.prices[roundID]
+	senders := n.roundPrices.senders[roundID]
 	log.Debug().Str("Player", "Aggregator").Int("peerCount", n.Raft.SubscribersCount()).Str("Name", n.Name).Any("collected prices", prices).Int32("roundId", roundID).Msg("collected prices")
 
-	filteredCollectedPrices := FilterNegative(prices)
-	if len(filteredCollectedPrices) == 0 {
-		log.Warn().Str("Player", "Aggregator").Str("Name", n.Name).Int32("roundId", roundID).Msg("no prices collected")
-		return nil
+	peerPrices := make([]PeerPrice, len(prices))
+	for i, price := range prices {
+		peerPrices[i] = PeerPrice{Peer: senders[i], Value: price}
 	}
 
-	median, err := calculator.GetInt64Med(filteredCollectedPrices)
+	record, err := n.ConsensusPolicy.Evaluate(n.ID, roundID, peerPrices, n.Raft.SubscribersCount()+1)
 	if err != nil {
-		log.Error().Str("Player", "Aggregator").Err(err).Msg("failed to get median")
+		n.recordTranscript(roundID, nil, timestamp, TranscriptStatusFailed, err.Error(), nil, nil)
+		if errors.Is(err, errorSentinel.ErrAggregatorQuorumNotReached) {
+			log.Warn().Str("Player", "Aggregator").Str("Name", n.Name).Int32("roundId", roundID).Msg("not enough valid prices collected")
+			return nil
+		}
+		log.Error().Str("Player", "Aggregator").Err(err).Msg("failed to evaluate collected prices")
 		return err
 	}
 
-	return n.PublishPriceFixMessage(ctx, roundID, median, timestamp)
+	err = record.Sign(n.Raft.GetHostId(), n.Raft.Host.Peerstore().PrivKey(n.Raft.Host.ID()))
+	if err != nil {
+		log.Error().Str("Player", "Aggregator").Err(err).Msg("failed to sign consensus record")
+		n.recordTranscript(roundID, &record.Median, timestamp, TranscriptStatusFailed, err.Error(), nil, nil)
+		return err
+	}
+	if len(record.Excluded) > 0 {
+		log.Warn().Str("Player", "Aggregator").Str("Name", n.Name).Int32("roundId", roundID).Any("excluded", record.Excluded).Msg("peers excluded from round")
+	}
+
+	err = n.PublishPriceFixMessage(ctx, roundID, record.Median, timestamp, &record)
+	if err != nil {
+		n.recordTranscript(roundID, &record.Median, timestamp, TranscriptStatusFailed, err.Error(), nil, nil)
+	}
+	return err
 }
 
 func (n *Aggregator) HandlePriceFixMessage(ctx context.Context, msg raft.Message) error {
@@ -265,6 +307,15 @@ func (n *Aggregator) HandlePriceFixMessage(ctx context.Context, msg raft.Message
 
 	n.roundPriceFixes.locked[priceFixMessage.RoundID] = true
 
+	err = n.checkConsensusRecord(msg.SentFrom, priceFixMessage)
+	if err != nil {
+		log.Error().Str("Player", "Aggregator").Err(err).Str("Sender", msg.SentFrom).Int32("RoundID", priceFixMessage.RoundID).Msg("invalid consensus record, not signing the price fix")
+		n.roundPrices.mu.Lock()
+		n.recordTranscript(priceFixMessage.RoundID, &priceFixMessage.PriceData, priceFixMessage.Timestamp, TranscriptStatusFailed, err.Error(), nil, nil)
+		n.roundPrices.mu.Unlock()
+		return err
+	}
+
 	proof, err := n.Signer.MakeGlobalAggregateProof(priceFixMessage.PriceData, priceFixMessage.Timestamp, n.Name)
 	if err != nil {
 		log.Error().Str("Player", "Aggregator").Err(err).Msg("failed to make global aggregate proof")
@@ -275,6 +326,40 @@ func (n *Aggregator) HandlePriceFixMessage(ctx context.Context, msg raft.Message
 
 }
 
+// checkConsensusRecord verifies the leader's record of the round against the
+// price fix and the prices this node collected for the round itself.
+func (n *Aggregator) checkConsensusRecord(sender string, priceFixMessage PriceFixMessage) error {
+	record := priceFixMessage.Consensus
+	if record == nil {
+		if n.RequireConsensusRecord {
+			return errorSentinel.ErrAggregatorInvalidConsensusRecord
+		}
+		log.Warn().Str("Player", "Aggregator").Str("Sender", sender).Int32("RoundID", priceFixMessage.RoundID).Msg("price fix without consensus record, leader runs an older version")
+		return nil
+	}
+	if err := record.Verify(); err != nil {
+		return err
+	}
+	if record.SignedBy != sender || record.ConfigID != n.ID || record.RoundID != priceFixMessage.RoundID || record.Median != priceFixMessage.PriceData {
+		return errorSentinel.ErrAggregatorInvalidConsensusRecord
+	}
+
+	n.roundPrices.mu.Lock()
+	observed := make([]PeerPrice, len(n.roundPrices.prices[record.RoundID]))
+	for i, price := range n.roundPrices.prices[record.RoundID] {
+		observed[i] = PeerPrice{Peer: n.roundPrices.senders[record.RoundID][i], Value: price}
+	}
+	n.roundPrices.mu.Unlock()
+
+	if err := record.Check(n.ConsensusPolicy, n.Raft.SubscribersCount()+1, observed); err != nil {
+		return err
+	}
+	if len(record.Excluded) > 0 {
+		log.Warn().Str("Player", "Aggregator").Str("Leader", record.SignedBy).Int32("RoundID", record.RoundID).Any("excluded", record.Excluded).Msg("leader excluded peers from round")
+	}
+	return nil
+}
+
 func (n *Aggregator) HandleProofMessage(ctx context.Context, msg raft.Message) error {
 	var proofMessage ProofMessage
 	err := json.Unmarshal(msg.Data, &proofMessage)
@@ -338,12 +423,17 @@ func (n *Aggregator) startProofCollectionTimeout(ctx context.Context, proofMessa
 		n.roundProofs.mu.Lock()
 		defer n.roundProofs.mu.Unlock()
 
-		if !n.roundProofs.locked[proofMessage.RoundID] && len(n.roundProofs.proofs[proofMessage.RoundID]) >= (n.Raft.SubscribersCount()+1)/2 {
-			log.Debug().Str("Player", "Aggregator").Int32("roundId", proofMessage.RoundID).Msg("timeout reached, processing available proofs")
-			err := n.processCollectedProofs(ctx, proofMessage)
-			if err != nil {
-				log.Error().Err(err).Int32("roundId", proofMessage.RoundID).Msg("failed to process collected proofs")
-			}
+		if n.roundProofs.locked[proofMessage.RoundID] {
+			return
+		}
+		if len(n.roundProofs.proofs[proofMessage.RoundID]) < (n.Raft.SubscribersCount()+1)/2 {
+			n.recordProofsTranscript(proofMessage, TranscriptStatusTimeout, "proof quorum not reached")
+			return
+		}
+		log.Debug().Str("Player", "Aggregator").Int32("roundId", proofMessage.RoundID).Msg("timeout reached, processing available proofs")
+		err := n.processCollectedProofs(ctx, proofMessage)
+		if err != nil {
+			log.Error().Err(err).Int32("roundId", proofMessage.RoundID).Msg("failed to process collected proofs")
 		}
 	case <-ctx.Done():
 		log.Debug().Str("Player", "Aggregator").Int32("roundId", proofMessage.RoundID).Msg("context canceled, stopping timeout")
@@ -367,11 +457,50 @@ func (n *Aggregator) processCollectedProofs(ctx context.Context, proofMessage Pr
 	err := PublishGlobalAggregateAndProof(ctx, n.Name, globalAggregate, proof)
 	if err != nil {
 		log.Error().Str("Player", "Aggregator").Err(err).Msg("failed to publish global aggregate and proof")
+		n.recordProofsTranscript(proofMessage, TranscriptStatusFailed, err.Error())
 		return err
 	}
+	n.recordProofsTranscript(proofMessage, TranscriptStatusCompleted, "")
+
+	err = PublishSubmissionData(ctx, n.submissionTopic.Load(), n.Name, globalAggregate, proof)
+	if err != nil {
+		log.Warn().Str("Player", "Aggregator").Err(err).Msg("failed to publish submission data to libp2p topic")
+	}
 
 	return nil
 }
+
+// recordProofsTranscript is called with roundProofs locked.
+func (n *Aggregator) recordProofsTranscript(proofMessage ProofMessage, status string, reason string) {
+	n.roundPrices.mu.Lock()
+	defer n.roundPrices.mu.Unlock()
+	n.recordTranscript(proofMessage.RoundID, &proofMessage.Value, proofMessage.Timestamp, status, reason, n.roundProofs.senders[proofMessage.RoundID], n.roundProofs.proofs[proofMessage.RoundID])
+}
+
+// recordTranscript is called with roundPrices locked.
+func (n *Aggregator) recordTranscript(roundID int32, median *int64, timestamp time.Time, status string, reason string, proofSenders []string, proofs [][]byte) {
+	if n.TranscriptWriter == nil {
+		return
+	}
+	if status != TranscriptStatusCompleted {
+		log.Warn().Str("Player", "Aggregator").Str("Name", n.Name).Int32("roundId", roundID).Str("status", status).Str("reason", reason).Msg("round not completed")
+	}
+
+	n.TranscriptWriter.Record(buildRoundTranscript(
+		n.ID,
+		roundID,
+		median,
+		status,
+		reason,
+		timestamp,
+		n.roundPrices.senders[roundID],
+		n.roundPrices.prices[roundID],
+		n.roundPrices.latencies[roundID],
+		proofSenders,
+		proofs,
+	))
+}
+
 func (n *Aggregator) PublishTriggerMessage(ctx context.Context, roundId int32, timestamp time.Time) error {
 	triggerMessage := TriggerMessage{
 		LeaderID:  n.Raft.GetHostId(),
@@ -416,11 +545,12 @@ func (n *Aggregator) PublishPriceDataMessage(ctx context.Context, roundId int32,
 	return n.Raft.PublishMessage(ctx, message)
 }
 
-func (n *Aggregator) PublishPriceFixMessage(ctx context.Context, roundId int32, value int64, timestamp time.Time) error {
+func (n *Aggregator) PublishPriceFixMessage(ctx context.Context, roundId int32, value int64, timestamp time.Time, consensus *ConsensusRecord) error {
 	priceFixMessage := PriceFixMessage{
 		RoundID:   roundId,
 		PriceData: value,
 		Timestamp: timestamp,
+		Consensus: consensus,
 	}
 
 	marshalledPriceFixMessage, err := json.Marshal(priceFixMessage)
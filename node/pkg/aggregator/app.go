@@ -11,6 +11,7 @@ import (
 	"bisonai.com/miko/node/pkg/db"
 
 	errorSentinel "bisonai.com/miko/node/pkg/error"
+	"bisonai.com/miko/node/pkg/raft"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/rs/zerolog/log"
@@ -27,6 +28,11 @@ func New(bus *bus.MessageBus, h host.Host, ps *pubsub.PubSub) *App {
 }
 
 func (a *App) Run(ctx context.Context) error {
+	if err := raft.CheckPeerAuth(); err != nil {
+		log.Error().Err(err).Str("Player", "Aggregator").Msg("refusing to start without raft peer authentication, set RAFT_PEER_WHITELIST or RAFT_ALLOW_ANY_PEER")
+		return err
+	}
+
 	// Create the singleton Signer BEFORE subscribing to the bus, so a.Signer is assigned
 	// single-threaded before any GET_SIGNER/RENEW_SIGNER handler goroutine can read it. This
 	// closes both the double-create race and the plain-field data race on a.Signer (issue #2516).
@@ -46,6 +52,11 @@ func (a *App) Run(ctx context.Context) error {
 	a.setGlobalAggregateBulkWriter(configs)
 	a.startGlobalAggregateBulkWriter(ctx)
 
+	if os.Getenv("AGGREGATOR_TRANSCRIPT_ENABLED") == "true" {
+		a.TranscriptWriter = NewTranscriptWriter()
+		a.startTranscriptWriter(ctx)
+	}
+
 	err = a.setAggregators(ctx, a.Host, a.Pubsub, configs)
 	if err != nil {
 		log.Error().Err(err).Str("Player", "Aggregator").Msg("failed to set aggregators")
@@ -81,6 +92,19 @@ func (a *App) stopGlobalAggregateBulkWriter() {
 	a.GlobalAggregateBulkWriter.Stop()
 }
 
+func (a *App) startTranscriptWriter(ctx context.Context) {
+	if a.TranscriptWriter != nil {
+		a.TranscriptWriter.Start(ctx)
+	}
+}
+
+// stopTranscriptWriter writes the transcripts still buffered before returning.
+func (a *App) stopTranscriptWriter() {
+	if a.TranscriptWriter != nil {
+		a.TranscriptWriter.Stop()
+	}
+}
+
 func (a *App) setAggregators(ctx context.Context, h host.Host, ps *pubsub.PubSub, configs []Config) error {
 	err := a.clearAggregators()
 	if err != nil {
@@ -154,6 +178,7 @@ func (a *App) initializeLoadedAggregators(ctx context.Context, loadedConfigs []C
 		if err != nil {
 			return err
 		}
+		tmpNode.TranscriptWriter = a.TranscriptWriter
 		a.Aggregators[config.ID] = tmpNode
 
 	}
@@ -353,9 +378,11 @@ func (a *App) handleMessage(ctx context.Context, msg bus.Message) {
 			bus.HandleMessageError(err, msg, "failed to stop all aggregators")
 			return
 		}
+		a.stopTranscriptWriter()
 		msg.Response <- bus.MessageResponse{Success: true}
 	case bus.START_AGGREGATOR_APP:
 		log.Debug().Str("Player", "Aggregator").Msg("start aggregator msg received")
+		a.startTranscriptWriter(ctx)
 		err := a.startAllAggregators(ctx)
 		if err != nil {
 			bus.HandleMessageError(err, msg, "failed to start all aggregators")
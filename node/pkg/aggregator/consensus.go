@@ -0,0 +1,279 @@
+package aggregator
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+
+	errorSentinel "bisonai.com/miko/node/pkg/error"
+	"bisonai.com/miko/node/pkg/utils/calculator"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	DefaultMinQuorum    = 1
+	DefaultMaxDeviation = 0
+
+	// ParticipantsTolerance is how many participants fewer than observed
+	// locally a leader may count, as peers join and leave during a round.
+	ParticipantsTolerance = 1
+
+	ExclusionReasonMissingValue = "missing local aggregate"
+	ExclusionReasonDeviation    = "deviation from median"
+)
+
+// ConsensusPolicy decides which peer submissions take part in a round and
+// whether enough of them arrived to fix a price.
+type ConsensusPolicy struct {
+	// MinQuorum is the absolute minimum number of accepted submissions.
+	MinQuorum int `json:"minQuorum"`
+	// QuorumRatio is the minimum share of participants (subscribers + self)
+	// whose submissions must be accepted.
+	QuorumRatio float64 `json:"quorumRatio"`
+	// MaxDeviation rejects submissions deviating from the median by more than
+	// this percentage. Zero disables outlier rejection.
+	MaxDeviation float64 `json:"maxDeviation"`
+}
+
+type PeerPrice struct {
+	Peer  string `json:"peer"`
+	Value int64  `json:"value"`
+}
+
+type ExcludedPeer struct {
+	Peer   string `json:"peer"`
+	Value  int64  `json:"value"`
+	Reason string `json:"reason"`
+}
+
+// ConsensusRecord is the evidence of how the leader fixed a round's price.
+// It is signed with the leader's libp2p key so it can be attributed to the
+// peer id found in SignedBy.
+type ConsensusRecord struct {
+	ConfigID int32 `json:"configId"`
+	RoundID  int32 `json:"roundId"`
+	// Participants and Policy are what the leader evaluated the round with
+	Participants int             `json:"participants"`
+	Policy       ConsensusPolicy `json:"policy"`
+	// Prices are the submissions the leader evaluated
+	Prices    []PeerPrice    `json:"prices"`
+	Median    int64          `json:"median"`
+	Accepted  []string       `json:"accepted"`
+	Excluded  []ExcludedPeer `json:"excluded"`
+	SignedBy  string         `json:"signedBy"`
+	Signature []byte         `json:"signature,omitempty"`
+}
+
+// LoadConsensusPolicy reads the policy from the environment, defaulting to
+// the legacy behavior of requiring half of the participants.
+func LoadConsensusPolicy() ConsensusPolicy {
+	policy := ConsensusPolicy{
+		MinQuorum:    DefaultMinQuorum,
+		QuorumRatio:  AGREEMENT_QUORUM,
+		MaxDeviation: DefaultMaxDeviation,
+	}
+
+	if raw := os.Getenv("AGGREGATOR_MIN_QUORUM"); raw != "" {
+		if minQuorum, err := strconv.Atoi(raw); err == nil && minQuorum > 0 {
+			policy.MinQuorum = minQuorum
+		}
+	}
+	if raw := os.Getenv("AGGREGATOR_QUORUM_RATIO"); raw != "" {
+		if ratio, err := strconv.ParseFloat(raw, 64); err == nil && ratio > 0 && ratio <= 1 {
+			policy.QuorumRatio = ratio
+		}
+	}
+	if raw := os.Getenv("AGGREGATOR_MAX_DEVIATION_PERCENT"); raw != "" {
+		if deviation, err := strconv.ParseFloat(raw, 64); err == nil && deviation >= 0 {
+			policy.MaxDeviation = deviation
+		}
+	}
+
+	return policy
+}
+
+// RequiredQuorum returns the number of accepted submissions needed to fix a
+// price for the given number of participants.
+func (p ConsensusPolicy) RequiredQuorum(participants int) int {
+	return max(int(float64(participants)*p.QuorumRatio), p.MinQuorum)
+}
+
+// Allows reports whether a leader's policy is at least as strict as p, so
+// that a leader can't lower the quorum or widen the accepted deviation below
+// what this node is configured with.
+func (p ConsensusPolicy) Allows(leader ConsensusPolicy) bool {
+	if leader.MinQuorum < p.MinQuorum || leader.QuorumRatio < p.QuorumRatio || leader.QuorumRatio > 1 || leader.MaxDeviation < 0 {
+		return false
+	}
+	return p.MaxDeviation == 0 || (leader.MaxDeviation > 0 && leader.MaxDeviation <= p.MaxDeviation)
+}
+
+// Evaluate drops missing values, rejects outliers against the median of the
+// remaining values, and checks quorum on what is left. The returned record
+// is unsigned.
+func (p ConsensusPolicy) Evaluate(configID int32, roundID int32, prices []PeerPrice, participants int) (ConsensusRecord, error) {
+	record := ConsensusRecord{
+		ConfigID:     configID,
+		RoundID:      roundID,
+		Participants: participants,
+		Policy:       p,
+		Prices:       prices,
+		Accepted:     []string{},
+		Excluded:     []ExcludedPeer{},
+	}
+
+	candidates := make([]PeerPrice, 0, len(prices))
+	for _, price := range prices {
+		if price.Value < 0 {
+			record.Excluded = append(record.Excluded, ExcludedPeer{Peer: price.Peer, Value: price.Value, Reason: ExclusionReasonMissingValue})
+			continue
+		}
+		candidates = append(candidates, price)
+	}
+
+	if len(candidates) == 0 {
+		return record, errorSentinel.ErrAggregatorQuorumNotReached
+	}
+
+	median, err := medianOf(candidates)
+	if err != nil {
+		return record, err
+	}
+
+	accepted := candidates
+	if p.MaxDeviation > 0 && median != 0 {
+		accepted = make([]PeerPrice, 0, len(candidates))
+		for _, price := range candidates {
+			deviation := math.Abs(float64(price.Value-median)) / math.Abs(float64(median)) * 100
+			if deviation > p.MaxDeviation {
+				record.Excluded = append(record.Excluded, ExcludedPeer{Peer: price.Peer, Value: price.Value, Reason: ExclusionReasonDeviation})
+				continue
+			}
+			accepted = append(accepted, price)
+		}
+	}
+
+	for _, price := range accepted {
+		record.Accepted = append(record.Accepted, price.Peer)
+	}
+
+	if len(accepted) < p.RequiredQuorum(participants) {
+		log.Warn().Str("Player", "Aggregator").Int32("roundId", roundID).Int("accepted", len(accepted)).Int("required", p.RequiredQuorum(participants)).Any("excluded", record.Excluded).Msg("quorum not reached")
+		return record, errorSentinel.ErrAggregatorQuorumNotReached
+	}
+
+	record.Median, err = medianOf(accepted)
+	if err != nil {
+		return record, err
+	}
+	return record, nil
+}
+
+// Check verifies that the leader's policy and participant count are within
+// the local bounds, that the record follows from its prices under them, and
+// that the prices agree with the ones observed locally. Peers missing from
+// either side are tolerated since submissions can arrive late.
+func (r *ConsensusRecord) Check(bounds ConsensusPolicy, participants int, observed []PeerPrice) error {
+	if !bounds.Allows(r.Policy) {
+		log.Warn().Str("Player", "Aggregator").Int32("roundId", r.RoundID).Any("policy", r.Policy).Any("bounds", bounds).Msg("leader policy is laxer than the local policy")
+		return errorSentinel.ErrAggregatorInvalidConsensusRecord
+	}
+	if r.Participants < len(r.Prices) || r.Participants < participants-ParticipantsTolerance {
+		log.Warn().Str("Player", "Aggregator").Int32("roundId", r.RoundID).Int("participants", r.Participants).Int("observed", participants).Msg("leader undercounted participants")
+		return errorSentinel.ErrAggregatorInvalidConsensusRecord
+	}
+
+	expected, err := r.Policy.Evaluate(r.ConfigID, r.RoundID, r.Prices, r.Participants)
+	if err != nil {
+		return errors.Join(errorSentinel.ErrAggregatorInvalidConsensusRecord, err)
+	}
+	if expected.Median != r.Median || !samePeers(expected.Accepted, r.Accepted) || !samePeers(excludedPeers(expected.Excluded), excludedPeers(r.Excluded)) {
+		return errorSentinel.ErrAggregatorInvalidConsensusRecord
+	}
+
+	recorded := make(map[string]int64, len(r.Prices))
+	for _, price := range r.Prices {
+		recorded[price.Peer] = price.Value
+	}
+	for _, price := range observed {
+		if value, ok := recorded[price.Peer]; ok && value != price.Value {
+			return errorSentinel.ErrAggregatorInvalidConsensusRecord
+		}
+	}
+	return nil
+}
+
+func excludedPeers(excluded []ExcludedPeer) []string {
+	peers := make([]string, len(excluded))
+	for i, peer := range excluded {
+		peers[i] = peer.Peer
+	}
+	return peers
+}
+
+func samePeers(a []string, b []string) bool {
+	return slices.Equal(slices.Sorted(slices.Values(a)), slices.Sorted(slices.Values(b)))
+}
+
+func medianOf(prices []PeerPrice) (int64, error) {
+	values := make([]int64, len(prices))
+	for i, price := range prices {
+		values[i] = price.Value
+	}
+	return calculator.GetInt64Med(values)
+}
+
+func (r *ConsensusRecord) signingPayload() ([]byte, error) {
+	unsigned := *r
+	unsigned.Signature = nil
+	return json.Marshal(unsigned)
+}
+
+// Sign signs the record with the given libp2p private key.
+func (r *ConsensusRecord) Sign(signedBy string, key crypto.PrivKey) error {
+	r.SignedBy = signedBy
+	payload, err := r.signingPayload()
+	if err != nil {
+		return err
+	}
+	signature, err := key.Sign(payload)
+	if err != nil {
+		return err
+	}
+	r.Signature = signature
+	return nil
+}
+
+// Verify checks the signature against the public key embedded in SignedBy.
+func (r *ConsensusRecord) Verify() error {
+	if len(r.Signature) == 0 {
+		return errorSentinel.ErrAggregatorInvalidConsensusRecord
+	}
+
+	signer, err := peer.Decode(r.SignedBy)
+	if err != nil {
+		return err
+	}
+	pubKey, err := signer.ExtractPublicKey()
+	if err != nil {
+		return err
+	}
+
+	payload, err := r.signingPayload()
+	if err != nil {
+		return err
+	}
+	ok, err := pubKey.Verify(payload, r.Signature)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errorSentinel.ErrAggregatorInvalidConsensusRecord
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+//nolint:all
+package aggregator
+
+import (
+	"testing"
+
+	errorSentinel "bisonai.com/miko/node/pkg/error"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConsensusPolicy(t *testing.T) {
+	policy := LoadConsensusPolicy()
+	assert.Equal(t, DefaultMinQuorum, policy.MinQuorum)
+	assert.Equal(t, AGREEMENT_QUORUM, policy.QuorumRatio)
+	assert.Equal(t, float64(DefaultMaxDeviation), policy.MaxDeviation)
+
+	t.Setenv("AGGREGATOR_MIN_QUORUM", "3")
+	t.Setenv("AGGREGATOR_QUORUM_RATIO", "0.67")
+	t.Setenv("AGGREGATOR_MAX_DEVIATION_PERCENT", "2.5")
+	policy = LoadConsensusPolicy()
+	assert.Equal(t, 3, policy.MinQuorum)
+	assert.Equal(t, 0.67, policy.QuorumRatio)
+	assert.Equal(t, 2.5, policy.MaxDeviation)
+}
+
+func TestConsensusPolicy_RequiredQuorum(t *testing.T) {
+	policy := ConsensusPolicy{MinQuorum: 1, QuorumRatio: 0.5}
+	assert.Equal(t, 2, policy.RequiredQuorum(5), "legacy behavior requires half of the participants")
+	assert.Equal(t, 1, policy.RequiredQuorum(1))
+
+	policy.MinQuorum = 3
+	assert.Equal(t, 3, policy.RequiredQuorum(2), "absolute quorum should take precedence")
+}
+
+func TestConsensusPolicy_Evaluate(t *testing.T) {
+	policy := ConsensusPolicy{MinQuorum: 1, QuorumRatio: 0.5, MaxDeviation: 5}
+	prices := []PeerPrice{
+		{Peer: "a", Value: 100},
+		{Peer: "b", Value: 101},
+		{Peer: "c", Value: 99},
+		{Peer: "d", Value: 1000},
+		{Peer: "e", Value: -1},
+	}
+
+	record, err := policy.Evaluate(1, 10, prices, len(prices))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), record.Median)
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, record.Accepted)
+	assert.ElementsMatch(t, []ExcludedPeer{
+		{Peer: "e", Value: -1, Reason: ExclusionReasonMissingValue},
+		{Peer: "d", Value: 1000, Reason: ExclusionReasonDeviation},
+	}, record.Excluded)
+}
+
+func TestConsensusPolicy_EvaluateWithoutDeviationLimit(t *testing.T) {
+	policy := ConsensusPolicy{MinQuorum: 1, QuorumRatio: 0.5}
+	record, err := policy.Evaluate(1, 10, []PeerPrice{{Peer: "a", Value: 100}, {Peer: "b", Value: 1000}}, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(550), record.Median)
+	assert.Empty(t, record.Excluded)
+}
+
+func TestConsensusPolicy_EvaluateQuorumNotReached(t *testing.T) {
+	policy := ConsensusPolicy{MinQuorum: 3, QuorumRatio: 0.5, MaxDeviation: 5}
+
+	_, err := policy.Evaluate(1, 10, []PeerPrice{{Peer: "a", Value: 100}, {Peer: "b", Value: 101}, {Peer: "c", Value: 500}}, 3)
+	assert.ErrorIs(t, err, errorSentinel.ErrAggregatorQuorumNotReached)
+
+	_, err = policy.Evaluate(1, 10, []PeerPrice{{Peer: "a", Value: -1}}, 1)
+	assert.ErrorIs(t, err, errorSentinel.ErrAggregatorQuorumNotReached)
+}
+
+func TestConsensusRecord_SignAndVerify(t *testing.T) {
+	priv, _, err := crypto.GenerateEd25519Key(nil)
+	assert.NoError(t, err)
+	id, err := peer.IDFromPrivateKey(priv)
+	assert.NoError(t, err)
+
+	record := ConsensusRecord{
+		ConfigID: 1,
+		RoundID:  10,
+		Median:   100,
+		Accepted: []string{"a", "b"},
+		Excluded: []ExcludedPeer{{Peer: "c", Value: 1000, Reason: ExclusionReasonDeviation}},
+	}
+	assert.ErrorIs(t, record.Verify(), errorSentinel.ErrAggregatorInvalidConsensusRecord)
+
+	assert.NoError(t, record.Sign(id.String(), priv))
+	assert.NoError(t, record.Verify())
+
+	record.Excluded[0].Value = 100
+	assert.ErrorIs(t, record.Verify(), errorSentinel.ErrAggregatorInvalidConsensusRecord, "tampered record should fail verification")
+}
+
+func TestConsensusRecord_Check(t *testing.T) {
+	policy := ConsensusPolicy{MinQuorum: 1, QuorumRatio: 0.5, MaxDeviation: 5}
+	prices := []PeerPrice{{Peer: "a", Value: 100}, {Peer: "b", Value: 101}, {Peer: "c", Value: 1000}}
+	record, err := policy.Evaluate(1, 10, prices, 3)
+	assert.NoError(t, err)
+
+	assert.NoError(t, record.Check(policy, 3, []PeerPrice{{Peer: "a", Value: 100}, {Peer: "c", Value: 1000}}))
+	assert.NoError(t, record.Check(policy, 3, []PeerPrice{{Peer: "d", Value: 100}}), "peers missing from the record are tolerated")
+
+	assert.ErrorIs(t, record.Check(policy, 3, []PeerPrice{{Peer: "c", Value: 102}}), errorSentinel.ErrAggregatorInvalidConsensusRecord, "leader misreported a peer's price")
+
+	tampered := record
+	tampered.Median = 1000
+	assert.ErrorIs(t, tampered.Check(policy, 3, nil), errorSentinel.ErrAggregatorInvalidConsensusRecord)
+
+	tampered = record
+	tampered.Accepted = []string{"a"}
+	tampered.Excluded = append([]ExcludedPeer{{Peer: "b", Value: 101, Reason: ExclusionReasonDeviation}}, record.Excluded...)
+	assert.ErrorIs(t, tampered.Check(policy, 3, nil), errorSentinel.ErrAggregatorInvalidConsensusRecord, "honest peer excluded")
+}
+
+func TestConsensusRecord_CheckLeaderPolicy(t *testing.T) {
+	bounds := ConsensusPolicy{MinQuorum: 1, QuorumRatio: 0.5, MaxDeviation: 5}
+	prices := []PeerPrice{{Peer: "a", Value: 100}, {Peer: "b", Value: 102}, {Peer: "c", Value: 1000}}
+
+	stricter := ConsensusPolicy{MinQuorum: 2, QuorumRatio: 0.6, MaxDeviation: 1}
+	record, err := stricter.Evaluate(1, 10, prices, 3)
+	assert.Error(t, err, "only one price within 1% of the median")
+	stricter.MaxDeviation = 3
+	record, err = stricter.Evaluate(1, 10, prices, 3)
+	assert.NoError(t, err)
+	assert.NoError(t, record.Check(bounds, 3, nil), "re-evaluated with the leader's policy")
+	assert.NoError(t, record.Check(bounds, 4, nil), "a peer joined during the round")
+	assert.ErrorIs(t, record.Check(bounds, 5, nil), errorSentinel.ErrAggregatorInvalidConsensusRecord, "leader undercounted participants")
+
+	laxer := []ConsensusPolicy{
+		{MinQuorum: 0, QuorumRatio: 0.5, MaxDeviation: 5},
+		{MinQuorum: 1, QuorumRatio: 0.3, MaxDeviation: 5},
+		{MinQuorum: 1, QuorumRatio: 0.5, MaxDeviation: 50},
+		{MinQuorum: 1, QuorumRatio: 0.5},
+	}
+	for _, policy := range laxer {
+		record, err := policy.Evaluate(1, 10, prices, 3)
+		assert.NoError(t, err)
+		assert.ErrorIs(t, record.Check(bounds, 3, nil), errorSentinel.ErrAggregatorInvalidConsensusRecord, "leader policy %+v is laxer", policy)
+	}
+
+	record, err = bounds.Evaluate(1, 10, prices, 3)
+	assert.NoError(t, err)
+	record.Participants = 2
+	assert.ErrorIs(t, record.Check(bounds, 2, nil), errorSentinel.ErrAggregatorInvalidConsensusRecord, "fewer participants than prices")
+}
@@ -165,6 +165,7 @@ func aggregatorCleanup(ctx context.Context, admin *fiber.App, app *App) func() e
 
 func TestMain(m *testing.M) {
 	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	os.Setenv("RAFT_ALLOW_ANY_PEER", "true")
 	code := m.Run()
 	db.ClosePool()
 	db.CloseRedis()
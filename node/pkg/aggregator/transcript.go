@@ -0,0 +1,244 @@
+package aggregator
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"bisonai.com/miko/node/pkg/db"
+	"github.com/rs/zerolog/log"
+)
+
+/*
+optional per-round consensus transcripts, bulk copied into pgsql
+*/
+
+const (
+	DefaultTranscriptWriteInterval = 1 * time.Second
+	DefaultTranscriptBufferSize    = 2000
+	DefaultTranscriptFlushTimeout  = 5 * time.Second
+
+	TranscriptStatusCompleted = "completed"
+	TranscriptStatusTimeout   = "timeout"
+	TranscriptStatusFailed    = "failed"
+)
+
+type RoundSubmission struct {
+	Peer    string
+	Value   *int64
+	Latency *time.Duration
+	Proof   []byte
+}
+
+// RoundTranscript is kept for failed rounds as well, Median is empty when the
+// round didn't get to fix a price and Reason tells where it stopped. A round
+// without any submission is stored as a single row with an empty peer.
+type RoundTranscript struct {
+	ConfigID    int32
+	Round       int32
+	Median      *int64
+	Status      string
+	Reason      string
+	Submissions []RoundSubmission
+	Timestamp   time.Time
+}
+
+type TranscriptWriter struct {
+	Buffer        chan RoundTranscript
+	WriteInterval time.Duration
+
+	ctx        context.Context
+	cancelFunc context.CancelFunc
+	done       chan struct{}
+	writeMu    sync.Mutex
+}
+
+type TranscriptWriterConfig struct {
+	WriteInterval time.Duration
+	BufferSize    int
+}
+
+type TranscriptWriterOption func(*TranscriptWriterConfig)
+
+func WithTranscriptWriteInterval(interval time.Duration) TranscriptWriterOption {
+	return func(config *TranscriptWriterConfig) {
+		config.WriteInterval = interval
+	}
+}
+
+func WithTranscriptBufferSize(size int) TranscriptWriterOption {
+	return func(config *TranscriptWriterConfig) {
+		config.BufferSize = size
+	}
+}
+
+func NewTranscriptWriter(opts ...TranscriptWriterOption) *TranscriptWriter {
+	config := &TranscriptWriterConfig{
+		WriteInterval: DefaultTranscriptWriteInterval,
+		BufferSize:    DefaultTranscriptBufferSize,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	return &TranscriptWriter{
+		Buffer:        make(chan RoundTranscript, config.BufferSize),
+		WriteInterval: config.WriteInterval,
+	}
+}
+
+func (w *TranscriptWriter) Start(ctx context.Context) {
+	if w.ctx != nil {
+		log.Debug().Str("Player", "Aggregator").Msg("TranscriptWriter already running")
+		return
+	}
+
+	ctxWithCancel, cancel := context.WithCancel(ctx)
+	w.cancelFunc = cancel
+	w.ctx = ctxWithCancel
+	done := make(chan struct{})
+	w.done = done
+
+	ticker := time.NewTicker(w.WriteInterval)
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-ctxWithCancel.Done():
+				ticker.Stop()
+				w.flush()
+				return
+			case <-ticker.C:
+				if !w.writeMu.TryLock() {
+					continue
+				}
+				go func() {
+					defer w.writeMu.Unlock()
+					w.write(ctxWithCancel)
+				}()
+			}
+		}
+	}()
+}
+
+func (w *TranscriptWriter) Stop() {
+	if w.ctx == nil {
+		log.Debug().Str("Player", "Aggregator").Msg("TranscriptWriter not running")
+		return
+	}
+
+	w.cancelFunc()
+	<-w.done
+	w.cancelFunc = nil
+	w.ctx = nil
+	w.done = nil
+}
+
+// flush writes the transcripts left in the buffer once the writer stops. The
+// writer's context is done by then, so the write gets a context of its own.
+func (w *TranscriptWriter) flush() {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTranscriptFlushTimeout)
+	defer cancel()
+	w.write(ctx)
+}
+
+// Record queues a transcript without blocking the round. Transcripts are
+// dropped when the buffer is full since they are diagnostics only.
+func (w *TranscriptWriter) Record(transcript RoundTranscript) {
+	select {
+	case w.Buffer <- transcript:
+	default:
+		log.Warn().Str("Player", "Aggregator").Int32("configId", transcript.ConfigID).Int32("round", transcript.Round).Msg("transcript buffer full, dropping transcript")
+	}
+}
+
+func (w *TranscriptWriter) write(ctx context.Context) {
+	transcripts := []RoundTranscript{}
+loop:
+	for {
+		select {
+		case transcript := <-w.Buffer:
+			transcripts = append(transcripts, transcript)
+		default:
+			break loop
+		}
+	}
+
+	err := storeTranscripts(ctx, transcripts)
+	if err != nil {
+		log.Error().Err(err).Str("Player", "Aggregator").Msg("failed to store round transcripts")
+	}
+}
+
+func storeTranscripts(ctx context.Context, transcripts []RoundTranscript) error {
+	insertRows := transcriptRows(transcripts)
+	if len(insertRows) == 0 {
+		return nil
+	}
+
+	_, err := db.BulkCopy(ctx, "round_transcripts", []string{"config_id", "round", "peer", "value", "latency_ms", "proof", "median", "status", "reason", "timestamp"}, insertRows)
+	return err
+}
+
+func transcriptRows(transcripts []RoundTranscript) [][]any {
+	insertRows := [][]any{}
+	for _, transcript := range transcripts {
+		var reason *string
+		if transcript.Reason != "" {
+			reason = &transcript.Reason
+		}
+
+		submissions := transcript.Submissions
+		if len(submissions) == 0 {
+			submissions = []RoundSubmission{{}}
+		}
+		for _, submission := range submissions {
+			var latencyMs *int32
+			if submission.Latency != nil {
+				ms := int32(submission.Latency.Milliseconds())
+				latencyMs = &ms
+			}
+			insertRows = append(insertRows, []any{transcript.ConfigID, transcript.Round, submission.Peer, submission.Value, latencyMs, submission.Proof, transcript.Median, transcript.Status, reason, transcript.Timestamp})
+		}
+	}
+	return insertRows
+}
+
+// buildRoundTranscript merges the price and proof submissions of a round by
+// sender. Peers that only sent one of the two keep the other field empty.
+func buildRoundTranscript(configID int32, roundID int32, median *int64, status string, reason string, timestamp time.Time, priceSenders []string, prices []int64, latencies []time.Duration, proofSenders []string, proofs [][]byte) RoundTranscript {
+	transcript := RoundTranscript{
+		ConfigID:    configID,
+		Round:       roundID,
+		Median:      median,
+		Status:      status,
+		Reason:      reason,
+		Timestamp:   timestamp,
+		Submissions: make([]RoundSubmission, 0, len(priceSenders)),
+	}
+
+	index := make(map[string]int, len(priceSenders))
+	for i, sender := range priceSenders {
+		value := prices[i]
+		submission := RoundSubmission{Peer: sender, Value: &value}
+		if i < len(latencies) {
+			latency := latencies[i]
+			submission.Latency = &latency
+		}
+		index[sender] = len(transcript.Submissions)
+		transcript.Submissions = append(transcript.Submissions, submission)
+	}
+
+	for i, sender := range proofSenders {
+		if idx, ok := index[sender]; ok {
+			transcript.Submissions[idx].Proof = proofs[i]
+			continue
+		}
+		transcript.Submissions = append(transcript.Submissions, RoundSubmission{Peer: sender, Proof: proofs[i]})
+	}
+
+	return transcript
+}
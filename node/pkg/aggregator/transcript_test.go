@@ -0,0 +1,106 @@
+//nolint:all
+package aggregator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildRoundTranscript(t *testing.T) {
+	now := time.Now()
+	median := int64(100)
+	transcript := buildRoundTranscript(
+		1,
+		10,
+		&median,
+		TranscriptStatusCompleted,
+		"",
+		now,
+		[]string{"a", "b"},
+		[]int64{100, -1},
+		[]time.Duration{5 * time.Millisecond, 20 * time.Millisecond},
+		[]string{"b", "c"},
+		[][]byte{{0x02}, {0x03}},
+	)
+
+	assert.Equal(t, int32(1), transcript.ConfigID)
+	assert.Equal(t, int32(10), transcript.Round)
+	assert.Equal(t, int64(100), *transcript.Median)
+	assert.Equal(t, TranscriptStatusCompleted, transcript.Status)
+	assert.Equal(t, 3, len(transcript.Submissions))
+
+	a := transcript.Submissions[0]
+	assert.Equal(t, "a", a.Peer)
+	assert.Equal(t, int64(100), *a.Value)
+	assert.Equal(t, 5*time.Millisecond, *a.Latency)
+	assert.Nil(t, a.Proof, "peer without a proof should keep it empty")
+
+	b := transcript.Submissions[1]
+	assert.Equal(t, int64(-1), *b.Value)
+	assert.Equal(t, []byte{0x02}, b.Proof)
+
+	c := transcript.Submissions[2]
+	assert.Equal(t, "c", c.Peer)
+	assert.Nil(t, c.Value, "peer without a price should keep it empty")
+	assert.Equal(t, []byte{0x03}, c.Proof)
+}
+
+func TestTranscriptWriterRecord_DropsWhenFull(t *testing.T) {
+	writer := NewTranscriptWriter(WithTranscriptBufferSize(1))
+	writer.Record(RoundTranscript{ConfigID: 1, Round: 1})
+	writer.Record(RoundTranscript{ConfigID: 1, Round: 2})
+
+	assert.Equal(t, 1, len(writer.Buffer))
+	assert.Equal(t, int32(1), (<-writer.Buffer).Round)
+}
+
+func TestRecordTranscript_Timeout(t *testing.T) {
+	node := &Aggregator{
+		Config:           Config{ID: 1},
+		TranscriptWriter: NewTranscriptWriter(),
+		roundPrices: &RoundPrices{
+			prices:    map[int32][]int64{10: {100}},
+			senders:   map[int32][]string{10: {"a"}},
+			latencies: map[int32][]time.Duration{10: {time.Millisecond}},
+			locked:    map[int32]bool{},
+		},
+	}
+
+	node.recordTranscript(10, nil, time.Now(), TranscriptStatusTimeout, "price quorum not reached", nil, nil)
+
+	transcript := <-node.TranscriptWriter.Buffer
+	assert.Equal(t, int32(10), transcript.Round)
+	assert.Nil(t, transcript.Median, "round without a fixed price has no median")
+	assert.Equal(t, TranscriptStatusTimeout, transcript.Status)
+	assert.Equal(t, "price quorum not reached", transcript.Reason)
+	assert.Equal(t, "a", transcript.Submissions[0].Peer)
+}
+
+func TestTranscriptRows_EmptyRound(t *testing.T) {
+	now := time.Now()
+	rows := transcriptRows([]RoundTranscript{
+		{ConfigID: 1, Round: 10, Status: TranscriptStatusTimeout, Reason: "price quorum not reached", Timestamp: now},
+		{ConfigID: 1, Round: 11, Status: TranscriptStatusCompleted, Timestamp: now, Submissions: []RoundSubmission{{Peer: "a"}, {Peer: "b"}}},
+	})
+
+	assert.Equal(t, 3, len(rows))
+	assert.Equal(t, int32(10), rows[0][1])
+	assert.Equal(t, "", rows[0][2], "round without submissions is kept as a row without a peer")
+	assert.Equal(t, TranscriptStatusTimeout, rows[0][7])
+	assert.Equal(t, "price quorum not reached", *rows[0][8].(*string))
+	assert.Equal(t, "a", rows[1][2])
+	assert.Nil(t, rows[1][8])
+}
+
+func TestTranscriptWriterStop_Flushes(t *testing.T) {
+	writer := NewTranscriptWriter(WithTranscriptWriteInterval(time.Hour))
+	writer.Start(context.Background())
+	writer.Record(RoundTranscript{ConfigID: 1, Round: 1})
+
+	writer.Stop()
+	assert.Equal(t, 0, len(writer.Buffer), "stop writes what is left in the buffer")
+	assert.Nil(t, writer.ctx)
+}
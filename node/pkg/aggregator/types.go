@@ -3,6 +3,7 @@ package aggregator
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"bisonai.com/miko/node/pkg/bus"
@@ -42,6 +43,7 @@ type App struct {
 	Bus                       *bus.MessageBus
 	Aggregators               map[int32]*Aggregator
 	GlobalAggregateBulkWriter *GlobalAggregateBulkWriter
+	TranscriptWriter          *TranscriptWriter
 	Host                      host.Host
 	Pubsub                    *pubsub.PubSub
 	Signer                    *helper.Signer
@@ -75,10 +77,11 @@ func (r *RoundTriggers) leaveOnlyLast10Entries(roundID int32) {
 }
 
 type RoundPrices struct {
-	senders map[int32][]string
-	prices  map[int32][]int64
-	locked  map[int32]bool
-	mu      sync.Mutex
+	senders   map[int32][]string
+	prices    map[int32][]int64
+	latencies map[int32][]time.Duration
+	locked    map[int32]bool
+	mu        sync.Mutex
 }
 
 func (r *RoundPrices) isReplay(roundID int32, sender string) bool {
@@ -110,6 +113,14 @@ func (r *RoundPrices) leaveOnlyLast10Entries(roundID int32) {
 	}
 	r.prices = newPrices
 
+	newLatencies := make(map[int32][]time.Duration)
+	for i := roundID; i > roundID-10; i-- {
+		if val, exists := r.latencies[i]; exists {
+			newLatencies[i] = val
+		}
+	}
+	r.latencies = newLatencies
+
 	newSenders := make(map[int32][]string)
 	for i := roundID; i > roundID-10; i-- {
 		if val, exists := r.senders[i]; exists {
@@ -192,8 +203,17 @@ type Aggregator struct {
 	roundPriceFixes       *RoundPriceFixes
 	roundProofs           *RoundProofs
 
-	RoundID int32
-	Signer  *helper.Signer
+	RoundID          int32
+	Signer           *helper.Signer
+	ConsensusPolicy  ConsensusPolicy
+	TranscriptWriter *TranscriptWriter
+	// RequireConsensusRecord rejects price fixes from leaders that predate
+	// consensus records, they are accepted with a warning until then
+	RequireConsensusRecord bool
+
+	// submissionTopic carries the submission data of the aggregator to DALs
+	// subscribed over libp2p, it is only joined while running
+	submissionTopic atomic.Pointer[pubsub.Topic]
 
 	nodeCtx    context.Context
 	nodeCancel context.CancelFunc
@@ -209,9 +229,10 @@ type PriceDataMessage struct {
 }
 
 type PriceFixMessage struct {
-	RoundID   int32     `json:"roundID"`
-	PriceData int64     `json:"priceData"`
-	Timestamp time.Time `json:"timestamp"`
+	RoundID   int32            `json:"roundID"`
+	PriceData int64            `json:"priceData"`
+	Timestamp time.Time        `json:"timestamp"`
+	Consensus *ConsensusRecord `json:"consensus,omitempty"`
 }
 
 type ProofMessage struct {
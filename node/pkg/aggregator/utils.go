@@ -2,22 +2,13 @@ package aggregator
 
 import (
 	"context"
+	"encoding/json"
 
 	"bisonai.com/miko/node/pkg/common/keys"
 	"bisonai.com/miko/node/pkg/db"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
 )
 
-func FilterNegative(values []int64) []int64 {
-	result := make([]int64, 0, len(values))
-	for _, value := range values {
-		if value < 0 {
-			continue
-		}
-		result = append(result, value)
-	}
-	return result
-}
-
 func PublishGlobalAggregateAndProof(ctx context.Context, name string, globalAggregate GlobalAggregate, proof Proof) error {
 	if globalAggregate.Value == 0 || globalAggregate.Timestamp.IsZero() {
 		return nil
@@ -30,6 +21,23 @@ func PublishGlobalAggregateAndProof(ctx context.Context, name string, globalAggr
 	return db.Publish(ctx, keys.SubmissionDataStreamKey(name), data)
 }
 
+// PublishSubmissionData publishes the global aggregate and proof on the
+// libp2p submission topic of name, which the DAL can subscribe to directly.
+func PublishSubmissionData(ctx context.Context, topic *pubsub.Topic, name string, globalAggregate GlobalAggregate, proof Proof) error {
+	if topic == nil || globalAggregate.Value == 0 || globalAggregate.Timestamp.IsZero() {
+		return nil
+	}
+	data, err := json.Marshal(SubmissionData{
+		Symbol:          name,
+		GlobalAggregate: globalAggregate,
+		Proof:           proof,
+	})
+	if err != nil {
+		return err
+	}
+	return topic.Publish(ctx, data)
+}
+
 func getLatestRoundId(ctx context.Context, configId int32) (int32, error) {
 	result, err := db.QueryRow[GlobalAggregate](ctx, SelectLatestGlobalAggregateQuery, map[string]any{"config_id": configId})
 	if err != nil {
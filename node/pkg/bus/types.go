@@ -16,6 +16,7 @@ const (
 	START_FETCHER_APP   = "start_fetcher_app"
 	STOP_FETCHER_APP    = "stop_fetcher_app"
 	REFRESH_FETCHER_APP = "refresh_fetcher_app"
+	GET_FEED_STATUS     = "get_feed_status"
 
 	ACTIVATE_FETCHER   = "activate_fetcher"
 	DEACTIVATE_FETCHER = "deactivate_fetcher"
@@ -3,6 +3,7 @@ package helper
 import (
 	"context"
 	"crypto/ecdsa"
+	"errors"
 	"math/big"
 	"os"
 	"strings"
@@ -96,6 +97,8 @@ func NewChainHelper(ctx context.Context, opts ...ChainHelperOption) (*ChainHelpe
 		chainID:      chainID,
 		delegatorUrl: delegatorUrl,
 		noncemanager: nonceManager,
+		gasStrategy:  utils.LoadGasPriceStrategy(),
+		tracker:      NewTxTracker(chainLabel(chainID), primaryClient, config.TxStore),
 	}, nil
 }
 
@@ -204,6 +207,113 @@ func (t *ChainHelper) SubmitDelegatedFallbackDirect(ctx context.Context, contrac
 	return utils.SubmitRawTx(ctx, t.client, tx)
 }
 
+// EstimateGas estimates the gas of a call sent from the reporter wallet.
+func (t *ChainHelper) EstimateGas(ctx context.Context, contractAddress, functionString string, args ...interface{}) (uint64, error) {
+	from, err := t.PublicAddress()
+	if err != nil {
+		return 0, err
+	}
+	return utils.EstimateCallGas(ctx, t.client, contractAddress, from, functionString, args...)
+}
+
+// SubmitWithGasStrategy submits like SubmitDelegatedFallbackDirect, pricing
+// the tx with the gas price strategy. If the tx isn't mined in time it is
+// replaced under the same nonce with a bumped price, up to MaxBumps times.
+func (t *ChainHelper) SubmitWithGasStrategy(ctx context.Context, contractAddress, functionString string, gasLimit uint64, args ...interface{}) error {
+	return t.SubmitTracked(ctx, nil, contractAddress, functionString, gasLimit, args...)
+}
+
+// SubmitTracked is SubmitWithGasStrategy recording every sent tx, labeled
+// with pairs, in the tx tracker. It only returns nil once one of the txs is
+// mined successfully.
+func (t *ChainHelper) SubmitTracked(ctx context.Context, pairs []string, contractAddress, functionString string, gasLimit uint64, args ...interface{}) error {
+	nonce := t.noncemanager.GetNonce()
+	log.Debug().Uint64("nonce", nonce).Msg("nonce")
+
+	suggested, err := t.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return err
+	}
+	gasPrice := t.gasStrategy.Price(suggested)
+
+	sent := []common.Hash{}
+	for bumps := 0; ; bumps++ {
+		tx, err := t.makeTxWithGas(ctx, contractAddress, functionString, nonce, gasPrice, gasLimit, args...)
+		if err != nil {
+			return err
+		}
+
+		t.tracker.Track(tx, pairs)
+		err = utils.SendRawTx(ctx, t.client, tx)
+		if err != nil {
+			t.tracker.Drop(tx.Hash())
+			if utils.IsNonceError(err) && t.confirmMined(ctx, sent) {
+				// an earlier attempt made it in before its replacement
+				return nil
+			}
+			return err
+		}
+		sent = append(sent, tx.Hash())
+
+		receipt, err := utils.WaitRawTxMined(ctx, t.client, tx)
+		if err == nil {
+			t.tracker.Confirm(tx.Hash(), receipt)
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				log.Error().Str("tx", receipt.TxHash.String()).Msg("tx failed")
+				return errorSentinel.ErrChainTransactionFail
+			}
+			return nil
+		}
+
+		// the tx stays pending in the tracker, it can still be mined or replaced
+		if !errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil || bumps >= t.gasStrategy.MaxBumps {
+			return err
+		}
+
+		bumped := t.gasStrategy.Bump(gasPrice)
+		if bumped.Cmp(gasPrice) <= 0 {
+			log.Warn().Str("Player", "ChainHelper").Str("gasPrice", gasPrice.String()).Msg("gas price ceiling reached, not replacing stuck tx")
+			return err
+		}
+		log.Warn().Str("Player", "ChainHelper").Uint64("nonce", nonce).Str("gasPrice", bumped.String()).Msg("tx not mined in time, replacing with bumped gas price")
+		gasPrice = bumped
+	}
+}
+
+// TrackTransactions polls the receipts of pending txs until ctx is done.
+func (t *ChainHelper) TrackTransactions(ctx context.Context) {
+	t.tracker.Run(ctx, DefaultTxPollInterval)
+}
+
+func (t *ChainHelper) makeTxWithGas(ctx context.Context, contractAddress, functionString string, nonce uint64, gasPrice *big.Int, gasLimit uint64, args ...interface{}) (*types.Transaction, error) {
+	tx, err := utils.MakeFeeDelegatedTxWithGas(ctx, t.client, contractAddress, t.wallet, functionString, t.chainID, nonce, gasPrice, gasLimit, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	signed, err := t.GetSignedFromDelegator(tx)
+	if err != nil {
+		return utils.MakeDirectTxWithGas(ctx, t.client, contractAddress, t.wallet, functionString, t.chainID, nonce, gasPrice, gasLimit, args...)
+	}
+	return signed, nil
+}
+
+// confirmMined reports whether one of the hashes was mined successfully,
+// resolving it in the tracker.
+func (t *ChainHelper) confirmMined(ctx context.Context, hashes []common.Hash) bool {
+	for _, hash := range hashes {
+		receipt, err := t.client.TransactionReceipt(ctx, hash)
+		if err != nil || receipt == nil {
+			continue
+		}
+		t.tracker.Confirm(hash, receipt)
+		if receipt.Status == types.ReceiptStatusSuccessful {
+			return true
+		}
+	}
+	return false
+}
+
 func (t *ChainHelper) SubmitDirect(ctx context.Context, contractAddress, functionString string, args ...interface{}) error {
 	tx, err := t.MakeDirectTx(ctx, contractAddress, functionString, args...)
 	if err != nil {
@@ -0,0 +1,273 @@
+package helper
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+
+	"bisonai.com/miko/node/pkg/chain/utils"
+	"bisonai.com/miko/node/pkg/db"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	TxStatusPending  = "pending"
+	TxStatusMined    = "mined"
+	TxStatusReverted = "reverted"
+	TxStatusReplaced = "replaced"
+	TxStatusDropped  = "dropped"
+
+	DefaultTxPollInterval = 5 * time.Second
+	// DefaultTxDropTimeout is how long a tx without receipt stays pending
+	// before it is considered dropped from the mempool
+	DefaultTxDropTimeout = 10 * time.Minute
+	TxPersistTimeout     = 3 * time.Second
+
+	UpsertTransactionQuery = `INSERT INTO reporter_transactions (hash, chain, nonce, gas_price, status, pairs, replaced_by, block_number, gas_used, submitted_at, mined_at)
+		VALUES (@hash, @chain, @nonce, @gas_price, @status, @pairs, @replaced_by, @block_number, @gas_used, @submitted_at, @mined_at)
+		ON CONFLICT (hash) DO UPDATE SET status = EXCLUDED.status, replaced_by = EXCLUDED.replaced_by, block_number = EXCLUDED.block_number, gas_used = EXCLUDED.gas_used, mined_at = EXCLUDED.mined_at`
+)
+
+var (
+	txSubmittedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "chain_tx_submitted_total",
+		Help: "Total number of submitted transactions",
+	}, []string{"chain"})
+	txFinalizedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "chain_tx_finalized_total",
+		Help: "Total number of transactions that left the pending state, by final status",
+	}, []string{"chain", "status"})
+	txPending = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "chain_tx_pending",
+		Help: "Current number of submitted transactions without receipt",
+	}, []string{"chain"})
+	txConfirmationSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "chain_tx_confirmation_seconds",
+		Help:    "Time from submission until a transaction is mined",
+		Buckets: []float64{1, 2, 5, 10, 20, 30, 60, 120, 300},
+	}, []string{"chain"})
+)
+
+// TrackedTx is the lifecycle record of a submitted transaction. Pairs are
+// the labels given by the submitter, so a value can be traced to the tx that
+// put it on chain.
+type TrackedTx struct {
+	Hash        string     `db:"hash" json:"hash"`
+	Chain       string     `db:"chain" json:"chain"`
+	Nonce       int64      `db:"nonce" json:"nonce"`
+	GasPrice    string     `db:"gas_price" json:"gasPrice"`
+	Status      string     `db:"status" json:"status"`
+	Pairs       []string   `db:"pairs" json:"pairs"`
+	ReplacedBy  *string    `db:"replaced_by" json:"replacedBy"`
+	BlockNumber *int64     `db:"block_number" json:"blockNumber"`
+	GasUsed     *int64     `db:"gas_used" json:"gasUsed"`
+	SubmittedAt time.Time  `db:"submitted_at" json:"submittedAt"`
+	MinedAt     *time.Time `db:"mined_at" json:"minedAt"`
+}
+
+type TxStore interface {
+	Save(ctx context.Context, tx TrackedTx) error
+}
+
+// PgsqlTxStore keeps tx records in the reporter_transactions table.
+type PgsqlTxStore struct{}
+
+func NewPgsqlTxStore() *PgsqlTxStore {
+	return &PgsqlTxStore{}
+}
+
+func (s *PgsqlTxStore) Save(ctx context.Context, tx TrackedTx) error {
+	return db.QueryWithoutResult(ctx, UpsertTransactionQuery, map[string]any{
+		"hash":         tx.Hash,
+		"chain":        tx.Chain,
+		"nonce":        tx.Nonce,
+		"gas_price":    tx.GasPrice,
+		"status":       tx.Status,
+		"pairs":        tx.Pairs,
+		"replaced_by":  tx.ReplacedBy,
+		"block_number": tx.BlockNumber,
+		"gas_used":     tx.GasUsed,
+		"submitted_at": tx.SubmittedAt,
+		"mined_at":     tx.MinedAt,
+	})
+}
+
+// TxTracker follows submitted txs until they are mined, reverted, replaced by
+// a tx with the same nonce, or dropped. Txs that outlive the submitter's wait
+// are resolved by Run polling their receipts.
+type TxTracker struct {
+	chain       string
+	client      utils.ClientInterface
+	store       TxStore
+	dropTimeout time.Duration
+
+	mu      sync.Mutex
+	pending map[common.Hash]*TrackedTx
+}
+
+func NewTxTracker(chain string, client utils.ClientInterface, store TxStore) *TxTracker {
+	return &TxTracker{
+		chain:       chain,
+		client:      client,
+		store:       store,
+		dropTimeout: DefaultTxDropTimeout,
+		pending:     make(map[common.Hash]*TrackedTx),
+	}
+}
+
+func (t *TxTracker) Track(tx *types.Transaction, pairs []string) TrackedTx {
+	record := &TrackedTx{
+		Hash:        tx.Hash().Hex(),
+		Chain:       t.chain,
+		Nonce:       int64(tx.Nonce()),
+		GasPrice:    tx.GasPrice().String(),
+		Status:      TxStatusPending,
+		Pairs:       pairs,
+		SubmittedAt: time.Now(),
+	}
+	if record.Pairs == nil {
+		record.Pairs = []string{}
+	}
+
+	t.mu.Lock()
+	t.pending[tx.Hash()] = record
+	t.mu.Unlock()
+
+	txSubmittedTotal.WithLabelValues(t.chain).Inc()
+	txPending.WithLabelValues(t.chain).Inc()
+	t.persist(*record)
+	return *record
+}
+
+// Confirm resolves a tx by its receipt. Other pending txs with the same nonce
+// can't be mined anymore and are marked as replaced by it.
+func (t *TxTracker) Confirm(hash common.Hash, receipt *types.Receipt) {
+	t.mu.Lock()
+	record, ok := t.pending[hash]
+	if !ok {
+		t.mu.Unlock()
+		return
+	}
+
+	status := TxStatusMined
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		status = TxStatusReverted
+	}
+	minedAt := time.Now()
+	record.MinedAt = &minedAt
+	gasUsed := int64(receipt.GasUsed)
+	record.GasUsed = &gasUsed
+	// kaia receipts carry no block number, the emitted logs do
+	if len(receipt.Logs) > 0 {
+		blockNumber := int64(receipt.Logs[0].BlockNumber)
+		record.BlockNumber = &blockNumber
+	}
+	resolved := []TrackedTx{t.resolve(hash, status)}
+
+	for otherHash, other := range t.pending {
+		if other.Nonce != record.Nonce {
+			continue
+		}
+		replacedBy := record.Hash
+		other.ReplacedBy = &replacedBy
+		resolved = append(resolved, t.resolve(otherHash, TxStatusReplaced))
+	}
+	t.mu.Unlock()
+
+	txConfirmationSeconds.WithLabelValues(t.chain).Observe(minedAt.Sub(record.SubmittedAt).Seconds())
+	for _, r := range resolved {
+		t.persist(r)
+	}
+}
+
+// Drop marks a tx that never made it into the mempool or was evicted from it.
+func (t *TxTracker) Drop(hash common.Hash) {
+	t.mu.Lock()
+	if _, ok := t.pending[hash]; !ok {
+		t.mu.Unlock()
+		return
+	}
+	record := t.resolve(hash, TxStatusDropped)
+	t.mu.Unlock()
+
+	t.persist(record)
+}
+
+func (t *TxTracker) PendingCount() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.pending)
+}
+
+// Run polls the receipts of pending txs until ctx is done.
+func (t *TxTracker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.poll(ctx)
+		}
+	}
+}
+
+func (t *TxTracker) poll(ctx context.Context) {
+	t.mu.Lock()
+	hashes := make([]common.Hash, 0, len(t.pending))
+	submittedAt := make([]time.Time, 0, len(t.pending))
+	for hash, record := range t.pending {
+		hashes = append(hashes, hash)
+		submittedAt = append(submittedAt, record.SubmittedAt)
+	}
+	t.mu.Unlock()
+
+	for i, hash := range hashes {
+		receipt, err := t.client.TransactionReceipt(ctx, hash)
+		if err == nil && receipt != nil {
+			t.Confirm(hash, receipt)
+			continue
+		}
+		if time.Since(submittedAt[i]) > t.dropTimeout {
+			log.Warn().Str("Player", "ChainHelper").Str("tx", hash.Hex()).Msg("tx without receipt past drop timeout, marking as dropped")
+			t.Drop(hash)
+		}
+	}
+}
+
+// resolve moves a pending tx to its final status, t.mu has to be held.
+func (t *TxTracker) resolve(hash common.Hash, status string) TrackedTx {
+	record := t.pending[hash]
+	record.Status = status
+	delete(t.pending, hash)
+
+	txPending.WithLabelValues(t.chain).Dec()
+	txFinalizedTotal.WithLabelValues(t.chain, status).Inc()
+	return *record
+}
+
+func (t *TxTracker) persist(record TrackedTx) {
+	if t.store == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), TxPersistTimeout)
+	defer cancel()
+	if err := t.store.Save(ctx, record); err != nil {
+		log.Warn().Str("Player", "ChainHelper").Str("tx", record.Hash).Err(err).Msg("failed to persist tx record")
+	}
+}
+
+func chainLabel(chainID *big.Int) string {
+	if chainID == nil {
+		return ""
+	}
+	return chainID.String()
+}
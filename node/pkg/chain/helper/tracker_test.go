@@ -0,0 +1,114 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"bisonai.com/miko/node/pkg/chain/utils"
+	"github.com/kaiachain/kaia/blockchain/types"
+	"github.com/kaiachain/kaia/common"
+)
+
+type fakeReceiptClient struct {
+	utils.ClientInterface
+	receipts map[common.Hash]*types.Receipt
+}
+
+func (c *fakeReceiptClient) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	receipt, ok := c.receipts[hash]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return receipt, nil
+}
+
+type fakeTxStore struct {
+	mu      sync.Mutex
+	records map[string]TrackedTx
+}
+
+func (s *fakeTxStore) Save(ctx context.Context, tx TrackedTx) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[tx.Hash] = tx
+	return nil
+}
+
+func (s *fakeTxStore) get(hash common.Hash) TrackedTx {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.records[hash.Hex()]
+}
+
+func newTestTx(nonce uint64, gasPrice int64) *types.Transaction {
+	return types.NewTransaction(nonce, common.Address{}, big.NewInt(0), 100000, big.NewInt(gasPrice), nil)
+}
+
+func newTestTracker() (*TxTracker, *fakeReceiptClient, *fakeTxStore) {
+	client := &fakeReceiptClient{receipts: map[common.Hash]*types.Receipt{}}
+	store := &fakeTxStore{records: map[string]TrackedTx{}}
+	return NewTxTracker("test", client, store), client, store
+}
+
+func TestTxTrackerConfirmMarksReplaced(t *testing.T) {
+	tracker, _, store := newTestTracker()
+
+	stuck := newTestTx(1, 100)
+	replacement := newTestTx(1, 110)
+	other := newTestTx(2, 100)
+	tracker.Track(stuck, []string{"BTC-USDT"})
+	tracker.Track(replacement, []string{"BTC-USDT"})
+	tracker.Track(other, []string{"ETH-USDT"})
+
+	if got := store.get(stuck.Hash()).Status; got != TxStatusPending {
+		t.Fatalf("expected pending after track, got %s", got)
+	}
+
+	tracker.Confirm(replacement.Hash(), &types.Receipt{Status: types.ReceiptStatusSuccessful, GasUsed: 21000})
+
+	if got := store.get(replacement.Hash()); got.Status != TxStatusMined || got.MinedAt == nil || *got.GasUsed != 21000 {
+		t.Fatalf("expected mined record with gas used, got %+v", got)
+	}
+	stuckRecord := store.get(stuck.Hash())
+	if stuckRecord.Status != TxStatusReplaced || stuckRecord.ReplacedBy == nil || *stuckRecord.ReplacedBy != replacement.Hash().Hex() {
+		t.Fatalf("expected stuck tx to be replaced, got %+v", stuckRecord)
+	}
+	if got := store.get(other.Hash()).Status; got != TxStatusPending {
+		t.Fatalf("expected tx with other nonce to stay pending, got %s", got)
+	}
+	if tracker.PendingCount() != 1 {
+		t.Fatalf("expected 1 pending tx, got %d", tracker.PendingCount())
+	}
+}
+
+func TestTxTrackerPoll(t *testing.T) {
+	tracker, client, store := newTestTracker()
+
+	reverted := newTestTx(1, 100)
+	lost := newTestTx(2, 100)
+	waiting := newTestTx(3, 100)
+	tracker.Track(reverted, nil)
+	tracker.Track(lost, nil)
+	tracker.Track(waiting, nil)
+
+	client.receipts[reverted.Hash()] = &types.Receipt{Status: types.ReceiptStatusFailed}
+	tracker.mu.Lock()
+	tracker.pending[lost.Hash()].SubmittedAt = time.Now().Add(-2 * tracker.dropTimeout)
+	tracker.mu.Unlock()
+
+	tracker.poll(context.Background())
+
+	if got := store.get(reverted.Hash()).Status; got != TxStatusReverted {
+		t.Fatalf("expected reverted, got %s", got)
+	}
+	if got := store.get(lost.Hash()).Status; got != TxStatusDropped {
+		t.Fatalf("expected dropped, got %s", got)
+	}
+	if got := store.get(waiting.Hash()).Status; got != TxStatusPending {
+		t.Fatalf("expected pending, got %s", got)
+	}
+}
@@ -19,6 +19,8 @@ type ChainHelper struct {
 	chainID      *big.Int
 	delegatorUrl string
 	noncemanager *noncemanagerv2.NonceManagerV2
+	gasStrategy  utils.GasPriceStrategy
+	tracker      *TxTracker
 }
 
 type ChainHelperConfig struct {
@@ -26,6 +28,7 @@ type ChainHelperConfig struct {
 	ReporterPk                string
 	BlockchainType            BlockchainType
 	UseAdditionalProviderUrls bool
+	TxStore                   TxStore
 }
 
 type ChainHelperOption func(*ChainHelperConfig)
@@ -42,6 +45,14 @@ func WithReporterPk(pk string) ChainHelperOption {
 	}
 }
 
+// WithTxStore persists the records of submitted txs, they are kept in memory
+// only while pending otherwise.
+func WithTxStore(store TxStore) ChainHelperOption {
+	return func(c *ChainHelperConfig) {
+		c.TxStore = store
+	}
+}
+
 func WithBlockchainType(t BlockchainType) ChainHelperOption {
 	return func(c *ChainHelperConfig) {
 		c.BlockchainType = t
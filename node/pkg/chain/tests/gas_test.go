@@ -0,0 +1,40 @@
+package tests
+
+import (
+	"math/big"
+	"testing"
+
+	"bisonai.com/miko/node/pkg/chain/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGasPriceStrategy(t *testing.T) {
+	strategy := utils.GasPriceStrategy{
+		BaseFeeMultiplier: 1.5,
+		MaxGasPrice:       big.NewInt(200),
+		BumpPercent:       10,
+		MaxBumps:          3,
+	}
+
+	assert.Equal(t, big.NewInt(150), strategy.Price(big.NewInt(100)))
+	assert.Equal(t, big.NewInt(200), strategy.Price(big.NewInt(1000)))
+
+	assert.Equal(t, big.NewInt(110), strategy.Bump(big.NewInt(100)))
+	assert.Equal(t, big.NewInt(6), strategy.Bump(big.NewInt(5)), "bump should add at least one wei")
+	assert.Equal(t, big.NewInt(200), strategy.Bump(big.NewInt(195)))
+}
+
+func TestLoadGasPriceStrategy(t *testing.T) {
+	assert.Equal(t, utils.DefaultGasPriceStrategy(), utils.LoadGasPriceStrategy())
+
+	t.Setenv("GAS_PRICE_MULTIPLIER", "1.2")
+	t.Setenv("MAX_GAS_PRICE", "1000000000000")
+	t.Setenv("GAS_PRICE_BUMP_PERCENT", "25")
+	t.Setenv("GAS_PRICE_MAX_BUMPS", "invalid")
+
+	strategy := utils.LoadGasPriceStrategy()
+	assert.Equal(t, 1.2, strategy.BaseFeeMultiplier)
+	assert.Equal(t, big.NewInt(1000000000000), strategy.MaxGasPrice)
+	assert.Equal(t, int64(25), strategy.BumpPercent)
+	assert.Equal(t, utils.DefaultGasPriceMaxBumps, strategy.MaxBumps)
+}
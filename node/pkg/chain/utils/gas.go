@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"strconv"
+
+	errorSentinel "bisonai.com/miko/node/pkg/error"
+	"github.com/kaiachain/kaia"
+	"github.com/kaiachain/kaia/common"
+)
+
+const (
+	DefaultGasPriceMultiplier  = 1.0
+	DefaultGasPriceBumpPercent = 10
+	DefaultGasPriceMaxBumps    = 3
+)
+
+// GasPriceStrategy derives the gas price of a transaction from the node's
+// suggested price and bumps it when a transaction has to be replaced.
+type GasPriceStrategy struct {
+	// BaseFeeMultiplier is applied to the suggested gas price
+	BaseFeeMultiplier float64
+	// MaxGasPrice caps every price in wei, nil disables the ceiling
+	MaxGasPrice *big.Int
+	// BumpPercent is added to the previous price of a replaced transaction
+	BumpPercent int64
+	// MaxBumps is how many times a stuck transaction is replaced
+	MaxBumps int
+}
+
+func DefaultGasPriceStrategy() GasPriceStrategy {
+	return GasPriceStrategy{
+		BaseFeeMultiplier: DefaultGasPriceMultiplier,
+		BumpPercent:       DefaultGasPriceBumpPercent,
+		MaxBumps:          DefaultGasPriceMaxBumps,
+	}
+}
+
+// LoadGasPriceStrategy reads GAS_PRICE_MULTIPLIER, MAX_GAS_PRICE (wei),
+// GAS_PRICE_BUMP_PERCENT and GAS_PRICE_MAX_BUMPS, keeping the defaults for
+// unset or invalid values.
+func LoadGasPriceStrategy() GasPriceStrategy {
+	strategy := DefaultGasPriceStrategy()
+
+	if raw := os.Getenv("GAS_PRICE_MULTIPLIER"); raw != "" {
+		if multiplier, err := strconv.ParseFloat(raw, 64); err == nil && multiplier > 0 {
+			strategy.BaseFeeMultiplier = multiplier
+		}
+	}
+	if raw := os.Getenv("MAX_GAS_PRICE"); raw != "" {
+		if maxGasPrice, ok := new(big.Int).SetString(raw, 10); ok && maxGasPrice.Sign() > 0 {
+			strategy.MaxGasPrice = maxGasPrice
+		}
+	}
+	if raw := os.Getenv("GAS_PRICE_BUMP_PERCENT"); raw != "" {
+		if bumpPercent, err := strconv.ParseInt(raw, 10, 64); err == nil && bumpPercent > 0 {
+			strategy.BumpPercent = bumpPercent
+		}
+	}
+	if raw := os.Getenv("GAS_PRICE_MAX_BUMPS"); raw != "" {
+		if maxBumps, err := strconv.Atoi(raw); err == nil && maxBumps >= 0 {
+			strategy.MaxBumps = maxBumps
+		}
+	}
+
+	return strategy
+}
+
+// Price applies the multiplier to the suggested price and caps the result.
+func (s GasPriceStrategy) Price(suggested *big.Int) *big.Int {
+	price := new(big.Float).Mul(new(big.Float).SetInt(suggested), big.NewFloat(s.BaseFeeMultiplier))
+	result, _ := price.Int(nil)
+	return s.capped(result)
+}
+
+// Bump raises the price of a replaced transaction by BumpPercent, at least by
+// one wei, and caps the result.
+func (s GasPriceStrategy) Bump(previous *big.Int) *big.Int {
+	increase := new(big.Int).Mul(previous, big.NewInt(s.BumpPercent))
+	increase.Div(increase, big.NewInt(100))
+	if increase.Sign() <= 0 {
+		increase = big.NewInt(1)
+	}
+	return s.capped(new(big.Int).Add(previous, increase))
+}
+
+func (s GasPriceStrategy) capped(price *big.Int) *big.Int {
+	if s.MaxGasPrice != nil && price.Cmp(s.MaxGasPrice) > 0 {
+		return new(big.Int).Set(s.MaxGasPrice)
+	}
+	return price
+}
+
+// EstimateCallGas estimates the gas of a call sent from `from`. The sender is
+// set since contracts restricting their callers revert otherwise.
+func EstimateCallGas(ctx context.Context, client ClientInterface, contractAddressHex string, from common.Address, functionString string, args ...interface{}) (uint64, error) {
+	if client == nil {
+		return 0, errorSentinel.ErrChainEmptyClientParam
+	}
+
+	packed, err := PackCall(functionString, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	contractAddress := common.HexToAddress(contractAddressHex)
+	return client.EstimateGas(ctx, kaia.CallMsg{
+		From: from,
+		To:   &contractAddress,
+		Data: packed,
+	})
+}
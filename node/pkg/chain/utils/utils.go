@@ -153,11 +153,46 @@ func GenerateEventABI(eventName string, inputs string) (*abi.ABI, error) {
 	return &parsedABI, nil
 }
 
+// PackCall packs the call data of a state changing function, caching the
+// generated abi per function string.
+func PackCall(functionString string, args ...interface{}) ([]byte, error) {
+	abi, functionName, err := GetAbi(functionString)
+	if err != nil {
+		var inputs, outputs string
+		functionName, inputs, outputs, err = ParseMethodSignature(functionString)
+		if err != nil {
+			return nil, err
+		}
+
+		abi, err = GenerateCallABI(functionName, inputs, outputs)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to generate abi")
+			return nil, err
+		}
+
+		SetAbi(functionString, abi, functionName)
+	}
+
+	packed, err := abi.Pack(functionName, args...)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to pack abi")
+		return nil, err
+	}
+	return packed, nil
+}
+
 func GetChainID(ctx context.Context, client ClientInterface) (*big.Int, error) {
 	return client.NetworkID(ctx)
 }
 
 func MakeDirectTx(ctx context.Context, client ClientInterface, contractAddressHex string, reporter string, functionString string, chainID *big.Int, nonce uint64, args ...interface{}) (*types.Transaction, error) {
+	return MakeDirectTxWithGas(ctx, client, contractAddressHex, reporter, functionString, chainID, nonce, nil, 0, args...)
+}
+
+// MakeDirectTxWithGas builds a direct tx with the given gas price and limit.
+// A nil gas price or zero gas limit falls back to the suggested price and the
+// estimated limit.
+func MakeDirectTxWithGas(ctx context.Context, client ClientInterface, contractAddressHex string, reporter string, functionString string, chainID *big.Int, nonce uint64, gasPrice *big.Int, gasLimit uint64, args ...interface{}) (*types.Transaction, error) {
 	if client == nil {
 		return nil, errorSentinel.ErrChainEmptyClientParam
 	}
@@ -178,26 +213,8 @@ func MakeDirectTx(ctx context.Context, client ClientInterface, contractAddressHe
 		return nil, errorSentinel.ErrChainEmptyChainIdParam
 	}
 
-	abi, functionName, err := GetAbi(functionString)
-	if err != nil {
-		var inputs, outputs string
-		functionName, inputs, outputs, err = ParseMethodSignature(functionString)
-		if err != nil {
-			return nil, err
-		}
-
-		abi, err = GenerateCallABI(functionName, inputs, outputs)
-		if err != nil {
-			log.Error().Err(err).Msg("failed to generate abi")
-			return nil, err
-		}
-
-		SetAbi(functionString, abi, functionName)
-	}
-
-	packed, err := abi.Pack(functionName, args...)
+	packed, err := PackCall(functionString, args...)
 	if err != nil {
-		log.Error().Err(err).Msg("failed to pack abi")
 		return nil, err
 	}
 
@@ -206,24 +223,29 @@ func MakeDirectTx(ctx context.Context, client ClientInterface, contractAddressHe
 		return nil, err
 	}
 
-	gasPrice, err := client.SuggestGasPrice(ctx)
-	if err != nil {
-		return nil, err
+	if gasPrice == nil {
+		gasPrice, err = client.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	contractAddress := common.HexToAddress(contractAddressHex)
 
-	estimatedGas, err := client.EstimateGas(ctx, kaia.CallMsg{
-		To:   &contractAddress,
-		Data: packed,
-	})
-	if err != nil {
-		log.Debug().Msg("failed to estimate gas, using default gas limit")
-		estimatedGas = DEFAULT_GAS_LIMIT
-	}
+	estimatedGas := gasLimit
+	if estimatedGas == 0 {
+		estimatedGas, err = client.EstimateGas(ctx, kaia.CallMsg{
+			To:   &contractAddress,
+			Data: packed,
+		})
+		if err != nil {
+			log.Debug().Msg("failed to estimate gas, using default gas limit")
+			estimatedGas = DEFAULT_GAS_LIMIT
+		}
 
-	if estimatedGas < DEFAULT_GAS_LIMIT {
-		estimatedGas = DEFAULT_GAS_LIMIT
+		if estimatedGas < DEFAULT_GAS_LIMIT {
+			estimatedGas = DEFAULT_GAS_LIMIT
+		}
 	}
 
 	tx := types.NewTransaction(nonce, contractAddress, big.NewInt(0), estimatedGas, gasPrice, packed)
@@ -231,6 +253,13 @@ func MakeDirectTx(ctx context.Context, client ClientInterface, contractAddressHe
 }
 
 func MakeFeeDelegatedTx(ctx context.Context, client ClientInterface, contractAddressHex string, reporter string, functionString string, chainID *big.Int, nonce uint64, args ...interface{}) (*types.Transaction, error) {
+	return MakeFeeDelegatedTxWithGas(ctx, client, contractAddressHex, reporter, functionString, chainID, nonce, nil, 0, args...)
+}
+
+// MakeFeeDelegatedTxWithGas builds a fee delegated tx with the given gas
+// price and limit. A nil gas price or zero gas limit falls back to the
+// suggested price and the estimated limit.
+func MakeFeeDelegatedTxWithGas(ctx context.Context, client ClientInterface, contractAddressHex string, reporter string, functionString string, chainID *big.Int, nonce uint64, gasPrice *big.Int, gasLimit uint64, args ...interface{}) (*types.Transaction, error) {
 	if client == nil {
 		return nil, errorSentinel.ErrChainEmptyClientParam
 	}
@@ -251,26 +280,8 @@ func MakeFeeDelegatedTx(ctx context.Context, client ClientInterface, contractAdd
 		return nil, errorSentinel.ErrChainEmptyChainIdParam
 	}
 
-	abi, functionName, err := GetAbi(functionString)
+	packed, err := PackCall(functionString, args...)
 	if err != nil {
-		var inputs, outputs string
-		functionName, inputs, outputs, err = ParseMethodSignature(functionString)
-		if err != nil {
-			return nil, err
-		}
-
-		abi, err = GenerateCallABI(functionName, inputs, outputs)
-		if err != nil {
-			log.Error().Err(err).Msg("failed to generate abi")
-			return nil, err
-		}
-
-		SetAbi(functionString, abi, functionName)
-	}
-
-	packed, err := abi.Pack(functionName, args...)
-	if err != nil {
-		log.Error().Err(err).Msg("failed to pack abi")
 		return nil, err
 	}
 
@@ -287,23 +298,28 @@ func MakeFeeDelegatedTx(ctx context.Context, client ClientInterface, contractAdd
 
 	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
 
-	gasPrice, err := client.SuggestGasPrice(ctx)
-	if err != nil {
-		return nil, err
+	if gasPrice == nil {
+		gasPrice, err = client.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	contractAddress := common.HexToAddress(contractAddressHex)
 
-	estimatedGas, err := client.EstimateGas(ctx, kaia.CallMsg{
-		To:   &contractAddress,
-		Data: packed,
-	})
-	if err != nil {
-		log.Debug().Msg("failed to estimate gas, using default gas limit")
-		estimatedGas = DEFAULT_GAS_LIMIT
-	}
-	if estimatedGas < DEFAULT_GAS_LIMIT {
-		estimatedGas = DEFAULT_GAS_LIMIT
+	estimatedGas := gasLimit
+	if estimatedGas == 0 {
+		estimatedGas, err = client.EstimateGas(ctx, kaia.CallMsg{
+			To:   &contractAddress,
+			Data: packed,
+		})
+		if err != nil {
+			log.Debug().Msg("failed to estimate gas, using default gas limit")
+			estimatedGas = DEFAULT_GAS_LIMIT
+		}
+		if estimatedGas < DEFAULT_GAS_LIMIT {
+			estimatedGas = DEFAULT_GAS_LIMIT
+		}
 	}
 
 	txMap := map[types.TxValueKeyType]interface{}{
@@ -347,6 +363,26 @@ func SignTxByFeePayer(ctx context.Context, tx *types.Transaction, chainID *big.I
 }
 
 func SubmitRawTx(ctx context.Context, client ClientInterface, tx *types.Transaction) error {
+	err := SendRawTx(ctx, client, tx)
+	if err != nil {
+		return err
+	}
+
+	receipt, err := WaitRawTxMined(ctx, client, tx)
+	if err != nil {
+		return err
+	}
+
+	if receipt.Status != 1 {
+		log.Error().Str("tx", receipt.TxHash.String()).Msg("tx failed")
+		return errorSentinel.ErrChainTransactionFail
+	}
+
+	log.Debug().Str("Player", "ChainHelper").Any("hash", receipt.TxHash).Msg("tx success")
+	return nil
+}
+
+func SendRawTx(ctx context.Context, client ClientInterface, tx *types.Transaction) error {
 	log.Debug().Str("Player", "ChainHelper").Str("tx", tx.Hash().String()).Msg("submitting tx")
 	err := client.SendTransaction(ctx, tx)
 	if err != nil {
@@ -354,7 +390,12 @@ func SubmitRawTx(ctx context.Context, client ClientInterface, tx *types.Transact
 		return err
 	}
 	log.Debug().Str("Player", "ChainHelper").Str("tx", tx.Hash().String()).Msg("tx sent")
+	return nil
+}
 
+// WaitRawTxMined waits up to DEFAULT_MINE_WAIT_TIME for the receipt of a sent
+// tx. The receipt is returned for reverted txs as well.
+func WaitRawTxMined(ctx context.Context, client ClientInterface, tx *types.Transaction) (*types.Receipt, error) {
 	ctxWithTimeout, cancel := context.WithTimeout(ctx, DEFAULT_MINE_WAIT_TIME)
 	defer cancel()
 
@@ -362,17 +403,10 @@ func SubmitRawTx(ctx context.Context, client ClientInterface, tx *types.Transact
 	receipt, err := bind.WaitMined(ctxWithTimeout, client, tx)
 	if err != nil {
 		log.Error().Str("Player", "ChainHelper").Err(err).Msg("failed to wait for tx to be mined")
-		return err
+		return nil, err
 	}
 	log.Debug().Str("Player", "ChainHelper").Str("tx", tx.Hash().String()).Msg("tx mined")
-
-	if receipt.Status != 1 {
-		log.Error().Str("tx", receipt.TxHash.String()).Msg("tx failed")
-		return errorSentinel.ErrChainTransactionFail
-	}
-
-	log.Debug().Str("Player", "ChainHelper").Any("hash", receipt.TxHash).Msg("tx success")
-	return nil
+	return receipt, nil
 }
 
 func SubmitRawTxString(ctx context.Context, client ClientInterface, rawTx string) error {
@@ -800,3 +834,13 @@ func IsNonceError(err error) bool {
 	}
 	return false
 }
+
+// IsGasError reports gas and fee errors that the next tx of the same sender
+// would run into as well.
+func IsGasError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "insufficient funds") ||
+		strings.Contains(msg, "underpriced") ||
+		strings.Contains(msg, "intrinsic gas too low") ||
+		strings.Contains(msg, "exceeds block gas limit")
+}
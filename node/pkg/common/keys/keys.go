@@ -3,3 +3,7 @@ package keys
 func SubmissionDataStreamKey(name string) string {
 	return "submissionDataStream:" + name
 }
+
+func SubmissionDataTopic(name string) string {
+	return name + "-submission-data-topic"
+}
@@ -32,6 +32,19 @@ type FeedData struct {
 	Value     float64    `db:"value"`
 	Volume    float64    `db:"volume"`
 	Timestamp *time.Time `db:"timestamp"`
+	// Book is set by the websocket providers keeping an order book
+	Book *BookData `db:"-"`
+}
+
+// BookData summarizes the order book of a feed when its data was fetched.
+type BookData struct {
+	Mid    float64
+	Spread float64
+	// BidDepth and AskDepth are the base sizes quoted within DepthPercent of
+	// the mid price
+	BidDepth     float64
+	AskDepth     float64
+	DepthPercent float64
 }
 
 type LocalAggregate struct {
@@ -66,6 +79,8 @@ type Config struct {
 type LatestFeedDataMap struct {
 	FeedDataMap map[int32]*FeedData
 	Mu          sync.RWMutex
+	// stale holds the feeds the websocket watchdog saw stop updating
+	stale map[int32]struct{}
 }
 
 func (m *LatestFeedDataMap) GetLatestFeedData(feedIds []int32) ([]*FeedData, error) {
@@ -102,6 +117,27 @@ func (m *LatestFeedDataMap) SetLatestFeedData(feedData []*FeedData) error {
 	return nil
 }
 
+// SetStale marks feedId as stale, or fresh again, for the aggregators.
+func (m *LatestFeedDataMap) SetStale(feedId int32, stale bool) {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+	if !stale {
+		delete(m.stale, feedId)
+		return
+	}
+	if m.stale == nil {
+		m.stale = make(map[int32]struct{})
+	}
+	m.stale[feedId] = struct{}{}
+}
+
+func (m *LatestFeedDataMap) IsStale(feedId int32) bool {
+	m.Mu.RLock()
+	defer m.Mu.RUnlock()
+	_, ok := m.stale[feedId]
+	return ok
+}
+
 func (m *LatestFeedDataMap) CleanupJob(ctx context.Context) {
 	ticker := time.NewTicker(60 * time.Minute)
 	defer ticker.Stop()
@@ -31,6 +31,10 @@ var (
 		Name: "dal_websocket_connections_total",
 		Help: "Total number of WebSocket connections",
 	})
+	sseActiveStreams = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "dal_sse_active_streams",
+		Help: "Current number of active server-sent event streams",
+	})
 	wsSubscriptionsTotal = promauto.NewCounter(prometheus.CounterOpts{
 		Name: "dal_websocket_subscriptions_total",
 		Help: "Total number of WebSocket subscriptions",
@@ -72,6 +76,8 @@ func Start(ctx context.Context, opts ...ServerV2Option) error {
 	}
 
 	wsServer := NewServer(config.Collector, config.KeyCache, config.Hub, config.StatsApp)
+	wsServer.webhooks = config.Webhooks
+	wsServer.SetHistoryDB(config.HistoryDB)
 	httpServer := &http.Server{
 		Handler: wsServer,
 		BaseContext: func(_ net.Listener) context.Context {
@@ -96,6 +102,7 @@ func NewServer(collector *collector.Collector, keyCache *keycache.KeyCache, hub
 	serveMux := http.NewServeMux()
 
 	serveMux.HandleFunc("/ws", s.WSHandler)
+	serveMux.HandleFunc("GET /stream", s.StreamHandler)
 
 	serveMux.HandleFunc("GET /symbols", s.SymbolsHandler)
 	serveMux.HandleFunc("GET /latest-data-feeds/all", s.AllLatestFeedsHandler)
@@ -104,11 +111,23 @@ func NewServer(collector *collector.Collector, keyCache *keycache.KeyCache, hub
 	serveMux.HandleFunc("GET /latest-data-feeds/{symbols}", s.LatestFeedsHandler)
 	serveMux.HandleFunc("GET /latest-data-feeds-unstrict/{symbols}", s.LatestFeedsHandlerUnstrict)
 
+	serveMux.HandleFunc("GET /history/{symbol}", s.HistoryAtHandler)
+	serveMux.HandleFunc("GET /history/{symbol}/rounds/{round}", s.HistoryRoundHandler)
+	serveMux.HandleFunc("GET /history/{symbol}/range", s.HistoryRangeHandler)
+
+	serveMux.HandleFunc("GET /usage", s.UsageHandler)
+	serveMux.HandleFunc("GET /health/symbols", s.SymbolsHealthHandler)
+
+	serveMux.HandleFunc("POST /webhooks", s.CreateWebhookHandler)
+	serveMux.HandleFunc("GET /webhooks", s.WebhooksHandler)
+	serveMux.HandleFunc("DELETE /webhooks/{id}", s.DeleteWebhookHandler)
+	serveMux.HandleFunc("GET /webhooks/{id}/dead-letters", s.DeadLettersHandler)
+
 	serveMux.Handle("GET /metrics", promhttp.Handler())
 	serveMux.HandleFunc("/", s.HealthCheckHandler)
 
 	// Apply the RequestLoggerMiddleware to the ServeMux
-	loggedMux := statsApp.RequestLoggerMiddleware(serveMux)
+	loggedMux := statsApp.RequestLoggerMiddleware(s.limitMiddleware(serveMux))
 
 	s.handler = metricsMiddleware(loggedMux)
 
@@ -125,9 +144,13 @@ func metricsMiddleware(next http.Handler) http.Handler {
 }
 
 func (s *ServerV2) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	key := r.Header.Get("X-API-Key")
+	if r.RequestURI == "/" || r.RequestURI == "/metrics" {
+		s.handler.ServeHTTP(w, r)
+		return
+	}
 
-	if r.RequestURI != "/" && r.RequestURI != "/metrics" && !s.checkAPIKey(r.Context(), key) {
+	policy, ok := s.keyCache.Authorize(r.Context(), r.Header.Get("X-API-Key"))
+	if !ok {
 		w.WriteHeader(http.StatusUnauthorized)
 		_, err := w.Write([]byte("Unauthorized"))
 		if err != nil {
@@ -136,27 +159,20 @@ func (s *ServerV2) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.handler.ServeHTTP(w, r)
+	s.handler.ServeHTTP(w, r.WithContext(withPolicy(r.Context(), policy)))
 }
 
-func (s *ServerV2) checkAPIKey(ctx context.Context, key string) bool {
-	if key == "" {
-		return false
-	}
-
-	if s.keyCache.Get(key) {
-		return true
-	}
-
-	if keycache.ValidateApiKeyFromDB(ctx, key) {
-		s.keyCache.Set(key)
-		return true
+func (s *ServerV2) WSHandler(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get("X-API-Key")
+	policy := policyFromContext(r.Context())
+	release, ok := s.keyCache.AcquireConnection(key, policy)
+	if !ok {
+		limitedRequestsTotal.WithLabelValues("connections").Inc()
+		writeTooManyRequests(w, "too many connections")
+		return
 	}
+	defer release()
 
-	return false
-}
-
-func (s *ServerV2) WSHandler(w http.ResponseWriter, r *http.Request) {
 	c, err := websocket.Accept(w, r, nil)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to accept websocket connection")
@@ -167,8 +183,11 @@ func (s *ServerV2) WSHandler(w http.ResponseWriter, r *http.Request) {
 	s.hub.Register <- c
 	wsConnectionsTotal.Inc()
 	wsActiveConnections.Set(float64(s.hub.ConnectionCount()))
+	defer func() {
+		s.hub.Unregister <- c
+		wsActiveConnections.Set(float64(s.hub.ConnectionCount()))
+	}()
 
-	key := r.Header.Get("X-API-Key")
 	id, err := stats.InsertWebsocketConnection(r.Context(), key)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to insert websocket connection")
@@ -177,8 +196,6 @@ func (s *ServerV2) WSHandler(w http.ResponseWriter, r *http.Request) {
 	log.Info().Int32("id", id).Msg("inserted websocket connection")
 
 	defer func() {
-		s.hub.Unregister <- c
-		wsActiveConnections.Set(float64(s.hub.ConnectionCount()))
 		err = stats.UpdateWebsocketConnection(r.Context(), id)
 		if err != nil {
 			log.Error().Err(err).Msg("failed to update websocket connection")
@@ -194,10 +211,7 @@ func (s *ServerV2) WSHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		if msg.Method == "SUBSCRIBE" {
-			s.hub.HandleSubscription(r.Context(), c, msg, id)
-			wsSubscriptionsTotal.Add(float64(len(msg.Params)))
-		}
+		s.handleWsRequest(r.Context(), c, msg, id, policy)
 	}
 }
 
@@ -215,8 +229,12 @@ func (s *ServerV2) HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *ServerV2) SymbolsHandler(w http.ResponseWriter, r *http.Request) {
+	policy := policyFromContext(r.Context())
 	result := make([]string, 0, len(s.hub.Symbols))
 	for key := range s.hub.Symbols {
+		if !policy.AllowsSymbol(key) {
+			continue
+		}
 		result = append(result, key)
 	}
 
@@ -229,7 +247,13 @@ func (s *ServerV2) SymbolsHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *ServerV2) AllLatestFeedsHandler(w http.ResponseWriter, r *http.Request) {
-	result := s.collector.GetAllLatestData()
+	result := s.allowedLatestData(r.Context())
+	if isStrict(r) {
+		if symbol, ok := firstStale(result); ok {
+			writeStale(w, symbol)
+			return
+		}
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	err := json.NewEncoder(w).Encode(result)
@@ -239,22 +263,26 @@ func (s *ServerV2) AllLatestFeedsHandler(w http.ResponseWriter, r *http.Request)
 }
 
 func (s *ServerV2) AllLatestFeedsTransposedHandler(w http.ResponseWriter, r *http.Request) {
-	result := s.collector.GetAllLatestData()
+	result := s.allowedLatestData(r.Context())
+	if isStrict(r) {
+		if symbol, ok := firstStale(result); ok {
+			writeStale(w, symbol)
+			return
+		}
+	}
 	bulk := BulkResponse{
-		Symbols:        make([]string, len(result)),
-		Values:         make([]string, len(result)),
-		AggregateTimes: make([]string, len(result)),
-		Proofs:         make([]string, len(result)),
-		FeedHashes:     make([]string, len(result)),
-		Decimals:       make([]string, len(result)),
-	}
-	for i, data := range result {
-		bulk.Symbols[i] = data.Symbol
-		bulk.Values[i] = data.Value
-		bulk.AggregateTimes[i] = data.AggregateTime
-		bulk.Proofs[i] = data.Proof
-		bulk.FeedHashes[i] = data.FeedHash
-		bulk.Decimals[i] = data.Decimals
+		Symbols:             make([]string, 0, len(result)),
+		Values:              make([]string, 0, len(result)),
+		AggregateTimes:      make([]string, 0, len(result)),
+		Proofs:              make([]string, 0, len(result)),
+		FeedHashes:          make([]string, 0, len(result)),
+		Decimals:            make([]string, 0, len(result)),
+		AgesMs:              make([]int64, 0, len(result)),
+		ExpectedIntervalsMs: make([]int64, 0, len(result)),
+		Stale:               make([]bool, 0, len(result)),
+	}
+	for i := range result {
+		bulk.add(&result[i])
 	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -279,6 +307,7 @@ func (s *ServerV2) TransposedLatestFeedsHandler(w http.ResponseWriter, r *http.R
 	symbolsStr = strings.ReplaceAll(symbolsStr, " ", "")
 
 	symbols := strings.Split(symbolsStr, ",")
+	strict := isStrict(r)
 	bulk := BulkResponse{}
 	for _, symbol := range symbols {
 		if symbol == "" {
@@ -298,7 +327,12 @@ func (s *ServerV2) TransposedLatestFeedsHandler(w http.ResponseWriter, r *http.R
 			symbol = strings.ToUpper(symbol)
 		}
 
-		result, err := s.collector.GetLatestData(symbol)
+		if !policyFromContext(r.Context()).AllowsSymbol(symbol) {
+			writeForbidden(w, "symbol not allowed: "+symbol)
+			return
+		}
+
+		result, err := s.collector.GetLatestFreshData(symbol)
 		if err != nil {
 			log.Error().Err(err).Msg("failed to get latest data")
 			w.WriteHeader(http.StatusInternalServerError)
@@ -309,12 +343,12 @@ func (s *ServerV2) TransposedLatestFeedsHandler(w http.ResponseWriter, r *http.R
 			return
 		}
 
-		bulk.Symbols = append(bulk.Symbols, result.Symbol)
-		bulk.Values = append(bulk.Values, result.Value)
-		bulk.AggregateTimes = append(bulk.AggregateTimes, result.AggregateTime)
-		bulk.Proofs = append(bulk.Proofs, result.Proof)
-		bulk.FeedHashes = append(bulk.FeedHashes, result.FeedHash)
-		bulk.Decimals = append(bulk.Decimals, result.Decimals)
+		if strict && result.Stale {
+			writeStale(w, symbol)
+			return
+		}
+
+		bulk.add(result)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -339,7 +373,8 @@ func (s *ServerV2) LatestFeedsHandler(w http.ResponseWriter, r *http.Request) {
 	symbolsStr = strings.ReplaceAll(symbolsStr, " ", "")
 
 	symbols := strings.Split(symbolsStr, ",")
-	results := make([]*dalcommon.OutgoingSubmissionData, 0, len(symbols))
+	strict := isStrict(r)
+	results := make([]*dalcommon.FreshSubmissionData, 0, len(symbols))
 	for _, symbol := range symbols {
 		if symbol == "" {
 			continue
@@ -358,7 +393,12 @@ func (s *ServerV2) LatestFeedsHandler(w http.ResponseWriter, r *http.Request) {
 			symbol = strings.ToUpper(symbol)
 		}
 
-		result, err := s.collector.GetLatestData(symbol)
+		if !policyFromContext(r.Context()).AllowsSymbol(symbol) {
+			writeForbidden(w, "symbol not allowed: "+symbol)
+			return
+		}
+
+		result, err := s.collector.GetLatestFreshData(symbol)
 		if err != nil {
 			log.Error().Err(err).Msg("failed to get latest data")
 			w.WriteHeader(http.StatusInternalServerError)
@@ -369,6 +409,11 @@ func (s *ServerV2) LatestFeedsHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if strict && result.Stale {
+			writeStale(w, symbol)
+			return
+		}
+
 		results = append(results, result)
 	}
 
@@ -394,7 +439,8 @@ func (s *ServerV2) LatestFeedsHandlerUnstrict(w http.ResponseWriter, r *http.Req
 	symbolsStr = strings.ReplaceAll(symbolsStr, " ", "")
 
 	symbols := strings.Split(symbolsStr, ",")
-	results := make([]*dalcommon.OutgoingSubmissionData, 0, len(symbols))
+	strict := isStrict(r)
+	results := make([]*dalcommon.FreshSubmissionData, 0, len(symbols))
 	for _, symbol := range symbols {
 		if symbol == "" {
 			continue
@@ -413,8 +459,12 @@ func (s *ServerV2) LatestFeedsHandlerUnstrict(w http.ResponseWriter, r *http.Req
 			symbol = strings.ToUpper(symbol)
 		}
 
-		result, err := s.collector.GetLatestData(symbol)
-		if err != nil {
+		if !policyFromContext(r.Context()).AllowsSymbol(symbol) {
+			continue
+		}
+
+		result, err := s.collector.GetLatestFreshData(symbol)
+		if err != nil || (strict && result.Stale) {
 			continue
 		}
 
@@ -0,0 +1,62 @@
+package apiv2
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	dalcommon "bisonai.com/miko/node/pkg/dal/common"
+	"github.com/rs/zerolog/log"
+)
+
+// SymbolsHealthHandler summarizes the freshness of the symbols the caller can
+// read. With strict=true it responds 503 when any of them is stale.
+func (s *ServerV2) SymbolsHealthHandler(w http.ResponseWriter, r *http.Request) {
+	policy := policyFromContext(r.Context())
+
+	response := SymbolsHealthResponse{Symbols: []dalcommon.SymbolFreshness{}}
+	for _, entry := range s.collector.SymbolsFreshness() {
+		if !policy.AllowsSymbol(entry.Symbol) {
+			continue
+		}
+		response.Symbols = append(response.Symbols, entry)
+		if entry.Stale {
+			response.Stale++
+		}
+	}
+	response.Total = len(response.Symbols)
+	response.Healthy = response.Stale == 0
+
+	if !response.Healthy && isStrict(r) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			log.Error().Err(err).Msg("failed to encode response")
+		}
+		return
+	}
+	writeJSON(w, response)
+}
+
+// isStrict reports whether the request asked to fail on stale data.
+func isStrict(r *http.Request) bool {
+	strict, _ := strconv.ParseBool(r.URL.Query().Get("strict"))
+	return strict
+}
+
+func firstStale(data []dalcommon.FreshSubmissionData) (string, bool) {
+	for _, entry := range data {
+		if entry.Stale {
+			return entry.Symbol, true
+		}
+	}
+	return "", false
+}
+
+func writeStale(w http.ResponseWriter, symbol string) {
+	w.WriteHeader(http.StatusServiceUnavailable)
+	_, err := w.Write([]byte("stale symbol: " + symbol))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to write response")
+	}
+}
@@ -0,0 +1,335 @@
+package apiv2
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"bisonai.com/miko/node/pkg/aggregator"
+	"bisonai.com/miko/node/pkg/db"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	DefaultHistoryLimit = 100
+	MaxHistoryLimit     = 1000
+	MinHistoryInterval  = time.Second
+
+	historyColumns = `ga.value, ga.round, ga.timestamp, COALESCE(p.proof, ''::bytea) AS proof`
+
+	GetHistoryAtTimestampQuery = `SELECT ` + historyColumns + ` FROM global_aggregates ga
+		LEFT JOIN proofs p ON p.config_id = ga.config_id AND p.round = ga.round
+		WHERE ga.config_id = @config_id AND ga.timestamp <= @timestamp
+		ORDER BY ga.timestamp DESC, ga.round DESC LIMIT 1`
+	GetHistoryAtRoundQuery = `SELECT ` + historyColumns + ` FROM global_aggregates ga
+		LEFT JOIN proofs p ON p.config_id = ga.config_id AND p.round = ga.round
+		WHERE ga.config_id = @config_id AND ga.round = @round LIMIT 1`
+	GetHistoryRangeQuery = `SELECT ` + historyColumns + ` FROM global_aggregates ga
+		LEFT JOIN proofs p ON p.config_id = ga.config_id AND p.round = ga.round
+		WHERE ga.config_id = @config_id AND ga.timestamp >= @from AND ga.timestamp < @to AND ga.round > @cursor
+		ORDER BY ga.round ASC LIMIT @limit`
+	// the close of every bucket carries the proof, so a bucket can be settled
+	// against the last signed value in it
+	GetHistoryOHLCQuery = `WITH rows AS (
+			SELECT to_timestamp(floor(extract(epoch FROM timestamp)::float8 / @bucket::float8) * @bucket::float8) AS bucket, value, round, timestamp
+			FROM global_aggregates
+			WHERE config_id = @config_id AND timestamp >= @from AND timestamp < @to
+		), buckets AS (
+			SELECT bucket,
+				(array_agg(value ORDER BY round ASC))[1] AS open,
+				MAX(value) AS high,
+				MIN(value) AS low,
+				(array_agg(value ORDER BY round DESC))[1] AS close,
+				(array_agg(round ORDER BY round DESC))[1] AS round,
+				(array_agg(timestamp ORDER BY round DESC))[1] AS timestamp,
+				COUNT(*) AS count
+			FROM rows GROUP BY bucket
+		)
+		SELECT b.bucket, b.open, b.high, b.low, b.close AS value, b.round, b.timestamp, b.count, COALESCE(p.proof, ''::bytea) AS proof
+		FROM buckets b
+		LEFT JOIN proofs p ON p.config_id = @config_id AND p.round = b.round
+		WHERE b.bucket > @cursor
+		ORDER BY b.bucket ASC LIMIT @limit`
+)
+
+// SetHistoryDB sets the node database holding global_aggregates and proofs,
+// the DAL database doesn't have them.
+func (s *ServerV2) SetHistoryDB(pool *pgxpool.Pool) {
+	s.history = pool
+}
+
+type historyRow struct {
+	Value     int64     `db:"value"`
+	Round     int32     `db:"round"`
+	Timestamp time.Time `db:"timestamp"`
+	Proof     []byte    `db:"proof"`
+}
+
+type ohlcRow struct {
+	Bucket    time.Time `db:"bucket"`
+	Open      int64     `db:"open"`
+	High      int64     `db:"high"`
+	Low       int64     `db:"low"`
+	Value     int64     `db:"value"`
+	Round     int32     `db:"round"`
+	Timestamp time.Time `db:"timestamp"`
+	Count     int64     `db:"count"`
+	Proof     []byte    `db:"proof"`
+}
+
+// HistoryAtHandler returns the round of a symbol that was latest at
+// ?timestamp= (unix ms), the current time if omitted.
+func (s *ServerV2) HistoryAtHandler(w http.ResponseWriter, r *http.Request) {
+	symbol, configID, ok := s.historySymbol(w, r)
+	if !ok {
+		return
+	}
+
+	timestamp := time.Now()
+	if raw := r.URL.Query().Get("timestamp"); raw != "" {
+		parsed, err := parseUnixMilli(raw)
+		if err != nil {
+			writeBadRequest(w, "invalid timestamp: "+raw)
+			return
+		}
+		timestamp = parsed
+	}
+
+	row, err := db.QueryRowTransient[historyRow](r.Context(), s.history, GetHistoryAtTimestampQuery, map[string]any{"config_id": configID, "timestamp": timestamp})
+	s.writeHistoryRow(w, r, symbol, row, err)
+}
+
+func (s *ServerV2) HistoryRoundHandler(w http.ResponseWriter, r *http.Request) {
+	symbol, configID, ok := s.historySymbol(w, r)
+	if !ok {
+		return
+	}
+
+	round, err := strconv.ParseInt(r.PathValue("round"), 10, 32)
+	if err != nil {
+		writeBadRequest(w, "invalid round: "+r.PathValue("round"))
+		return
+	}
+
+	row, err := db.QueryRowTransient[historyRow](r.Context(), s.history, GetHistoryAtRoundQuery, map[string]any{"config_id": configID, "round": round})
+	s.writeHistoryRow(w, r, symbol, row, err)
+}
+
+// HistoryRangeHandler returns the rounds of a symbol within [from, to) (unix
+// ms). With ?interval= (e.g. 1m) the rounds are downsampled into OHLC
+// buckets. Pages are continued by passing nextCursor as ?cursor=.
+func (s *ServerV2) HistoryRangeHandler(w http.ResponseWriter, r *http.Request) {
+	symbol, configID, ok := s.historySymbol(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	from, err := parseUnixMilli(query.Get("from"))
+	if err != nil {
+		writeBadRequest(w, "invalid from: "+query.Get("from"))
+		return
+	}
+	to := time.Now()
+	if raw := query.Get("to"); raw != "" {
+		to, err = parseUnixMilli(raw)
+		if err != nil {
+			writeBadRequest(w, "invalid to: "+raw)
+			return
+		}
+	}
+	if !from.Before(to) {
+		writeBadRequest(w, "from should be before to")
+		return
+	}
+
+	limit := DefaultHistoryLimit
+	if raw := query.Get("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			writeBadRequest(w, "invalid limit: "+raw)
+			return
+		}
+		limit = min(limit, MaxHistoryLimit)
+	}
+
+	var cursor int64
+	if raw := query.Get("cursor"); raw != "" {
+		cursor, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			writeBadRequest(w, "invalid cursor: "+raw)
+			return
+		}
+	}
+
+	args := map[string]any{"config_id": configID, "from": from, "to": to, "limit": limit}
+
+	rawInterval := query.Get("interval")
+	if rawInterval == "" {
+		args["cursor"] = cursor
+		rows, err := db.QueryRowsTransient[historyRow](r.Context(), s.history, GetHistoryRangeQuery, args)
+		if err != nil {
+			log.Error().Err(err).Str("Symbol", symbol).Msg("failed to query history range")
+			writeInternalError(w, "failed to query history")
+			return
+		}
+
+		result := HistoryRangeResponse{Symbol: symbol, Data: make([]HistoricalData, 0, len(rows))}
+		for _, row := range rows {
+			data, err := s.historicalData(r.Context(), symbol, row)
+			if err != nil {
+				writeInternalError(w, err.Error())
+				return
+			}
+			result.Data = append(result.Data, data)
+		}
+		if len(rows) == limit {
+			result.NextCursor = strconv.FormatInt(int64(rows[len(rows)-1].Round), 10)
+		}
+		writeJSON(w, result)
+		return
+	}
+
+	interval, err := time.ParseDuration(rawInterval)
+	if err != nil || interval < MinHistoryInterval {
+		writeBadRequest(w, "invalid interval: "+rawInterval)
+		return
+	}
+	args["bucket"] = interval.Seconds()
+	args["cursor"] = time.UnixMilli(cursor)
+
+	rows, err := db.QueryRowsTransient[ohlcRow](r.Context(), s.history, GetHistoryOHLCQuery, args)
+	if err != nil {
+		log.Error().Err(err).Str("Symbol", symbol).Msg("failed to query history buckets")
+		writeInternalError(w, "failed to query history")
+		return
+	}
+
+	result := OHLCResponse{Symbol: symbol, Interval: interval.String(), Buckets: make([]OHLCBucket, 0, len(rows))}
+	for _, row := range rows {
+		closeData, err := s.historicalData(r.Context(), symbol, historyRow{Value: row.Value, Round: row.Round, Timestamp: row.Timestamp, Proof: row.Proof})
+		if err != nil {
+			writeInternalError(w, err.Error())
+			return
+		}
+		result.Buckets = append(result.Buckets, OHLCBucket{
+			Start: strconv.FormatInt(row.Bucket.UnixMilli(), 10),
+			Open:  strconv.FormatInt(row.Open, 10),
+			High:  strconv.FormatInt(row.High, 10),
+			Low:   strconv.FormatInt(row.Low, 10),
+			Count: row.Count,
+			Close: closeData,
+		})
+	}
+	if len(rows) == limit {
+		result.NextCursor = strconv.FormatInt(rows[len(rows)-1].Bucket.UnixMilli(), 10)
+	}
+	writeJSON(w, result)
+}
+
+func (s *ServerV2) historySymbol(w http.ResponseWriter, r *http.Request) (string, int32, bool) {
+	if s.history == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, err := w.Write([]byte("history is not available, node database not configured"))
+		if err != nil {
+			log.Error().Err(err).Msg("failed to write response")
+		}
+		return "", 0, false
+	}
+
+	symbol := strings.TrimSpace(r.PathValue("symbol"))
+	if !strings.Contains(symbol, "-") {
+		writeBadRequest(w, "wrong symbol format: "+symbol+", symbol should be in {BASE}-{QUOTE} format")
+		return "", 0, false
+	}
+	if !strings.Contains(symbol, "test") {
+		symbol = strings.ToUpper(symbol)
+	}
+
+	config, ok := s.collector.Configs[symbol]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		_, err := w.Write([]byte("symbol not found: " + symbol))
+		if err != nil {
+			log.Error().Err(err).Msg("failed to write response")
+		}
+		return "", 0, false
+	}
+	if !policyFromContext(r.Context()).AllowsSymbol(symbol) {
+		writeForbidden(w, "symbol not allowed: "+symbol)
+		return "", 0, false
+	}
+	return symbol, config.ID, true
+}
+
+func (s *ServerV2) writeHistoryRow(w http.ResponseWriter, r *http.Request, symbol string, row historyRow, err error) {
+	if err != nil {
+		log.Error().Err(err).Str("Symbol", symbol).Msg("failed to query history")
+		writeInternalError(w, "failed to query history")
+		return
+	}
+	if row.Timestamp.IsZero() {
+		w.WriteHeader(http.StatusNotFound)
+		_, err = w.Write([]byte("no data found for " + symbol))
+		if err != nil {
+			log.Error().Err(err).Msg("failed to write response")
+		}
+		return
+	}
+
+	data, err := s.historicalData(r.Context(), symbol, row)
+	if err != nil {
+		writeInternalError(w, err.Error())
+		return
+	}
+	writeJSON(w, data)
+}
+
+func (s *ServerV2) historicalData(ctx context.Context, symbol string, row historyRow) (HistoricalData, error) {
+	data, err := s.collector.HistoricalDataToOutgoingData(ctx, &aggregator.SubmissionData{
+		Symbol:          symbol,
+		GlobalAggregate: aggregator.GlobalAggregate{Value: row.Value, Round: row.Round, Timestamp: row.Timestamp},
+		Proof:           aggregator.Proof{Round: row.Round, Proof: row.Proof},
+	})
+	if err != nil {
+		return HistoricalData{}, err
+	}
+	return HistoricalData{OutgoingSubmissionData: *data, Round: strconv.FormatInt(int64(row.Round), 10)}, nil
+}
+
+func parseUnixMilli(raw string) (time.Time, error) {
+	ms, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(ms), nil
+}
+
+func writeBadRequest(w http.ResponseWriter, message string) {
+	w.WriteHeader(http.StatusBadRequest)
+	_, err := w.Write([]byte(message))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to write response")
+	}
+}
+
+func writeInternalError(w http.ResponseWriter, message string) {
+	w.WriteHeader(http.StatusInternalServerError)
+	_, err := w.Write([]byte(message))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to write response")
+	}
+}
+
+func writeJSON(w http.ResponseWriter, result any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	err := json.NewEncoder(w).Encode(result)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to encode response")
+	}
+}
@@ -0,0 +1,102 @@
+package apiv2
+
+import (
+	"context"
+	"net/http"
+
+	dalcommon "bisonai.com/miko/node/pkg/dal/common"
+	"bisonai.com/miko/node/pkg/dal/utils/keycache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+)
+
+var limitedRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "dal_limited_requests_total",
+	Help: "Total number of requests rejected by the limits of their api key",
+}, []string{"reason"})
+
+type policyContextKey struct{}
+
+func withPolicy(ctx context.Context, policy *keycache.KeyPolicy) context.Context {
+	return context.WithValue(ctx, policyContextKey{}, policy)
+}
+
+// policyFromContext returns the policy of the request's api key, nil (no
+// limits) for the endpoints that don't require one.
+func policyFromContext(ctx context.Context) *keycache.KeyPolicy {
+	policy, _ := ctx.Value(policyContextKey{}).(*keycache.KeyPolicy)
+	return policy
+}
+
+// limitMiddleware enforces the rate limit and the monthly quota of the api
+// key. It runs inside the stats middleware so rejected calls show up in
+// rest_calls with their 429.
+func (s *ServerV2) limitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		policy := policyFromContext(r.Context())
+		if policy == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := r.Header.Get("X-API-Key")
+		if !s.keyCache.Allow(key, policy) {
+			limitedRequestsTotal.WithLabelValues("rate").Inc()
+			w.Header().Set("Retry-After", "1")
+			writeTooManyRequests(w, "rate limit exceeded")
+			return
+		}
+		if !s.keyCache.UseQuota(r.Context(), key, policy) {
+			limitedRequestsTotal.WithLabelValues("quota").Inc()
+			writeTooManyRequests(w, "monthly quota exceeded")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// UsageHandler returns the limits of the caller's api key with its usage of
+// the current month.
+func (s *ServerV2) UsageHandler(w http.ResponseWriter, r *http.Request) {
+	usage, err := s.keyCache.Usage(r.Context(), r.Header.Get("X-API-Key"))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get key usage")
+		writeInternalError(w, "failed to get usage")
+		return
+	}
+	writeJSON(w, usage)
+}
+
+func (s *ServerV2) allowedLatestData(ctx context.Context) []dalcommon.FreshSubmissionData {
+	policy := policyFromContext(ctx)
+	result := s.collector.GetAllLatestFreshData()
+	if policy == nil || len(policy.AllowedSymbols) == 0 {
+		return result
+	}
+
+	allowed := make([]dalcommon.FreshSubmissionData, 0, len(result))
+	for _, data := range result {
+		if policy.AllowsSymbol(data.Symbol) {
+			allowed = append(allowed, data)
+		}
+	}
+	return allowed
+}
+
+func writeTooManyRequests(w http.ResponseWriter, message string) {
+	w.WriteHeader(http.StatusTooManyRequests)
+	_, err := w.Write([]byte(message))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to write response")
+	}
+}
+
+func writeForbidden(w http.ResponseWriter, message string) {
+	w.WriteHeader(http.StatusForbidden)
+	_, err := w.Write([]byte(message))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to write response")
+	}
+}
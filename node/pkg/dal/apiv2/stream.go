@@ -0,0 +1,107 @@
+package apiv2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	dalcommon "bisonai.com/miko/node/pkg/dal/common"
+	"bisonai.com/miko/node/pkg/dal/hub"
+	"github.com/rs/zerolog/log"
+)
+
+const StreamKeepAliveInterval = 15 * time.Second
+
+// StreamHandler streams the requested symbols as server-sent events, for
+// clients behind proxies that don't pass websockets through. The latest
+// known value of every symbol is sent first.
+func (s *ServerV2) StreamHandler(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeInternalError(w, "streaming not supported")
+		return
+	}
+
+	policy := policyFromContext(r.Context())
+	symbols := []string{}
+	for _, symbol := range strings.Split(strings.ReplaceAll(r.URL.Query().Get("symbols"), " ", ""), ",") {
+		if symbol == "" {
+			continue
+		}
+		if !strings.Contains(symbol, "test") {
+			symbol = strings.ToUpper(symbol)
+		}
+		if _, ok := s.hub.Symbols[symbol]; ok && policy.AllowsSymbol(symbol) {
+			symbols = append(symbols, symbol)
+		}
+	}
+	if len(symbols) == 0 {
+		writeBadRequest(w, "no valid symbols, symbols should be in {BASE}-{QUOTE} format")
+		return
+	}
+
+	release, ok := s.keyCache.AcquireConnection(r.Header.Get("X-API-Key"), policy)
+	if !ok {
+		limitedRequestsTotal.WithLabelValues("connections").Inc()
+		writeTooManyRequests(w, "too many connections")
+		return
+	}
+	defer release()
+
+	stream, stop := s.hub.Listen(hub.ListenerSSE, symbols)
+	defer stop()
+	sseActiveStreams.Inc()
+	defer sseActiveStreams.Dec()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// disables response buffering in nginx based proxies
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	for _, symbol := range symbols {
+		if data, err := s.collector.GetLatestFreshData(symbol); err == nil {
+			if err := writeEvent(w, data); err != nil {
+				return
+			}
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(StreamKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case data, ok := <-stream:
+			if !ok {
+				// dropped by the hub for falling behind
+				return
+			}
+			if err := writeEvent(w, s.collector.WithFreshness(data)); err != nil {
+				log.Warn().Err(err).Msg("failed to write event to stream")
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, data *dalcommon.FreshSubmissionData) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: submission\nid: %s\ndata: %s\n\n", data.AggregateTime, payload)
+	return err
+}
@@ -3,10 +3,14 @@ package apiv2
 import (
 	"net/http"
 
+	"github.com/jackc/pgx/v5/pgxpool"
+
 	"bisonai.com/miko/node/pkg/dal/collector"
+	dalcommon "bisonai.com/miko/node/pkg/dal/common"
 	"bisonai.com/miko/node/pkg/dal/hub"
 	"bisonai.com/miko/node/pkg/dal/utils/keycache"
 	"bisonai.com/miko/node/pkg/dal/utils/stats"
+	"bisonai.com/miko/node/pkg/dal/webhook"
 )
 
 type BulkResponse struct {
@@ -16,12 +20,38 @@ type BulkResponse struct {
 	Proofs         []string `json:"proofs"`
 	FeedHashes     []string `json:"feedHashes"`
 	Decimals       []string `json:"decimals"`
+
+	AgesMs              []int64 `json:"agesMs"`
+	ExpectedIntervalsMs []int64 `json:"expectedIntervalsMs"`
+	Stale               []bool  `json:"stale"`
+}
+
+func (b *BulkResponse) add(data *dalcommon.FreshSubmissionData) {
+	b.Symbols = append(b.Symbols, data.Symbol)
+	b.Values = append(b.Values, data.Value)
+	b.AggregateTimes = append(b.AggregateTimes, data.AggregateTime)
+	b.Proofs = append(b.Proofs, data.Proof)
+	b.FeedHashes = append(b.FeedHashes, data.FeedHash)
+	b.Decimals = append(b.Decimals, data.Decimals)
+	b.AgesMs = append(b.AgesMs, data.AgeMs)
+	b.ExpectedIntervalsMs = append(b.ExpectedIntervalsMs, data.ExpectedIntervalMs)
+	b.Stale = append(b.Stale, data.Stale)
+}
+
+type SymbolsHealthResponse struct {
+	// Healthy is false if any symbol is stale
+	Healthy bool                        `json:"healthy"`
+	Total   int                         `json:"total"`
+	Stale   int                         `json:"stale"`
+	Symbols []dalcommon.SymbolFreshness `json:"symbols"`
 }
 
 type ServerV2 struct {
 	collector *collector.Collector
 	hub       *hub.Hub
 	keyCache  *keycache.KeyCache
+	webhooks  *webhook.Dispatcher
+	history   *pgxpool.Pool
 	handler   http.Handler
 }
 
@@ -31,6 +61,8 @@ type ServerV2Config struct {
 	Hub       *hub.Hub
 	KeyCache  *keycache.KeyCache
 	StatsApp  *stats.StatsApp
+	Webhooks  *webhook.Dispatcher
+	HistoryDB *pgxpool.Pool
 }
 
 type ServerV2Option func(*ServerV2Config)
@@ -64,3 +96,47 @@ func WithKeyCache(k *keycache.KeyCache) ServerV2Option {
 		config.KeyCache = k
 	}
 }
+
+// WithWebhooks sets the dispatcher to notify when webhooks change, otherwise
+// they are picked up on its next reload.
+func WithWebhooks(d *webhook.Dispatcher) ServerV2Option {
+	return func(config *ServerV2Config) {
+		config.Webhooks = d
+	}
+}
+
+// WithHistoryDB sets the node database the history endpoints read rounds and
+// proofs from, they respond with 503 without it.
+func WithHistoryDB(pool *pgxpool.Pool) ServerV2Option {
+	return func(config *ServerV2Config) {
+		config.HistoryDB = pool
+	}
+}
+
+type HistoricalData struct {
+	dalcommon.OutgoingSubmissionData
+	Round string `json:"round"`
+}
+
+type HistoryRangeResponse struct {
+	Symbol     string           `json:"symbol"`
+	Data       []HistoricalData `json:"data"`
+	NextCursor string           `json:"nextCursor,omitempty"`
+}
+
+type OHLCBucket struct {
+	// Start is the bucket start in unix ms
+	Start string         `json:"start"`
+	Open  string         `json:"open"`
+	High  string         `json:"high"`
+	Low   string         `json:"low"`
+	Count int64          `json:"count"`
+	Close HistoricalData `json:"close"`
+}
+
+type OHLCResponse struct {
+	Symbol     string       `json:"symbol"`
+	Interval   string       `json:"interval"`
+	Buckets    []OHLCBucket `json:"buckets"`
+	NextCursor string       `json:"nextCursor,omitempty"`
+}
@@ -0,0 +1,199 @@
+package apiv2
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"bisonai.com/miko/node/pkg/dal/webhook"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	MaxWebhooksPerKey      = 10
+	MinWebhookHeartbeat    = 10 * time.Second
+	DefaultDeadLetterLimit = 100
+	MaxDeadLetterLimit     = 1000
+)
+
+type WebhookRequest struct {
+	Url     string   `json:"url"`
+	Symbols []string `json:"symbols"`
+	// Deviation in percent, every update is delivered without deviation and
+	// heartbeat
+	Deviation *float64 `json:"deviation"`
+	// Heartbeat in ms
+	Heartbeat *int32 `json:"heartbeat"`
+}
+
+// CreateWebhookHandler registers a webhook for the caller's api key. The
+// secret deliveries are signed with is only part of this response.
+func (s *ServerV2) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var req WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBadRequest(w, "invalid body")
+		return
+	}
+
+	target, err := url.Parse(req.Url)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		writeBadRequest(w, "invalid url: "+req.Url)
+		return
+	}
+	if err := webhook.CheckURL(r.Context(), target, webhook.LoadAllowPrivate()); err != nil {
+		writeBadRequest(w, "url not allowed: "+req.Url)
+		return
+	}
+	if len(req.Symbols) == 0 {
+		writeBadRequest(w, "symbols should not be empty")
+		return
+	}
+
+	policy := policyFromContext(r.Context())
+	symbols := make([]string, 0, len(req.Symbols))
+	seen := map[string]struct{}{}
+	for _, symbol := range req.Symbols {
+		symbol = strings.TrimSpace(symbol)
+		if !strings.Contains(symbol, "test") {
+			symbol = strings.ToUpper(symbol)
+		}
+		if _, ok := s.hub.Symbols[symbol]; !ok {
+			writeBadRequest(w, "unknown symbol: "+symbol)
+			return
+		}
+		if !policy.AllowsSymbol(symbol) {
+			writeForbidden(w, "symbol not allowed: "+symbol)
+			return
+		}
+		if _, ok := seen[symbol]; ok {
+			continue
+		}
+		seen[symbol] = struct{}{}
+		symbols = append(symbols, symbol)
+	}
+
+	if req.Deviation != nil && *req.Deviation <= 0 {
+		writeBadRequest(w, "deviation should be positive")
+		return
+	}
+	if req.Heartbeat != nil && time.Duration(*req.Heartbeat)*time.Millisecond < MinWebhookHeartbeat {
+		writeBadRequest(w, "heartbeat should be at least "+strconv.FormatInt(MinWebhookHeartbeat.Milliseconds(), 10)+"ms")
+		return
+	}
+
+	key := r.Header.Get("X-API-Key")
+	count, err := webhook.CountByKey(r.Context(), key)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to count webhooks")
+		writeInternalError(w, "failed to create webhook")
+		return
+	}
+	if count >= MaxWebhooksPerKey {
+		writeForbidden(w, "too many webhooks")
+		return
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		log.Error().Err(err).Msg("failed to generate webhook secret")
+		writeInternalError(w, "failed to create webhook")
+		return
+	}
+
+	result, err := webhook.Insert(r.Context(), webhook.Webhook{
+		ApiKey:    key,
+		Url:       target.String(),
+		Symbols:   symbols,
+		Deviation: req.Deviation,
+		Heartbeat: req.Heartbeat,
+		Secret:    secret,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("failed to insert webhook")
+		writeInternalError(w, "failed to create webhook")
+		return
+	}
+
+	if s.webhooks != nil {
+		s.webhooks.Reload()
+	}
+	writeJSON(w, result)
+}
+
+// WebhooksHandler lists the webhooks of the caller's api key, without their
+// secrets.
+func (s *ServerV2) WebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	result, err := webhook.GetByKey(r.Context(), r.Header.Get("X-API-Key"))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get webhooks")
+		writeInternalError(w, "failed to get webhooks")
+		return
+	}
+	for i := range result {
+		result[i].Secret = ""
+	}
+	writeJSON(w, result)
+}
+
+func (s *ServerV2) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := webhook.Delete(r.Context(), r.Header.Get("X-API-Key"), id)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to delete webhook")
+		writeInternalError(w, "failed to delete webhook")
+		return
+	}
+	if !deleted {
+		http.NotFound(w, r)
+		return
+	}
+
+	if s.webhooks != nil {
+		s.webhooks.Reload()
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeadLettersHandler returns the deliveries to a webhook of the caller that
+// failed every attempt, newest first.
+func (s *ServerV2) DeadLettersHandler(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookID(w, r)
+	if !ok {
+		return
+	}
+
+	limit := DefaultDeadLetterLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		var err error
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			writeBadRequest(w, "invalid limit: "+raw)
+			return
+		}
+		limit = min(limit, MaxDeadLetterLimit)
+	}
+
+	result, err := webhook.GetDeadLetters(r.Context(), r.Header.Get("X-API-Key"), id, limit)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get dead letters")
+		writeInternalError(w, "failed to get dead letters")
+		return
+	}
+	writeJSON(w, result)
+}
+
+func webhookID(w http.ResponseWriter, r *http.Request) (int32, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 32)
+	if err != nil {
+		writeBadRequest(w, "invalid id: "+r.PathValue("id"))
+		return 0, false
+	}
+	return int32(id), true
+}
@@ -0,0 +1,43 @@
+package apiv2
+
+import (
+	"context"
+
+	"bisonai.com/miko/node/pkg/dal/hub"
+	"bisonai.com/miko/node/pkg/dal/utils/keycache"
+	"nhooyr.io/websocket"
+)
+
+// handleWsRequest answers a request of a websocket client through its send
+// queue. SUBSCRIBE and UNSUBSCRIBE are only acknowledged when the request has
+// an id, since clients of the original protocol read every message as
+// submission data.
+func (s *ServerV2) handleWsRequest(ctx context.Context, c *websocket.Conn, msg hub.Subscription, id int32, policy *keycache.KeyPolicy) {
+	switch msg.Method {
+	case hub.MethodSubscribe:
+		// the hub queues the acknowledgement and the snapshot
+		subscribed, _ := s.hub.HandleSubscription(ctx, c, msg, id, policy)
+		wsSubscriptionsTotal.Add(float64(len(subscribed)))
+	case hub.MethodUnsubscribe:
+		removed := s.hub.HandleUnsubscription(c, msg.Params)
+		if msg.ID != nil {
+			s.hub.Send(c, hub.Response{ID: msg.ID, Result: toTopics(removed)})
+		}
+	case hub.MethodListSubscriptions:
+		s.hub.Send(c, hub.Response{ID: msg.ID, Result: s.hub.Subscriptions(c)})
+	case hub.MethodPing:
+		s.hub.Send(c, hub.Response{ID: msg.ID, Result: "pong"})
+	default:
+		if msg.ID != nil {
+			s.hub.Send(c, hub.Response{ID: msg.ID, Error: "unknown method: " + msg.Method})
+		}
+	}
+}
+
+func toTopics(symbols []string) []string {
+	topics := make([]string, len(symbols))
+	for i, symbol := range symbols {
+		topics[i] = hub.SubmissionTopicPrefix + symbol
+	}
+	return topics
+}
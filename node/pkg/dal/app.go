@@ -4,17 +4,26 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strconv"
 	"time"
 
 	"bisonai.com/miko/node/pkg/common/types"
 	"bisonai.com/miko/node/pkg/dal/apiv2"
 	"bisonai.com/miko/node/pkg/dal/collector"
 	"bisonai.com/miko/node/pkg/dal/hub"
+	"bisonai.com/miko/node/pkg/dal/rpc"
 	"bisonai.com/miko/node/pkg/dal/utils/keycache"
 	"bisonai.com/miko/node/pkg/dal/utils/stats"
+	"bisonai.com/miko/node/pkg/dal/webhook"
+	"bisonai.com/miko/node/pkg/db"
 	errorsentinel "bisonai.com/miko/node/pkg/error"
+	libp2pSetup "bisonai.com/miko/node/pkg/libp2p/setup"
+	"bisonai.com/miko/node/pkg/secrets"
 	"bisonai.com/miko/node/pkg/utils/request"
+	"bisonai.com/miko/node/pkg/utils/retrier"
 
+	"github.com/jackc/pgx/v5/pgxpool"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/rs/zerolog/log"
 )
 
@@ -43,17 +52,48 @@ func Run(ctx context.Context) error {
 		return err
 	}
 
-	collector, err := collector.NewCollector(ctx, configs)
+	collectorOptions := []collector.CollectorOption{}
+	ps, err := setupPubSub(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to setup libp2p, collecting from redis only")
+	} else if ps != nil {
+		collectorOptions = append(collectorOptions, collector.WithPubSub(ps))
+	}
+
+	collector, err := collector.NewCollector(ctx, configs, collectorOptions...)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to setup collector")
 		return err
 	}
 	collector.Start(ctx)
 
-	hub := hub.HubSetup(ctx, configs)
+	hub := hub.HubSetup(ctx, append(configs, collector.DerivedConfigs()...))
 	go hub.Start(ctx, collector)
 
-	err = apiv2.Start(ctx, apiv2.WithCollector(collector), apiv2.WithHub(hub), apiv2.WithKeyCache(keyCache), apiv2.WithStatsApp(statsApp))
+	webhooks := webhook.NewDispatcher(collector, hub)
+	go webhooks.Start(ctx)
+
+	grpcPort := os.Getenv("DAL_GRPC_PORT")
+	if grpcPort == "" {
+		grpcPort = "8091"
+	}
+	go func() {
+		rpcErr := rpc.Start(ctx, rpc.WithPort(grpcPort), rpc.WithCollector(collector), rpc.WithHub(hub), rpc.WithKeyCache(keyCache))
+		if rpcErr != nil {
+			log.Error().Err(rpcErr).Msg("Failed to start DAL gRPC server")
+		}
+	}()
+
+	serverOptions := []apiv2.ServerV2Option{apiv2.WithCollector(collector), apiv2.WithHub(hub), apiv2.WithKeyCache(keyCache), apiv2.WithStatsApp(statsApp), apiv2.WithWebhooks(webhooks)}
+	historyDB, err := openHistoryDB(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to connect to node database, history endpoints are disabled")
+	} else if historyDB != nil {
+		defer historyDB.Close()
+		serverOptions = append(serverOptions, apiv2.WithHistoryDB(historyDB))
+	}
+
+	err = apiv2.Start(ctx, serverOptions...)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to start DAL WS server")
 		return err
@@ -62,6 +102,52 @@ func Run(ctx context.Context) error {
 	return nil
 }
 
+// openHistoryDB connects to the node database set by DAL_NODE_DB_URL, which
+// holds the rounds and proofs served by the history endpoints.
+func openHistoryDB(ctx context.Context) (*pgxpool.Pool, error) {
+	nodeDBUrl := secrets.GetSecret("DAL_NODE_DB_URL")
+	if nodeDBUrl == "" {
+		log.Warn().Msg("DAL_NODE_DB_URL not set, history endpoints are disabled")
+		return nil, nil
+	}
+	return db.GetTransientPool(ctx, nodeDBUrl)
+}
+
+// setupPubSub joins the libp2p network of the nodes when DAL_LIBP2P_ENABLED
+// is set, so submission data can be received without going through redis.
+func setupPubSub(ctx context.Context) (*pubsub.PubSub, error) {
+	if enabled, _ := strconv.ParseBool(os.Getenv("DAL_LIBP2P_ENABLED")); !enabled {
+		return nil, nil
+	}
+
+	hostOptions := []libp2pSetup.HostOption{}
+	if rawPort := os.Getenv("DAL_LIBP2P_PORT"); rawPort != "" {
+		port, err := strconv.Atoi(rawPort)
+		if err != nil {
+			return nil, err
+		}
+		hostOptions = append(hostOptions, libp2pSetup.WithPort(port))
+	}
+
+	host, err := libp2pSetup.NewHost(ctx, hostOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	ps, err := libp2pSetup.MakePubsub(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	err = retrier.Retry(func() error {
+		return libp2pSetup.ConnectThroughBootApi(ctx, host)
+	}, 5, 10*time.Second, 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return ps, nil
+}
+
 func fetchConfigs(chain string) ([]Config, error) {
 	return request.Request[[]Config](
 		request.WithEndpoint(fmt.Sprintf(baseMikoConfigUrl, chain)),
@@ -0,0 +1,229 @@
+// Package client reads prices from the DAL and verifies their proofs against
+// an oracle whitelist before handing them out.
+package client
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	dalcommon "bisonai.com/miko/node/pkg/dal/common"
+	errorsentinel "bisonai.com/miko/node/pkg/error"
+	"bisonai.com/miko/node/pkg/utils/request"
+	wsfcommon "bisonai.com/miko/node/pkg/websocketfetcher/common"
+	"bisonai.com/miko/node/pkg/wss"
+	kaiacommon "github.com/kaiachain/kaia/common"
+	"github.com/rs/zerolog/log"
+)
+
+const DefaultTimeout = 10 * time.Second
+
+type Client struct {
+	endpoint  string
+	apiKey    string
+	whitelist []kaiacommon.Address
+	quorum    int
+	timeout   time.Duration
+}
+
+type ClientConfig struct {
+	Endpoint  string
+	ApiKey    string
+	Whitelist []kaiacommon.Address
+	Quorum    int
+	Timeout   time.Duration
+}
+
+type ClientOption func(*ClientConfig)
+
+// WithEndpoint sets the base url of the DAL, e.g. https://dal.baobab.orakl.network
+func WithEndpoint(endpoint string) ClientOption {
+	return func(config *ClientConfig) {
+		config.Endpoint = endpoint
+	}
+}
+
+func WithApiKey(apiKey string) ClientOption {
+	return func(config *ClientConfig) {
+		config.ApiKey = apiKey
+	}
+}
+
+// WithWhitelist sets the oracles allowed to sign, usually getAllOracles of
+// the submission proxy.
+func WithWhitelist(whitelist []kaiacommon.Address) ClientOption {
+	return func(config *ClientConfig) {
+		config.Whitelist = whitelist
+	}
+}
+
+// WithQuorum sets the number of distinct oracles that should sign a value,
+// a majority of the whitelist by default.
+func WithQuorum(quorum int) ClientOption {
+	return func(config *ClientConfig) {
+		config.Quorum = quorum
+	}
+}
+
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(config *ClientConfig) {
+		config.Timeout = timeout
+	}
+}
+
+func NewClient(opts ...ClientOption) (*Client, error) {
+	config := &ClientConfig{
+		Timeout: DefaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if config.Endpoint == "" {
+		return nil, errorsentinel.ErrDalClientEndpointNotFound
+	}
+	if len(config.Whitelist) == 0 {
+		return nil, errorsentinel.ErrDalClientWhitelistNotFound
+	}
+	if config.Quorum == 0 {
+		config.Quorum = len(config.Whitelist)/2 + 1
+	}
+	if config.Quorum < 0 || config.Quorum > len(config.Whitelist) {
+		return nil, errorsentinel.ErrDalInvalidQuorum
+	}
+
+	return &Client{
+		endpoint:  strings.TrimSuffix(config.Endpoint, "/"),
+		apiKey:    config.ApiKey,
+		whitelist: config.Whitelist,
+		quorum:    config.Quorum,
+		timeout:   config.Timeout,
+	}, nil
+}
+
+// Verify verifies data against the whitelist and quorum of the client.
+func (c *Client) Verify(data dalcommon.OutgoingSubmissionData) (Price, error) {
+	return Verify(data, c.whitelist, c.quorum)
+}
+
+// SymbolError is the verification failure of the data of a symbol.
+type SymbolError struct {
+	Symbol string
+	Err    error
+}
+
+func (e *SymbolError) Error() string {
+	return e.Symbol + ": " + e.Err.Error()
+}
+
+func (e *SymbolError) Unwrap() error {
+	return e.Err
+}
+
+// GetLatest returns the verified latest prices of symbols. Data failing
+// verification is left out, and its SymbolError joined into the error
+// returned along with the other prices.
+func (c *Client) GetLatest(ctx context.Context, symbols ...string) ([]Price, error) {
+	escaped := make([]string, len(symbols))
+	for i, symbol := range symbols {
+		escaped[i] = url.PathEscape(symbol)
+	}
+	data, err := c.get(ctx, "/latest-data-feeds/"+strings.Join(escaped, ","))
+	if err != nil {
+		return nil, err
+	}
+	return c.verifyAll(data)
+}
+
+// GetAll returns the verified latest prices of every symbol served, with the
+// failures joined into the error like in GetLatest.
+func (c *Client) GetAll(ctx context.Context) ([]Price, error) {
+	data, err := c.get(ctx, "/latest-data-feeds/all")
+	if err != nil {
+		return nil, err
+	}
+	return c.verifyAll(data)
+}
+
+// Subscribe streams the verified prices of symbols to handler until ctx is
+// done, reconnecting as needed. Data failing verification or older than the
+// last price of its symbol is dropped.
+func (c *Client) Subscribe(ctx context.Context, symbols []string, handler func(Price)) error {
+	params := make([]string, len(symbols))
+	for i, symbol := range symbols {
+		params[i] = "submission@" + symbol
+	}
+
+	endpoint := c.endpoint + "/ws"
+	if strings.HasPrefix(endpoint, "http") {
+		endpoint = "ws" + strings.TrimPrefix(endpoint, "http")
+	}
+
+	wsHelper, err := wss.NewWebsocketHelper(
+		ctx,
+		wss.WithEndpoint(endpoint),
+		wss.WithSubscriptions([]any{map[string]any{"method": "SUBSCRIBE", "params": params}}),
+		wss.WithRequestHeaders(map[string]string{"X-API-Key": c.apiKey}),
+	)
+	if err != nil {
+		return err
+	}
+
+	var mu sync.Mutex
+	latest := map[string]time.Time{}
+
+	wsHelper.Run(ctx, func(ctx context.Context, message map[string]any) error {
+		// responses of the websocket protocol carry no symbol
+		if _, ok := message["symbol"]; !ok {
+			return nil
+		}
+
+		data, err := wsfcommon.MessageToStruct[dalcommon.OutgoingSubmissionData](message)
+		if err != nil {
+			return err
+		}
+		price, err := c.Verify(data)
+		if err != nil {
+			log.Warn().Err(err).Str("Symbol", data.Symbol).Msg("dropping unverified dal data")
+			return nil
+		}
+
+		mu.Lock()
+		if !price.AggregateTime.After(latest[price.Symbol]) {
+			mu.Unlock()
+			return nil
+		}
+		latest[price.Symbol] = price.AggregateTime
+		mu.Unlock()
+
+		handler(price)
+		return nil
+	})
+	return ctx.Err()
+}
+
+func (c *Client) get(ctx context.Context, path string) ([]dalcommon.OutgoingSubmissionData, error) {
+	return request.Request[[]dalcommon.OutgoingSubmissionData](
+		request.WithContext(ctx),
+		request.WithEndpoint(c.endpoint+path),
+		request.WithHeaders(map[string]string{"X-API-Key": c.apiKey}),
+		request.WithTimeout(c.timeout))
+}
+
+func (c *Client) verifyAll(data []dalcommon.OutgoingSubmissionData) ([]Price, error) {
+	prices := make([]Price, 0, len(data))
+	var errs []error
+	for _, entry := range data {
+		price, err := c.Verify(entry)
+		if err != nil {
+			log.Warn().Err(err).Str("Symbol", entry.Symbol).Msg("failed to verify dal data")
+			errs = append(errs, &SymbolError{Symbol: entry.Symbol, Err: err})
+			continue
+		}
+		prices = append(prices, price)
+	}
+	return prices, errors.Join(errs...)
+}
@@ -0,0 +1,232 @@
+//nolint:all
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	chainutils "bisonai.com/miko/node/pkg/chain/utils"
+	dalcommon "bisonai.com/miko/node/pkg/dal/common"
+	errorsentinel "bisonai.com/miko/node/pkg/error"
+	kaiacommon "github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSymbol = "BTC-USDT"
+
+func generateOracles(t *testing.T, count int) ([]*ecdsa.PrivateKey, []kaiacommon.Address) {
+	keys := make([]*ecdsa.PrivateKey, count)
+	addresses := make([]kaiacommon.Address, count)
+	for i := range keys {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatalf("error generating key: %v", err)
+		}
+		keys[i] = key
+		addresses[i] = crypto.PubkeyToAddress(key.PublicKey)
+	}
+	return keys, addresses
+}
+
+func signedData(t *testing.T, value int64, timestamp time.Time, signers ...*ecdsa.PrivateKey) dalcommon.OutgoingSubmissionData {
+	return signedSymbolData(t, testSymbol, value, timestamp, signers...)
+}
+
+func signedSymbolData(t *testing.T, symbol string, value int64, timestamp time.Time, signers ...*ecdsa.PrivateKey) dalcommon.OutgoingSubmissionData {
+	proof := []byte{}
+	for _, signer := range signers {
+		signature, err := chainutils.MakeValueSignature(value, timestamp.UnixMilli(), symbol, signer)
+		if err != nil {
+			t.Fatalf("error signing value: %v", err)
+		}
+		proof = append(proof, signature...)
+	}
+
+	return dalcommon.OutgoingSubmissionData{
+		Symbol:        symbol,
+		Value:         strconv.FormatInt(value, 10),
+		AggregateTime: strconv.FormatInt(timestamp.UnixMilli(), 10),
+		Proof:         "0x" + kaiacommon.Bytes2Hex(proof),
+		FeedHash:      "0x" + kaiacommon.Bytes2Hex(crypto.Keccak256([]byte(symbol))),
+		Decimals:      "8",
+	}
+}
+
+func TestVerify(t *testing.T) {
+	keys, whitelist := generateOracles(t, 3)
+	now := time.UnixMilli(time.Now().UnixMilli())
+
+	price, err := Verify(signedData(t, 6512345678901, now, keys[0], keys[1]), whitelist, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6512345678901), price.RawValue)
+	assert.Equal(t, "65123.45678901", price.String())
+	assert.InDelta(t, 65123.45678901, price.Float64(), 1e-6)
+	assert.Equal(t, now, price.AggregateTime)
+	assert.ElementsMatch(t, whitelist[:2], price.Signers)
+
+	// a repeated signature counts once
+	_, err = Verify(signedData(t, 10, now, keys[0], keys[0]), whitelist, 2)
+	assert.ErrorIs(t, err, errorsentinel.ErrDalQuorumNotReached)
+
+	outsider, _ := generateOracles(t, 1)
+	_, err = Verify(signedData(t, 10, now, keys[0], outsider[0]), whitelist, 1)
+	assert.ErrorIs(t, err, errorsentinel.ErrDalSignerNotWhitelisted)
+
+	tampered := signedData(t, 10, now, keys[0], keys[1])
+	tampered.Value = "11"
+	_, err = Verify(tampered, whitelist, 2)
+	assert.Error(t, err)
+
+	wrongFeed := signedData(t, 10, now, keys[0])
+	wrongFeed.FeedHash = "0x01"
+	_, err = Verify(wrongFeed, whitelist, 1)
+	assert.ErrorIs(t, err, errorsentinel.ErrDalFeedHashMismatch)
+
+	truncated := signedData(t, 10, now, keys[0])
+	truncated.Proof = truncated.Proof[:len(truncated.Proof)-2]
+	_, err = Verify(truncated, whitelist, 1)
+	assert.ErrorIs(t, err, errorsentinel.ErrDalInvalidProofLength)
+
+	_, err = Verify(signedData(t, 10, now, keys[0]), whitelist, 4)
+	assert.ErrorIs(t, err, errorsentinel.ErrDalInvalidQuorum)
+}
+
+func TestVerifyDerived(t *testing.T) {
+	keys, whitelist := generateOracles(t, 3)
+	now := time.UnixMilli(time.Now().UnixMilli())
+
+	ethUsdt := signedSymbolData(t, "ETH-USDT", 300000000000, now, keys[0], keys[1])
+	usdtKrw := signedSymbolData(t, "USDT-KRW", 135000000000, now.Add(-time.Second), keys[1], keys[2])
+	derived := dalcommon.OutgoingSubmissionData{
+		Symbol:        "ETH-KRW",
+		Value:         "405000000000000",
+		AggregateTime: strconv.FormatInt(now.Add(-time.Second).UnixMilli(), 10),
+		FeedHash:      "0x" + kaiacommon.Bytes2Hex(crypto.Keccak256([]byte("ETH-KRW"))),
+		Decimals:      "8",
+		Derived:       true,
+		Components:    []dalcommon.ComponentData{{OutgoingSubmissionData: ethUsdt, Round: "10"}, {OutgoingSubmissionData: usdtKrw, Round: "20"}},
+		Formula: &dalcommon.DerivedFormula{
+			Type:       dalcommon.Product,
+			Components: []dalcommon.DerivedComponent{{Symbol: "ETH-USDT"}, {Symbol: "USDT-KRW"}},
+			Decimals:   8,
+		},
+	}
+
+	price, err := Verify(derived, whitelist, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "4050000.00000000", price.String())
+	assert.Empty(t, price.Signers)
+	if assert.Len(t, price.Components, 2) {
+		assert.Equal(t, "ETH-USDT", price.Components[0].Symbol)
+		assert.ElementsMatch(t, whitelist[1:], price.Components[1].Signers)
+	}
+
+	tampered := derived
+	tampered.Components = []dalcommon.ComponentData{derived.Components[0], {OutgoingSubmissionData: signedSymbolData(t, "USDT-KRW", 135000000000, now, keys[2]), Round: "20"}}
+	_, err = Verify(tampered, whitelist, 2)
+	assert.ErrorIs(t, err, errorsentinel.ErrDalQuorumNotReached, "every component should verify")
+
+	newer := derived
+	newer.AggregateTime = strconv.FormatInt(now.UnixMilli(), 10)
+	_, err = Verify(newer, whitelist, 2)
+	assert.ErrorIs(t, err, errorsentinel.ErrDalInvalidSubmissionData, "derived data is as old as its oldest component")
+
+	empty := derived
+	empty.Components = nil
+	_, err = Verify(empty, whitelist, 2)
+	assert.ErrorIs(t, err, errorsentinel.ErrDalInvalidDerivedFeed)
+
+	forged := derived
+	forged.Value = "500000000000000"
+	_, err = Verify(forged, whitelist, 2)
+	assert.ErrorIs(t, err, errorsentinel.ErrDalDerivedMismatch, "the value is recomputed from the components")
+
+	inverted := derived
+	inverted.Formula = &dalcommon.DerivedFormula{
+		Type:       dalcommon.Product,
+		Components: []dalcommon.DerivedComponent{{Symbol: "ETH-USDT"}, {Symbol: "USDT-KRW", Inverse: true}},
+		Decimals:   8,
+	}
+	_, err = Verify(inverted, whitelist, 2)
+	assert.ErrorIs(t, err, errorsentinel.ErrDalDerivedMismatch)
+
+	reordered := derived
+	reordered.Formula = &dalcommon.DerivedFormula{
+		Type:       dalcommon.Product,
+		Components: []dalcommon.DerivedComponent{{Symbol: "USDT-KRW"}, {Symbol: "ETH-USDT"}},
+		Decimals:   8,
+	}
+	_, err = Verify(reordered, whitelist, 2)
+	assert.ErrorIs(t, err, errorsentinel.ErrDalInvalidDerivedFeed, "formula components follow the data components")
+
+	withoutFormula := derived
+	withoutFormula.Formula = nil
+	_, err = Verify(withoutFormula, whitelist, 2)
+	assert.ErrorIs(t, err, errorsentinel.ErrDalInvalidDerivedFeed)
+}
+
+func TestClientGetLatest(t *testing.T) {
+	keys, whitelist := generateOracles(t, 3)
+	valid := signedData(t, 100000000, time.Now(), keys...)
+	unsigned := signedSymbolData(t, "ETH-USDT", 100000000, time.Now())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "testApiKey" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/latest-data-feeds/all" {
+			json.NewEncoder(w).Encode([]dalcommon.OutgoingSubmissionData{valid, unsigned})
+			return
+		}
+		json.NewEncoder(w).Encode([]dalcommon.OutgoingSubmissionData{valid})
+	}))
+	defer server.Close()
+
+	_, err := NewClient(WithEndpoint(server.URL))
+	assert.ErrorIs(t, err, errorsentinel.ErrDalClientWhitelistNotFound)
+
+	c, err := NewClient(WithEndpoint(server.URL), WithApiKey("testApiKey"), WithWhitelist(whitelist))
+	if err != nil {
+		t.Fatalf("error creating client: %v", err)
+	}
+	assert.Equal(t, 2, c.quorum)
+
+	prices, err := c.GetLatest(context.Background(), testSymbol)
+	assert.NoError(t, err)
+	if assert.Len(t, prices, 1) {
+		assert.Equal(t, "1.00000000", prices[0].String())
+	}
+
+	// an entry failing verification doesn't hold back the others
+	prices, err = c.GetAll(context.Background())
+	assert.ErrorIs(t, err, errorsentinel.ErrDalEmptyProofParam)
+	var symbolErr *SymbolError
+	if assert.ErrorAs(t, err, &symbolErr) {
+		assert.Equal(t, "ETH-USDT", symbolErr.Symbol)
+	}
+	if assert.Len(t, prices, 1) {
+		assert.Equal(t, testSymbol, prices[0].Symbol)
+	}
+
+	_, others := generateOracles(t, 1)
+	strict, err := NewClient(WithEndpoint(server.URL), WithApiKey("testApiKey"), WithWhitelist(others))
+	if err != nil {
+		t.Fatalf("error creating client: %v", err)
+	}
+	prices, err = strict.GetLatest(context.Background(), testSymbol)
+	assert.ErrorIs(t, err, errorsentinel.ErrDalSignerNotWhitelisted)
+	assert.Empty(t, prices)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.GetLatest(ctx, testSymbol)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,194 @@
+package client
+
+import (
+	"bytes"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	chainutils "bisonai.com/miko/node/pkg/chain/utils"
+	dalcommon "bisonai.com/miko/node/pkg/dal/common"
+	errorsentinel "bisonai.com/miko/node/pkg/error"
+	kaiacommon "github.com/kaiachain/kaia/common"
+	"github.com/kaiachain/kaia/crypto"
+)
+
+const SignatureLength = 65
+
+// Price is a DAL submission whose proof was verified.
+type Price struct {
+	Symbol string
+	// RawValue is the value as submitted on-chain, Decimals digits of it are
+	// fractional
+	RawValue      int64
+	Decimals      int
+	AggregateTime time.Time
+	Proof         []byte
+	FeedHash      [32]byte
+	// Signers are the whitelisted oracles that signed the value
+	Signers []kaiacommon.Address
+	// Components are the verified prices a derived symbol was computed from,
+	// derived prices have no proof nor signers of their own
+	Components []Price
+}
+
+// Value returns the value with its decimals applied.
+func (p Price) Value() *big.Rat {
+	return new(big.Rat).SetFrac(big.NewInt(p.RawValue), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(p.Decimals)), nil))
+}
+
+func (p Price) Float64() float64 {
+	value, _ := p.Value().Float64()
+	return value
+}
+
+// String formats the value with its decimals applied, e.g. 1234.5678.
+func (p Price) String() string {
+	return p.Value().FloatString(p.Decimals)
+}
+
+// Verify checks that data was signed by at least quorum distinct oracles of
+// whitelist and returns it typed. Signatures of oracles outside the
+// whitelist fail the verification, like they do on-chain. Derived data is
+// verified through its components, every one of them should verify, it
+// should be as old as the oldest and its value is recomputed from them with
+// its formula.
+func Verify(data dalcommon.OutgoingSubmissionData, whitelist []kaiacommon.Address, quorum int) (Price, error) {
+	if len(whitelist) == 0 {
+		return Price{}, errorsentinel.ErrDalClientWhitelistNotFound
+	}
+	if quorum <= 0 || quorum > len(whitelist) {
+		return Price{}, errorsentinel.ErrDalInvalidQuorum
+	}
+
+	result, err := parsePrice(data)
+	if err != nil {
+		return Price{}, err
+	}
+	if data.Derived {
+		return verifyComponents(result, data, whitelist, quorum)
+	}
+
+	proof := kaiacommon.FromHex(strings.TrimSpace(data.Proof))
+	if len(proof) == 0 {
+		return Price{}, errorsentinel.ErrDalEmptyProofParam
+	}
+	if len(proof)%SignatureLength != 0 {
+		return Price{}, errorsentinel.ErrDalInvalidProofLength
+	}
+
+	signers, err := RecoverSigners(proof, result.RawValue, result.AggregateTime.UnixMilli(), data.Symbol)
+	if err != nil {
+		return Price{}, err
+	}
+
+	allowed := make(map[kaiacommon.Address]struct{}, len(whitelist))
+	for _, oracle := range whitelist {
+		allowed[oracle] = struct{}{}
+	}
+	unique := make(map[kaiacommon.Address]struct{}, len(signers))
+	verified := make([]kaiacommon.Address, 0, len(signers))
+	for _, signer := range signers {
+		if _, ok := allowed[signer]; !ok {
+			return Price{}, errorsentinel.ErrDalSignerNotWhitelisted
+		}
+		if _, ok := unique[signer]; ok {
+			continue
+		}
+		unique[signer] = struct{}{}
+		verified = append(verified, signer)
+	}
+	if len(verified) < quorum {
+		return Price{}, errorsentinel.ErrDalQuorumNotReached
+	}
+
+	result.Proof = proof
+	result.Signers = verified
+	return result, nil
+}
+
+func parsePrice(data dalcommon.OutgoingSubmissionData) (Price, error) {
+	value, err := strconv.ParseInt(data.Value, 10, 64)
+	if err != nil {
+		return Price{}, errorsentinel.ErrDalInvalidSubmissionData
+	}
+	aggregateTime, err := strconv.ParseInt(data.AggregateTime, 10, 64)
+	if err != nil {
+		return Price{}, errorsentinel.ErrDalInvalidSubmissionData
+	}
+	decimals, err := strconv.Atoi(data.Decimals)
+	if err != nil || decimals < 0 {
+		return Price{}, errorsentinel.ErrDalInvalidSubmissionData
+	}
+
+	feedHash := crypto.Keccak256([]byte(data.Symbol))
+	if data.FeedHash != "" && !bytes.Equal(kaiacommon.FromHex(data.FeedHash), feedHash) {
+		return Price{}, errorsentinel.ErrDalFeedHashMismatch
+	}
+
+	result := Price{
+		Symbol:        data.Symbol,
+		RawValue:      value,
+		Decimals:      decimals,
+		AggregateTime: time.UnixMilli(aggregateTime),
+	}
+	copy(result.FeedHash[:], feedHash)
+	return result, nil
+}
+
+func verifyComponents(result Price, data dalcommon.OutgoingSubmissionData, whitelist []kaiacommon.Address, quorum int) (Price, error) {
+	formula, components := data.Formula, data.Components
+	if len(components) == 0 || formula == nil || len(formula.Components) != len(components) || formula.Decimals != result.Decimals {
+		return Price{}, errorsentinel.ErrDalInvalidDerivedFeed
+	}
+
+	var oldest time.Time
+	result.Components = make([]Price, 0, len(components))
+	values := make([]*dalcommon.OutgoingSubmissionData, len(components))
+	for i, component := range components {
+		if component.Derived || component.Symbol != formula.Components[i].Symbol {
+			return Price{}, errorsentinel.ErrDalInvalidDerivedFeed
+		}
+		price, err := Verify(component.OutgoingSubmissionData, whitelist, quorum)
+		if err != nil {
+			return Price{}, err
+		}
+		if oldest.IsZero() || price.AggregateTime.Before(oldest) {
+			oldest = price.AggregateTime
+		}
+		result.Components = append(result.Components, price)
+		values[i] = &components[i].OutgoingSubmissionData
+	}
+	if !result.AggregateTime.Equal(oldest) {
+		return Price{}, errorsentinel.ErrDalInvalidSubmissionData
+	}
+
+	value, err := formula.Compute(values)
+	if err != nil {
+		return Price{}, err
+	}
+	if value != result.RawValue {
+		return Price{}, errorsentinel.ErrDalDerivedMismatch
+	}
+	return result, nil
+}
+
+// RecoverSigners returns the signer of every 65 byte signature of proof over
+// the value of symbol at timestamp (unix ms).
+func RecoverSigners(proof []byte, value int64, timestamp int64, symbol string) ([]kaiacommon.Address, error) {
+	if len(proof)%SignatureLength != 0 {
+		return nil, errorsentinel.ErrDalInvalidProofLength
+	}
+
+	hash := chainutils.Value2HashForSign(value, timestamp, symbol)
+	signers := make([]kaiacommon.Address, 0, len(proof)/SignatureLength)
+	for i := 0; i < len(proof); i += SignatureLength {
+		signer, err := chainutils.RecoverSigner(hash, proof[i:i+SignatureLength])
+		if err != nil {
+			return nil, err
+		}
+		signers = append(signers, signer)
+	}
+	return signers, nil
+}
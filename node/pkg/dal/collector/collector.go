@@ -2,7 +2,6 @@ package collector
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"os"
 	"strconv"
@@ -15,12 +14,11 @@ import (
 	"bisonai.com/miko/node/pkg/common/keys"
 	"bisonai.com/miko/node/pkg/common/types"
 	dalcommon "bisonai.com/miko/node/pkg/dal/common"
-	"bisonai.com/miko/node/pkg/db"
 	errorsentinel "bisonai.com/miko/node/pkg/error"
 	"bisonai.com/miko/node/pkg/utils/pool"
 	kaiacommon "github.com/kaiachain/kaia/common"
 	"github.com/kaiachain/kaia/crypto"
-	"github.com/redis/go-redis/v9"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/rs/zerolog/log"
 )
 
@@ -45,12 +43,19 @@ type Collector struct {
 
 	FeedHashes       map[string][]byte
 	LatestTimestamps map[string]time.Time
+	LatestRounds     map[string]int32
 	LatestData       map[string]*dalcommon.OutgoingSubmissionData
 	Configs          map[string]Config
 	CachedWhitelist  []kaiacommon.Address
 
-	baseRediscribe *db.Rediscribe
-	subRediscribe  *db.Rediscribe
+	upstreams []upstream
+
+	derivedFeeds    []DerivedFeed
+	derivedBySymbol map[string][]DerivedFeed
+
+	// staleMultiplier times the aggregate interval of a symbol is the age
+	// after which its data is stale
+	staleMultiplier float64
 
 	IsRunning  bool
 	CancelFunc context.CancelFunc
@@ -67,7 +72,26 @@ type Collector struct {
 	lastOnDemandRefresh atomic.Int64
 }
 
-func NewCollector(ctx context.Context, configs []types.Config) (*Collector, error) {
+type CollectorConfig struct {
+	PubSub *pubsub.PubSub
+}
+
+type CollectorOption func(*CollectorConfig)
+
+// WithPubSub subscribes the collector directly to the libp2p submission
+// topics of the aggregators, next to its redis upstreams.
+func WithPubSub(ps *pubsub.PubSub) CollectorOption {
+	return func(config *CollectorConfig) {
+		config.PubSub = ps
+	}
+}
+
+func NewCollector(ctx context.Context, configs []types.Config, opts ...CollectorOption) (*Collector, error) {
+	collectorConfig := &CollectorConfig{}
+	for _, opt := range opts {
+		opt(collectorConfig)
+	}
+
 	kaiaRestUrl := os.Getenv("KAIA_PROVIDER_URL")
 	if kaiaRestUrl == "" {
 		return nil, errors.New("KAIA_PROVIDER_URL is not set")
@@ -112,6 +136,7 @@ func NewCollector(ctx context.Context, configs []types.Config) (*Collector, erro
 		OutgoingStream:   make(map[string]chan *dalcommon.OutgoingSubmissionData, len(configs)),
 		FeedHashes:       make(map[string][]byte, len(configs)),
 		LatestTimestamps: make(map[string]time.Time),
+		LatestRounds:     make(map[string]int32),
 		LatestData:       make(map[string]*dalcommon.OutgoingSubmissionData),
 		Configs:          make(map[string]Config, len(configs)),
 
@@ -119,35 +144,53 @@ func NewCollector(ctx context.Context, configs []types.Config) (*Collector, erro
 		CachedWhitelist:             initialWhitelist,
 		submissionProxyContractAddr: submissionProxyContractAddr,
 		processPool:                 pool.NewPool(ProcessWorkerCount),
+		staleMultiplier:             staleMultiplierFromEnv(),
 	}
 
+	symbols := []string{}
 	redisTopics := []string{}
 	for _, config := range configs {
 		collector.OutgoingStream[config.Name] = make(chan *dalcommon.OutgoingSubmissionData, 1000)
 		collector.FeedHashes[config.Name] = crypto.Keccak256([]byte(config.Name))
 		collector.Configs[config.Name] = config
+		symbols = append(symbols, config.Name)
 		redisTopics = append(redisTopics, keys.SubmissionDataStreamKey(config.Name))
 	}
 
-	baseRediscribe, err := db.NewRediscribe(
-		ctx,
-		db.WithRedisHost(baseRedisHost),
-		db.WithRedisPort(baseRedisPort),
-		db.WithRedisTopics(redisTopics),
-		db.WithRedisRouter(collector.redisRouter))
+	derivedFeeds, err := loadDerivedFeeds(collector.Configs)
+	if err != nil {
+		return nil, err
+	}
+	collector.registerDerivedFeeds(derivedFeeds)
+
+	baseUpstream, err := collector.newRedisUpstream(ctx, "redis", redisAddress{host: baseRedisHost, port: baseRedisPort}, redisTopics, 0)
 	if err != nil {
 		return nil, err
 	}
-	collector.baseRediscribe = baseRediscribe
+	collector.upstreams = append(collector.upstreams, baseUpstream)
 
 	if subRedisHost != "" && subRedisPort != "" {
-		subRediscribe, err := db.NewRediscribe(
-			ctx,
-			db.WithRedisHost(subRedisHost), db.WithRedisPort(subRedisPort), db.WithRedisTopics(redisTopics), db.WithRedisRouter(collector.redisRouter))
+		subUpstream, err := collector.newRedisUpstream(ctx, "sub_redis", redisAddress{host: subRedisHost, port: subRedisPort}, redisTopics, SubRedisStartDelay)
+		if err != nil {
+			return nil, err
+		}
+		collector.upstreams = append(collector.upstreams, subUpstream)
+	}
+
+	for _, address := range redisUpstreamAddresses() {
+		extraUpstream, err := collector.newRedisUpstream(ctx, "redis@"+address.String(), address, redisTopics, 0)
+		if err != nil {
+			return nil, err
+		}
+		collector.upstreams = append(collector.upstreams, extraUpstream)
+	}
+
+	if collectorConfig.PubSub != nil {
+		libp2pUpstream, err := collector.newLibp2pUpstream(collectorConfig.PubSub, symbols)
 		if err != nil {
 			return nil, err
 		}
-		collector.subRediscribe = subRediscribe
+		collector.upstreams = append(collector.upstreams, libp2pUpstream)
 	}
 
 	return collector, nil
@@ -198,67 +241,84 @@ func (c *Collector) Stop() {
 }
 
 func (c *Collector) receive(ctx context.Context) {
-	go c.baseRediscribe.Start(ctx)
-
-	if c.subRediscribe != nil {
-		// wait for sidecar to be ready
-		time.Sleep(10 * time.Second)
-		go c.subRediscribe.Start(ctx)
+	for _, source := range c.upstreams {
+		log.Info().Str("Player", "DalCollector").Str("upstream", source.name).Msg("starting upstream")
+		go func(source upstream) {
+			if source.delay > 0 {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(source.delay):
+				}
+			}
+			source.start(ctx)
+		}(source)
 	}
 }
 
-func (c *Collector) redisRouter(ctx context.Context, msg *redis.Message) error {
-	var data *aggregator.SubmissionData
-	err := json.Unmarshal([]byte(msg.Payload), &data)
-	if err != nil {
-		return err
-	}
+// isNewer reports whether data is newer than the latest data of its symbol,
+// letting duplicates from other upstreams skip proof verification.
+func (c *Collector) isNewer(data *aggregator.SubmissionData) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 
-	c.processPool.AddJob(func() {
-		c.processIncomingData(ctx, data)
-	})
-	return nil
+	old, ok := c.LatestTimestamps[data.Symbol]
+	return !ok || data.GlobalAggregate.Timestamp.After(old)
 }
 
-func (c *Collector) compareAndSwapLatestTimestamp(data *aggregator.SubmissionData) bool {
+// compareAndSwapLatestTimestamp stores result as the latest data of its
+// symbol if data is newer than what any upstream delivered so far.
+func (c *Collector) compareAndSwapLatestTimestamp(data *aggregator.SubmissionData, result *dalcommon.OutgoingSubmissionData) bool {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	old, ok := c.LatestTimestamps[data.Symbol]
 	if !ok || data.GlobalAggregate.Timestamp.After(old) {
 		c.LatestTimestamps[data.Symbol] = data.GlobalAggregate.Timestamp
+		c.LatestData[result.Symbol] = result
+		if c.LatestRounds == nil {
+			c.LatestRounds = make(map[string]int32)
+		}
+		c.LatestRounds[data.Symbol] = data.GlobalAggregate.Round
 		return true
 	}
 
 	return false
 }
 
-func (c *Collector) processIncomingData(ctx context.Context, data *aggregator.SubmissionData) {
+// processIncomingData publishes data unless the same or a newer round was
+// already received, possibly from another upstream. The proof is verified
+// before the latest timestamp moves so an invalid message can't shadow valid
+// data of the same round. It reports whether data was published.
+func (c *Collector) processIncomingData(ctx context.Context, data *aggregator.SubmissionData) bool {
 	select {
 	case <-ctx.Done():
-		return
+		return false
 	default:
-		valid := c.compareAndSwapLatestTimestamp(data)
-		if !valid {
+		if !c.isNewer(data) {
 			log.Debug().Str("Player", "DalCollector").Str("Symbol", data.Symbol).Msg("old data recieved")
-			return
+			return false
 		}
 
 		result, err := c.IncomingDataToOutgoingData(ctx, data)
 		if err != nil {
 			log.Error().Err(err).Str("Player", "DalCollector").Msg("failed to convert incoming data to outgoing data")
-			return
+			return false
 		}
 
-		c.mu.Lock()
-		c.LatestData[result.Symbol] = result
-		c.mu.Unlock()
+		if !c.compareAndSwapLatestTimestamp(data, result) {
+			log.Debug().Str("Player", "DalCollector").Str("Symbol", data.Symbol).Msg("old data recieved")
+			return false
+		}
 
 		select {
 		case c.OutgoingStream[result.Symbol] <- result:
 		default:
 			log.Debug().Str("Player", "DalCollector").Str("Symbol", result.Symbol).Msg("outgoing stream full, dropping data")
 		}
+
+		c.updateDerived(result.Symbol)
+		return true
 	}
 }
 
@@ -288,6 +348,33 @@ func (c *Collector) IncomingDataToOutgoingData(ctx context.Context, data *aggreg
 		return nil, err
 	}
 
+	return c.outgoingData(data, feedHashBytes, orderedProof), nil
+}
+
+// HistoricalDataToOutgoingData formats a persisted round like live data.
+// Rounds signed by oracles that were rotated out since can't be ordered
+// against the current whitelist, their proof is returned as stored.
+func (c *Collector) HistoricalDataToOutgoingData(ctx context.Context, data *aggregator.SubmissionData) (*dalcommon.OutgoingSubmissionData, error) {
+	feedHashBytes, ok := c.FeedHashes[data.Symbol]
+	if !ok {
+		return nil, errorsentinel.ErrDalFeedHashNotFound
+	}
+
+	c.mu.RLock()
+	whitelist := make([]kaiacommon.Address, len(c.CachedWhitelist))
+	copy(whitelist, c.CachedWhitelist)
+	c.mu.RUnlock()
+
+	proof, err := orderProof(ctx, data.Proof.Proof, data.GlobalAggregate.Value, data.GlobalAggregate.Timestamp, data.Symbol, whitelist)
+	if err != nil {
+		log.Debug().Err(err).Str("Player", "DalCollector").Str("Symbol", data.Symbol).Int32("Round", data.GlobalAggregate.Round).Msg("returning historical proof unordered")
+		proof = data.Proof.Proof
+	}
+
+	return c.outgoingData(data, feedHashBytes, proof), nil
+}
+
+func (c *Collector) outgoingData(data *aggregator.SubmissionData, feedHashBytes []byte, proof []byte) *dalcommon.OutgoingSubmissionData {
 	decimals := DefaultDecimals
 	if config, ok := c.Configs[data.Symbol]; ok {
 		if config.Decimals != nil && *config.Decimals != 0 {
@@ -299,10 +386,10 @@ func (c *Collector) IncomingDataToOutgoingData(ctx context.Context, data *aggreg
 		Symbol:        data.Symbol,
 		Value:         strconv.FormatInt(data.GlobalAggregate.Value, 10),
 		AggregateTime: strconv.FormatInt(data.GlobalAggregate.Timestamp.UnixMilli(), 10),
-		Proof:         formatBytesToHex(orderedProof),
+		Proof:         formatBytesToHex(proof),
 		FeedHash:      formatBytesToHex(feedHashBytes),
 		Decimals:      decimals,
-	}, nil
+	}
 }
 
 // maybeRefreshWhitelist triggers an immediate getAllOracles read after a proof was rejected for a
@@ -0,0 +1,255 @@
+package collector
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	dalcommon "bisonai.com/miko/node/pkg/dal/common"
+	errorsentinel "bisonai.com/miko/node/pkg/error"
+	"github.com/kaiachain/kaia/crypto"
+	"github.com/rs/zerolog/log"
+)
+
+type DerivedType = dalcommon.DerivedType
+
+const (
+	Product = dalcommon.Product
+	Inverse = dalcommon.Inverse
+	Basket  = dalcommon.Basket
+)
+
+type DerivedComponent = dalcommon.DerivedComponent
+
+// DerivedFeed is a symbol the DAL computes from the latest data of other
+// symbols instead of receiving it from the aggregators.
+type DerivedFeed struct {
+	Name       string             `json:"name"`
+	Type       DerivedType        `json:"type"`
+	Components []DerivedComponent `json:"components"`
+	Decimals   *int               `json:"decimals"`
+}
+
+// loadDerivedFeeds reads the derived feeds from DAL_DERIVED_FEEDS, a json
+// array of DerivedFeed. Feeds over symbols missing from configs are skipped.
+func loadDerivedFeeds(configs map[string]Config) ([]DerivedFeed, error) {
+	raw := os.Getenv("DAL_DERIVED_FEEDS")
+	if raw == "" {
+		return nil, nil
+	}
+
+	var feeds []DerivedFeed
+	if err := json.Unmarshal([]byte(raw), &feeds); err != nil {
+		log.Error().Err(err).Str("Player", "DalCollector").Msg("failed to parse DAL_DERIVED_FEEDS")
+		return nil, errorsentinel.ErrDalInvalidDerivedFeed
+	}
+
+	result := make([]DerivedFeed, 0, len(feeds))
+	names := map[string]struct{}{}
+	for _, feed := range feeds {
+		if err := feed.validate(); err != nil {
+			log.Error().Str("Player", "DalCollector").Str("name", feed.Name).Msg("invalid derived feed")
+			return nil, err
+		}
+		if _, ok := configs[feed.Name]; ok {
+			log.Error().Str("Player", "DalCollector").Str("name", feed.Name).Msg("derived feed shadows an aggregated symbol")
+			return nil, errorsentinel.ErrDalInvalidDerivedFeed
+		}
+		if _, ok := names[feed.Name]; ok {
+			log.Error().Str("Player", "DalCollector").Str("name", feed.Name).Msg("duplicated derived feed")
+			return nil, errorsentinel.ErrDalInvalidDerivedFeed
+		}
+
+		missing := ""
+		for _, component := range feed.Components {
+			if _, ok := configs[component.Symbol]; !ok {
+				missing = component.Symbol
+				break
+			}
+		}
+		if missing != "" {
+			log.Warn().Str("Player", "DalCollector").Str("name", feed.Name).Str("component", missing).Msg("skipping derived feed with unknown component")
+			continue
+		}
+
+		names[feed.Name] = struct{}{}
+		result = append(result, feed)
+	}
+	return result, nil
+}
+
+func (f DerivedFeed) validate() error {
+	if f.Name == "" || !strings.Contains(f.Name, "-") || len(f.Components) == 0 {
+		return errorsentinel.ErrDalInvalidDerivedFeed
+	}
+	if f.Decimals != nil && (*f.Decimals < 0 || *f.Decimals > 18) {
+		return errorsentinel.ErrDalInvalidDerivedFeed
+	}
+
+	switch f.Type {
+	case Product:
+	case Inverse:
+		if len(f.Components) != 1 {
+			return errorsentinel.ErrDalInvalidDerivedFeed
+		}
+	case Basket:
+		for _, component := range f.Components {
+			if component.Weight == 0 {
+				return errorsentinel.ErrDalInvalidDerivedFeed
+			}
+		}
+	default:
+		return errorsentinel.ErrDalInvalidDerivedFeed
+	}
+	return nil
+}
+
+// config returns the config the derived symbol is served with. Its rounds are
+// expected at the slowest interval of its components.
+func (f DerivedFeed) config(configs map[string]Config) Config {
+	decimals, _ := strconv.Atoi(DefaultDecimals)
+	if f.Decimals != nil {
+		decimals = *f.Decimals
+	}
+
+	config := Config{Name: f.Name, Decimals: &decimals}
+	for _, component := range f.Components {
+		interval := configs[component.Symbol].AggregateInterval
+		if interval != nil && (config.AggregateInterval == nil || *interval > *config.AggregateInterval) {
+			config.AggregateInterval = interval
+		}
+	}
+	return config
+}
+
+// Formula returns the formula of the feed as published with its data.
+func (f DerivedFeed) Formula() dalcommon.DerivedFormula {
+	decimals, _ := strconv.Atoi(DefaultDecimals)
+	if f.Decimals != nil {
+		decimals = *f.Decimals
+	}
+	return dalcommon.DerivedFormula{Type: f.Type, Components: f.Components, Decimals: decimals}
+}
+
+// Compute returns the raw value of the feed from the data of its components,
+// in the order of Components.
+func (f DerivedFeed) Compute(components []*dalcommon.OutgoingSubmissionData) (int64, error) {
+	return f.Formula().Compute(components)
+}
+
+// DerivedConfigs returns the configs of the derived symbols, to be served
+// next to the aggregated ones.
+func (c *Collector) DerivedConfigs() []Config {
+	result := make([]Config, 0, len(c.derivedFeeds))
+	for _, feed := range c.derivedFeeds {
+		result = append(result, c.Configs[feed.Name])
+	}
+	return result
+}
+
+func (c *Collector) registerDerivedFeeds(feeds []DerivedFeed) {
+	baseConfigs := make(map[string]Config, len(c.Configs))
+	for name, config := range c.Configs {
+		baseConfigs[name] = config
+	}
+
+	c.derivedFeeds = feeds
+	c.derivedBySymbol = make(map[string][]DerivedFeed)
+	for _, feed := range feeds {
+		c.OutgoingStream[feed.Name] = make(chan *dalcommon.OutgoingSubmissionData, 1000)
+		c.FeedHashes[feed.Name] = crypto.Keccak256([]byte(feed.Name))
+		c.Configs[feed.Name] = feed.config(baseConfigs)
+
+		seen := map[string]struct{}{}
+		for _, component := range feed.Components {
+			if _, ok := seen[component.Symbol]; ok {
+				continue
+			}
+			seen[component.Symbol] = struct{}{}
+			c.derivedBySymbol[component.Symbol] = append(c.derivedBySymbol[component.Symbol], feed)
+		}
+	}
+}
+
+// updateDerived recomputes the derived feeds depending on symbol, and
+// publishes them whenever any of their components changed. A derived value is
+// as old as its oldest component.
+func (c *Collector) updateDerived(symbol string) {
+	for _, feed := range c.derivedBySymbol[symbol] {
+		result, asOf, ok := c.computeDerived(feed)
+		if !ok {
+			continue
+		}
+
+		c.mu.Lock()
+		if old, exists := c.LatestData[feed.Name]; exists && sameComponents(old.Components, result.Components) {
+			c.mu.Unlock()
+			continue
+		}
+		c.LatestTimestamps[feed.Name] = asOf
+		c.LatestData[feed.Name] = result
+		c.mu.Unlock()
+
+		select {
+		case c.OutgoingStream[feed.Name] <- result:
+		default:
+			log.Debug().Str("Player", "DalCollector").Str("Symbol", feed.Name).Msg("outgoing stream full, dropping data")
+		}
+	}
+}
+
+func sameComponents(a, b []dalcommon.ComponentData) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Symbol != b[i].Symbol || a[i].Round != b[i].Round || a[i].AggregateTime != b[i].AggregateTime || a[i].Value != b[i].Value {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Collector) computeDerived(feed DerivedFeed) (*dalcommon.OutgoingSubmissionData, time.Time, bool) {
+	c.mu.RLock()
+	components := make([]*dalcommon.OutgoingSubmissionData, len(feed.Components))
+	refs := make([]dalcommon.ComponentData, len(feed.Components))
+	var asOf time.Time
+	for i, component := range feed.Components {
+		data, ok := c.LatestData[component.Symbol]
+		if !ok {
+			c.mu.RUnlock()
+			return nil, time.Time{}, false
+		}
+		components[i] = data
+		refs[i] = dalcommon.ComponentData{
+			OutgoingSubmissionData: *data,
+			Round:                  strconv.FormatInt(int64(c.LatestRounds[component.Symbol]), 10),
+		}
+		timestamp := c.LatestTimestamps[component.Symbol]
+		if asOf.IsZero() || timestamp.Before(asOf) {
+			asOf = timestamp
+		}
+	}
+	c.mu.RUnlock()
+
+	formula := feed.Formula()
+	value, err := formula.Compute(components)
+	if err != nil {
+		log.Error().Err(err).Str("Player", "DalCollector").Str("Symbol", feed.Name).Msg("failed to compute derived feed")
+		return nil, time.Time{}, false
+	}
+
+	return &dalcommon.OutgoingSubmissionData{
+		Symbol:        feed.Name,
+		Value:         strconv.FormatInt(value, 10),
+		AggregateTime: strconv.FormatInt(asOf.UnixMilli(), 10),
+		FeedHash:      formatBytesToHex(c.FeedHashes[feed.Name]),
+		Decimals:      strconv.Itoa(formula.Decimals),
+		Derived:       true,
+		Components:    refs,
+		Formula:       &formula,
+	}, asOf, true
+}
@@ -0,0 +1,156 @@
+//nolint:all
+package collector
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"bisonai.com/miko/node/pkg/aggregator"
+	dalcommon "bisonai.com/miko/node/pkg/dal/common"
+	errorsentinel "bisonai.com/miko/node/pkg/error"
+	"github.com/stretchr/testify/assert"
+)
+
+func outgoing(symbol string, value string, decimals string) *dalcommon.OutgoingSubmissionData {
+	return &dalcommon.OutgoingSubmissionData{Symbol: symbol, Value: value, Decimals: decimals}
+}
+
+func TestDerivedFeedCompute(t *testing.T) {
+	t.Run("cross rate", func(t *testing.T) {
+		feed := DerivedFeed{Name: "ETH-KRW", Type: Product, Components: []DerivedComponent{{Symbol: "ETH-USDT"}, {Symbol: "USDT-KRW"}}}
+		value, err := feed.Compute([]*dalcommon.OutgoingSubmissionData{
+			outgoing("ETH-USDT", "300000000000", "8"),
+			outgoing("USDT-KRW", "1350", "0"),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(405000000000000), value)
+	})
+
+	t.Run("product with inverse component", func(t *testing.T) {
+		decimals := 4
+		feed := DerivedFeed{Name: "ETH-BTC", Type: Product, Decimals: &decimals, Components: []DerivedComponent{{Symbol: "ETH-USDT"}, {Symbol: "BTC-USDT", Inverse: true}}}
+		value, err := feed.Compute([]*dalcommon.OutgoingSubmissionData{
+			outgoing("ETH-USDT", "300000000000", "8"),
+			outgoing("BTC-USDT", "6000000000000", "8"),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(500), value)
+	})
+
+	t.Run("inverse", func(t *testing.T) {
+		feed := DerivedFeed{Name: "USDT-ETH", Type: Inverse, Components: []DerivedComponent{{Symbol: "ETH-USDT"}}}
+		value, err := feed.Compute([]*dalcommon.OutgoingSubmissionData{outgoing("ETH-USDT", "400000000000", "8")})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(25000), value)
+
+		_, err = feed.Compute([]*dalcommon.OutgoingSubmissionData{outgoing("ETH-USDT", "0", "8")})
+		assert.ErrorIs(t, err, errorsentinel.ErrDalDerivedDivideByZero)
+	})
+
+	t.Run("basket", func(t *testing.T) {
+		feed := DerivedFeed{Name: "IDX-USDT", Type: Basket, Components: []DerivedComponent{{Symbol: "BTC-USDT", Weight: 0.5}, {Symbol: "ETH-USDT", Weight: 2}}}
+		value, err := feed.Compute([]*dalcommon.OutgoingSubmissionData{
+			outgoing("BTC-USDT", "6000000000000", "8"),
+			outgoing("ETH-USDT", "300000000000", "8"),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3600000000000), value)
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		feed := DerivedFeed{Name: "BIG-USDT", Type: Product, Components: []DerivedComponent{{Symbol: "A-USDT"}, {Symbol: "B-USDT"}}}
+		_, err := feed.Compute([]*dalcommon.OutgoingSubmissionData{
+			outgoing("A-USDT", "9000000000000000000", "0"),
+			outgoing("B-USDT", "10", "0"),
+		})
+		assert.ErrorIs(t, err, errorsentinel.ErrDalDerivedOverflow)
+	})
+}
+
+func TestLoadDerivedFeeds(t *testing.T) {
+	configs := map[string]Config{"ETH-USDT": {Name: "ETH-USDT"}, "USDT-KRW": {Name: "USDT-KRW"}}
+
+	t.Setenv("DAL_DERIVED_FEEDS", `[
+		{"name": "ETH-KRW", "type": "product", "components": [{"symbol": "ETH-USDT"}, {"symbol": "USDT-KRW"}]},
+		{"name": "SOL-KRW", "type": "product", "components": [{"symbol": "SOL-USDT"}, {"symbol": "USDT-KRW"}]}
+	]`)
+	feeds, err := loadDerivedFeeds(configs)
+	assert.NoError(t, err)
+	assert.Len(t, feeds, 1)
+	assert.Equal(t, "ETH-KRW", feeds[0].Name)
+
+	for _, raw := range []string{
+		`not json`,
+		`[{"name": "ETH-USDT", "type": "inverse", "components": [{"symbol": "USDT-KRW"}]}]`,
+		`[{"name": "USDT-ETH", "type": "inverse", "components": [{"symbol": "ETH-USDT"}, {"symbol": "USDT-KRW"}]}]`,
+		`[{"name": "IDX-USDT", "type": "basket", "components": [{"symbol": "ETH-USDT"}]}]`,
+		`[{"name": "ETH-KRW", "type": "sum", "components": [{"symbol": "ETH-USDT"}]}]`,
+	} {
+		t.Setenv("DAL_DERIVED_FEEDS", raw)
+		_, err := loadDerivedFeeds(configs)
+		assert.ErrorIs(t, err, errorsentinel.ErrDalInvalidDerivedFeed, raw)
+	}
+}
+
+func TestUpdateDerived(t *testing.T) {
+	interval := 400
+	c := &Collector{
+		OutgoingStream:   map[string]chan *dalcommon.OutgoingSubmissionData{},
+		FeedHashes:       map[string][]byte{},
+		LatestTimestamps: map[string]time.Time{},
+		LatestRounds:     map[string]int32{},
+		LatestData:       map[string]*dalcommon.OutgoingSubmissionData{},
+		Configs: map[string]Config{
+			"ETH-USDT": {Name: "ETH-USDT", AggregateInterval: &interval},
+			"USDT-KRW": {Name: "USDT-KRW"},
+		},
+	}
+	c.registerDerivedFeeds([]DerivedFeed{{Name: "ETH-KRW", Type: Product, Components: []DerivedComponent{{Symbol: "ETH-USDT"}, {Symbol: "USDT-KRW"}}}})
+	assert.Len(t, c.DerivedConfigs(), 1)
+
+	now := time.Now()
+	receive := func(symbol string, value string, round int32, timestamp time.Time) {
+		data := &aggregator.SubmissionData{Symbol: symbol, GlobalAggregate: aggregator.GlobalAggregate{Round: round, Timestamp: timestamp}}
+		result := outgoing(symbol, value, "8")
+		result.AggregateTime = "0"
+		assert.True(t, c.compareAndSwapLatestTimestamp(data, result))
+		c.updateDerived(symbol)
+	}
+
+	receive("ETH-USDT", "300000000000", 10, now)
+	_, err := c.GetLatestData("ETH-KRW")
+	assert.Error(t, err, "derived feed needs all of its components")
+
+	receive("USDT-KRW", "135000000000", 20, now.Add(-time.Second))
+	derived, err := c.GetLatestData("ETH-KRW")
+	assert.NoError(t, err)
+	assert.Equal(t, "405000000000000", derived.Value)
+	assert.Equal(t, strconv.FormatInt(now.Add(-time.Second).UnixMilli(), 10), derived.AggregateTime)
+	assert.Len(t, derived.Components, 2)
+	assert.Equal(t, "10", derived.Components[0].Round)
+	assert.Equal(t, "20", derived.Components[1].Round)
+	assert.True(t, derived.Derived)
+	if assert.NotNil(t, derived.Formula) {
+		assert.Equal(t, Product, derived.Formula.Type)
+		assert.Equal(t, 8, derived.Formula.Decimals)
+		assert.Equal(t, []DerivedComponent{{Symbol: "ETH-USDT"}, {Symbol: "USDT-KRW"}}, derived.Formula.Components)
+	}
+	assert.Len(t, c.OutgoingStream["ETH-KRW"], 1)
+
+	// a newer component is published even though the oldest didn't move
+	receive("ETH-USDT", "310000000000", 11, now.Add(time.Second))
+	derived, _ = c.GetLatestData("ETH-KRW")
+	assert.Equal(t, "418500000000000", derived.Value)
+	assert.Equal(t, strconv.FormatInt(now.Add(-time.Second).UnixMilli(), 10), derived.AggregateTime)
+	assert.Len(t, c.OutgoingStream["ETH-KRW"], 2)
+
+	// unchanged components aren't published again
+	c.updateDerived("USDT-KRW")
+	assert.Len(t, c.OutgoingStream["ETH-KRW"], 2)
+
+	receive("USDT-KRW", "135000000000", 21, now.Add(2*time.Second))
+	derived, _ = c.GetLatestData("ETH-KRW")
+	assert.Equal(t, strconv.FormatInt(now.Add(time.Second).UnixMilli(), 10), derived.AggregateTime)
+	assert.Len(t, c.OutgoingStream["ETH-KRW"], 3)
+}
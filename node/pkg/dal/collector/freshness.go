@@ -0,0 +1,127 @@
+package collector
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	dalcommon "bisonai.com/miko/node/pkg/dal/common"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultAggregateInterval is used for configs without an aggregate
+	// interval, like the admin does when it creates them
+	DefaultAggregateInterval = 3000 * time.Millisecond
+	// DefaultStaleMultiplier is how many expected intervals may pass without
+	// a new round before a symbol is stale
+	DefaultStaleMultiplier = 5.0
+)
+
+func staleMultiplierFromEnv() float64 {
+	raw := os.Getenv("DAL_STALE_MULTIPLIER")
+	if raw == "" {
+		return DefaultStaleMultiplier
+	}
+	multiplier, err := strconv.ParseFloat(raw, 64)
+	if err != nil || multiplier <= 0 {
+		log.Warn().Str("Player", "DalCollector").Str("DAL_STALE_MULTIPLIER", raw).Msg("invalid stale multiplier, using default")
+		return DefaultStaleMultiplier
+	}
+	return multiplier
+}
+
+// ExpectedInterval returns the interval new rounds of symbol are expected at.
+func (c *Collector) ExpectedInterval(symbol string) time.Duration {
+	if config, ok := c.Configs[symbol]; ok && config.AggregateInterval != nil && *config.AggregateInterval > 0 {
+		return time.Duration(*config.AggregateInterval) * time.Millisecond
+	}
+	return DefaultAggregateInterval
+}
+
+// StaleThreshold returns the age after which the data of symbol is stale.
+func (c *Collector) StaleThreshold(symbol string) time.Duration {
+	multiplier := c.staleMultiplier
+	if multiplier <= 0 {
+		multiplier = DefaultStaleMultiplier
+	}
+	return time.Duration(float64(c.ExpectedInterval(symbol)) * multiplier)
+}
+
+// Freshness returns the freshness of data now. Data without a valid
+// aggregate time is stale.
+func (c *Collector) Freshness(data *dalcommon.OutgoingSubmissionData) dalcommon.Freshness {
+	return c.freshnessAt(data, time.Now())
+}
+
+func (c *Collector) freshnessAt(data *dalcommon.OutgoingSubmissionData, now time.Time) dalcommon.Freshness {
+	freshness := dalcommon.Freshness{
+		ExpectedIntervalMs: c.ExpectedInterval(data.Symbol).Milliseconds(),
+		Stale:              true,
+	}
+
+	aggregateTime, err := strconv.ParseInt(data.AggregateTime, 10, 64)
+	if err != nil {
+		return freshness
+	}
+
+	age := now.Sub(time.UnixMilli(aggregateTime))
+	if age < 0 {
+		age = 0
+	}
+	freshness.AgeMs = age.Milliseconds()
+	freshness.Stale = age > c.StaleThreshold(data.Symbol)
+	return freshness
+}
+
+// WithFreshness returns data along with its current freshness.
+func (c *Collector) WithFreshness(data *dalcommon.OutgoingSubmissionData) *dalcommon.FreshSubmissionData {
+	return &dalcommon.FreshSubmissionData{
+		OutgoingSubmissionData: *data,
+		Freshness:              c.Freshness(data),
+	}
+}
+
+func (c *Collector) GetLatestFreshData(symbol string) (*dalcommon.FreshSubmissionData, error) {
+	data, err := c.GetLatestData(symbol)
+	if err != nil {
+		return nil, err
+	}
+	return c.WithFreshness(data), nil
+}
+
+func (c *Collector) GetAllLatestFreshData() []dalcommon.FreshSubmissionData {
+	data := c.GetAllLatestData()
+	result := make([]dalcommon.FreshSubmissionData, len(data))
+	for i := range data {
+		result[i] = *c.WithFreshness(&data[i])
+	}
+	return result
+}
+
+// SymbolsFreshness returns the freshness of every configured symbol sorted
+// by symbol. Symbols without data are stale.
+func (c *Collector) SymbolsFreshness() []dalcommon.SymbolFreshness {
+	now := time.Now()
+	result := make([]dalcommon.SymbolFreshness, 0, len(c.Configs))
+	for symbol := range c.Configs {
+		entry := dalcommon.SymbolFreshness{Symbol: symbol}
+		data, err := c.GetLatestData(symbol)
+		if err != nil {
+			entry.Freshness = dalcommon.Freshness{
+				ExpectedIntervalMs: c.ExpectedInterval(symbol).Milliseconds(),
+				Stale:              true,
+			}
+		} else {
+			entry.AggregateTime = data.AggregateTime
+			entry.Freshness = c.freshnessAt(data, now)
+		}
+		result = append(result, entry)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Symbol < result[j].Symbol
+	})
+	return result
+}
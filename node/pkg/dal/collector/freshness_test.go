@@ -0,0 +1,62 @@
+//nolint:all
+package collector
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	dalcommon "bisonai.com/miko/node/pkg/dal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFreshness(t *testing.T) {
+	interval := 400
+	c := &Collector{
+		Configs:         map[string]Config{"BTC-USDT": {Name: "BTC-USDT", AggregateInterval: &interval}},
+		LatestData:      map[string]*dalcommon.OutgoingSubmissionData{},
+		staleMultiplier: 5,
+	}
+	now := time.UnixMilli(1_700_000_000_000)
+	data := func(symbol string, age time.Duration) *dalcommon.OutgoingSubmissionData {
+		return &dalcommon.OutgoingSubmissionData{Symbol: symbol, AggregateTime: strconv.FormatInt(now.Add(-age).UnixMilli(), 10)}
+	}
+
+	t.Run("fresh", func(t *testing.T) {
+		freshness := c.freshnessAt(data("BTC-USDT", time.Second), now)
+		assert.Equal(t, dalcommon.Freshness{AgeMs: 1000, ExpectedIntervalMs: 400, Stale: false}, freshness)
+	})
+
+	t.Run("stale after multiplier intervals", func(t *testing.T) {
+		freshness := c.freshnessAt(data("BTC-USDT", 2001*time.Millisecond), now)
+		assert.True(t, freshness.Stale)
+	})
+
+	t.Run("default interval", func(t *testing.T) {
+		freshness := c.freshnessAt(data("ETH-USDT", 10*time.Second), now)
+		assert.Equal(t, DefaultAggregateInterval.Milliseconds(), freshness.ExpectedIntervalMs)
+		assert.False(t, freshness.Stale)
+	})
+
+	t.Run("invalid aggregate time", func(t *testing.T) {
+		freshness := c.freshnessAt(&dalcommon.OutgoingSubmissionData{Symbol: "BTC-USDT"}, now)
+		assert.True(t, freshness.Stale)
+	})
+}
+
+func TestSymbolsFreshness(t *testing.T) {
+	c := &Collector{
+		Configs: map[string]Config{"BTC-USDT": {Name: "BTC-USDT"}, "ADA-USDT": {Name: "ADA-USDT"}},
+		LatestData: map[string]*dalcommon.OutgoingSubmissionData{
+			"BTC-USDT": {Symbol: "BTC-USDT", AggregateTime: strconv.FormatInt(time.Now().UnixMilli(), 10)},
+		},
+	}
+
+	result := c.SymbolsFreshness()
+	assert.Len(t, result, 2)
+	assert.Equal(t, "ADA-USDT", result[0].Symbol)
+	assert.Empty(t, result[0].AggregateTime)
+	assert.True(t, result[0].Stale)
+	assert.Equal(t, "BTC-USDT", result[1].Symbol)
+	assert.False(t, result[1].Stale)
+}
@@ -0,0 +1,184 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"bisonai.com/miko/node/pkg/aggregator"
+	"bisonai.com/miko/node/pkg/common/keys"
+	"bisonai.com/miko/node/pkg/db"
+	errorsentinel "bisonai.com/miko/node/pkg/error"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// SubRedisStartDelay gives the sub redis sidecar time to be ready
+	SubRedisStartDelay = 10 * time.Second
+	Libp2pUpstreamName = "libp2p"
+)
+
+var (
+	upstreamMessagesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "dal_upstream_messages_total",
+		Help: "Total number of submission data messages received per upstream",
+	}, []string{"upstream"})
+	upstreamAcceptedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "dal_upstream_accepted_total",
+		Help: "Total number of submission data messages per upstream that were the first to deliver their round",
+	}, []string{"upstream"})
+	upstreamLastMessageTimestamp = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "dal_upstream_last_message_timestamp_seconds",
+		Help: "Unix time of the last submission data message received per upstream",
+	}, []string{"upstream"})
+)
+
+// upstream is a source of submission data. Every upstream runs on its own and
+// reconnects by itself, so the collector keeps serving while any of them is up.
+type upstream struct {
+	name  string
+	delay time.Duration
+	start func(ctx context.Context)
+}
+
+type redisAddress struct {
+	host string
+	port string
+}
+
+func (a redisAddress) String() string {
+	return net.JoinHostPort(a.host, a.port)
+}
+
+// redisUpstreamAddresses returns the redis instances to subscribe to besides
+// REDIS_HOST and SUB_REDIS_HOST, read from DAL_REDIS_UPSTREAMS as comma
+// separated host:port entries.
+func redisUpstreamAddresses() []redisAddress {
+	raw := os.Getenv("DAL_REDIS_UPSTREAMS")
+	if raw == "" {
+		return nil
+	}
+
+	addresses := []redisAddress{}
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		host, port, err := net.SplitHostPort(entry)
+		if err != nil {
+			log.Warn().Err(err).Str("Player", "DalCollector").Str("entry", entry).Msg("ignoring invalid redis upstream")
+			continue
+		}
+		addresses = append(addresses, redisAddress{host: host, port: port})
+	}
+	return addresses
+}
+
+func (c *Collector) newRedisUpstream(ctx context.Context, name string, address redisAddress, topics []string, delay time.Duration) (upstream, error) {
+	rediscribe, err := db.NewRediscribe(
+		ctx,
+		db.WithRedisHost(address.host),
+		db.WithRedisPort(address.port),
+		db.WithRedisTopics(topics),
+		db.WithRedisRouter(c.redisRouter(name)))
+	if err != nil {
+		return upstream{}, err
+	}
+	return upstream{name: name, delay: delay, start: rediscribe.Start}, nil
+}
+
+// newLibp2pUpstream subscribes to the submission topics the aggregators
+// publish to, skipping the redis hop entirely.
+func (c *Collector) newLibp2pUpstream(ps *pubsub.PubSub, symbols []string) (upstream, error) {
+	topics := make([]*pubsub.Topic, 0, len(symbols))
+	for _, symbol := range symbols {
+		topic, err := ps.Join(keys.SubmissionDataTopic(symbol))
+		if err != nil {
+			for _, joined := range topics {
+				joined.Close()
+			}
+			return upstream{}, err
+		}
+		topics = append(topics, topic)
+	}
+
+	return upstream{
+		name: Libp2pUpstreamName,
+		start: func(ctx context.Context) {
+			for _, topic := range topics {
+				go c.subscribeTopic(ctx, topic)
+			}
+		},
+	}, nil
+}
+
+func (c *Collector) subscribeTopic(ctx context.Context, topic *pubsub.Topic) {
+	for {
+		sub, err := topic.Subscribe()
+		if err != nil {
+			log.Error().Err(err).Str("Player", "DalCollector").Str("topic", topic.String()).Msg("failed to subscribe to submission topic")
+		} else {
+			c.readTopic(ctx, sub)
+			sub.Cancel()
+		}
+
+		select {
+		case <-ctx.Done():
+			topic.Close()
+			return
+		case <-time.After(db.DefaultReconnectInterval):
+		}
+	}
+}
+
+func (c *Collector) readTopic(ctx context.Context, sub *pubsub.Subscription) {
+	for {
+		msg, err := sub.Next(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Error().Err(err).Str("Player", "DalCollector").Msg("failed to read submission topic")
+			}
+			return
+		}
+
+		if err := c.route(ctx, Libp2pUpstreamName, msg.Data); err != nil {
+			log.Error().Err(err).Str("Player", "DalCollector").Str("from", msg.GetFrom().String()).Msg("failed to handle libp2p message")
+		}
+	}
+}
+
+func (c *Collector) redisRouter(name string) func(context.Context, *redis.Message) error {
+	return func(ctx context.Context, msg *redis.Message) error {
+		return c.route(ctx, name, []byte(msg.Payload))
+	}
+}
+
+// route queues raw submission data received from an upstream for processing.
+func (c *Collector) route(ctx context.Context, name string, payload []byte) error {
+	upstreamMessagesTotal.WithLabelValues(name).Inc()
+	upstreamLastMessageTimestamp.WithLabelValues(name).SetToCurrentTime()
+
+	var data *aggregator.SubmissionData
+	err := json.Unmarshal(payload, &data)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return errorsentinel.ErrDalInvalidSubmissionData
+	}
+
+	c.processPool.AddJob(func() {
+		if c.processIncomingData(ctx, data) {
+			upstreamAcceptedTotal.WithLabelValues(name).Inc()
+		}
+	})
+	return nil
+}
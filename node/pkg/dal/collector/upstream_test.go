@@ -0,0 +1,64 @@
+//nolint:all
+package collector
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"bisonai.com/miko/node/pkg/aggregator"
+	dalcommon "bisonai.com/miko/node/pkg/dal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisUpstreamAddresses(t *testing.T) {
+	t.Setenv("DAL_REDIS_UPSTREAMS", "redis-a:6379, redis-b:6380,,invalid")
+
+	addresses := redisUpstreamAddresses()
+	assert.Equal(t, []redisAddress{{host: "redis-a", port: "6379"}, {host: "redis-b", port: "6380"}}, addresses)
+	assert.Equal(t, "redis-a:6379", addresses[0].String())
+
+	t.Setenv("DAL_REDIS_UPSTREAMS", "")
+	assert.Empty(t, redisUpstreamAddresses())
+}
+
+// The same round delivered by every upstream at once must be published once.
+func TestCompareAndSwapLatestTimestamp_DeduplicatesUpstreams(t *testing.T) {
+	c := &Collector{
+		LatestTimestamps: map[string]time.Time{},
+		LatestData:       map[string]*dalcommon.OutgoingSubmissionData{},
+	}
+	now := time.Now()
+	data := &aggregator.SubmissionData{Symbol: "BTC-USDT", GlobalAggregate: aggregator.GlobalAggregate{Timestamp: now}}
+	result := &dalcommon.OutgoingSubmissionData{Symbol: "BTC-USDT"}
+
+	var accepted atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if c.compareAndSwapLatestTimestamp(data, result) {
+				accepted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(1), accepted.Load())
+	assert.False(t, c.isNewer(data))
+
+	older := &aggregator.SubmissionData{Symbol: "BTC-USDT", GlobalAggregate: aggregator.GlobalAggregate{Timestamp: now.Add(-time.Second)}}
+	assert.False(t, c.compareAndSwapLatestTimestamp(older, result))
+
+	newer := &aggregator.SubmissionData{Symbol: "BTC-USDT", GlobalAggregate: aggregator.GlobalAggregate{Timestamp: now.Add(time.Second)}}
+	assert.True(t, c.isNewer(newer))
+	assert.True(t, c.compareAndSwapLatestTimestamp(newer, result))
+}
+
+func TestRoute_RejectsEmptyPayload(t *testing.T) {
+	c := &Collector{}
+	assert.Error(t, c.route(nil, "redis", []byte("null")))
+	assert.Error(t, c.route(nil, "redis", []byte("{")))
+}
@@ -0,0 +1,108 @@
+package common
+
+import (
+	"math/big"
+	"strconv"
+
+	errorsentinel "bisonai.com/miko/node/pkg/error"
+)
+
+type DerivedType string
+
+const (
+	// Product multiplies its components, or divides by the inverse ones,
+	// e.g. ETH-KRW = ETH-USDT × USDT-KRW
+	Product DerivedType = "product"
+	// Inverse is the reciprocal of its single component, e.g. USDT-ETH
+	Inverse DerivedType = "inverse"
+	// Basket is the weighted sum of its components, e.g. an index
+	Basket DerivedType = "basket"
+)
+
+type DerivedComponent struct {
+	Symbol string `json:"symbol"`
+	// Inverse divides by the component in a product
+	Inverse bool `json:"inverse,omitempty"`
+	// Weight of the component in a basket
+	Weight float64 `json:"weight,omitempty"`
+}
+
+// DerivedFormula is how the value of derived data follows from its
+// components. It is published with the data so that consumers can recompute
+// the value from the verified components.
+type DerivedFormula struct {
+	Type       DerivedType        `json:"type"`
+	Components []DerivedComponent `json:"components"`
+	Decimals   int                `json:"decimals"`
+}
+
+// Compute returns the raw value of the formula from the data of its
+// components, in the order of Components.
+func (f DerivedFormula) Compute(components []*OutgoingSubmissionData) (int64, error) {
+	if len(components) != len(f.Components) || f.Decimals < 0 {
+		return 0, errorsentinel.ErrDalInvalidDerivedFeed
+	}
+
+	values := make([]*big.Rat, len(components))
+	for i, data := range components {
+		value, err := toRat(data)
+		if err != nil {
+			return 0, err
+		}
+		values[i] = value
+	}
+
+	var result *big.Rat
+	switch f.Type {
+	case Product:
+		result = big.NewRat(1, 1)
+		for i, component := range f.Components {
+			if component.Inverse {
+				if values[i].Sign() == 0 {
+					return 0, errorsentinel.ErrDalDerivedDivideByZero
+				}
+				result.Quo(result, values[i])
+			} else {
+				result.Mul(result, values[i])
+			}
+		}
+	case Inverse:
+		if len(values) != 1 {
+			return 0, errorsentinel.ErrDalInvalidDerivedFeed
+		}
+		if values[0].Sign() == 0 {
+			return 0, errorsentinel.ErrDalDerivedDivideByZero
+		}
+		result = new(big.Rat).Inv(values[0])
+	case Basket:
+		result = new(big.Rat)
+		for i, component := range f.Components {
+			weight := new(big.Rat).SetFloat64(component.Weight)
+			if weight == nil {
+				return 0, errorsentinel.ErrDalInvalidDerivedFeed
+			}
+			result.Add(result, weight.Mul(weight, values[i]))
+		}
+	default:
+		return 0, errorsentinel.ErrDalInvalidDerivedFeed
+	}
+
+	scaled := result.Mul(result, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(f.Decimals)), nil)))
+	value, err := strconv.ParseInt(scaled.FloatString(0), 10, 64)
+	if err != nil {
+		return 0, errorsentinel.ErrDalDerivedOverflow
+	}
+	return value, nil
+}
+
+func toRat(data *OutgoingSubmissionData) (*big.Rat, error) {
+	value, ok := new(big.Int).SetString(data.Value, 10)
+	if !ok {
+		return nil, errorsentinel.ErrDalInvalidSubmissionData
+	}
+	decimals, err := strconv.Atoi(data.Decimals)
+	if err != nil || decimals < 0 {
+		return nil, errorsentinel.ErrDalInvalidSubmissionData
+	}
+	return new(big.Rat).SetFrac(value, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)), nil
+}
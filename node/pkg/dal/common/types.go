@@ -7,4 +7,36 @@ type OutgoingSubmissionData struct {
 	Proof         string `json:"proof"`
 	FeedHash      string `json:"feedHash"`
 	Decimals      string `json:"decimals"`
+	// Derived data is computed by the DAL and carries no proof of its own,
+	// the proofs of the Components it was computed from are verified instead
+	Derived    bool            `json:"derived,omitempty"`
+	Components []ComponentData `json:"components,omitempty"`
+	Formula    *DerivedFormula `json:"formula,omitempty"`
+}
+
+type ComponentData struct {
+	OutgoingSubmissionData
+	Round string `json:"round"`
+}
+
+// Freshness tells how old the data of a symbol is compared to the interval
+// its rounds are expected at.
+type Freshness struct {
+	AgeMs              int64 `json:"ageMs"`
+	ExpectedIntervalMs int64 `json:"expectedIntervalMs"`
+	Stale              bool  `json:"stale"`
+}
+
+// FreshSubmissionData is submission data with its freshness at the time it
+// was served.
+type FreshSubmissionData struct {
+	OutgoingSubmissionData
+	Freshness
+}
+
+type SymbolFreshness struct {
+	Symbol string `json:"symbol"`
+	// AggregateTime is empty if no data was received for the symbol yet
+	AggregateTime string `json:"aggregateTime,omitempty"`
+	Freshness
 }
@@ -2,6 +2,10 @@ package hub
 
 import (
 	"context"
+	"os"
+	"path"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -9,15 +13,25 @@ import (
 	"bisonai.com/miko/node/pkg/common/types"
 	"bisonai.com/miko/node/pkg/dal/collector"
 	dalcommon "bisonai.com/miko/node/pkg/dal/common"
+	"bisonai.com/miko/node/pkg/dal/utils/keycache"
 	"bisonai.com/miko/node/pkg/dal/utils/stats"
 	"github.com/rs/zerolog/log"
 	"nhooyr.io/websocket"
-	"nhooyr.io/websocket/wsjson"
 )
 
+// Subscription is a request of a websocket client. Requests carrying an id
+// are acknowledged with a Response of the same id.
 type Subscription struct {
 	Method string   `json:"method"`
 	Params []string `json:"params"`
+	ID     *int64   `json:"id,omitempty"`
+}
+
+type Response struct {
+	ID       *int64   `json:"id"`
+	Result   any      `json:"result"`
+	Rejected []string `json:"rejected,omitempty"`
+	Error    string   `json:"error,omitempty"`
 }
 
 type Hub struct {
@@ -27,35 +41,130 @@ type Hub struct {
 	Unregister chan *websocket.Conn
 	broadcast  map[string]chan *dalcommon.OutgoingSubmissionData
 	mu         sync.RWMutex
+
+	queues     map[*websocket.Conn]*clientQueue
+	queueSize  int
+	dropPolicy DropPolicy
+	// evicted holds the clients disconnected by the hub until their handler
+	// unregisters them, so they don't get a new queue and writer
+	evicted map[*websocket.Conn]struct{}
+	// latest returns the data sent as snapshot on subscription
+	latest func(symbol string) (*dalcommon.FreshSubmissionData, error)
+	// fresh attaches the freshness to broadcast data
+	fresh func(data *dalcommon.OutgoingSubmissionData) *dalcommon.FreshSubmissionData
+
+	listeners   map[*listener]struct{}
+	listenersMu sync.RWMutex
+}
+
+// listener receives the data of its symbols over a channel, for streams that
+// aren't websocket connections (SSE, gRPC, webhooks).
+type listener struct {
+	kind    ListenerKind
+	symbols map[string]struct{}
+	queue   *clientQueue
+	ch      chan *dalcommon.OutgoingSubmissionData
 }
 
+// ListenerKind labels the dropped messages of a listener.
+type ListenerKind string
+
+const (
+	ListenerSSE     ListenerKind = "sse"
+	ListenerGRPC    ListenerKind = "grpc"
+	ListenerWebhook ListenerKind = "webhook"
+)
+
+const (
+	MethodSubscribe         = "SUBSCRIBE"
+	MethodUnsubscribe       = "UNSUBSCRIBE"
+	MethodListSubscriptions = "LIST_SUBSCRIPTIONS"
+	MethodPing              = "PING"
+
+	SubmissionTopicPrefix = "submission@"
+)
+
 const (
-	MAX_CONNECTIONS = 10
 	CleanupInterval = time.Hour
 	WriteTimeout    = 10 * time.Second
 )
 
+type HubConfig struct {
+	QueueSize  int
+	DropPolicy DropPolicy
+}
+
+type HubOption func(*HubConfig)
+
+func WithQueueSize(size int) HubOption {
+	return func(config *HubConfig) {
+		config.QueueSize = size
+	}
+}
+
+func WithDropPolicy(policy DropPolicy) HubOption {
+	return func(config *HubConfig) {
+		config.DropPolicy = policy
+	}
+}
+
 func HubSetup(ctx context.Context, configs []types.Config) *Hub {
 	symbolsMap := make(map[string]struct{})
 	for _, config := range configs {
 		symbolsMap[config.Name] = struct{}{}
 	}
 
-	hub := NewHub(symbolsMap)
+	opts := []HubOption{}
+	if rawSize := os.Getenv("DAL_CLIENT_QUEUE_SIZE"); rawSize != "" {
+		size, err := strconv.Atoi(rawSize)
+		if err != nil || size <= 0 {
+			log.Warn().Str("DAL_CLIENT_QUEUE_SIZE", rawSize).Msg("invalid client queue size, using default")
+		} else {
+			opts = append(opts, WithQueueSize(size))
+		}
+	}
+	if rawPolicy := os.Getenv("DAL_CLIENT_DROP_POLICY"); rawPolicy != "" {
+		policy, ok := ParseDropPolicy(rawPolicy)
+		if !ok {
+			log.Warn().Str("DAL_CLIENT_DROP_POLICY", rawPolicy).Msg("invalid client drop policy, using default")
+		} else {
+			opts = append(opts, WithDropPolicy(policy))
+		}
+	}
+
+	hub := NewHub(symbolsMap, opts...)
 	return hub
 }
 
-func NewHub(symbols map[string]struct{}) *Hub {
+func NewHub(symbols map[string]struct{}, opts ...HubOption) *Hub {
+	config := &HubConfig{
+		QueueSize:  DefaultClientQueueSize,
+		DropPolicy: DefaultDropPolicy,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+
 	return &Hub{
 		Symbols:    symbols,
 		Clients:    make(map[*websocket.Conn]map[string]struct{}),
 		Register:   make(chan *websocket.Conn),
 		Unregister: make(chan *websocket.Conn),
 		broadcast:  make(map[string]chan *dalcommon.OutgoingSubmissionData),
+		queues:     make(map[*websocket.Conn]*clientQueue),
+		evicted:    make(map[*websocket.Conn]struct{}),
+		queueSize:  config.QueueSize,
+		dropPolicy: config.DropPolicy,
+		listeners:  make(map[*listener]struct{}),
 	}
 }
 
 func (h *Hub) Start(ctx context.Context, collector *collector.Collector) {
+	h.mu.Lock()
+	h.latest = collector.GetLatestFreshData
+	h.fresh = collector.WithFreshness
+	h.mu.Unlock()
+
 	go h.handleClientRegistration(ctx)
 
 	h.initializeBroadcastChannels(collector)
@@ -68,35 +177,220 @@ func (h *Hub) Start(ctx context.Context, collector *collector.Collector) {
 	go h.cleanupJob(ctx)
 }
 
-func (h *Hub) HandleSubscription(ctx context.Context, client *websocket.Conn, msg Subscription, id int32) {
+// HandleSubscription subscribes client to the known symbols of msg that
+// policy allows and returns them. Params can be glob patterns such as
+// submission@* or submission@*-USDT. The params matching no symbol the key
+// can read are returned as rejected.
+//
+// The acknowledgement, if msg has an id, and the latest data of the newly
+// subscribed symbols are queued before any later broadcast. A client that
+// can't keep up with them is disconnected, and nothing is returned.
+func (h *Hub) HandleSubscription(ctx context.Context, client *websocket.Conn, msg Subscription, id int32, policy *keycache.KeyPolicy) (subscribed []string, rejected []string) {
+	h.mu.Lock()
+	subscriptions, queue, ok := h.ensureClient(client)
+	if !ok {
+		h.mu.Unlock()
+		return nil, nil
+	}
+
+	for _, param := range msg.Params {
+		matched := false
+		for _, symbol := range h.matchSymbols(strings.TrimPrefix(param, SubmissionTopicPrefix)) {
+			if !policy.AllowsSymbol(symbol) {
+				log.Warn().Str("Symbol", symbol).Int32("id", id).Msg("symbol not allowed for api key")
+				continue
+			}
+			matched = true
+			if _, ok := subscriptions[symbol]; ok {
+				continue
+			}
+			subscriptions[symbol] = struct{}{}
+			subscribed = append(subscribed, symbol)
+		}
+		if !matched {
+			rejected = append(rejected, param)
+		}
+	}
+
+	topics := make([]string, len(subscribed))
+	for i, symbol := range subscribed {
+		topics[i] = SubmissionTopicPrefix + symbol
+	}
+	queued := true
+	if msg.ID != nil {
+		queued = queue.push(queuedMessage{payload: Response{ID: msg.ID, Result: topics, Rejected: rejected}})
+	}
+	if queued && h.latest != nil {
+		for _, symbol := range subscribed {
+			data, err := h.latest(symbol)
+			if err != nil {
+				continue
+			}
+			if queued = queue.push(queuedMessage{symbol: symbol, payload: data}); !queued {
+				break
+			}
+		}
+	}
+	h.mu.Unlock()
+
+	if !queued {
+		h.evict(client)
+		return nil, nil
+	}
+
+	if len(subscribed) > 0 {
+		if err := stats.InsertWebsocketSubscriptions(ctx, id, topics); err != nil {
+			log.Error().Err(err).Msg("failed to insert websocket subscriptions")
+		}
+	}
+	return subscribed, rejected
+}
+
+// HandleUnsubscription removes the subscriptions of client matching params,
+// which can be glob patterns like in HandleSubscription, and returns the
+// removed symbols.
+func (h *Hub) HandleUnsubscription(client *websocket.Conn, params []string) []string {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
 	subscriptions, ok := h.Clients[client]
 	if !ok {
-		subscriptions = map[string]struct{}{}
+		return nil
 	}
 
-	valid := []string{}
-	for _, param := range msg.Params {
-		symbol := strings.TrimPrefix(param, "submission@")
-		if _, ok := h.Symbols[symbol]; !ok {
-			continue
+	removed := []string{}
+	for _, param := range params {
+		for _, symbol := range h.matchSymbols(strings.TrimPrefix(param, SubmissionTopicPrefix)) {
+			if _, ok := subscriptions[symbol]; ok {
+				delete(subscriptions, symbol)
+				removed = append(removed, symbol)
+			}
 		}
-		subscriptions[symbol] = struct{}{}
-		valid = append(valid, param)
 	}
-	h.Clients[client] = subscriptions
+	return removed
+}
 
-	defer func(subscribed []string) {
-		if len(valid) == 0 {
-			return
+// Send queues v, a message that is never coalesced, to client.
+func (h *Hub) Send(client *websocket.Conn, v any) {
+	h.mu.Lock()
+	_, queue, ok := h.ensureClient(client)
+	h.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	if !queue.push(queuedMessage{payload: v}) {
+		h.evict(client)
+	}
+}
+
+// QueueLen returns the number of messages waiting to be sent to client.
+func (h *Hub) QueueLen(client *websocket.Conn) int {
+	h.mu.RLock()
+	queue, ok := h.queues[client]
+	h.mu.RUnlock()
+	if !ok {
+		return 0
+	}
+	return queue.len()
+}
+
+// Subscriptions returns the topics client is subscribed to, sorted.
+func (h *Hub) Subscriptions(client *websocket.Conn) []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	topics := make([]string, 0, len(h.Clients[client]))
+	for symbol := range h.Clients[client] {
+		topics = append(topics, SubmissionTopicPrefix+symbol)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// matchSymbols returns the known symbols matching pattern, a symbol or a
+// path.Match pattern.
+func (h *Hub) matchSymbols(pattern string) []string {
+	if !strings.ContainsAny(pattern, "*?[") {
+		if _, ok := h.Symbols[pattern]; ok {
+			return []string{pattern}
 		}
+		return nil
+	}
 
-		if err := stats.InsertWebsocketSubscriptions(ctx, id, valid); err != nil {
-			log.Error().Err(err).Msg("failed to insert websocket subscriptions")
+	result := []string{}
+	for symbol := range h.Symbols {
+		if ok, err := path.Match(pattern, symbol); err == nil && ok {
+			result = append(result, symbol)
 		}
-	}(valid)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Listen returns a channel receiving the data of the known symbols among
+// symbols, and a function to stop listening. A listener that falls behind is
+// handled by the queue size and drop policy of the clients rather than
+// holding them back: it misses data or, with Disconnect, its channel is
+// closed.
+func (h *Hub) Listen(kind ListenerKind, symbols []string) (<-chan *dalcommon.OutgoingSubmissionData, func()) {
+	l := &listener{
+		kind:    kind,
+		symbols: make(map[string]struct{}, len(symbols)),
+		queue:   newListenerQueue(kind, h.queueSize, h.dropPolicy),
+		ch:      make(chan *dalcommon.OutgoingSubmissionData),
+	}
+	for _, symbol := range symbols {
+		if _, ok := h.Symbols[symbol]; ok {
+			l.symbols[symbol] = struct{}{}
+		}
+	}
+
+	h.listenersMu.Lock()
+	h.listeners[l] = struct{}{}
+	h.listenersMu.Unlock()
+	go l.forward()
+
+	return l.ch, func() {
+		h.removeListener(l)
+	}
+}
+
+func (h *Hub) removeListener(l *listener) {
+	h.listenersMu.Lock()
+	delete(h.listeners, l)
+	h.listenersMu.Unlock()
+	l.queue.close()
+}
+
+// forward sends the queued data of l to its channel until its queue is
+// closed, then closes the channel.
+func (l *listener) forward() {
+	defer close(l.ch)
+	for {
+		select {
+		case <-l.queue.done:
+			return
+		case <-l.queue.notify:
+			for {
+				msg, ok := l.queue.pop()
+				if !ok {
+					break
+				}
+				select {
+				case l.ch <- msg.payload.(*dalcommon.OutgoingSubmissionData):
+				case <-l.queue.done:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (h *Hub) ListenerCount() int {
+	h.listenersMu.RLock()
+	defer h.listenersMu.RUnlock()
+	return len(h.listeners)
 }
 
 func (h *Hub) ConnectionCount() int {
@@ -121,29 +415,82 @@ func (h *Hub) handleClientRegistration(ctx context.Context) {
 func (h *Hub) addClient(client *websocket.Conn) {
 	h.mu.Lock() // Use write lock for both checking and insertion
 	defer h.mu.Unlock()
-	if _, ok := h.Clients[client]; ok {
-		return
-	}
-	h.Clients[client] = make(map[string]struct{})
+	h.ensureClient(client)
 }
 
-func (h *Hub) removeClient(client *websocket.Conn) {
-	h.mu.Lock() // Use write lock for both checking and removal
-	defer h.mu.Unlock()
+// ensureClient returns the subscriptions and the send queue of client,
+// starting its writer if it's new, or false if the hub disconnected client. It
+// should be called with mu held.
+func (h *Hub) ensureClient(client *websocket.Conn) (map[string]struct{}, *clientQueue, bool) {
+	if _, ok := h.evicted[client]; ok {
+		return nil, nil, false
+	}
 	subscriptions, ok := h.Clients[client]
 	if !ok {
-		return
+		subscriptions = make(map[string]struct{})
+		h.Clients[client] = subscriptions
+	}
+	queue, ok := h.queues[client]
+	if !ok {
+		queue = newClientQueue(h.queueSize, h.dropPolicy)
+		h.queues[client] = queue
+		go h.writeLoop(client, queue)
 	}
-	delete(h.Clients, client)
-	for symbol := range subscriptions {
-		delete(subscriptions, symbol)
+	return subscriptions, queue, true
+}
+
+// dropClient removes client and stops its writer, returning false if it was
+// already removed. It should be called with mu held.
+func (h *Hub) dropClient(client *websocket.Conn) bool {
+	_, ok := h.Clients[client]
+	if ok {
+		delete(h.Clients, client)
+	}
+	if queue, queued := h.queues[client]; queued {
+		queue.close()
+		delete(h.queues, client)
+	}
+	return ok
+}
+
+// removeClient forgets client once its handler is done with it.
+func (h *Hub) removeClient(client *websocket.Conn) {
+	h.mu.Lock()
+	removed := h.dropClient(client)
+	delete(h.evicted, client)
+	h.mu.Unlock()
+	if !removed {
+		return
 	}
 
 	err := client.Close(websocket.StatusNormalClosure, "")
 	if err != nil {
 		log.Warn().Err(err).Msg("failed to write close message")
 	}
+}
 
+func (h *Hub) evict(client *websocket.Conn) {
+	slowClientDisconnectsTotal.Inc()
+	h.disconnect(client, websocket.StatusPolicyViolation, "slow consumer")
+}
+
+// disconnect closes client, which is refused by ensureClient until it's
+// unregistered.
+func (h *Hub) disconnect(client *websocket.Conn, code websocket.StatusCode, reason string) {
+	h.mu.Lock()
+	removed := h.dropClient(client)
+	if removed {
+		h.evicted[client] = struct{}{}
+	}
+	h.mu.Unlock()
+	if !removed {
+		return
+	}
+
+	err := client.Close(code, reason)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to write close message")
+	}
 }
 
 func (h *Hub) initializeBroadcastChannels(collector *collector.Collector) {
@@ -154,42 +501,58 @@ func (h *Hub) initializeBroadcastChannels(collector *collector.Collector) {
 
 func (h *Hub) broadcastDataForSymbol(ctx context.Context, symbol string) {
 	for data := range h.broadcast[symbol] {
-		h.castSubmissionData(ctx, data, symbol)
+		h.castToListeners(data, symbol)
+		h.castSubmissionData(data, symbol)
+	}
+}
+
+func (h *Hub) castToListeners(data *dalcommon.OutgoingSubmissionData, symbol string) {
+	var slowListeners []*listener
+
+	h.listenersMu.RLock()
+	for l := range h.listeners {
+		if _, ok := l.symbols[symbol]; !ok {
+			continue
+		}
+		if !l.queue.push(queuedMessage{symbol: symbol, payload: data}) {
+			slowListeners = append(slowListeners, l)
+		}
+	}
+	h.listenersMu.RUnlock()
+
+	for _, l := range slowListeners {
+		log.Warn().Str("Listener", string(l.kind)).Msg("listener queue full, disconnecting")
+		l.queue.dropped("disconnect")
+		h.removeListener(l)
 	}
 }
 
-func (h *Hub) castSubmissionData(ctx context.Context, data *dalcommon.OutgoingSubmissionData, symbol string) {
-	var wg sync.WaitGroup
-	var failedMu sync.Mutex
-	var failedClients []*websocket.Conn
+// castSubmissionData queues data for the clients subscribed to symbol, the
+// writes happen in the writer of each client.
+func (h *Hub) castSubmissionData(data *dalcommon.OutgoingSubmissionData, symbol string) {
+	var slowClients []*websocket.Conn
 
 	h.mu.RLock()
+	var payload any = data
+	if h.fresh != nil {
+		payload = h.fresh(data)
+	}
 	for client, subscriptions := range h.Clients {
-		if _, ok := subscriptions[symbol]; ok {
-			wg.Add(1)
-			go func(entry *websocket.Conn) {
-				defer wg.Done()
-				writeCtx, cancel := context.WithTimeout(ctx, WriteTimeout)
-				defer cancel()
-				if err := wsjson.Write(writeCtx, entry, data); err != nil {
-					log.Warn().Err(err).Msg("failed to write message to client")
-					failedMu.Lock()
-					failedClients = append(failedClients, entry)
-					failedMu.Unlock()
-				}
-			}(client)
+		if _, ok := subscriptions[symbol]; !ok {
+			continue
+		}
+		queue, ok := h.queues[client]
+		if !ok {
+			continue
+		}
+		if !queue.push(queuedMessage{symbol: symbol, payload: payload}) {
+			slowClients = append(slowClients, client)
 		}
 	}
 	h.mu.RUnlock()
-	wg.Wait()
 
-	if len(failedClients) > 0 {
-		h.mu.Lock()
-		for _, client := range failedClients {
-			delete(h.Clients, client)
-			client.Close(websocket.StatusGoingAway, "write failed")
-		}
-		h.mu.Unlock()
+	for _, client := range slowClients {
+		h.evict(client)
 	}
 }
 
@@ -213,7 +576,8 @@ func (h *Hub) cleanup() {
 
 	for client, subscriptions := range h.Clients {
 		if len(subscriptions) == 0 {
-			delete(h.Clients, client)
+			h.dropClient(client)
+			h.evicted[client] = struct{}{}
 			client.Close(websocket.StatusNormalClosure, "")
 		}
 	}
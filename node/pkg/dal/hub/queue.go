@@ -0,0 +1,210 @@
+package hub
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+// DropPolicy decides what happens when the send queue of a client is full.
+type DropPolicy string
+
+const (
+	// DropOldest drops the oldest queued message.
+	DropOldest DropPolicy = "drop_oldest"
+	// Coalesce replaces a queued message of the same symbol with the latest
+	// one, falling back to DropOldest for other messages.
+	Coalesce DropPolicy = "coalesce"
+	// Disconnect closes the connection of the client.
+	Disconnect DropPolicy = "disconnect"
+)
+
+const (
+	DefaultClientQueueSize = 256
+	DefaultDropPolicy      = Coalesce
+)
+
+var (
+	clientQueueDepth = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "dal_client_queue_depth",
+		Help:    "Depth of the client send queues when a message is queued",
+		Buckets: []float64{0, 1, 2, 4, 8, 16, 32, 64, 128, 256, 512},
+	})
+	clientQueueDroppedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "dal_client_queue_dropped_total",
+		Help: "Total number of messages dropped from client send queues",
+	}, []string{"reason"})
+	slowClientDisconnectsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "dal_slow_client_disconnects_total",
+		Help: "Total number of clients disconnected for not keeping up with their send queue",
+	})
+	listenerDroppedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "dal_listener_dropped_total",
+		Help: "Total number of messages dropped from listener queues, by listener type",
+	}, []string{"listener", "reason"})
+)
+
+func ParseDropPolicy(raw string) (DropPolicy, bool) {
+	switch policy := DropPolicy(raw); policy {
+	case DropOldest, Coalesce, Disconnect:
+		return policy, true
+	default:
+		return "", false
+	}
+}
+
+type queuedMessage struct {
+	// symbol is empty for control frames, like acknowledgements and pongs,
+	// which are never coalesced nor dropped
+	symbol  string
+	payload any
+}
+
+// clientQueue is the bounded outbound queue of a websocket client, drained by
+// its own writer so a slow client only holds back itself.
+type clientQueue struct {
+	mu       sync.Mutex
+	messages []queuedMessage
+	size     int
+	policy   DropPolicy
+	notify   chan struct{}
+	done     chan struct{}
+	closed   bool
+	// dropped counts a message dropped for reason
+	dropped func(reason string)
+	// depth observes the queue depth, if set
+	depth prometheus.Observer
+}
+
+func newClientQueue(size int, policy DropPolicy) *clientQueue {
+	return &clientQueue{
+		messages: make([]queuedMessage, 0, size),
+		size:     size,
+		policy:   policy,
+		notify:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		dropped:  func(reason string) { clientQueueDroppedTotal.WithLabelValues(reason).Inc() },
+		depth:    clientQueueDepth,
+	}
+}
+
+// newListenerQueue returns the queue of a listener of kind, its drops are
+// counted per listener kind.
+func newListenerQueue(kind ListenerKind, size int, policy DropPolicy) *clientQueue {
+	return &clientQueue{
+		messages: make([]queuedMessage, 0, size),
+		size:     size,
+		policy:   policy,
+		notify:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		dropped:  func(reason string) { listenerDroppedTotal.WithLabelValues(string(kind), reason).Inc() },
+	}
+}
+
+// push queues msg, returning false if the client should be disconnected.
+// When the queue is full the oldest submission data is dropped to make room,
+// a queue holding nothing but control frames disconnects the client instead.
+func (q *clientQueue) push(msg queuedMessage) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return true
+	}
+
+	if q.policy == Coalesce && msg.symbol != "" {
+		for i := range q.messages {
+			if q.messages[i].symbol == msg.symbol {
+				q.messages[i] = msg
+				q.dropped("coalesced")
+				return true
+			}
+		}
+	}
+
+	if len(q.messages) >= q.size {
+		if q.policy == Disconnect {
+			return false
+		}
+		oldest := slices.IndexFunc(q.messages, func(queued queuedMessage) bool { return queued.symbol != "" })
+		if oldest < 0 {
+			return false
+		}
+		q.messages = slices.Delete(q.messages, oldest, oldest+1)
+		q.dropped("drop_oldest")
+	}
+
+	q.messages = append(q.messages, msg)
+	if q.depth != nil {
+		q.depth.Observe(float64(len(q.messages)))
+	}
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+func (q *clientQueue) drain() []queuedMessage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	messages := q.messages
+	q.messages = make([]queuedMessage, 0, q.size)
+	return messages
+}
+
+// pop removes and returns the oldest queued message.
+func (q *clientQueue) pop() (queuedMessage, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.messages) == 0 {
+		return queuedMessage{}, false
+	}
+	msg := q.messages[0]
+	q.messages = q.messages[1:]
+	return msg, true
+}
+
+func (q *clientQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.messages)
+}
+
+func (q *clientQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	close(q.done)
+}
+
+// writeLoop writes the queued messages of client until its queue is closed.
+// A failed write disconnects the client.
+func (h *Hub) writeLoop(client *websocket.Conn, q *clientQueue) {
+	for {
+		select {
+		case <-q.done:
+			return
+		case <-q.notify:
+			for _, msg := range q.drain() {
+				writeCtx, cancel := context.WithTimeout(context.Background(), WriteTimeout)
+				err := wsjson.Write(writeCtx, client, msg.payload)
+				cancel()
+				if err != nil {
+					log.Warn().Err(err).Msg("failed to write message to client")
+					h.disconnect(client, websocket.StatusGoingAway, "write failed")
+					return
+				}
+			}
+		}
+	}
+}
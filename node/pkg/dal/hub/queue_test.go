@@ -0,0 +1,158 @@
+//nolint:all
+package hub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	dalcommon "bisonai.com/miko/node/pkg/dal/common"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"nhooyr.io/websocket"
+)
+
+func payloads(messages []queuedMessage) []any {
+	result := make([]any, len(messages))
+	for i, msg := range messages {
+		result[i] = msg.payload
+	}
+	return result
+}
+
+func TestClientQueueDropOldest(t *testing.T) {
+	q := newClientQueue(2, DropOldest)
+
+	assert.True(t, q.push(queuedMessage{symbol: "BTC-USDT", payload: 1}))
+	assert.True(t, q.push(queuedMessage{symbol: "BTC-USDT", payload: 2}))
+	assert.True(t, q.push(queuedMessage{symbol: "ETH-USDT", payload: 3}))
+
+	assert.Equal(t, []any{2, 3}, payloads(q.drain()))
+	assert.Equal(t, 0, q.len())
+
+	// control frames are never dropped
+	assert.True(t, q.push(queuedMessage{payload: "ack"}))
+	assert.True(t, q.push(queuedMessage{symbol: "BTC-USDT", payload: 4}))
+	assert.True(t, q.push(queuedMessage{payload: "pong"}))
+	assert.Equal(t, []any{"ack", "pong"}, payloads(q.drain()))
+
+	assert.True(t, q.push(queuedMessage{payload: "ack"}))
+	assert.True(t, q.push(queuedMessage{payload: "pong"}))
+	assert.False(t, q.push(queuedMessage{symbol: "BTC-USDT", payload: 5}), "a queue of control frames disconnects")
+}
+
+func TestClientQueueCoalesce(t *testing.T) {
+	q := newClientQueue(2, Coalesce)
+
+	assert.True(t, q.push(queuedMessage{symbol: "BTC-USDT", payload: 1}))
+	assert.True(t, q.push(queuedMessage{symbol: "ETH-USDT", payload: 2}))
+	assert.True(t, q.push(queuedMessage{symbol: "BTC-USDT", payload: 3}))
+	assert.Equal(t, []any{3, 2}, payloads(q.drain()))
+
+	// responses are never coalesced nor dropped
+	assert.True(t, q.push(queuedMessage{symbol: "BTC-USDT", payload: 4}))
+	assert.True(t, q.push(queuedMessage{payload: "ack"}))
+	assert.True(t, q.push(queuedMessage{payload: "pong"}))
+	assert.Equal(t, []any{"ack", "pong"}, payloads(q.drain()))
+}
+
+func TestClientQueueDisconnect(t *testing.T) {
+	q := newClientQueue(1, Disconnect)
+
+	assert.True(t, q.push(queuedMessage{symbol: "BTC-USDT", payload: 1}))
+	assert.False(t, q.push(queuedMessage{symbol: "BTC-USDT", payload: 2}))
+
+	q.close()
+	q.close()
+	assert.True(t, q.push(queuedMessage{symbol: "BTC-USDT", payload: 3}))
+}
+
+func TestParseDropPolicy(t *testing.T) {
+	policy, ok := ParseDropPolicy("coalesce")
+	assert.True(t, ok)
+	assert.Equal(t, Coalesce, policy)
+
+	_, ok = ParseDropPolicy("block")
+	assert.False(t, ok)
+}
+
+func TestHubRefusesEvictedClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		conn.Read(r.Context())
+	}))
+	defer server.Close()
+
+	client, _, err := websocket.Dial(context.Background(), "ws"+server.URL[len("http"):], nil)
+	assert.NoError(t, err)
+
+	h := NewHub(map[string]struct{}{"BTC-USDT": {}})
+	// without a writer, so the queue stays full
+	h.Clients[client] = map[string]struct{}{}
+	h.queues[client] = newClientQueue(1, Disconnect)
+	h.Send(client, Response{Result: "pong"})
+	h.Send(client, Response{Result: "pong"})
+	assert.Equal(t, 0, h.ConnectionCount(), "slow client is evicted")
+
+	h.Send(client, Response{Result: "pong"})
+	subscribed, _ := h.HandleSubscription(context.Background(), client, Subscription{Method: MethodSubscribe, Params: []string{"BTC-USDT"}}, 1, nil)
+	assert.Empty(t, subscribed)
+	h.mu.RLock()
+	assert.Empty(t, h.queues, "no writer is started for an evicted client")
+	assert.Empty(t, h.Clients)
+	h.mu.RUnlock()
+
+	h.removeClient(client)
+	h.mu.RLock()
+	assert.Empty(t, h.evicted)
+	h.mu.RUnlock()
+}
+
+func TestListenerCoalesce(t *testing.T) {
+	h := NewHub(map[string]struct{}{"BTC-USDT": {}}, WithQueueSize(2), WithDropPolicy(Coalesce))
+	data, stop := h.Listen(ListenerSSE, []string{"BTC-USDT"})
+	defer stop()
+
+	dropped := testutil.ToFloat64(listenerDroppedTotal.WithLabelValues("sse", "coalesced"))
+	for i := 1; i <= 10; i++ {
+		h.castToListeners(&dalcommon.OutgoingSubmissionData{Symbol: "BTC-USDT", Value: strconv.Itoa(i)}, "BTC-USDT")
+	}
+	assert.Greater(t, testutil.ToFloat64(listenerDroppedTotal.WithLabelValues("sse", "coalesced")), dropped)
+
+	last := ""
+	for last != "10" {
+		select {
+		case received := <-data:
+			last = received.Value
+		case <-time.After(time.Second):
+			t.Fatalf("latest data not received, last %s", last)
+		}
+	}
+}
+
+func TestListenerDisconnect(t *testing.T) {
+	h := NewHub(map[string]struct{}{"BTC-USDT": {}}, WithQueueSize(1), WithDropPolicy(Disconnect))
+	data, stop := h.Listen(ListenerGRPC, []string{"BTC-USDT"})
+	defer stop()
+	assert.Equal(t, 1, h.ListenerCount())
+
+	for i := 1; i <= 3; i++ {
+		h.castToListeners(&dalcommon.OutgoingSubmissionData{Symbol: "BTC-USDT", Value: strconv.Itoa(i)}, "BTC-USDT")
+	}
+	assert.Equal(t, 0, h.ListenerCount())
+
+	assert.Eventually(t, func() bool {
+		select {
+		case _, ok := <-data:
+			return !ok
+		default:
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,398 @@
+// Typed access to the DAL feeds for services that can't rely on websockets.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v5.29.3
+// source: dal.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SubmissionData struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Symbol string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Value  int64                  `protobuf:"varint,2,opt,name=value,proto3" json:"value,omitempty"`
+	// Unix milliseconds of the global aggregate.
+	AggregateTime int64 `protobuf:"varint,3,opt,name=aggregate_time,json=aggregateTime,proto3" json:"aggregate_time,omitempty"`
+	// Signatures ordered for on-chain submission.
+	Proof    []byte `protobuf:"bytes,4,opt,name=proof,proto3" json:"proof,omitempty"`
+	FeedHash []byte `protobuf:"bytes,5,opt,name=feed_hash,json=feedHash,proto3" json:"feed_hash,omitempty"`
+	Decimals int32  `protobuf:"varint,6,opt,name=decimals,proto3" json:"decimals,omitempty"`
+	// Milliseconds since the aggregate time when the data was sent.
+	AgeMs              int64 `protobuf:"varint,7,opt,name=age_ms,json=ageMs,proto3" json:"age_ms,omitempty"`
+	ExpectedIntervalMs int64 `protobuf:"varint,8,opt,name=expected_interval_ms,json=expectedIntervalMs,proto3" json:"expected_interval_ms,omitempty"`
+	Stale              bool  `protobuf:"varint,9,opt,name=stale,proto3" json:"stale,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *SubmissionData) Reset() {
+	*x = SubmissionData{}
+	mi := &file_dal_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmissionData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmissionData) ProtoMessage() {}
+
+func (x *SubmissionData) ProtoReflect() protoreflect.Message {
+	mi := &file_dal_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmissionData.ProtoReflect.Descriptor instead.
+func (*SubmissionData) Descriptor() ([]byte, []int) {
+	return file_dal_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SubmissionData) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *SubmissionData) GetValue() int64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *SubmissionData) GetAggregateTime() int64 {
+	if x != nil {
+		return x.AggregateTime
+	}
+	return 0
+}
+
+func (x *SubmissionData) GetProof() []byte {
+	if x != nil {
+		return x.Proof
+	}
+	return nil
+}
+
+func (x *SubmissionData) GetFeedHash() []byte {
+	if x != nil {
+		return x.FeedHash
+	}
+	return nil
+}
+
+func (x *SubmissionData) GetDecimals() int32 {
+	if x != nil {
+		return x.Decimals
+	}
+	return 0
+}
+
+func (x *SubmissionData) GetAgeMs() int64 {
+	if x != nil {
+		return x.AgeMs
+	}
+	return 0
+}
+
+func (x *SubmissionData) GetExpectedIntervalMs() int64 {
+	if x != nil {
+		return x.ExpectedIntervalMs
+	}
+	return 0
+}
+
+func (x *SubmissionData) GetStale() bool {
+	if x != nil {
+		return x.Stale
+	}
+	return false
+}
+
+type SubscribeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbols       []string               `protobuf:"bytes,1,rep,name=symbols,proto3" json:"symbols,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	mi := &file_dal_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dal_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_dal_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SubscribeRequest) GetSymbols() []string {
+	if x != nil {
+		return x.Symbols
+	}
+	return nil
+}
+
+type GetLatestRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbol        string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetLatestRequest) Reset() {
+	*x = GetLatestRequest{}
+	mi := &file_dal_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetLatestRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetLatestRequest) ProtoMessage() {}
+
+func (x *GetLatestRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dal_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetLatestRequest.ProtoReflect.Descriptor instead.
+func (*GetLatestRequest) Descriptor() ([]byte, []int) {
+	return file_dal_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetLatestRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+type GetBatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbols       []string               `protobuf:"bytes,1,rep,name=symbols,proto3" json:"symbols,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBatchRequest) Reset() {
+	*x = GetBatchRequest{}
+	mi := &file_dal_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBatchRequest) ProtoMessage() {}
+
+func (x *GetBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_dal_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBatchRequest.ProtoReflect.Descriptor instead.
+func (*GetBatchRequest) Descriptor() ([]byte, []int) {
+	return file_dal_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetBatchRequest) GetSymbols() []string {
+	if x != nil {
+		return x.Symbols
+	}
+	return nil
+}
+
+type GetBatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []*SubmissionData      `protobuf:"bytes,1,rep,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBatchResponse) Reset() {
+	*x = GetBatchResponse{}
+	mi := &file_dal_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBatchResponse) ProtoMessage() {}
+
+func (x *GetBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_dal_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBatchResponse.ProtoReflect.Descriptor instead.
+func (*GetBatchResponse) Descriptor() ([]byte, []int) {
+	return file_dal_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetBatchResponse) GetData() []*SubmissionData {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+var File_dal_proto protoreflect.FileDescriptor
+
+const file_dal_proto_rawDesc = "" +
+	"\n" +
+	"\tdal.proto\x12\x06dal.v1\"\x93\x02\n" +
+	"\x0eSubmissionData\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value\x12%\n" +
+	"\x0eaggregate_time\x18\x03 \x01(\x03R\raggregateTime\x12\x14\n" +
+	"\x05proof\x18\x04 \x01(\fR\x05proof\x12\x1b\n" +
+	"\tfeed_hash\x18\x05 \x01(\fR\bfeedHash\x12\x1a\n" +
+	"\bdecimals\x18\x06 \x01(\x05R\bdecimals\x12\x15\n" +
+	"\x06age_ms\x18\a \x01(\x03R\x05ageMs\x120\n" +
+	"\x14expected_interval_ms\x18\b \x01(\x03R\x12expectedIntervalMs\x12\x14\n" +
+	"\x05stale\x18\t \x01(\bR\x05stale\",\n" +
+	"\x10SubscribeRequest\x12\x18\n" +
+	"\asymbols\x18\x01 \x03(\tR\asymbols\"*\n" +
+	"\x10GetLatestRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\"+\n" +
+	"\x0fGetBatchRequest\x12\x18\n" +
+	"\asymbols\x18\x01 \x03(\tR\asymbols\">\n" +
+	"\x10GetBatchResponse\x12*\n" +
+	"\x04data\x18\x01 \x03(\v2\x16.dal.v1.SubmissionDataR\x04data2\xc4\x01\n" +
+	"\x03Dal\x12?\n" +
+	"\tSubscribe\x12\x18.dal.v1.SubscribeRequest\x1a\x16.dal.v1.SubmissionData0\x01\x12=\n" +
+	"\tGetLatest\x12\x18.dal.v1.GetLatestRequest\x1a\x16.dal.v1.SubmissionData\x12=\n" +
+	"\bGetBatch\x12\x17.dal.v1.GetBatchRequest\x1a\x18.dal.v1.GetBatchResponseB&Z$bisonai.com/miko/node/pkg/dal/rpc/pbb\x06proto3"
+
+var (
+	file_dal_proto_rawDescOnce sync.Once
+	file_dal_proto_rawDescData []byte
+)
+
+func file_dal_proto_rawDescGZIP() []byte {
+	file_dal_proto_rawDescOnce.Do(func() {
+		file_dal_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_dal_proto_rawDesc), len(file_dal_proto_rawDesc)))
+	})
+	return file_dal_proto_rawDescData
+}
+
+var file_dal_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_dal_proto_goTypes = []any{
+	(*SubmissionData)(nil),   // 0: dal.v1.SubmissionData
+	(*SubscribeRequest)(nil), // 1: dal.v1.SubscribeRequest
+	(*GetLatestRequest)(nil), // 2: dal.v1.GetLatestRequest
+	(*GetBatchRequest)(nil),  // 3: dal.v1.GetBatchRequest
+	(*GetBatchResponse)(nil), // 4: dal.v1.GetBatchResponse
+}
+var file_dal_proto_depIdxs = []int32{
+	0, // 0: dal.v1.GetBatchResponse.data:type_name -> dal.v1.SubmissionData
+	1, // 1: dal.v1.Dal.Subscribe:input_type -> dal.v1.SubscribeRequest
+	2, // 2: dal.v1.Dal.GetLatest:input_type -> dal.v1.GetLatestRequest
+	3, // 3: dal.v1.Dal.GetBatch:input_type -> dal.v1.GetBatchRequest
+	0, // 4: dal.v1.Dal.Subscribe:output_type -> dal.v1.SubmissionData
+	0, // 5: dal.v1.Dal.GetLatest:output_type -> dal.v1.SubmissionData
+	4, // 6: dal.v1.Dal.GetBatch:output_type -> dal.v1.GetBatchResponse
+	4, // [4:7] is the sub-list for method output_type
+	1, // [1:4] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_dal_proto_init() }
+func file_dal_proto_init() {
+	if File_dal_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_dal_proto_rawDesc), len(file_dal_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_dal_proto_goTypes,
+		DependencyIndexes: file_dal_proto_depIdxs,
+		MessageInfos:      file_dal_proto_msgTypes,
+	}.Build()
+	File_dal_proto = out.File
+	file_dal_proto_goTypes = nil
+	file_dal_proto_depIdxs = nil
+}
@@ -0,0 +1,291 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+
+	"bisonai.com/miko/node/pkg/dal/collector"
+	dalcommon "bisonai.com/miko/node/pkg/dal/common"
+	"bisonai.com/miko/node/pkg/dal/hub"
+	"bisonai.com/miko/node/pkg/dal/rpc/pb"
+	"bisonai.com/miko/node/pkg/dal/utils/keycache"
+	errorsentinel "bisonai.com/miko/node/pkg/error"
+	"github.com/kaiachain/kaia/common"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const ApiKeyMetadata = "x-api-key"
+
+type apiKeyContextKey struct{}
+
+type policyContextKey struct{}
+
+// policyStream passes the context carrying the api key policy to stream
+// handlers.
+type policyStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *policyStream) Context() context.Context {
+	return s.ctx
+}
+
+type Server struct {
+	pb.UnimplementedDalServer
+
+	collector *collector.Collector
+	hub       *hub.Hub
+	keyCache  *keycache.KeyCache
+}
+
+type ServerConfig struct {
+	Port      string
+	Collector *collector.Collector
+	Hub       *hub.Hub
+	KeyCache  *keycache.KeyCache
+}
+
+type ServerOption func(*ServerConfig)
+
+func WithPort(port string) ServerOption {
+	return func(config *ServerConfig) {
+		config.Port = port
+	}
+}
+
+func WithCollector(c *collector.Collector) ServerOption {
+	return func(config *ServerConfig) {
+		config.Collector = c
+	}
+}
+
+func WithHub(h *hub.Hub) ServerOption {
+	return func(config *ServerConfig) {
+		config.Hub = h
+	}
+}
+
+func WithKeyCache(k *keycache.KeyCache) ServerOption {
+	return func(config *ServerConfig) {
+		config.KeyCache = k
+	}
+}
+
+func Start(ctx context.Context, opts ...ServerOption) error {
+	config := &ServerConfig{
+		Port: "8091",
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	if config.Port == "" {
+		return errorsentinel.ErrDalPortNotFound
+	}
+	if config.Collector == nil {
+		return errorsentinel.ErrDalCollectorNotFound
+	}
+	if config.Hub == nil {
+		return errorsentinel.ErrDalHubNotFound
+	}
+	if config.KeyCache == nil {
+		return errorsentinel.ErrDalKeyCacheNotFound
+	}
+
+	l, err := net.Listen("tcp", ":"+config.Port)
+	if err != nil {
+		return err
+	}
+
+	grpcServer := NewGrpcServer(NewServer(config.Collector, config.Hub, config.KeyCache))
+	go func() {
+		<-ctx.Done()
+		grpcServer.GracefulStop()
+	}()
+
+	return grpcServer.Serve(l)
+}
+
+func NewServer(collector *collector.Collector, hub *hub.Hub, keyCache *keycache.KeyCache) *Server {
+	return &Server{
+		collector: collector,
+		hub:       hub,
+		keyCache:  keyCache,
+	}
+}
+
+// NewGrpcServer registers s on a grpc server that checks the api key of
+// every call, passed as x-api-key metadata, and enforces its limits.
+func NewGrpcServer(s *Server) *grpc.Server {
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			ctx, err := s.authorize(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			ctx, err := s.authorize(ss.Context())
+			if err != nil {
+				return err
+			}
+			return handler(srv, &policyStream{ServerStream: ss, ctx: ctx})
+		}),
+	)
+	pb.RegisterDalServer(grpcServer, s)
+	return grpcServer
+}
+
+func (s *Server) Subscribe(req *pb.SubscribeRequest, stream pb.Dal_SubscribeServer) error {
+	ctx := stream.Context()
+	symbols := s.knownSymbols(ctx, req.GetSymbols())
+	if len(symbols) == 0 {
+		return status.Error(codes.InvalidArgument, "no valid symbols")
+	}
+
+	key, _ := ctx.Value(apiKeyContextKey{}).(string)
+	release, ok := s.keyCache.AcquireConnection(key, policyFromContext(ctx))
+	if !ok {
+		return status.Error(codes.ResourceExhausted, "too many connections")
+	}
+	defer release()
+
+	data, stop := s.hub.Listen(hub.ListenerGRPC, symbols)
+	defer stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case outgoing, ok := <-data:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "slow consumer")
+			}
+			result, err := ToProto(s.collector.WithFreshness(outgoing))
+			if err != nil {
+				log.Error().Err(err).Str("Symbol", outgoing.Symbol).Msg("failed to convert data to protobuf")
+				continue
+			}
+			if err := stream.Send(result); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Server) GetLatest(ctx context.Context, req *pb.GetLatestRequest) (*pb.SubmissionData, error) {
+	symbols := s.knownSymbols(ctx, []string{req.GetSymbol()})
+	if len(symbols) == 0 {
+		return nil, status.Error(codes.NotFound, "symbol not found")
+	}
+
+	data, err := s.collector.GetLatestFreshData(symbols[0])
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	result, err := ToProto(data)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return result, nil
+}
+
+func (s *Server) GetBatch(ctx context.Context, req *pb.GetBatchRequest) (*pb.GetBatchResponse, error) {
+	symbols := s.knownSymbols(ctx, req.GetSymbols())
+	response := &pb.GetBatchResponse{Data: make([]*pb.SubmissionData, 0, len(symbols))}
+	for _, symbol := range symbols {
+		data, err := s.collector.GetLatestFreshData(symbol)
+		if err != nil {
+			continue
+		}
+		result, err := ToProto(data)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		response.Data = append(response.Data, result)
+	}
+	return response, nil
+}
+
+// authorize checks the api key of the call against its rate limit and
+// quota, and returns ctx carrying the key and its policy.
+func (s *Server) authorize(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	keys := md.Get(ApiKeyMetadata)
+	if len(keys) == 0 || keys[0] == "" {
+		return ctx, status.Error(codes.Unauthenticated, "missing api key")
+	}
+
+	policy, ok := s.keyCache.Authorize(ctx, keys[0])
+	if !ok {
+		return ctx, status.Error(codes.Unauthenticated, "invalid api key")
+	}
+	if !s.keyCache.Allow(keys[0], policy) {
+		return ctx, status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	}
+	if !s.keyCache.UseQuota(ctx, keys[0], policy) {
+		return ctx, status.Error(codes.ResourceExhausted, "monthly quota exceeded")
+	}
+
+	ctx = context.WithValue(ctx, apiKeyContextKey{}, keys[0])
+	return context.WithValue(ctx, policyContextKey{}, policy), nil
+}
+
+func policyFromContext(ctx context.Context) *keycache.KeyPolicy {
+	policy, _ := ctx.Value(policyContextKey{}).(*keycache.KeyPolicy)
+	return policy
+}
+
+// knownSymbols normalizes symbols like the http api and drops unknown ones
+// and the ones the api key isn't allowed to read.
+func (s *Server) knownSymbols(ctx context.Context, symbols []string) []string {
+	policy := policyFromContext(ctx)
+	result := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		symbol = strings.TrimSpace(symbol)
+		if !strings.Contains(symbol, "test") {
+			symbol = strings.ToUpper(symbol)
+		}
+		if _, ok := s.hub.Symbols[symbol]; ok && policy.AllowsSymbol(symbol) {
+			result = append(result, symbol)
+		}
+	}
+	return result
+}
+
+func ToProto(data *dalcommon.FreshSubmissionData) (*pb.SubmissionData, error) {
+	value, err := strconv.ParseInt(data.Value, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	aggregateTime, err := strconv.ParseInt(data.AggregateTime, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	decimals, err := strconv.ParseInt(data.Decimals, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.SubmissionData{
+		Symbol:        data.Symbol,
+		Value:         value,
+		AggregateTime: aggregateTime,
+		Proof:         common.FromHex(data.Proof),
+		FeedHash:      common.FromHex(data.FeedHash),
+		Decimals:      int32(decimals),
+
+		AgeMs:              data.AgeMs,
+		ExpectedIntervalMs: data.ExpectedIntervalMs,
+		Stale:              data.Stale,
+	}, nil
+}
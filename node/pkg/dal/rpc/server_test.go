@@ -0,0 +1,131 @@
+//nolint:all
+package rpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"bisonai.com/miko/node/pkg/dal/collector"
+	dalcommon "bisonai.com/miko/node/pkg/dal/common"
+	"bisonai.com/miko/node/pkg/dal/hub"
+	"bisonai.com/miko/node/pkg/dal/rpc/pb"
+	"bisonai.com/miko/node/pkg/dal/utils/keycache"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testSymbol = "test-aggregate"
+
+var testData = &dalcommon.OutgoingSubmissionData{
+	Symbol:        testSymbol,
+	Value:         "15",
+	AggregateTime: "1700000000000",
+	Proof:         "0x0102",
+	FeedHash:      "0x0a0b",
+	Decimals:      "8",
+}
+
+func setupServer(t *testing.T) (pb.DalClient, chan *dalcommon.OutgoingSubmissionData, *hub.Hub) {
+	return setupServerWithPolicy(t, &keycache.KeyPolicy{})
+}
+
+func setupServerWithPolicy(t *testing.T, policy *keycache.KeyPolicy) (pb.DalClient, chan *dalcommon.OutgoingSubmissionData, *hub.Hub) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	stream := make(chan *dalcommon.OutgoingSubmissionData, 10)
+	c := &collector.Collector{
+		OutgoingStream: map[string]chan *dalcommon.OutgoingSubmissionData{testSymbol: stream},
+		LatestData:     map[string]*dalcommon.OutgoingSubmissionData{testSymbol: testData},
+	}
+	h := hub.NewHub(map[string]struct{}{testSymbol: {}})
+	go h.Start(ctx, c)
+
+	keyCache := keycache.NewAPIKeyCache(time.Hour)
+	keyCache.SetPolicy("testApiKey", policy)
+
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := NewGrpcServer(NewServer(c, h, keyCache))
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.DialContext(ctx, "bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("error dialing grpc server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewDalClient(conn), stream, h
+}
+
+func withKey(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, ApiKeyMetadata, "testApiKey")
+}
+
+func TestGetLatest(t *testing.T) {
+	client, _, _ := setupServer(t)
+	ctx := context.Background()
+
+	_, err := client.GetLatest(ctx, &pb.GetLatestRequest{Symbol: testSymbol})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	result, err := client.GetLatest(withKey(ctx), &pb.GetLatestRequest{Symbol: testSymbol})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(15), result.GetValue())
+	assert.Equal(t, int64(1700000000000), result.GetAggregateTime())
+	assert.Equal(t, []byte{0x01, 0x02}, result.GetProof())
+	assert.Equal(t, int32(8), result.GetDecimals())
+	assert.True(t, result.GetStale())
+	assert.Equal(t, collector.DefaultAggregateInterval.Milliseconds(), result.GetExpectedIntervalMs())
+
+	_, err = client.GetLatest(withKey(ctx), &pb.GetLatestRequest{Symbol: "UNKNOWN-USDT"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGetBatch(t *testing.T) {
+	client, _, _ := setupServer(t)
+
+	result, err := client.GetBatch(withKey(context.Background()), &pb.GetBatchRequest{Symbols: []string{testSymbol, "UNKNOWN-USDT"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(result.GetData()))
+	assert.Equal(t, testSymbol, result.GetData()[0].GetSymbol())
+}
+
+func TestSubscribe(t *testing.T) {
+	client, outgoing, h := setupServer(t)
+	ctx, cancel := context.WithTimeout(withKey(context.Background()), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.Subscribe(ctx, &pb.SubscribeRequest{Symbols: []string{testSymbol}})
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool { return h.ListenerCount() == 1 }, 2*time.Second, 10*time.Millisecond)
+	outgoing <- testData
+
+	result, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(15), result.GetValue())
+}
+
+func TestKeyPolicy(t *testing.T) {
+	rateLimit := 1.0
+	client, _, _ := setupServerWithPolicy(t, &keycache.KeyPolicy{
+		RateLimit:      &rateLimit,
+		AllowedSymbols: []string{"BTC-USDT"},
+	})
+
+	_, err := client.GetLatest(withKey(context.Background()), &pb.GetLatestRequest{Symbol: testSymbol})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.GetBatch(withKey(context.Background()), &pb.GetBatchRequest{Symbols: []string{testSymbol}})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
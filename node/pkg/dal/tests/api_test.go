@@ -2,7 +2,11 @@
 package test
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -341,6 +345,61 @@ func TestApiWebsocket(t *testing.T) {
 		}
 	})
 
+	t.Run("test protocol", func(t *testing.T) {
+		conn, err := wss.NewWebsocketHelper(ctx, wss.WithEndpoint(testItems.MockDal.URL+"/ws"), wss.WithRequestHeaders(headers))
+		if err != nil {
+			t.Fatalf("error creating websocket helper: %v", err)
+		}
+		err = conn.Dial(ctx)
+		if err != nil {
+			t.Fatalf("error dialing websocket: %v", err)
+		}
+		defer conn.Close()
+
+		ch := make(chan any, 16)
+		go conn.Read(ctx, ch)
+
+		send := func(method string, id int64, params ...string) {
+			err := conn.Write(ctx, hub.Subscription{Method: method, Params: params, ID: &id})
+			if err != nil {
+				t.Fatalf("error writing %s: %v", method, err)
+			}
+		}
+		next := func() map[string]any {
+			select {
+			case msg := <-ch:
+				return msg.(map[string]any)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timeout waiting for websocket message")
+				return nil
+			}
+		}
+
+		send("SUBSCRIBE", 1, "submission@test-*", "submission@UNKNOWN-USDT")
+		ack := next()
+		assert.Equal(t, float64(1), ack["id"])
+		assert.Equal(t, []any{"submission@test-aggregate"}, ack["result"])
+		assert.Equal(t, []any{"submission@UNKNOWN-USDT"}, ack["rejected"])
+
+		// the value published by the previous subtest
+		snapshot := next()
+		assert.Equal(t, "test-aggregate", snapshot["symbol"])
+
+		send("LIST_SUBSCRIPTIONS", 2)
+		list := next()
+		assert.Equal(t, float64(2), list["id"])
+		assert.Equal(t, []any{"submission@test-aggregate"}, list["result"])
+
+		send("PING", 3)
+		assert.Equal(t, "pong", next()["result"])
+
+		send("UNSUBSCRIBE", 4, "submission@test-aggregate")
+		assert.Equal(t, []any{"submission@test-aggregate"}, next()["result"])
+
+		send("LIST_SUBSCRIPTIONS", 5)
+		assert.Equal(t, []any{}, next()["result"])
+	})
+
 	t.Run("test fail for 10+ dial", func(t *testing.T) {
 		t.Skip("IP restriction will be implemented later")
 		conns := []*wss.WebsocketHelper{}
@@ -377,6 +436,21 @@ func TestApiWebsocket(t *testing.T) {
 		connCount := 10
 		subscriptions := []string{"submission@test-aggregate"}
 
+		expectedData, err := generateSampleSubmissionData(
+			testItems.TmpConfig.ID,
+			int64(15),
+			time.Now(),
+			1,
+			"test-aggregate",
+		)
+		if err != nil {
+			t.Fatalf("error generating expected data: %v", err)
+		}
+		expected, err := testItems.Collector.IncomingDataToOutgoingData(ctx, expectedData)
+		if err != nil {
+			t.Fatalf("error converting sample submission data to outgoing data: %v", err)
+		}
+
 		// Create a channel to collect all results
 		resultsChan := make(chan common.OutgoingSubmissionData, connCount*len(subscriptions))
 		readyChan := make(chan any, connCount)
@@ -420,17 +494,25 @@ func TestApiWebsocket(t *testing.T) {
 				// Read messages from the channel and store the results
 				for j := 0; j < len(subscriptions); j++ {
 					go func() {
-						select {
-						case sample := <-ch:
-							result, err := wsfcommon.MessageToStruct[common.OutgoingSubmissionData](sample.(map[string]any))
-							if err != nil {
-								t.Errorf("error converting sample to struct for client %d: %v", clientID, err)
+						timeout := time.After(10 * time.Second)
+						for {
+							select {
+							case sample := <-ch:
+								result, err := wsfcommon.MessageToStruct[common.OutgoingSubmissionData](sample.(map[string]any))
+								if err != nil {
+									t.Errorf("error converting sample to struct for client %d: %v", clientID, err)
+									return
+								}
+								// skip the snapshot sent on subscription
+								if result.AggregateTime != expected.AggregateTime {
+									continue
+								}
+								resultsChan <- result
+								return
+							case <-timeout: // Timeout if no message is received
+								t.Errorf("timeout waiting for message for client %d", clientID)
 								return
 							}
-							resultsChan <- result
-						case <-time.After(10 * time.Second): // Timeout if no message is received
-							t.Errorf("timeout waiting for message for client %d", clientID)
-							return
 						}
 					}()
 				}
@@ -444,22 +526,6 @@ func TestApiWebsocket(t *testing.T) {
 			<-readyChan
 		}
 
-		// Simulate data publication
-		expectedData, err := generateSampleSubmissionData(
-			testItems.TmpConfig.ID,
-			int64(15),
-			time.Now(),
-			1,
-			"test-aggregate",
-		)
-		if err != nil {
-			t.Fatalf("error generating expected data: %v", err)
-		}
-		expected, err := testItems.Collector.IncomingDataToOutgoingData(ctx, expectedData)
-		if err != nil {
-			t.Fatalf("error converting sample submission data to outgoing data: %v", err)
-		}
-
 		// Publish data
 		err = testPublishData(ctx, "test-aggregate", *expectedData)
 		if err != nil {
@@ -481,3 +547,55 @@ func TestApiWebsocket(t *testing.T) {
 	})
 
 }
+
+func TestApiStream(t *testing.T) {
+	ctx := context.Background()
+	clean, testItems, err := setup(ctx)
+	if err != nil {
+		t.Fatalf("error setting up test: %v", err)
+	}
+	defer func() {
+		if cleanupErr := clean(); cleanupErr != nil {
+			t.Logf("Cleanup failed: %v", cleanupErr)
+		}
+	}()
+
+	streamCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, testItems.MockDal.URL+"/stream?symbols=test-aggregate", nil)
+	if err != nil {
+		t.Fatalf("error creating request: %v", err)
+	}
+	req.Header.Set("X-API-Key", testItems.ApiKey)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("error opening stream: %v", err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	sampleSubmissionData, err := generateSampleSubmissionData(testItems.TmpConfig.ID, int64(15), time.Now(), 1, "test-aggregate")
+	if err != nil {
+		t.Fatalf("error generating sample submission data: %v", err)
+	}
+	publishAndAwait(ctx, t, testItems, "test-aggregate", *sampleSubmissionData)
+
+	expected, err := testItems.Collector.IncomingDataToOutgoingData(ctx, sampleSubmissionData)
+	if err != nil {
+		t.Fatalf("error converting sample submission data to outgoing data: %v", err)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		var result common.OutgoingSubmissionData
+		assert.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &result))
+		assert.Equal(t, *expected, result)
+		return
+	}
+	t.Fatal("stream closed before receiving data")
+}
@@ -0,0 +1,63 @@
+//nolint:all
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bisonai.com/miko/node/pkg/dal/apiv2"
+	"bisonai.com/miko/node/pkg/dal/common"
+	"bisonai.com/miko/node/pkg/utils/request"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiFreshness(t *testing.T) {
+	ctx := context.Background()
+	clean, testItems, err := setup(ctx)
+	if err != nil {
+		t.Fatalf("error setting up test: %v", err)
+	}
+	defer func() {
+		if cleanupErr := clean(); cleanupErr != nil {
+			t.Logf("Cleanup failed: %v", cleanupErr)
+		}
+	}()
+
+	headers := map[string]string{"X-API-Key": testItems.ApiKey}
+	status := func(path string) int {
+		resp, err := request.RequestRaw(request.WithEndpoint(testItems.MockDal.URL+path), request.WithHeaders(headers))
+		if err != nil {
+			t.Fatalf("error requesting %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	t.Run("symbols without data are stale", func(t *testing.T) {
+		health, err := request.Request[apiv2.SymbolsHealthResponse](request.WithEndpoint(testItems.MockDal.URL+"/health/symbols"), request.WithHeaders(headers))
+		assert.NoError(t, err)
+		assert.False(t, health.Healthy)
+		assert.Equal(t, 1, health.Stale)
+		assert.Equal(t, 503, status("/health/symbols?strict=true"))
+	})
+
+	sampleSubmissionData, err := generateSampleSubmissionData(testItems.TmpConfig.ID, int64(15), time.Now(), 1, "test-aggregate")
+	if err != nil {
+		t.Fatalf("error generating sample submission data: %v", err)
+	}
+	publishAndAwait(ctx, t, testItems, "test-aggregate", *sampleSubmissionData)
+
+	t.Run("fresh data", func(t *testing.T) {
+		result, err := request.Request[[]common.FreshSubmissionData](request.WithEndpoint(testItems.MockDal.URL+"/latest-data-feeds/test-aggregate?strict=true"), request.WithHeaders(headers))
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+		assert.False(t, result[0].Stale)
+		assert.Equal(t, int64(15000), result[0].ExpectedIntervalMs)
+
+		health, err := request.Request[apiv2.SymbolsHealthResponse](request.WithEndpoint(testItems.MockDal.URL+"/health/symbols?strict=true"), request.WithHeaders(headers))
+		assert.NoError(t, err)
+		assert.True(t, health.Healthy)
+		assert.Equal(t, 1, health.Total)
+	})
+}
@@ -0,0 +1,146 @@
+//nolint:all
+package test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"bisonai.com/miko/node/pkg/dal/apiv2"
+	"bisonai.com/miko/node/pkg/db"
+	"bisonai.com/miko/node/pkg/utils/request"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+)
+
+// insertHistory writes the rounds to the node database, the DAL database
+// has no global_aggregates or proofs
+func insertHistory(ctx context.Context, t *testing.T, nodeDB *pgxpool.Pool, testItems *TestItems, start time.Time, values []int64) {
+	t.Helper()
+	_, err := nodeDB.Exec(ctx, "INSERT INTO configs (id, name, fetch_interval, aggregate_interval, submit_interval, feed_data_freshness) VALUES (@id, @name, 15000, 15000, 15000, 1) ON CONFLICT DO NOTHING", pgx.NamedArgs{"id": testItems.TmpConfig.ID, "name": testItems.TmpConfig.Name})
+	if err != nil {
+		t.Fatalf("error inserting config: %v", err)
+	}
+
+	for i, value := range values {
+		data, err := generateSampleSubmissionData(testItems.TmpConfig.ID, value, start.Add(time.Duration(i)*time.Second), int32(i+1), testItems.TmpConfig.Name)
+		if err != nil {
+			t.Fatalf("error generating sample submission data: %v", err)
+		}
+		_, err = nodeDB.Exec(ctx, "INSERT INTO global_aggregates (config_id, value, timestamp, round) VALUES (@config_id, @value, @timestamp, @round)", pgx.NamedArgs{"config_id": data.GlobalAggregate.ConfigID, "value": data.GlobalAggregate.Value, "timestamp": data.GlobalAggregate.Timestamp, "round": data.GlobalAggregate.Round})
+		if err != nil {
+			t.Fatalf("error inserting global aggregate: %v", err)
+		}
+		_, err = nodeDB.Exec(ctx, "INSERT INTO proofs (config_id, round, proof) VALUES (@config_id, @round, @proof)", pgx.NamedArgs{"config_id": data.Proof.ConfigID, "round": data.Proof.Round, "proof": data.Proof.Proof})
+		if err != nil {
+			t.Fatalf("error inserting proof: %v", err)
+		}
+	}
+}
+
+func TestApiHistoryWithoutNodeDB(t *testing.T) {
+	ctx := context.Background()
+	clean, testItems, err := setup(ctx)
+	if err != nil {
+		t.Fatalf("error setting up test: %v", err)
+	}
+	defer func() {
+		if cleanupErr := clean(); cleanupErr != nil {
+			t.Logf("Cleanup failed: %v", cleanupErr)
+		}
+	}()
+
+	req, err := http.NewRequest(http.MethodGet, testItems.MockDal.URL+"/history/test-aggregate/rounds/1", nil)
+	assert.NoError(t, err)
+	req.Header.Set("X-API-Key", testItems.ApiKey)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
+
+func TestApiHistory(t *testing.T) {
+	nodeDBUrl := os.Getenv("DAL_NODE_DB_URL")
+	if nodeDBUrl == "" {
+		t.Skip("DAL_NODE_DB_URL not set")
+	}
+
+	ctx := context.Background()
+	nodeDB, err := db.GetTransientPool(ctx, nodeDBUrl)
+	if err != nil {
+		t.Fatalf("error connecting to node db: %v", err)
+	}
+	defer nodeDB.Close()
+
+	clean, testItems, err := setup(ctx)
+	if err != nil {
+		t.Fatalf("error setting up test: %v", err)
+	}
+	testItems.App.SetHistoryDB(nodeDB)
+	defer func() {
+		_, _ = nodeDB.Exec(ctx, "DELETE FROM configs WHERE id = @id", pgx.NamedArgs{"id": testItems.TmpConfig.ID})
+		if cleanupErr := clean(); cleanupErr != nil {
+			t.Logf("Cleanup failed: %v", cleanupErr)
+		}
+	}()
+
+	start := time.UnixMilli(time.Now().Add(-time.Hour).UnixMilli())
+	insertHistory(ctx, t, nodeDB, testItems, start, []int64{10, 30, 20, 15})
+
+	headers := request.WithHeaders(map[string]string{"X-API-Key": testItems.ApiKey})
+	url := testItems.MockDal.URL + "/history/test-aggregate"
+
+	t.Run("at round", func(t *testing.T) {
+		result, err := request.Request[apiv2.HistoricalData](request.WithEndpoint(url+"/rounds/2"), headers)
+		assert.NoError(t, err)
+		assert.Equal(t, "30", result.Value)
+		assert.Equal(t, "2", result.Round)
+		assert.NotEmpty(t, result.Proof)
+	})
+
+	t.Run("at timestamp", func(t *testing.T) {
+		at := start.Add(2500 * time.Millisecond).UnixMilli()
+		result, err := request.Request[apiv2.HistoricalData](request.WithEndpoint(url+"?timestamp="+strconv.FormatInt(at, 10)), headers)
+		assert.NoError(t, err)
+		assert.Equal(t, "20", result.Value)
+		assert.Equal(t, strconv.FormatInt(start.Add(2*time.Second).UnixMilli(), 10), result.AggregateTime)
+	})
+
+	t.Run("range with pagination", func(t *testing.T) {
+		from := strconv.FormatInt(start.UnixMilli(), 10)
+		first, err := request.Request[apiv2.HistoryRangeResponse](request.WithEndpoint(url+"/range?from="+from+"&limit=3"), headers)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, len(first.Data))
+		assert.Equal(t, "3", first.NextCursor)
+
+		second, err := request.Request[apiv2.HistoryRangeResponse](request.WithEndpoint(url+"/range?from="+from+"&limit=3&cursor="+first.NextCursor), headers)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(second.Data))
+		assert.Equal(t, "15", second.Data[0].Value)
+		assert.Empty(t, second.NextCursor)
+	})
+
+	t.Run("ohlc buckets", func(t *testing.T) {
+		from := strconv.FormatInt(start.Add(-time.Hour).UnixMilli(), 10)
+		result, err := request.Request[apiv2.OHLCResponse](request.WithEndpoint(url+"/range?from="+from+"&interval=24h"), headers)
+		assert.NoError(t, err)
+		if !assert.NotEmpty(t, result.Buckets) {
+			return
+		}
+		// the rows can straddle a bucket boundary
+		count := int64(0)
+		for _, bucket := range result.Buckets {
+			count += bucket.Count
+		}
+		assert.Equal(t, int64(4), count)
+
+		bucket := result.Buckets[len(result.Buckets)-1]
+		assert.Equal(t, "15", bucket.Close.Value)
+		assert.Equal(t, "4", bucket.Close.Round)
+		assert.NotEmpty(t, bucket.Close.Proof)
+	})
+}
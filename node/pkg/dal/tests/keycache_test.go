@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"bisonai.com/miko/node/pkg/dal/utils/keycache"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestKeyCache_SetAndGet(t *testing.T) {
@@ -77,3 +78,54 @@ func TestKeyCache_Concurrency(t *testing.T) {
 	// Wait to ensure goroutines have completed
 	time.Sleep(100 * time.Millisecond)
 }
+
+func TestKeyCache_Allow(t *testing.T) {
+	cache := keycache.NewAPIKeyCache(1 * time.Second)
+
+	rateLimit := 2.0
+	policy := &keycache.KeyPolicy{RateLimit: &rateLimit}
+	cache.SetPolicy("test-key", policy)
+
+	assert.True(t, cache.Allow("test-key", policy))
+	assert.True(t, cache.Allow("test-key", policy))
+	assert.False(t, cache.Allow("test-key", policy))
+
+	// other keys have their own bucket
+	assert.True(t, cache.Allow("other-key", policy))
+	assert.True(t, cache.Allow("unlimited-key", &keycache.KeyPolicy{}))
+
+	time.Sleep(600 * time.Millisecond)
+	assert.True(t, cache.Allow("test-key", policy))
+}
+
+func TestKeyCache_AcquireConnection(t *testing.T) {
+	cache := keycache.NewAPIKeyCache(1 * time.Second)
+
+	maxConnections := int32(1)
+	policy := &keycache.KeyPolicy{MaxConnections: &maxConnections}
+
+	release, ok := cache.AcquireConnection("test-key", policy)
+	assert.True(t, ok)
+
+	_, ok = cache.AcquireConnection("test-key", policy)
+	assert.False(t, ok)
+
+	release()
+	release()
+
+	second, ok := cache.AcquireConnection("test-key", policy)
+	assert.True(t, ok)
+	_, ok = cache.AcquireConnection("test-key", policy)
+	assert.False(t, ok)
+	second()
+}
+
+func TestKeyPolicy_AllowsSymbol(t *testing.T) {
+	var unlimited *keycache.KeyPolicy
+	assert.True(t, unlimited.AllowsSymbol("ADA-USDT"))
+	assert.True(t, (&keycache.KeyPolicy{}).AllowsSymbol("ADA-USDT"))
+
+	policy := &keycache.KeyPolicy{AllowedSymbols: []string{"BTC-USDT"}}
+	assert.True(t, policy.AllowsSymbol("BTC-USDT"))
+	assert.False(t, policy.AllowsSymbol("ADA-USDT"))
+}
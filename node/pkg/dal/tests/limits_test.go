@@ -0,0 +1,90 @@
+//nolint:all
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bisonai.com/miko/node/pkg/dal/utils/keycache"
+	"bisonai.com/miko/node/pkg/db"
+	"bisonai.com/miko/node/pkg/utils/request"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiKeyPolicy(t *testing.T) {
+	ctx := context.Background()
+	clean, testItems, err := setup(ctx)
+	if err != nil {
+		t.Fatalf("error setting up test: %v", err)
+	}
+	defer func() {
+		if cleanupErr := clean(); cleanupErr != nil {
+			t.Logf("Cleanup failed: %v", cleanupErr)
+		}
+	}()
+
+	err = db.QueryWithoutResult(ctx, `INSERT INTO keys (key, rate_limit, max_connections, allowed_symbols, monthly_quota) VALUES
+		('rateLimitedKey', 1, NULL, NULL, NULL),
+		('connectionLimitedKey', NULL, 0, NULL, NULL),
+		('restrictedKey', NULL, NULL, ARRAY['BTC-USDT'], NULL),
+		('quotaKey', NULL, NULL, NULL, 2)`, nil)
+	if err != nil {
+		t.Fatalf("error inserting keys: %v", err)
+	}
+
+	status := func(key string, path string) int {
+		resp, err := request.RequestRaw(request.WithEndpoint(testItems.MockDal.URL+path), request.WithHeaders(map[string]string{"X-API-Key": key}))
+		if err != nil {
+			t.Fatalf("error requesting %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	t.Run("rate limit", func(t *testing.T) {
+		assert.Equal(t, 200, status("rateLimitedKey", "/symbols"))
+		assert.Equal(t, 429, status("rateLimitedKey", "/symbols"))
+	})
+
+	t.Run("connection limit", func(t *testing.T) {
+		assert.Equal(t, 429, status("connectionLimitedKey", "/ws"))
+	})
+
+	t.Run("allowed symbols", func(t *testing.T) {
+		assert.Equal(t, 403, status("restrictedKey", "/latest-data-feeds/test-aggregate"))
+
+		symbols, err := request.Request[[]string](request.WithEndpoint(testItems.MockDal.URL+"/symbols"), request.WithHeaders(map[string]string{"X-API-Key": "restrictedKey"}))
+		assert.NoError(t, err)
+		assert.Empty(t, symbols)
+	})
+
+	t.Run("monthly quota", func(t *testing.T) {
+		assert.Equal(t, 200, status("quotaKey", "/symbols"))
+
+		usage, err := request.Request[keycache.KeyUsage](request.WithEndpoint(testItems.MockDal.URL+"/usage"), request.WithHeaders(map[string]string{"X-API-Key": "quotaKey"}))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), usage.MonthlyRequests)
+		assert.Equal(t, int64(2), *usage.Policy.MonthlyQuota)
+
+		assert.Equal(t, 429, status("quotaKey", "/symbols"))
+	})
+
+	t.Run("monthly quota used on other instances", func(t *testing.T) {
+		quota := int64(2)
+		policy := &keycache.KeyPolicy{MonthlyQuota: &quota}
+		keyCache := keycache.NewAPIKeyCache(time.Minute)
+		keyCache.SetPolicy("sharedQuotaKey", policy)
+		assert.True(t, keyCache.UseQuota(ctx, "sharedQuotaKey", policy))
+
+		err := db.QueryWithoutResult(ctx, `INSERT INTO rest_calls (api_key, endpoint, status_code, response_time) VALUES
+			('sharedQuotaKey', '/symbols', 200, 1),
+			('sharedQuotaKey', '/symbols', 200, 1)`, nil)
+		assert.NoError(t, err)
+		defer db.QueryWithoutResult(ctx, "DELETE FROM rest_calls WHERE api_key = 'sharedQuotaKey'", nil)
+
+		// reloading the policy syncs the usage like UsageSyncInterval does
+		keyCache.SetPolicy("sharedQuotaKey", policy)
+		assert.False(t, keyCache.UseQuota(ctx, "sharedQuotaKey", policy))
+	})
+}
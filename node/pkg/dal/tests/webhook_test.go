@@ -0,0 +1,129 @@
+//nolint:all
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"bisonai.com/miko/node/pkg/dal/webhook"
+	"bisonai.com/miko/node/pkg/utils/request"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhooks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clean, testItems, err := setup(ctx)
+	if err != nil {
+		t.Fatalf("error setting up test: %v", err)
+	}
+	defer func() {
+		if cleanupErr := clean(); cleanupErr != nil {
+			t.Logf("Cleanup failed: %v", cleanupErr)
+		}
+	}()
+
+	events := make(chan webhook.Event, 10)
+	var secret string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !webhook.Verify(secret, r.Header.Get(webhook.TimestampHeader), body, r.Header.Get(webhook.SignatureHeader)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var event webhook.Event
+		if err := json.Unmarshal(body, &event); err == nil {
+			events <- event
+		}
+	}))
+	defer receiver.Close()
+
+	// the receiver listens on loopback
+	dispatcher := webhook.NewDispatcher(testItems.Collector, testItems.Controller, webhook.WithAllowPrivate(true))
+	go dispatcher.Start(ctx)
+
+	headers := request.WithHeaders(map[string]string{"X-API-Key": testItems.ApiKey})
+	url := testItems.MockDal.URL + "/webhooks"
+
+	t.Run("invalid webhook", func(t *testing.T) {
+		for _, body := range []map[string]any{
+			{"url": "ftp://example.com", "symbols": []string{"test-aggregate"}},
+			{"url": receiver.URL, "symbols": []string{"UNKNOWN-SYMBOL"}},
+			{"url": receiver.URL, "symbols": []string{"test-aggregate"}, "deviation": -1},
+			{"url": receiver.URL, "symbols": []string{"test-aggregate"}, "heartbeat": 10},
+			{"url": "http://169.254.169.254/latest/meta-data", "symbols": []string{"test-aggregate"}},
+		} {
+			resp, err := request.RequestRaw(request.WithEndpoint(url), request.WithMethod("POST"), request.WithBody(body), headers)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			resp.Body.Close()
+		}
+	})
+
+	t.Setenv("DAL_WEBHOOK_ALLOW_PRIVATE", "true")
+
+	created, err := request.Request[webhook.Webhook](request.WithEndpoint(url), request.WithMethod("POST"), request.WithBody(map[string]any{"url": receiver.URL, "symbols": []string{"test-aggregate"}}), headers)
+	if err != nil {
+		t.Fatalf("error creating webhook: %v", err)
+	}
+	assert.NotEmpty(t, created.Secret)
+	secret = created.Secret
+	dispatcher.Reload()
+
+	t.Run("list hides secret", func(t *testing.T) {
+		result, err := request.Request[[]webhook.Webhook](request.WithEndpoint(url), headers)
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+		assert.Equal(t, created.ID, result[0].ID)
+		assert.Empty(t, result[0].Secret)
+	})
+
+	t.Run("leased to a single instance", func(t *testing.T) {
+		// give the dispatcher time to claim the new webhook
+		time.Sleep(200 * time.Millisecond)
+		claimed, err := webhook.Claim(ctx, "other-instance", time.Minute)
+		assert.NoError(t, err)
+		for _, other := range claimed {
+			assert.NotEqual(t, created.ID, other.ID)
+		}
+	})
+
+	t.Run("delivers updates", func(t *testing.T) {
+		// give the dispatcher time to pick up the new webhook
+		time.Sleep(200 * time.Millisecond)
+		data, err := generateSampleSubmissionData(testItems.TmpConfig.ID, 10, time.Now(), 1, "test-aggregate")
+		if err != nil {
+			t.Fatalf("error generating sample submission data: %v", err)
+		}
+		publishAndAwait(ctx, t, testItems, testItems.TmpConfig.Name, *data)
+
+		select {
+		case event := <-events:
+			assert.Equal(t, created.ID, event.WebhookID)
+			assert.Equal(t, webhook.EventUpdate, event.Event)
+			assert.Equal(t, "test-aggregate", event.Data.Symbol)
+			assert.Equal(t, "10", event.Data.Value)
+		case <-time.After(5 * time.Second):
+			t.Fatal("webhook was not delivered")
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		endpoint := url + "/" + strconv.Itoa(int(created.ID))
+		resp, err := request.RequestRaw(request.WithEndpoint(endpoint), request.WithMethod("DELETE"), headers)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		resp.Body.Close()
+
+		resp, err = request.RequestRaw(request.WithEndpoint(endpoint), request.WithMethod("DELETE"), headers)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		resp.Body.Close()
+	})
+}
@@ -9,37 +9,86 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+const GetKeyPolicyQuery = `SELECT true as exists, rate_limit, max_connections, allowed_symbols, monthly_quota FROM keys WHERE key = @key`
+
 type KeyCache struct {
 	mu   sync.RWMutex
-	keys map[string]time.Time
+	keys map[string]cachedKey
 	ttl  time.Duration
+
+	limitsMu sync.Mutex
+	limits   map[string]*keyLimits
+}
+
+type cachedKey struct {
+	expiry time.Time
+	policy *KeyPolicy
 }
 
 type DBKeyResult struct {
 	Exist bool `db:"exists"`
 }
 
+type DBKeyPolicyResult struct {
+	Exist bool `db:"exists"`
+	KeyPolicy
+}
+
 func NewAPIKeyCache(ttl time.Duration) *KeyCache {
 	return &KeyCache{
-		keys: make(map[string]time.Time),
-		ttl:  ttl,
+		keys:   make(map[string]cachedKey),
+		ttl:    ttl,
+		limits: make(map[string]*keyLimits),
 	}
 }
 
+// Set caches key without any limits.
 func (c *KeyCache) Set(key string) {
+	c.SetPolicy(key, &KeyPolicy{})
+}
+
+func (c *KeyCache) SetPolicy(key string, policy *KeyPolicy) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.keys[key] = time.Now().Add(c.ttl)
+	c.keys[key] = cachedKey{expiry: time.Now().Add(c.ttl), policy: policy}
+	c.mu.Unlock()
+
+	// the quota usage is synced from the stats tables again whenever the
+	// policy is reloaded, besides every UsageSyncInterval
+	c.resetUsage(key)
 }
 
 func (c *KeyCache) Get(key string) bool {
+	_, ok := c.GetPolicy(key)
+	return ok
+}
+
+func (c *KeyCache) GetPolicy(key string) (*KeyPolicy, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	expiry, exists := c.keys[key]
-	if !exists || time.Now().After(expiry) {
-		return false
+	cached, exists := c.keys[key]
+	if !exists || time.Now().After(cached.expiry) {
+		return nil, false
 	}
-	return true
+	return cached.policy, true
+}
+
+// Authorize returns the policy of key, loading it from the db if it isn't
+// cached. The second return value is false if the key doesn't exist.
+func (c *KeyCache) Authorize(ctx context.Context, key string) (*KeyPolicy, bool) {
+	if key == "" {
+		return nil, false
+	}
+
+	if policy, ok := c.GetPolicy(key); ok {
+		return policy, true
+	}
+
+	policy, ok := GetKeyPolicyFromDB(ctx, key)
+	if !ok {
+		return nil, false
+	}
+	c.SetPolicy(key, policy)
+	return policy, true
 }
 
 func (c *KeyCache) CleanupLoop(ctx context.Context, interval time.Duration) {
@@ -59,13 +108,15 @@ func (c *KeyCache) CleanupLoop(ctx context.Context, interval time.Duration) {
 
 func (c *KeyCache) Cleanup() {
 	c.mu.Lock()
-	defer c.mu.Unlock()
 	now := time.Now()
-	for key, expiry := range c.keys {
-		if now.After(expiry) {
+	for key, cached := range c.keys {
+		if now.After(cached.expiry) {
 			delete(c.keys, key)
 		}
 	}
+	c.mu.Unlock()
+
+	c.cleanupLimits()
 }
 
 func ValidateApiKeyFromDB(ctx context.Context, apiKey string) bool {
@@ -75,3 +126,15 @@ func ValidateApiKeyFromDB(ctx context.Context, apiKey string) bool {
 	}
 	return res.Exist && err == nil
 }
+
+func GetKeyPolicyFromDB(ctx context.Context, apiKey string) (*KeyPolicy, bool) {
+	res, err := db.QueryRow[DBKeyPolicyResult](ctx, GetKeyPolicyQuery, map[string]any{"key": apiKey})
+	if err != nil {
+		log.Error().Err(err).Msg("Error loading API key policy")
+		return nil, false
+	}
+	if !res.Exist {
+		return nil, false
+	}
+	return &res.KeyPolicy, true
+}
@@ -0,0 +1,232 @@
+package keycache
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"bisonai.com/miko/node/pkg/dal/utils/stats"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/time/rate"
+)
+
+// UsageSyncInterval is how often the quota usage of a key is read again from
+// the stats tables, which also count the requests served by other instances.
+const UsageSyncInterval = time.Minute
+
+// KeyPolicy holds the limits of an api key, stored in the keys table. A nil
+// or empty field means the key is unlimited on that dimension.
+//
+// RateLimit and MaxConnections are enforced per DAL instance, so behind a
+// load balancer a key gets them on every instance. MonthlyQuota is shared
+// through the stats tables and can be exceeded by what the instances serve
+// within UsageSyncInterval.
+type KeyPolicy struct {
+	// RateLimit is the allowed number of requests per second, per instance
+	RateLimit *float64 `db:"rate_limit" json:"rateLimit,omitempty"`
+	// MaxConnections bounds the concurrent websocket connections and streams,
+	// per instance
+	MaxConnections *int32   `db:"max_connections" json:"maxConnections,omitempty"`
+	AllowedSymbols []string `db:"allowed_symbols" json:"allowedSymbols,omitempty"`
+	// MonthlyQuota bounds the requests of a calendar month (UTC)
+	MonthlyQuota *int64 `db:"monthly_quota" json:"monthlyQuota,omitempty"`
+}
+
+type KeyUsage struct {
+	Policy          *KeyPolicy `json:"policy"`
+	MonthlyRequests int64      `json:"monthlyRequests"`
+	Connections     int32      `json:"connections"`
+}
+
+type keyLimits struct {
+	bucket      *rate.Limiter
+	connections int32
+	// month is the start of the month used counts the requests of, zero
+	// until it's loaded from the stats tables
+	month time.Time
+	used  int64
+	// syncedAt is when used was last synced from the stats tables
+	syncedAt time.Time
+}
+
+func (p *KeyPolicy) AllowsSymbol(symbol string) bool {
+	if p == nil || len(p.AllowedSymbols) == 0 {
+		return true
+	}
+	return slices.Contains(p.AllowedSymbols, symbol)
+}
+
+// Allow reports whether a request of key fits in its rate limit.
+func (c *KeyCache) Allow(key string, policy *KeyPolicy) bool {
+	if policy == nil || policy.RateLimit == nil {
+		return true
+	}
+
+	limit := rate.Limit(*policy.RateLimit)
+	burst := max(1, int(math.Ceil(*policy.RateLimit)))
+
+	c.limitsMu.Lock()
+	l := c.limitsOf(key)
+	if l.bucket == nil {
+		l.bucket = rate.NewLimiter(limit, burst)
+	} else if l.bucket.Limit() != limit {
+		l.bucket.SetLimit(limit)
+		l.bucket.SetBurst(burst)
+	}
+	bucket := l.bucket
+	c.limitsMu.Unlock()
+
+	return bucket.Allow()
+}
+
+// AcquireConnection takes a connection slot of key, returning false if all of
+// them are in use. The returned function gives the slot back.
+func (c *KeyCache) AcquireConnection(key string, policy *KeyPolicy) (func(), bool) {
+	c.limitsMu.Lock()
+	defer c.limitsMu.Unlock()
+
+	l := c.limitsOf(key)
+	if policy != nil && policy.MaxConnections != nil && l.connections >= *policy.MaxConnections {
+		return nil, false
+	}
+	l.connections++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.limitsMu.Lock()
+			defer c.limitsMu.Unlock()
+			c.limitsOf(key).connections--
+		})
+	}, true
+}
+
+// UseQuota counts a request against the monthly quota of key, returning false
+// once the quota is used up. Usage starts from the rest calls recorded in the
+// stats tables, is counted locally from there on and synced again every
+// UsageSyncInterval.
+func (c *KeyCache) UseQuota(ctx context.Context, key string, policy *KeyPolicy) bool {
+	if policy == nil || policy.MonthlyQuota == nil {
+		return true
+	}
+
+	month := startOfMonth(time.Now())
+	if err := c.loadUsage(ctx, key, month); err != nil {
+		// the db being unavailable shouldn't take the api down with it
+		log.Error().Err(err).Str("key", key).Msg("failed to load key usage")
+		return true
+	}
+
+	c.limitsMu.Lock()
+	defer c.limitsMu.Unlock()
+	l := c.limitsOf(key)
+	if l.used >= *policy.MonthlyQuota {
+		return false
+	}
+	l.used++
+	return true
+}
+
+// Usage returns the policy of key with its requests this month and its open
+// connections.
+func (c *KeyCache) Usage(ctx context.Context, key string) (KeyUsage, error) {
+	policy, _ := c.GetPolicy(key)
+	month := startOfMonth(time.Now())
+
+	// requests are only counted locally for keys with a quota
+	if policy == nil || policy.MonthlyQuota == nil {
+		used, err := stats.CountRestCallsSince(ctx, key, month)
+		if err != nil {
+			return KeyUsage{}, err
+		}
+		c.limitsMu.Lock()
+		defer c.limitsMu.Unlock()
+		return KeyUsage{Policy: policy, MonthlyRequests: used, Connections: c.limitsOf(key).connections}, nil
+	}
+
+	if err := c.loadUsage(ctx, key, month); err != nil {
+		return KeyUsage{}, err
+	}
+	c.limitsMu.Lock()
+	defer c.limitsMu.Unlock()
+	l := c.limitsOf(key)
+	return KeyUsage{Policy: policy, MonthlyRequests: l.used, Connections: l.connections}, nil
+}
+
+// loadUsage syncs the usage of key from the stats tables when the month
+// changed or the last sync is older than UsageSyncInterval. Within a month the
+// larger of the local and the recorded count is kept, the stats tables lag
+// behind the requests served locally.
+func (c *KeyCache) loadUsage(ctx context.Context, key string, month time.Time) error {
+	now := time.Now()
+	c.limitsMu.Lock()
+	l := c.limitsOf(key)
+	loaded := l.month.Equal(month)
+	if loaded && now.Sub(l.syncedAt) < UsageSyncInterval {
+		c.limitsMu.Unlock()
+		return nil
+	}
+	// concurrent requests keep the local count meanwhile
+	l.syncedAt = now
+	c.limitsMu.Unlock()
+
+	used, err := stats.CountRestCallsSince(ctx, key, month)
+	if err != nil {
+		if loaded {
+			log.Warn().Err(err).Str("key", key).Msg("failed to sync key usage, counting locally")
+			return nil
+		}
+		return err
+	}
+
+	c.limitsMu.Lock()
+	defer c.limitsMu.Unlock()
+	l = c.limitsOf(key)
+	if !l.month.Equal(month) {
+		l.month = month
+		l.used = used
+	} else {
+		l.used = max(l.used, used)
+	}
+	return nil
+}
+
+func (c *KeyCache) resetUsage(key string) {
+	c.limitsMu.Lock()
+	defer c.limitsMu.Unlock()
+	if l, ok := c.limits[key]; ok {
+		l.syncedAt = time.Time{}
+	}
+}
+
+// limitsOf should be called with limitsMu held.
+func (c *KeyCache) limitsOf(key string) *keyLimits {
+	l, ok := c.limits[key]
+	if !ok {
+		l = &keyLimits{}
+		c.limits[key] = l
+	}
+	return l
+}
+
+// cleanupLimits drops the limits of keys that left the cache and have no open
+// connections.
+func (c *KeyCache) cleanupLimits() {
+	c.limitsMu.Lock()
+	defer c.limitsMu.Unlock()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for key, l := range c.limits {
+		if _, ok := c.keys[key]; !ok && l.connections == 0 {
+			delete(c.limits, key)
+		}
+	}
+}
+
+func startOfMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -29,6 +29,11 @@ const (
 		SET connection_end = NOW(), duration = EXTRACT(EPOCH FROM (NOW() - timestamp)) * 1000
 		WHERE id = @id;
 	`
+	// rejected calls don't count towards the quota of a key
+	COUNT_REST_CALLS_SINCE = `
+		SELECT COUNT(*) AS count FROM rest_calls
+		WHERE api_key = @api_key AND timestamp >= @since AND status_code <> 429;
+	`
 )
 
 const (
@@ -65,6 +70,10 @@ type WebsocketId struct {
 	Id int32 `db:"id"`
 }
 
+type RestCallCount struct {
+	Count int64 `db:"count"`
+}
+
 type RestEntry struct {
 	ApiKey       string
 	Endpoint     string
@@ -182,6 +191,17 @@ func InsertRestCall(ctx context.Context, apiKey string, endpoint string, statusC
 	})
 }
 
+func CountRestCallsSince(ctx context.Context, apiKey string, since time.Time) (int64, error) {
+	result, err := db.QueryRow[RestCallCount](ctx, COUNT_REST_CALLS_SINCE, map[string]any{
+		"api_key": apiKey,
+		"since":   since,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.Count, nil
+}
+
 func InsertWebsocketConnection(ctx context.Context, apiKey string) (int32, error) {
 	result, err := db.QueryRow[WebsocketId](ctx, INSERT_WEBSOCKET_CONNECTIONS, map[string]any{
 		"api_key": apiKey,
@@ -234,6 +254,12 @@ func (sl StatsLogger) WriteHeader(statusCode int) {
 	(*sl.w).WriteHeader(statusCode)
 }
 
+func (sl StatsLogger) Flush() {
+	if f, ok := (*sl.w).(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 func (sl StatsLogger) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	h, ok := (*sl.w).(http.Hijacker)
 	if !ok {
@@ -0,0 +1,144 @@
+package webhook
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+
+	errorsentinel "bisonai.com/miko/node/pkg/error"
+	"github.com/rs/zerolog/log"
+)
+
+// LoadAllowPrivate reads DAL_WEBHOOK_ALLOW_PRIVATE, the explicit opt-out that
+// lets webhooks reach private, loopback and link-local addresses, e.g. for
+// local setups.
+func LoadAllowPrivate() bool {
+	raw := os.Getenv("DAL_WEBHOOK_ALLOW_PRIVATE")
+	if raw == "" {
+		return false
+	}
+	allow, err := strconv.ParseBool(raw)
+	if err != nil {
+		log.Warn().Str("Player", "DalWebhook").Str("DAL_WEBHOOK_ALLOW_PRIVATE", raw).Msg("invalid value, private addresses are not allowed")
+		return false
+	}
+	return allow
+}
+
+// CheckURL resolves the host of target and fails if any of its addresses
+// isn't public, so webhooks can't be pointed at the network of the DAL.
+func CheckURL(ctx context.Context, target *url.URL, allowPrivate bool) error {
+	if allowPrivate {
+		return nil
+	}
+
+	host := target.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if !isPublic(ip) {
+			return errorsentinel.ErrDalWebhookAddressNotAllowed
+		}
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+	if len(addrs) == 0 {
+		return errorsentinel.ErrDalWebhookAddressNotAllowed
+	}
+	for _, addr := range addrs {
+		if !isPublic(addr.IP) {
+			return errorsentinel.ErrDalWebhookAddressNotAllowed
+		}
+	}
+	return nil
+}
+
+// deniedNetworks are the special-purpose ranges webhooks can't reach,
+// including the shared address space of carrier-grade NATs, the benchmarking
+// range and the NAT64 prefix, which can reach private IPv4 addresses.
+var deniedNetworks = parseCIDRs(
+	"0.0.0.0/8",       // this network
+	"10.0.0.0/8",      // private
+	"100.64.0.0/10",   // shared address space
+	"127.0.0.0/8",     // loopback
+	"169.254.0.0/16",  // link local
+	"172.16.0.0/12",   // private
+	"192.0.0.0/24",    // IETF protocol assignments
+	"192.0.2.0/24",    // documentation
+	"192.88.99.0/24",  // 6to4 relay anycast
+	"192.168.0.0/16",  // private
+	"198.18.0.0/15",   // benchmarking
+	"198.51.100.0/24", // documentation
+	"203.0.113.0/24",  // documentation
+	"224.0.0.0/4",     // multicast
+	"240.0.0.0/4",     // reserved and broadcast
+	"::/128",          // unspecified
+	"::1/128",         // loopback
+	"64:ff9b::/96",    // NAT64
+	"64:ff9b:1::/48",  // local NAT64
+	"100::/64",        // discard
+	"2001::/23",       // IETF protocol assignments
+	"2001:db8::/32",   // documentation
+	"2002::/16",       // 6to4
+	"fc00::/7",        // unique local
+	"fe80::/10",       // link local
+	"ff00::/8",        // multicast
+)
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	result := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		result = append(result, network)
+	}
+	return result
+}
+
+func isPublic(ip net.IP) bool {
+	for _, network := range deniedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// dialControl refuses connections to addresses that aren't public, checked
+// again at dial time since the host may resolve differently than when the
+// webhook was registered.
+func dialControl(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublic(ip) {
+		return errorsentinel.ErrDalWebhookAddressNotAllowed
+	}
+	return nil
+}
+
+// newHTTPClient returns the client deliveries are POSTed with. Redirects are
+// dialed through the same checks.
+func newHTTPClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = dialControl
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// through a proxy only the proxy would be checked
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
@@ -0,0 +1,492 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"bisonai.com/miko/node/pkg/dal/collector"
+	dalcommon "bisonai.com/miko/node/pkg/dal/common"
+	"bisonai.com/miko/node/pkg/dal/hub"
+	errorsentinel "bisonai.com/miko/node/pkg/error"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	DefaultMaxAttempts    = 5
+	DefaultInitialBackoff = 1 * time.Second
+	DefaultMaxBackoff     = 1 * time.Minute
+	DefaultTimeout        = 5 * time.Second
+	DefaultQueueSize      = 100
+	DefaultReloadInterval = 1 * time.Minute
+
+	heartbeatCheckInterval = 1 * time.Second
+	// leaseIntervals is the lease of a webhook in reload intervals, so it
+	// survives a failed reload
+	leaseIntervals    = 3
+	deadLetterTimeout = 5 * time.Second
+)
+
+var (
+	deliveriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "dal_webhook_deliveries_total",
+		Help: "Total number of webhook deliveries by result",
+	}, []string{"result"})
+	deliveryDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "dal_webhook_delivery_duration_seconds",
+		Help:    "Duration of webhook delivery attempts",
+		Buckets: prometheus.DefBuckets,
+	})
+	activeWebhooks = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "dal_webhook_active",
+		Help: "Current number of webhooks delivered to",
+	})
+)
+
+type DispatcherConfig struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Timeout        time.Duration
+	QueueSize      int
+	ReloadInterval time.Duration
+	AllowPrivate   bool
+}
+
+type DispatcherOption func(*DispatcherConfig)
+
+// WithMaxAttempts sets how many times a delivery is tried before it goes to
+// the dead letters.
+func WithMaxAttempts(attempts int) DispatcherOption {
+	return func(config *DispatcherConfig) {
+		config.MaxAttempts = attempts
+	}
+}
+
+// WithBackoff sets the delay after the first failed attempt, doubled after
+// every following one up to max.
+func WithBackoff(initial time.Duration, max time.Duration) DispatcherOption {
+	return func(config *DispatcherConfig) {
+		config.InitialBackoff = initial
+		config.MaxBackoff = max
+	}
+}
+
+func WithTimeout(timeout time.Duration) DispatcherOption {
+	return func(config *DispatcherConfig) {
+		config.Timeout = timeout
+	}
+}
+
+// WithQueueSize sets how many deliveries a webhook may have pending before
+// new ones are dropped.
+func WithQueueSize(size int) DispatcherOption {
+	return func(config *DispatcherConfig) {
+		config.QueueSize = size
+	}
+}
+
+// WithReloadInterval sets how often webhooks are reloaded from the db, for
+// the changes made through other DAL instances. Leases on the webhooks last
+// three intervals.
+func WithReloadInterval(interval time.Duration) DispatcherOption {
+	return func(config *DispatcherConfig) {
+		config.ReloadInterval = interval
+	}
+}
+
+// WithAllowPrivate lets deliveries reach addresses that aren't public, see
+// LoadAllowPrivate.
+func WithAllowPrivate(allow bool) DispatcherOption {
+	return func(config *DispatcherConfig) {
+		config.AllowPrivate = allow
+	}
+}
+
+// Dispatcher delivers the updates of the hub to the registered webhooks.
+// Every webhook has its own queue and worker, so a slow or failing endpoint
+// only delays its own deliveries. With several DAL instances, each webhook is
+// delivered by the instance holding its lease.
+type Dispatcher struct {
+	collector *collector.Collector
+	hub       *hub.Hub
+	config    DispatcherConfig
+	client    *http.Client
+	owner     string
+
+	mu      sync.RWMutex
+	workers map[int32]*worker
+	reload  chan struct{}
+	// leasedUntil is when the leases of the last successful claim expire
+	leasedUntil time.Time
+}
+
+type worker struct {
+	webhook Webhook
+	symbols map[string]struct{}
+	queue   chan delivery
+	cancel  context.CancelFunc
+
+	mu   sync.Mutex
+	last map[string]*delivered
+}
+
+// delivery is a marshalled Event with the timestamp it's signed with.
+type delivery struct {
+	timestamp string
+	body      []byte
+}
+
+func NewDispatcher(c *collector.Collector, h *hub.Hub, opts ...DispatcherOption) *Dispatcher {
+	config := DispatcherConfig{
+		MaxAttempts:    DefaultMaxAttempts,
+		InitialBackoff: DefaultInitialBackoff,
+		MaxBackoff:     DefaultMaxBackoff,
+		Timeout:        DefaultTimeout,
+		QueueSize:      DefaultQueueSize,
+		ReloadInterval: DefaultReloadInterval,
+		AllowPrivate:   LoadAllowPrivate(),
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return &Dispatcher{
+		collector: c,
+		hub:       h,
+		config:    config,
+		client:    newHTTPClient(config.Timeout, config.AllowPrivate),
+		owner:     newOwner(),
+		workers:   map[int32]*worker{},
+		reload:    make(chan struct{}, 1),
+	}
+}
+
+// newOwner identifies the instance in the leases.
+func newOwner() string {
+	owner, err := NewSecret()
+	if err != nil {
+		owner = strconv.FormatInt(time.Now().UnixNano(), 10)
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		return hostname + "-" + owner[:8]
+	}
+	return owner
+}
+
+// Start loads the webhooks and delivers to them until ctx is done.
+func (d *Dispatcher) Start(ctx context.Context) {
+	symbols := make([]string, 0, len(d.hub.Symbols))
+	for symbol := range d.hub.Symbols {
+		symbols = append(symbols, symbol)
+	}
+	updates, stop := d.hub.Listen(hub.ListenerWebhook, symbols)
+	defer func() { stop() }()
+
+	if err := d.load(ctx); err != nil {
+		log.Error().Err(err).Str("Player", "DalWebhook").Msg("failed to load webhooks")
+	}
+
+	reloadTicker := time.NewTicker(d.config.ReloadInterval)
+	defer reloadTicker.Stop()
+	heartbeatTicker := time.NewTicker(heartbeatCheckInterval)
+	defer heartbeatTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			d.stopAll()
+			d.release(ctx)
+			return
+		case data, ok := <-updates:
+			if !ok {
+				log.Warn().Str("Player", "DalWebhook").Msg("dropped by the hub for falling behind, listening again")
+				updates, stop = d.hub.Listen(hub.ListenerWebhook, symbols)
+				continue
+			}
+			d.handleUpdate(data)
+		case now := <-heartbeatTicker.C:
+			d.sendHeartbeats(now)
+		case <-reloadTicker.C:
+			if err := d.load(ctx); err != nil {
+				log.Error().Err(err).Str("Player", "DalWebhook").Msg("failed to reload webhooks")
+			}
+		case <-d.reload:
+			if err := d.load(ctx); err != nil {
+				log.Error().Err(err).Str("Player", "DalWebhook").Msg("failed to reload webhooks")
+			}
+		}
+	}
+}
+
+// Reload asks the dispatcher to pick up the webhooks changed in the db.
+func (d *Dispatcher) Reload() {
+	select {
+	case d.reload <- struct{}{}:
+	default:
+	}
+}
+
+// load claims the webhooks of the instance and syncs the workers with them.
+// When claiming keeps failing past the last lease, the workers are stopped
+// since another instance may have taken over.
+func (d *Dispatcher) load(ctx context.Context) error {
+	lease := leaseIntervals * d.config.ReloadInterval
+	claimedAt := time.Now()
+	webhooks, err := Claim(ctx, d.owner, lease)
+	if err != nil {
+		d.mu.Lock()
+		expired := !d.leasedUntil.IsZero() && time.Now().After(d.leasedUntil)
+		d.mu.Unlock()
+		if expired {
+			log.Warn().Str("Player", "DalWebhook").Msg("webhook leases expired, stopping deliveries")
+			d.stopAll()
+		}
+		return err
+	}
+
+	d.mu.Lock()
+	d.leasedUntil = claimedAt.Add(lease)
+	d.mu.Unlock()
+	d.sync(ctx, webhooks)
+	return nil
+}
+
+// release gives up the leases on shutdown, ctx is done by then.
+func (d *Dispatcher) release(ctx context.Context) {
+	releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), deadLetterTimeout)
+	defer cancel()
+	if err := Release(releaseCtx, d.owner); err != nil {
+		log.Error().Err(err).Str("Player", "DalWebhook").Msg("failed to release webhook leases")
+	}
+}
+
+// sync starts the workers of new webhooks and stops those of the removed
+// ones. Webhooks are never updated in place, so existing workers are kept.
+func (d *Dispatcher) sync(ctx context.Context, webhooks []Webhook) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	current := make(map[int32]struct{}, len(webhooks))
+	for _, webhook := range webhooks {
+		current[webhook.ID] = struct{}{}
+		if _, ok := d.workers[webhook.ID]; ok {
+			continue
+		}
+
+		workerCtx, cancel := context.WithCancel(ctx)
+		w := &worker{
+			webhook: webhook,
+			symbols: make(map[string]struct{}, len(webhook.Symbols)),
+			queue:   make(chan delivery, d.config.QueueSize),
+			cancel:  cancel,
+			last:    map[string]*delivered{},
+		}
+		for _, symbol := range webhook.Symbols {
+			w.symbols[symbol] = struct{}{}
+		}
+		d.workers[webhook.ID] = w
+		go d.run(workerCtx, w)
+	}
+
+	for id, w := range d.workers {
+		if _, ok := current[id]; !ok {
+			w.cancel()
+			delete(d.workers, id)
+		}
+	}
+	activeWebhooks.Set(float64(len(d.workers)))
+}
+
+func (d *Dispatcher) stopAll() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for id, w := range d.workers {
+		w.cancel()
+		delete(d.workers, id)
+	}
+	activeWebhooks.Set(0)
+}
+
+func (d *Dispatcher) handleUpdate(data *dalcommon.OutgoingSubmissionData) {
+	value, ok := parseValue(data)
+	if !ok {
+		return
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for _, w := range d.workers {
+		if _, ok := w.symbols[data.Symbol]; !ok {
+			continue
+		}
+
+		w.mu.Lock()
+		if !w.webhook.shouldDeliver(w.last[data.Symbol], value) {
+			w.mu.Unlock()
+			continue
+		}
+		w.last[data.Symbol] = &delivered{value: value, at: time.Now()}
+		w.mu.Unlock()
+
+		d.enqueue(w, EventUpdate, d.collector.WithFreshness(data))
+	}
+}
+
+// sendHeartbeats resends the latest data of the symbols that weren't
+// delivered within the heartbeat of their webhook.
+func (d *Dispatcher) sendHeartbeats(now time.Time) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for _, w := range d.workers {
+		if w.webhook.Heartbeat == nil {
+			continue
+		}
+		for symbol := range w.symbols {
+			w.mu.Lock()
+			last := w.last[symbol]
+			if !w.webhook.heartbeatDue(last, now) {
+				w.mu.Unlock()
+				continue
+			}
+			data, err := d.collector.GetLatestFreshData(symbol)
+			if err != nil {
+				w.mu.Unlock()
+				continue
+			}
+			value, ok := parseValue(&data.OutgoingSubmissionData)
+			if !ok {
+				value = last.value
+			}
+			w.last[symbol] = &delivered{value: value, at: now}
+			w.mu.Unlock()
+
+			d.enqueue(w, EventHeartbeat, data)
+		}
+	}
+}
+
+func (d *Dispatcher) enqueue(w *worker, event string, data *dalcommon.FreshSubmissionData) {
+	timestamp := time.Now().UnixMilli()
+	body, err := json.Marshal(Event{
+		WebhookID: w.webhook.ID,
+		Event:     event,
+		Timestamp: timestamp,
+		Data:      *data,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("Player", "DalWebhook").Msg("failed to marshal webhook event")
+		return
+	}
+
+	select {
+	case w.queue <- delivery{timestamp: strconv.FormatInt(timestamp, 10), body: body}:
+	default:
+		deliveriesTotal.WithLabelValues("dropped").Inc()
+		log.Warn().Str("Player", "DalWebhook").Int32("id", w.webhook.ID).Msg("webhook queue full, dropping event")
+		// kept off the update loop, which would otherwise wait on the db
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), deadLetterTimeout)
+			defer cancel()
+			if dbErr := insertDeadLetter(ctx, w.webhook.ID, body, DeadLetterQueueFull, 0); dbErr != nil {
+				log.Error().Err(dbErr).Str("Player", "DalWebhook").Int32("id", w.webhook.ID).Msg("failed to insert dead letter")
+			}
+		}()
+	}
+}
+
+func (d *Dispatcher) run(ctx context.Context, w *worker) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case next := <-w.queue:
+			d.deliver(ctx, w.webhook, next)
+		}
+	}
+}
+
+// deliver POSTs next to webhook, retrying with an exponential backoff.
+// Deliveries still failing after the last attempt, or abandoned when the
+// worker stops, are kept in the dead letters.
+func (d *Dispatcher) deliver(ctx context.Context, webhook Webhook, next delivery) {
+	headers := map[string]string{
+		IdHeader:        strconv.FormatInt(int64(webhook.ID), 10),
+		TimestampHeader: next.timestamp,
+		SignatureHeader: Sign(webhook.Secret, next.timestamp, next.body),
+	}
+
+	backoff := d.config.InitialBackoff
+	var err error
+	attempts := 0
+	for attempts < d.config.MaxAttempts {
+		attempts++
+		if err = d.post(ctx, webhook.Url, headers, next.body); err == nil {
+			deliveriesTotal.WithLabelValues("success").Inc()
+			return
+		}
+		deliveriesTotal.WithLabelValues("retry").Inc()
+		log.Warn().Err(err).Str("Player", "DalWebhook").Int32("id", webhook.ID).Int("attempt", attempts).Msg("webhook delivery failed")
+
+		if attempts == d.config.MaxAttempts {
+			break
+		}
+		stopped := false
+		select {
+		case <-ctx.Done():
+			stopped = true
+		case <-time.After(backoff):
+		}
+		if stopped {
+			break
+		}
+		backoff *= 2
+		if backoff > d.config.MaxBackoff {
+			backoff = d.config.MaxBackoff
+		}
+	}
+
+	deliveriesTotal.WithLabelValues("dead_letter").Inc()
+	// the worker may be stopping, the dead letter is kept regardless
+	deadLetterCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), deadLetterTimeout)
+	defer cancel()
+	if dbErr := insertDeadLetter(deadLetterCtx, webhook.ID, next.body, err.Error(), attempts); dbErr != nil {
+		log.Error().Err(dbErr).Str("Player", "DalWebhook").Int32("id", webhook.ID).Msg("failed to insert dead letter")
+	}
+}
+
+func (d *Dispatcher) post(ctx context.Context, url string, headers map[string]string, body []byte) error {
+	start := time.Now()
+	defer func() {
+		deliveryDuration.Observe(time.Since(start).Seconds())
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errorsentinel.ErrDalWebhookStatusNotOk
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+// Package webhook pushes the price updates of the DAL to urls registered by
+// the api key owners, signed with a secret only they and the DAL know.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+
+	dalcommon "bisonai.com/miko/node/pkg/dal/common"
+	"bisonai.com/miko/node/pkg/db"
+)
+
+const (
+	EventUpdate    = "update"
+	EventHeartbeat = "heartbeat"
+
+	IdHeader        = "X-Miko-Webhook-Id"
+	TimestampHeader = "X-Miko-Timestamp"
+	// SignatureHeader holds sha256=hex(hmac-sha256(secret, timestamp + "." + body))
+	SignatureHeader = "X-Miko-Signature"
+
+	SignaturePrefix = "sha256="
+)
+
+const (
+	InsertWebhookQuery = `
+		INSERT INTO webhooks (api_key, url, symbols, deviation, heartbeat, secret)
+		VALUES (@api_key, @url, @symbols, @deviation, @heartbeat, @secret)
+		RETURNING *;`
+	GetWebhooksQuery      = `SELECT * FROM webhooks ORDER BY id;`
+	GetWebhooksByKeyQuery = `SELECT * FROM webhooks WHERE api_key = @api_key ORDER BY id;`
+	CountWebhooksQuery    = `SELECT COUNT(*) AS count FROM webhooks WHERE api_key = @api_key;`
+	DeleteWebhookQuery    = `DELETE FROM webhooks WHERE id = @id AND api_key = @api_key RETURNING *;`
+
+	// ClaimWebhooksQuery renews the leases of owner and takes over the
+	// webhooks without a live lease, skipping those another instance is
+	// claiming at the same time
+	ClaimWebhooksQuery = `
+		UPDATE webhooks SET lease_owner = @owner, lease_expires_at = NOW() + make_interval(secs => @lease)
+		WHERE id IN (
+			SELECT id FROM webhooks
+			WHERE lease_owner = @owner OR lease_owner IS NULL OR lease_expires_at < NOW()
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *;`
+	ReleaseWebhooksQuery = `UPDATE webhooks SET lease_owner = NULL, lease_expires_at = NULL WHERE lease_owner = @owner;`
+
+	InsertDeadLetterQuery = `
+		INSERT INTO webhook_dead_letters (webhook_id, payload, error, attempts)
+		VALUES (@webhook_id, @payload, @error, @attempts);`
+	GetDeadLettersQuery = `
+		SELECT d.* FROM webhook_dead_letters d
+		JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.webhook_id = @webhook_id AND w.api_key = @api_key
+		ORDER BY d.id DESC LIMIT @limit;`
+)
+
+type Webhook struct {
+	ID      int32    `db:"id" json:"id"`
+	ApiKey  string   `db:"api_key" json:"-"`
+	Url     string   `db:"url" json:"url"`
+	Symbols []string `db:"symbols" json:"symbols"`
+	// Deviation is the change in percent since the last delivered value of a
+	// symbol that triggers a delivery
+	Deviation *float64 `db:"deviation" json:"deviation,omitempty"`
+	// Heartbeat is the interval in ms a symbol is delivered at when its value
+	// didn't trigger any delivery
+	Heartbeat *int32 `db:"heartbeat" json:"heartbeat,omitempty"`
+	// Secret is only returned when the webhook is created
+	Secret    string    `db:"secret" json:"secret,omitempty"`
+	Timestamp time.Time `db:"timestamp" json:"createdAt"`
+	// LeaseOwner is the DAL instance delivering to the webhook until
+	// LeaseExpiresAt
+	LeaseOwner     *string    `db:"lease_owner" json:"-"`
+	LeaseExpiresAt *time.Time `db:"lease_expires_at" json:"-"`
+}
+
+// DeadLetterQueueFull is the error of the dead letters of events dropped
+// because the queue of their webhook was full, they have no attempts.
+const DeadLetterQueueFull = "queue_full"
+
+type DeadLetter struct {
+	ID        int32           `db:"id" json:"id"`
+	WebhookID int32           `db:"webhook_id" json:"webhookId"`
+	Payload   json.RawMessage `db:"payload" json:"payload"`
+	Error     string          `db:"error" json:"error"`
+	Attempts  int32           `db:"attempts" json:"attempts"`
+	Timestamp time.Time       `db:"timestamp" json:"timestamp"`
+}
+
+type webhookCount struct {
+	Count int64 `db:"count"`
+}
+
+// Event is the body POSTed to a webhook.
+type Event struct {
+	WebhookID int32  `json:"webhookId"`
+	Event     string `json:"event"`
+	// Timestamp is the unix ms the event was created at, also sent in
+	// TimestampHeader and part of the signature
+	Timestamp int64                         `json:"timestamp"`
+	Data      dalcommon.FreshSubmissionData `json:"data"`
+}
+
+func Insert(ctx context.Context, webhook Webhook) (Webhook, error) {
+	return db.QueryRow[Webhook](ctx, InsertWebhookQuery, map[string]any{
+		"api_key":   webhook.ApiKey,
+		"url":       webhook.Url,
+		"symbols":   webhook.Symbols,
+		"deviation": webhook.Deviation,
+		"heartbeat": webhook.Heartbeat,
+		"secret":    webhook.Secret,
+	})
+}
+
+func GetAll(ctx context.Context) ([]Webhook, error) {
+	return db.QueryRows[Webhook](ctx, GetWebhooksQuery, nil)
+}
+
+// Claim returns the webhooks owner delivers to for the next lease, see
+// ClaimWebhooksQuery.
+func Claim(ctx context.Context, owner string, lease time.Duration) ([]Webhook, error) {
+	return db.QueryRows[Webhook](ctx, ClaimWebhooksQuery, map[string]any{"owner": owner, "lease": lease.Seconds()})
+}
+
+// Release gives up the leases of owner, so other instances take over without
+// waiting for them to expire.
+func Release(ctx context.Context, owner string) error {
+	return db.QueryWithoutResult(ctx, ReleaseWebhooksQuery, map[string]any{"owner": owner})
+}
+
+func GetByKey(ctx context.Context, apiKey string) ([]Webhook, error) {
+	return db.QueryRows[Webhook](ctx, GetWebhooksByKeyQuery, map[string]any{"api_key": apiKey})
+}
+
+func CountByKey(ctx context.Context, apiKey string) (int64, error) {
+	result, err := db.QueryRow[webhookCount](ctx, CountWebhooksQuery, map[string]any{"api_key": apiKey})
+	return result.Count, err
+}
+
+// Delete removes the webhook id of apiKey, reporting whether it existed.
+func Delete(ctx context.Context, apiKey string, id int32) (bool, error) {
+	result, err := db.QueryRow[Webhook](ctx, DeleteWebhookQuery, map[string]any{"id": id, "api_key": apiKey})
+	if err != nil {
+		return false, err
+	}
+	return result.ID != 0, nil
+}
+
+// GetDeadLetters returns the latest failed deliveries of the webhook id of
+// apiKey, newest first.
+func GetDeadLetters(ctx context.Context, apiKey string, id int32, limit int) ([]DeadLetter, error) {
+	return db.QueryRows[DeadLetter](ctx, GetDeadLettersQuery, map[string]any{"webhook_id": id, "api_key": apiKey, "limit": limit})
+}
+
+func insertDeadLetter(ctx context.Context, id int32, payload []byte, reason string, attempts int) error {
+	return db.QueryWithoutResult(ctx, InsertDeadLetterQuery, map[string]any{
+		"webhook_id": id,
+		"payload":    string(payload),
+		"error":      reason,
+		"attempts":   attempts,
+	})
+}
+
+// NewSecret returns a random hex secret to sign the deliveries of a webhook.
+func NewSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// Sign returns the SignatureHeader value of body sent at timestamp.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return SignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the SignatureHeader of body sent at
+// timestamp, for receivers to check a delivery comes from the DAL.
+func Verify(secret string, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// delivered is the last value of a symbol sent to a webhook.
+type delivered struct {
+	value float64
+	at    time.Time
+}
+
+// shouldDeliver reports whether an update of value triggers a delivery given
+// the last one. Webhooks without conditions receive every update, the others
+// the first one and those deviating enough.
+func (w *Webhook) shouldDeliver(last *delivered, value float64) bool {
+	if w.Deviation == nil && w.Heartbeat == nil {
+		return true
+	}
+	if last == nil {
+		return true
+	}
+	if w.Deviation == nil {
+		return false
+	}
+	return deviation(last.value, value) >= *w.Deviation
+}
+
+// heartbeatDue reports whether a heartbeat should be sent given the last
+// delivery.
+func (w *Webhook) heartbeatDue(last *delivered, now time.Time) bool {
+	if w.Heartbeat == nil || last == nil {
+		return false
+	}
+	return now.Sub(last.at) >= time.Duration(*w.Heartbeat)*time.Millisecond
+}
+
+// deviation returns the change from old to value in percent.
+func deviation(old float64, value float64) float64 {
+	if old == 0 {
+		if value == 0 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	return math.Abs(value-old) / math.Abs(old) * 100
+}
+
+func parseValue(data *dalcommon.OutgoingSubmissionData) (float64, bool) {
+	value, err := strconv.ParseFloat(data.Value, 64)
+	return value, err == nil
+}
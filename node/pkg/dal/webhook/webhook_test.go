@@ -0,0 +1,137 @@
+//nolint:all
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	errorsentinel "bisonai.com/miko/node/pkg/error"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"webhookId":1,"event":"update"}`)
+	signature := Sign("secret", "1700000000000", body)
+
+	assert.Equal(t, SignaturePrefix, signature[:len(SignaturePrefix)])
+	assert.True(t, Verify("secret", "1700000000000", body, signature))
+	assert.False(t, Verify("other", "1700000000000", body, signature))
+	assert.False(t, Verify("secret", "1700000000001", body, signature))
+	assert.False(t, Verify("secret", "1700000000000", []byte(`{}`), signature))
+}
+
+func TestShouldDeliver(t *testing.T) {
+	deviation := 1.0
+	heartbeat := int32(60000)
+	last := &delivered{value: 100, at: time.Now()}
+
+	every := Webhook{}
+	assert.True(t, every.shouldDeliver(nil, 100))
+	assert.True(t, every.shouldDeliver(last, 100))
+
+	onDeviation := Webhook{Deviation: &deviation}
+	assert.True(t, onDeviation.shouldDeliver(nil, 100))
+	assert.False(t, onDeviation.shouldDeliver(last, 100.5))
+	assert.True(t, onDeviation.shouldDeliver(last, 101))
+	assert.True(t, onDeviation.shouldDeliver(last, 98))
+	assert.True(t, onDeviation.shouldDeliver(&delivered{value: 0}, 1))
+
+	onHeartbeat := Webhook{Heartbeat: &heartbeat}
+	assert.True(t, onHeartbeat.shouldDeliver(nil, 100))
+	assert.False(t, onHeartbeat.shouldDeliver(last, 200))
+}
+
+func TestHeartbeatDue(t *testing.T) {
+	heartbeat := int32(60000)
+	now := time.Now()
+
+	withHeartbeat := Webhook{Heartbeat: &heartbeat}
+	assert.False(t, withHeartbeat.heartbeatDue(nil, now))
+	assert.False(t, withHeartbeat.heartbeatDue(&delivered{at: now.Add(-30 * time.Second)}, now))
+	assert.True(t, withHeartbeat.heartbeatDue(&delivered{at: now.Add(-60 * time.Second)}, now))
+
+	withoutHeartbeat := Webhook{}
+	assert.False(t, withoutHeartbeat.heartbeatDue(&delivered{at: now.Add(-time.Hour)}, now))
+}
+
+func TestDeliverRetries(t *testing.T) {
+	var attempts atomic.Int32
+	var received atomic.Bool
+	body := []byte(`{"webhookId":7,"event":"update","timestamp":1700000000000}`)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		raw, _ := io.ReadAll(r.Body)
+		received.Store(
+			string(raw) == string(body) &&
+				r.Header.Get(IdHeader) == "7" &&
+				Verify("secret", r.Header.Get(TimestampHeader), raw, r.Header.Get(SignatureHeader)))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	d := NewDispatcher(nil, nil, WithBackoff(10*time.Millisecond, 20*time.Millisecond), WithAllowPrivate(true))
+	d.deliver(context.Background(), Webhook{ID: 7, Url: server.URL, Secret: "secret"}, delivery{timestamp: "1700000000000", body: body})
+
+	assert.Equal(t, int32(3), attempts.Load())
+	assert.True(t, received.Load())
+}
+
+func TestCheckURL(t *testing.T) {
+	for _, raw := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://10.0.0.1/hook",
+		"http://192.168.1.10/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/hook",
+		"http://[fe80::1]/hook",
+		"http://0.0.0.0/hook",
+		"http://100.64.0.1/hook",
+		"http://198.18.0.1/hook",
+		"http://[64:ff9b::a00:1]/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+		"http://[fd00::1]/hook",
+		"http://255.255.255.255/hook",
+		"http://localhost/hook",
+	} {
+		target, _ := url.Parse(raw)
+		assert.ErrorIs(t, CheckURL(context.Background(), target, false), errorsentinel.ErrDalWebhookAddressNotAllowed, raw)
+		assert.NoError(t, CheckURL(context.Background(), target, true), raw)
+	}
+
+	for _, raw := range []string{"https://8.8.8.8/hook", "https://[2606:4700:4700::1111]/hook"} {
+		target, _ := url.Parse(raw)
+		assert.NoError(t, CheckURL(context.Background(), target, false), raw)
+	}
+}
+
+func TestLoadAllowPrivate(t *testing.T) {
+	t.Setenv("DAL_WEBHOOK_ALLOW_PRIVATE", "")
+	assert.False(t, LoadAllowPrivate())
+	t.Setenv("DAL_WEBHOOK_ALLOW_PRIVATE", "true")
+	assert.True(t, LoadAllowPrivate())
+	t.Setenv("DAL_WEBHOOK_ALLOW_PRIVATE", "yes")
+	assert.False(t, LoadAllowPrivate())
+}
+
+func TestDeliverRefusesPrivateAddress(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+	}))
+	defer server.Close()
+
+	d := NewDispatcher(nil, nil, WithAllowPrivate(false))
+	err := d.post(context.Background(), server.URL, nil, []byte(`{}`))
+	assert.ErrorIs(t, err, errorsentinel.ErrDalWebhookAddressNotAllowed, "the address is checked again when dialing")
+	assert.Equal(t, int32(0), attempts.Load())
+}
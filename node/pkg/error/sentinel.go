@@ -81,6 +81,7 @@ var (
 	ErrAdminDbPoolNotFound     = &CustomError{Service: Admin, Code: InternalError, Message: "db pool not found"}
 	ErrAdminRedisConnNotFound  = &CustomError{Service: Admin, Code: InternalError, Message: "redisconn not found"}
 	ErrAdminMessageBusNotFound = &CustomError{Service: Admin, Code: InternalError, Message: "messagebus not found"}
+	ErrAdminInvalidAggregation = &CustomError{Service: Admin, Code: InvalidInputError, Message: "invalid aggregation strategy or params"}
 
 	ErrAggregatorInvalidInitValue         = &CustomError{Service: Aggregator, Code: InvalidInputError, Message: "Invalid init value parameters"}
 	ErrAggregatorUnhandledCustomMessage   = &CustomError{Service: Aggregator, Code: UnknownCaseError, Message: "Unhandled custom message"}
@@ -90,6 +91,8 @@ var (
 	ErrAggregatorNotFound                 = &CustomError{Service: Aggregator, Code: InternalError, Message: "Aggregator not found"}
 	ErrAggregatorCancelNotFound           = &CustomError{Service: Aggregator, Code: InternalError, Message: "Aggregator cancel function not found"}
 	ErrAggregatorEmptyProof               = &CustomError{Service: Aggregator, Code: InternalError, Message: "Empty proof"}
+	ErrAggregatorQuorumNotReached         = &CustomError{Service: Aggregator, Code: InternalError, Message: "Quorum not reached"}
+	ErrAggregatorInvalidConsensusRecord   = &CustomError{Service: Aggregator, Code: InvalidInputError, Message: "Invalid consensus record"}
 
 	ErrBootAPIDbPoolNotFound = &CustomError{Service: BootAPI, Code: InternalError, Message: "db pool not found"}
 
@@ -149,6 +152,8 @@ var (
 	ErrFetcherDivisionByZero                  = &CustomError{Service: Fetcher, Code: InternalError, Message: "Division by zero"}
 	ErrLocalAggregatorCancelNotFound          = &CustomError{Service: Fetcher, Code: InternalError, Message: "LocalAggregator cancel function not found"}
 	ErrLocalAggregatorZeroVolume              = &CustomError{Service: Fetcher, Code: InvalidInputError, Message: "Zero volume"}
+	ErrLocalAggregatorUnknownStrategy         = &CustomError{Service: Fetcher, Code: InvalidInputError, Message: "Unknown aggregation strategy"}
+	ErrLocalAggregatorInvalidStrategyParams   = &CustomError{Service: Fetcher, Code: InvalidInputError, Message: "Invalid aggregation strategy params"}
 	ErrFeedDataBulkWriterCancelNotFound       = &CustomError{Service: Fetcher, Code: InternalError, Message: "FeedDataBulkWriter cancel function not found"}
 	ErrLocalAggregateBulkWriterCancelNotFound = &CustomError{Service: Fetcher, Code: InternalError, Message: "LocalAggregateBulkWriter cancel function not found"}
 	ErrFetcherNoMatchingChainID               = &CustomError{Service: Fetcher, Code: InvalidInputError, Message: "No matching chain ID"}
@@ -168,7 +173,16 @@ var (
 	ErrPorAnswerCastFail      = &CustomError{Service: Por, Code: InternalError, Message: "Failed to cast answer to big.Int"}
 	ErrPorJobFail             = &CustomError{Service: Por, Code: InternalError, Message: "job failed"}
 
-	ErrRaftLeaderIdMismatch = &CustomError{Service: Others, Code: InternalError, Message: "Leader id mismatch"}
+	ErrRaftLeaderIdMismatch   = &CustomError{Service: Others, Code: InternalError, Message: "Leader id mismatch"}
+	ErrRaftMissingSignature   = &CustomError{Service: Others, Code: InvalidRaftMessageError, Message: "Raft message is not signed"}
+	ErrRaftInvalidSignature   = &CustomError{Service: Others, Code: InvalidRaftMessageError, Message: "Invalid raft message signature"}
+	ErrRaftSenderMismatch     = &CustomError{Service: Others, Code: InvalidRaftMessageError, Message: "Raft message sender does not match pubsub author"}
+	ErrRaftUnknownPeer        = &CustomError{Service: Others, Code: InvalidRaftMessageError, Message: "Raft message sent from unknown peer"}
+	ErrRaftPeerNotWhitelisted = &CustomError{Service: Others, Code: InvalidRaftMessageError, Message: "Raft message sent from non-whitelisted peer"}
+	ErrRaftStaleMessage       = &CustomError{Service: Others, Code: InvalidRaftMessageError, Message: "Raft message timestamp outside freshness window"}
+	ErrRaftDuplicateMessage   = &CustomError{Service: Others, Code: InvalidRaftMessageError, Message: "Raft message already received"}
+	ErrRaftEmptyPeerWhitelist = &CustomError{Service: Others, Code: InvalidInputError, Message: "RAFT_PEER_WHITELIST is empty and RAFT_ALLOW_ANY_PEER is not set"}
+	ErrRaftSigningKeyNotFound = &CustomError{Service: Others, Code: InternalError, Message: "Raft signing key not found"}
 
 	ErrReporterSubmissionProxyContractNotFound  = &CustomError{Service: Reporter, Code: InternalError, Message: "SUBMISSION_PROXY_CONTRACT not found"}
 	ErrReporterNoReportersSet                   = &CustomError{Service: Reporter, Code: InternalError, Message: "No reporters set"}
@@ -202,6 +216,8 @@ var (
 	ErrReporterDalApiKeyNotFound                = &CustomError{Service: Reporter, Code: InternalError, Message: "DAL API key not found in reporter"}
 	ErrReporterDalRestEndpointNotFound          = &CustomError{Service: Reporter, Code: InternalError, Message: "DAL REST endpoint not found in reporter"}
 	ErrReporterDalWsDataProcessingFailed        = &CustomError{Service: Reporter, Code: InternalError, Message: "Failed to process DAL WS data"}
+	ErrReporterChainReporterPkNotFound          = &CustomError{Service: Reporter, Code: InternalError, Message: "Reporter pk not found for chain target"}
+	ErrReporterChainProviderUnavailable         = &CustomError{Service: Reporter, Code: InternalError, Message: "No provider url available for chain target"}
 
 	ErrDalEmptyProofParam      = &CustomError{Service: Dal, Code: InvalidInputError, Message: "Empty proof param"}
 	ErrDalInvalidProofLength   = &CustomError{Service: Dal, Code: InvalidInputError, Message: "Invalid proof length"}
@@ -215,6 +231,21 @@ var (
 	ErrDalSymbolsNotFound      = &CustomError{Service: Dal, Code: InternalError, Message: "Symbols not found"}
 	ErrDalChainEnvNotFound     = &CustomError{Service: Dal, Code: InternalError, Message: "Chain env not found"}
 
+	ErrDalClientEndpointNotFound  = &CustomError{Service: Dal, Code: InvalidInputError, Message: "DAL endpoint not found in client"}
+	ErrDalClientWhitelistNotFound = &CustomError{Service: Dal, Code: InvalidInputError, Message: "Oracle whitelist not found in client"}
+	ErrDalInvalidQuorum           = &CustomError{Service: Dal, Code: InvalidInputError, Message: "Invalid quorum"}
+	ErrDalInvalidSubmissionData   = &CustomError{Service: Dal, Code: InvalidInputError, Message: "Invalid submission data"}
+	ErrDalFeedHashMismatch        = &CustomError{Service: Dal, Code: InvalidInputError, Message: "Feed hash doesn't match symbol"}
+	ErrDalQuorumNotReached        = &CustomError{Service: Dal, Code: InvalidInputError, Message: "Not enough whitelisted signatures"}
+
+	ErrDalInvalidDerivedFeed  = &CustomError{Service: Dal, Code: InvalidInputError, Message: "Invalid derived feed"}
+	ErrDalDerivedDivideByZero = &CustomError{Service: Dal, Code: InternalError, Message: "Derived feed component is zero"}
+	ErrDalDerivedOverflow     = &CustomError{Service: Dal, Code: InternalError, Message: "Derived feed value overflows int64"}
+	ErrDalDerivedMismatch     = &CustomError{Service: Dal, Code: InvalidInputError, Message: "Derived value does not follow from its components"}
+
+	ErrDalWebhookStatusNotOk       = &CustomError{Service: Dal, Code: NetworkError, Message: "Webhook responded with a non 2xx status"}
+	ErrDalWebhookAddressNotAllowed = &CustomError{Service: Dal, Code: InvalidInputError, Message: "Webhook address is not public"}
+
 	ErrReducerCastToFloatFail          = &CustomError{Service: Others, Code: InternalError, Message: "Failed to cast to float"}
 	ErrReducerIndexCastToInterfaceFail = &CustomError{Service: Others, Code: InternalError, Message: "Failed to cast to interface from INDEX"}
 	ErrReducerParseCastToInterfaceFail = &CustomError{Service: Others, Code: InternalError, Message: "Failed to cast to interface from PARSE"}
@@ -0,0 +1,408 @@
+package fetcher
+
+import (
+	"encoding/json"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	errorSentinel "bisonai.com/miko/node/pkg/error"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	MedianStrategy            = "median"
+	VWAPStrategy              = "vwap"
+	TWAPStrategy              = "twap"
+	TrimmedMeanStrategy       = "trimmed_mean"
+	LiquidityWeightedStrategy = "liquidity_weighted"
+	StaleWeightedStrategy     = "stale_weighted"
+	DepthWeightedStrategy     = "depth_weighted"
+
+	DefaultTWAPWindow          = 10 * time.Second
+	DefaultTrimRatio           = 0.1
+	DefaultStaleWeightHalfLife = 5 * time.Second
+)
+
+// AggregationStrategy reduces the latest feed data of a config into a single
+// local aggregate value. Implementations are resolved once per config when the
+// LocalAggregator is built, so stateful strategies (e.g. twap) keep their
+// history for the lifetime of the aggregator.
+type AggregationStrategy interface {
+	Name() string
+	Aggregate(feeds []*FeedData) (float64, error)
+}
+
+// AggregationParams holds the optional tuning knobs stored in
+// configs.aggregation_params. Only the fields relevant to the selected
+// strategy are read.
+type AggregationParams struct {
+	WindowMs   *int     `json:"windowMs"`
+	TrimRatio  *float64 `json:"trimRatio"`
+	HalfLifeMs *int     `json:"halfLifeMs"`
+	Base       *string  `json:"base"`
+	// PerFeed makes twap average each feed over the window before the base
+	// strategy combines them, instead of averaging the combined value
+	PerFeed *bool `json:"perFeed"`
+	// MaxSpreadBps drops the feeds whose order book spread is wider, in basis
+	// points of the mid price, before any strategy
+	MaxSpreadBps *float64 `json:"maxSpreadBps"`
+}
+
+// NewAggregationStrategy resolves the strategy configured on the config row.
+// Configs without an explicit strategy keep the legacy behavior: median for
+// foreign exchange pairs, vwap/median blend for everything else.
+func NewAggregationStrategy(config Config) (AggregationStrategy, error) {
+	params := AggregationParams{}
+	if len(config.AggregationParams) > 0 && string(config.AggregationParams) != "null" {
+		if err := json.Unmarshal(config.AggregationParams, &params); err != nil {
+			return nil, err
+		}
+	}
+
+	name := VWAPStrategy
+	if isFXPricePair(config.Name) {
+		name = MedianStrategy
+	}
+	if config.AggregationStrategy != nil && *config.AggregationStrategy != "" {
+		name = *config.AggregationStrategy
+	}
+
+	strategy, err := newAggregationStrategy(name, params)
+	if err != nil {
+		return nil, err
+	}
+	if params.MaxSpreadBps != nil {
+		if *params.MaxSpreadBps <= 0 {
+			return nil, errorSentinel.ErrLocalAggregatorInvalidStrategyParams
+		}
+		strategy = &spreadFilter{base: strategy, maxSpreadBps: *params.MaxSpreadBps}
+	}
+	return strategy, nil
+}
+
+func newAggregationStrategy(name string, params AggregationParams) (AggregationStrategy, error) {
+	switch name {
+	case MedianStrategy:
+		return &medianStrategy{}, nil
+	case VWAPStrategy:
+		return &vwapStrategy{}, nil
+	case TrimmedMeanStrategy:
+		trimRatio := DefaultTrimRatio
+		if params.TrimRatio != nil {
+			trimRatio = *params.TrimRatio
+		}
+		if trimRatio < 0 || trimRatio >= 0.5 {
+			return nil, errorSentinel.ErrLocalAggregatorInvalidStrategyParams
+		}
+		return &trimmedMeanStrategy{trimRatio: trimRatio}, nil
+	case LiquidityWeightedStrategy:
+		return &liquidityWeightedStrategy{}, nil
+	case DepthWeightedStrategy:
+		return &depthWeightedStrategy{}, nil
+	case StaleWeightedStrategy:
+		halfLife := DefaultStaleWeightHalfLife
+		if params.HalfLifeMs != nil {
+			halfLife = time.Duration(*params.HalfLifeMs) * time.Millisecond
+		}
+		if halfLife <= 0 {
+			return nil, errorSentinel.ErrLocalAggregatorInvalidStrategyParams
+		}
+		return &staleWeightedStrategy{halfLife: halfLife}, nil
+	case TWAPStrategy:
+		window := DefaultTWAPWindow
+		if params.WindowMs != nil {
+			window = time.Duration(*params.WindowMs) * time.Millisecond
+		}
+		if window <= 0 {
+			return nil, errorSentinel.ErrLocalAggregatorInvalidStrategyParams
+		}
+		baseName := MedianStrategy
+		if params.Base != nil && *params.Base != "" {
+			baseName = *params.Base
+		}
+		if baseName == TWAPStrategy {
+			return nil, errorSentinel.ErrLocalAggregatorInvalidStrategyParams
+		}
+		base, err := newAggregationStrategy(baseName, params)
+		if err != nil {
+			return nil, err
+		}
+		if params.PerFeed != nil && *params.PerFeed {
+			return &feedTWAPStrategy{base: base, twap: newFeedTWAP(window, getLocalAggregateInterval())}, nil
+		}
+		return &twapStrategy{base: base, window: window}, nil
+	default:
+		return nil, errorSentinel.ErrLocalAggregatorUnknownStrategy
+	}
+}
+
+type medianStrategy struct{}
+
+func (s *medianStrategy) Name() string {
+	return MedianStrategy
+}
+
+func (s *medianStrategy) Aggregate(feeds []*FeedData) (float64, error) {
+	return calculateMedian(feeds)
+}
+
+// vwapStrategy filters outliers and blends the volume weighted average of
+// feeds reporting volume with the median of feeds that don't.
+type vwapStrategy struct{}
+
+func (s *vwapStrategy) Name() string {
+	return VWAPStrategy
+}
+
+func (s *vwapStrategy) Aggregate(feeds []*FeedData) (float64, error) {
+	filtered, err := filterOutliers(feeds)
+	if err != nil {
+		return 0, err
+	}
+
+	volumeWeightedFeeds, medianFeeds := partitionFeeds(filtered)
+	vwap, err := calculateVWAP(volumeWeightedFeeds)
+	if err != nil {
+		return 0, err
+	}
+
+	median, err := calculateMedian(medianFeeds)
+	if err != nil {
+		return 0, err
+	}
+	log.Debug().Str("Player", "LocalAggregator").Float64("vwap", vwap).Float64("median", median).Msg("vwap strategy")
+	return calculateAggregatedPrice(vwap, median), nil
+}
+
+// trimmedMeanStrategy drops trimRatio of the values from each end before
+// averaging the rest.
+type trimmedMeanStrategy struct {
+	trimRatio float64
+}
+
+func (s *trimmedMeanStrategy) Name() string {
+	return TrimmedMeanStrategy
+}
+
+func (s *trimmedMeanStrategy) Aggregate(feeds []*FeedData) (float64, error) {
+	if len(feeds) == 0 {
+		return 0, nil
+	}
+
+	values := make([]float64, len(feeds))
+	for i, feed := range feeds {
+		values[i] = feed.Value
+	}
+	slices.Sort(values)
+
+	trim := int(float64(len(values)) * s.trimRatio)
+	trimmed := values[trim : len(values)-trim]
+
+	sum := 0.0
+	for _, value := range trimmed {
+		sum += value
+	}
+	return sum / float64(len(trimmed)), nil
+}
+
+// liquidityWeightedStrategy weights each feed by its quote volume
+// (value * volume) so venues with deeper notional liquidity dominate. Feeds
+// without volume are only used when no feed reports volume.
+type liquidityWeightedStrategy struct{}
+
+func (s *liquidityWeightedStrategy) Name() string {
+	return LiquidityWeightedStrategy
+}
+
+func (s *liquidityWeightedStrategy) Aggregate(feeds []*FeedData) (float64, error) {
+	weighted, unweighted := partitionFeeds(feeds)
+	if len(weighted) == 0 {
+		return calculateMedian(unweighted)
+	}
+
+	totalWeight := 0.0
+	totalValue := 0.0
+	for _, feed := range weighted {
+		weight := feed.Value * feed.Volume
+		totalWeight += weight
+		totalValue += feed.Value * weight
+	}
+
+	if totalWeight == 0 {
+		return 0, errorSentinel.ErrLocalAggregatorZeroVolume
+	}
+	return totalValue / totalWeight, nil
+}
+
+// depthWeightedStrategy weights each feed by the depth of its order book
+// within the depth percent of the mid price, so venues that can absorb more
+// size dominate. Feeds without a book are only used when no feed has one.
+type depthWeightedStrategy struct{}
+
+func (s *depthWeightedStrategy) Name() string {
+	return DepthWeightedStrategy
+}
+
+func (s *depthWeightedStrategy) Aggregate(feeds []*FeedData) (float64, error) {
+	totalWeight := 0.0
+	totalValue := 0.0
+	for _, feed := range feeds {
+		if feed.Book == nil {
+			continue
+		}
+		weight := feed.Book.BidDepth + feed.Book.AskDepth
+		totalWeight += weight
+		totalValue += feed.Value * weight
+	}
+
+	if totalWeight == 0 {
+		return calculateMedian(feeds)
+	}
+	return totalValue / totalWeight, nil
+}
+
+// spreadFilter drops the feeds whose order book spread exceeds maxSpreadBps
+// before the base strategy. Feeds without a book are kept, and so are all the
+// feeds if none passes.
+type spreadFilter struct {
+	base         AggregationStrategy
+	maxSpreadBps float64
+}
+
+func (s *spreadFilter) Name() string {
+	return s.base.Name()
+}
+
+func (s *spreadFilter) Aggregate(feeds []*FeedData) (float64, error) {
+	filtered := make([]*FeedData, 0, len(feeds))
+	for _, feed := range feeds {
+		if feed.Book != nil && feed.Book.Mid > 0 && feed.Book.Spread/feed.Book.Mid*10000 > s.maxSpreadBps {
+			log.Debug().Str("Player", "LocalAggregator").Int32("feed", feed.FeedID).Float64("spread", feed.Book.Spread).Msg("dropping feed with wide spread")
+			continue
+		}
+		filtered = append(filtered, feed)
+	}
+	if len(filtered) == 0 {
+		log.Warn().Str("Player", "LocalAggregator").Float64("maxSpreadBps", s.maxSpreadBps).Msg("every feed exceeds the max spread, keeping them all")
+		filtered = feeds
+	}
+	return s.base.Aggregate(filtered)
+}
+
+// staleWeightedStrategy decays the weight of each feed by its age, halving
+// it every halfLife. Feeds without a timestamp get full weight.
+type staleWeightedStrategy struct {
+	halfLife time.Duration
+}
+
+func (s *staleWeightedStrategy) Name() string {
+	return StaleWeightedStrategy
+}
+
+func (s *staleWeightedStrategy) Aggregate(feeds []*FeedData) (float64, error) {
+	if len(feeds) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	totalWeight := 0.0
+	totalValue := 0.0
+	for _, feed := range feeds {
+		weight := 1.0
+		if feed.Timestamp != nil {
+			age := max(now.Sub(*feed.Timestamp), 0)
+			weight = math.Pow(0.5, float64(age)/float64(s.halfLife))
+		}
+		totalWeight += weight
+		totalValue += feed.Value * weight
+	}
+
+	if totalWeight == 0 {
+		return 0, nil
+	}
+	return totalValue / totalWeight, nil
+}
+
+type aggregateSample struct {
+	value     float64
+	timestamp time.Time
+}
+
+// twapStrategy emits the time weighted average of the base strategy's
+// results over the trailing window. Each sample is weighted by how long it
+// stayed the latest value.
+type twapStrategy struct {
+	base   AggregationStrategy
+	window time.Duration
+
+	mu      sync.Mutex
+	samples []aggregateSample
+}
+
+func (s *twapStrategy) Name() string {
+	return TWAPStrategy
+}
+
+func (s *twapStrategy) Aggregate(feeds []*FeedData) (float64, error) {
+	value, err := s.base.Aggregate(feeds)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if value != 0 {
+		s.samples = append(s.samples, aggregateSample{value: value, timestamp: now})
+	}
+	start := now.Add(-s.window)
+	s.samples = pruneSamples(s.samples, start)
+
+	return timeWeightedAverage(s.samples, start, now), nil
+}
+
+// pruneSamples drops samples older than cutoff, but keeps the most recent
+// one before it since its value was still in effect at the window start.
+func pruneSamples(samples []aggregateSample, cutoff time.Time) []aggregateSample {
+	idx := 0
+	for idx < len(samples)-1 && !samples[idx+1].timestamp.After(cutoff) {
+		idx++
+	}
+	return samples[idx:]
+}
+
+// timeWeightedAverage weights each sample by the time it was in effect
+// within [start, now].
+func timeWeightedAverage(samples []aggregateSample, start time.Time, now time.Time) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	if len(samples) == 1 {
+		return samples[0].value
+	}
+
+	totalDuration := 0.0
+	totalValue := 0.0
+	for i, sample := range samples {
+		from := sample.timestamp
+		if from.Before(start) {
+			from = start
+		}
+		end := now
+		if i < len(samples)-1 {
+			end = samples[i+1].timestamp
+		}
+		duration := float64(max(end.Sub(from), 0))
+		totalDuration += duration
+		totalValue += sample.value * duration
+	}
+
+	if totalDuration == 0 {
+		return samples[len(samples)-1].value
+	}
+	return totalValue / totalDuration
+}
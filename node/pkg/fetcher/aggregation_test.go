@@ -0,0 +1,200 @@
+//nolint:all
+package fetcher
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"bisonai.com/miko/node/pkg/common/types"
+	errorSentinel "bisonai.com/miko/node/pkg/error"
+	"github.com/stretchr/testify/assert"
+)
+
+func strPtr(v string) *string {
+	return &v
+}
+
+func TestNewAggregationStrategy_Defaults(t *testing.T) {
+	strategy, err := NewAggregationStrategy(Config{Name: "KRW-USD"})
+	assert.NoError(t, err)
+	assert.Equal(t, MedianStrategy, strategy.Name(), "fx pairs should default to median")
+
+	strategy, err = NewAggregationStrategy(Config{Name: "BTC-USDT"})
+	assert.NoError(t, err)
+	assert.Equal(t, VWAPStrategy, strategy.Name(), "other pairs should default to vwap")
+}
+
+func TestNewAggregationStrategy_Configured(t *testing.T) {
+	strategy, err := NewAggregationStrategy(Config{
+		Name:                "USDT-KRW",
+		AggregationStrategy: strPtr(TrimmedMeanStrategy),
+		AggregationParams:   json.RawMessage(`{"trimRatio": 0.2}`),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, TrimmedMeanStrategy, strategy.Name())
+	assert.Equal(t, 0.2, strategy.(*trimmedMeanStrategy).trimRatio)
+}
+
+func TestNewAggregationStrategy_Invalid(t *testing.T) {
+	_, err := NewAggregationStrategy(Config{Name: "BTC-USDT", AggregationStrategy: strPtr("unknown")})
+	assert.ErrorIs(t, err, errorSentinel.ErrLocalAggregatorUnknownStrategy)
+
+	_, err = NewAggregationStrategy(Config{
+		Name:                "BTC-USDT",
+		AggregationStrategy: strPtr(TrimmedMeanStrategy),
+		AggregationParams:   json.RawMessage(`{"trimRatio": 0.5}`),
+	})
+	assert.ErrorIs(t, err, errorSentinel.ErrLocalAggregatorInvalidStrategyParams)
+
+	_, err = NewAggregationStrategy(Config{
+		Name:                "BTC-USDT",
+		AggregationStrategy: strPtr(TWAPStrategy),
+		AggregationParams:   json.RawMessage(`{"base": "twap"}`),
+	})
+	assert.ErrorIs(t, err, errorSentinel.ErrLocalAggregatorInvalidStrategyParams)
+}
+
+func TestNewLocalAggregator_FallsBackOnInvalidStrategy(t *testing.T) {
+	la := NewLocalAggregator(Config{Name: "BTC-USDT", AggregationStrategy: strPtr("unknown")}, nil, nil, nil, nil, nil)
+	assert.Equal(t, VWAPStrategy, la.aggregationStrategy().Name())
+}
+
+func TestTrimmedMeanStrategy(t *testing.T) {
+	strategy := &trimmedMeanStrategy{trimRatio: 0.2}
+	feeds := []*FeedData{
+		{FeedID: 1, Value: 1},
+		{FeedID: 2, Value: 100},
+		{FeedID: 3, Value: 101},
+		{FeedID: 4, Value: 102},
+		{FeedID: 5, Value: 1000},
+	}
+
+	result, err := strategy.Aggregate(feeds)
+	assert.NoError(t, err)
+	assert.Equal(t, 101.0, result)
+}
+
+func TestLiquidityWeightedStrategy(t *testing.T) {
+	strategy := &liquidityWeightedStrategy{}
+
+	result, err := strategy.Aggregate([]*FeedData{
+		{FeedID: 1, Value: 100, Volume: 3},
+		{FeedID: 2, Value: 200, Volume: 1},
+		{FeedID: 3, Value: 500},
+	})
+	assert.NoError(t, err)
+	// weights are 300 and 200, the volume-less feed is ignored
+	assert.InDelta(t, 140.0, result, 1e-9)
+
+	result, err = strategy.Aggregate([]*FeedData{
+		{FeedID: 1, Value: 100},
+		{FeedID: 2, Value: 200},
+		{FeedID: 3, Value: 300},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 200.0, result, "should fall back to median without volume")
+}
+
+func TestDepthWeightedStrategy(t *testing.T) {
+	strategy := &depthWeightedStrategy{}
+
+	result, err := strategy.Aggregate([]*FeedData{
+		{FeedID: 1, Value: 100, Book: &types.BookData{BidDepth: 2, AskDepth: 1}},
+		{FeedID: 2, Value: 200, Book: &types.BookData{BidDepth: 1}},
+		{FeedID: 3, Value: 500, Volume: 10},
+	})
+	assert.NoError(t, err)
+	// weights are 3 and 1, the feed without a book is ignored
+	assert.InDelta(t, 125.0, result, 1e-9)
+
+	result, err = strategy.Aggregate([]*FeedData{
+		{FeedID: 1, Value: 100},
+		{FeedID: 2, Value: 200},
+		{FeedID: 3, Value: 300},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 200.0, result, "should fall back to median without books")
+}
+
+func TestSpreadFilter(t *testing.T) {
+	strategy, err := NewAggregationStrategy(Config{
+		Name:                "BTC-USDT",
+		AggregationStrategy: strPtr(MedianStrategy),
+		AggregationParams:   json.RawMessage(`{"maxSpreadBps": 10}`),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, MedianStrategy, strategy.Name())
+
+	result, err := strategy.Aggregate([]*FeedData{
+		{FeedID: 1, Value: 100, Book: &types.BookData{Mid: 100, Spread: 0.05}},
+		{FeedID: 2, Value: 102, Book: &types.BookData{Mid: 102, Spread: 0.1}},
+		{FeedID: 3, Value: 130, Book: &types.BookData{Mid: 130, Spread: 5}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 101.0, result, "the feed over 10bps is dropped")
+
+	result, err = strategy.Aggregate([]*FeedData{
+		{FeedID: 1, Value: 100, Book: &types.BookData{Mid: 100, Spread: 1}},
+		{FeedID: 2, Value: 110, Book: &types.BookData{Mid: 110, Spread: 1}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 105.0, result, "feeds are kept if none passes")
+
+	_, err = NewAggregationStrategy(Config{Name: "BTC-USDT", AggregationParams: json.RawMessage(`{"maxSpreadBps": 0}`)})
+	assert.ErrorIs(t, err, errorSentinel.ErrLocalAggregatorInvalidStrategyParams)
+}
+
+func TestStaleWeightedStrategy(t *testing.T) {
+	strategy := &staleWeightedStrategy{halfLife: time.Second}
+
+	now := time.Now()
+	old := now.Add(-time.Second)
+	result, err := strategy.Aggregate([]*FeedData{
+		{FeedID: 1, Value: 100, Timestamp: &now},
+		{FeedID: 2, Value: 130, Timestamp: &old},
+	})
+	assert.NoError(t, err)
+	// the second feed is one half-life old so it weighs half as much
+	assert.InDelta(t, 110.0, result, 0.1)
+}
+
+func TestTimeWeightedAverage(t *testing.T) {
+	now := time.Now()
+	start := now.Add(-10 * time.Second)
+	samples := []aggregateSample{
+		{value: 100, timestamp: now.Add(-15 * time.Second)},
+		{value: 200, timestamp: now.Add(-5 * time.Second)},
+	}
+
+	// 100 holds for the first 5s of the window, 200 for the last 5s
+	assert.InDelta(t, 150.0, timeWeightedAverage(samples, start, now), 1e-9)
+	assert.Equal(t, 0.0, timeWeightedAverage(nil, start, now))
+}
+
+func TestPruneSamples(t *testing.T) {
+	now := time.Now()
+	samples := []aggregateSample{
+		{value: 1, timestamp: now.Add(-30 * time.Second)},
+		{value: 2, timestamp: now.Add(-20 * time.Second)},
+		{value: 3, timestamp: now.Add(-5 * time.Second)},
+	}
+
+	pruned := pruneSamples(samples, now.Add(-10*time.Second))
+	assert.Equal(t, 2, len(pruned), "the last sample before the cutoff should be kept")
+	assert.Equal(t, 2.0, pruned[0].value)
+}
+
+func TestTWAPStrategy(t *testing.T) {
+	strategy := &twapStrategy{base: &medianStrategy{}, window: time.Minute}
+
+	result, err := strategy.Aggregate([]*FeedData{{FeedID: 1, Value: 100}})
+	assert.NoError(t, err)
+	assert.Equal(t, 100.0, result)
+
+	// a single spike right after should barely move the twap
+	strategy.samples[0].timestamp = strategy.samples[0].timestamp.Add(-10 * time.Second)
+	result, err = strategy.Aggregate([]*FeedData{{FeedID: 1, Value: 1000}})
+	assert.NoError(t, err)
+	assert.Less(t, result, 110.0)
+}
@@ -94,18 +94,26 @@ func (a *App) handleMessage(ctx context.Context, msg bus.Message) {
 		}
 		msg.Response <- bus.MessageResponse{Success: true}
 	case bus.REFRESH_FETCHER_APP:
-		err := a.stopAll(ctx)
+		// websocket fetchers keep their connections, only the providers with
+		// changed feeds resubscribe
+		err := a.stopFetchers(ctx)
 		if err != nil {
 			log.Error().Err(err).Str("Player", "Fetcher").Msg("failed to stop all fetchers")
 			bus.HandleMessageError(err, msg, "failed to stop all fetchers")
 			return
 		}
-		err = a.initialize(ctx)
+		err = a.initializeFetchers(ctx)
 		if err != nil {
 			log.Error().Err(err).Str("Player", "Fetcher").Msg("failed to initialize fetchers")
 			bus.HandleMessageError(err, msg, "failed to initialize fetchers")
 			return
 		}
+		err = a.WebsocketFetcher.SyncFeeds(ctx)
+		if err != nil {
+			log.Error().Err(err).Str("Player", "Fetcher").Msg("failed to sync websocket feeds")
+			bus.HandleMessageError(err, msg, "failed to sync websocket feeds")
+			return
+		}
 		err = a.startAll(ctx)
 		if err != nil {
 			log.Error().Err(err).Str("Player", "Fetcher").Msg("failed to start all fetchers")
@@ -115,6 +123,10 @@ func (a *App) handleMessage(ctx context.Context, msg bus.Message) {
 
 		log.Debug().Str("Player", "Fetcher").Msg("refreshing fetcher")
 		msg.Response <- bus.MessageResponse{Success: true}
+	case bus.GET_FEED_STATUS:
+		msg.Response <- bus.MessageResponse{Success: true, Args: map[string]any{
+			"feeds": a.WebsocketFetcher.FeedStatus(),
+		}}
 	}
 }
 
@@ -138,7 +150,11 @@ func (a *App) startAll(ctx context.Context) error {
 
 func (a *App) stopAll(ctx context.Context) error {
 	a.WebsocketFetcher.Stop()
+	return a.stopFetchers(ctx)
+}
 
+// stopFetchers stops everything but the websocket fetcher.
+func (a *App) stopFetchers(ctx context.Context) error {
 	err := a.stopAllFetchers(ctx)
 	if err != nil {
 		return err
@@ -341,6 +357,23 @@ func (a *App) getProxies(ctx context.Context) ([]Proxy, error) {
 }
 
 func (a *App) initialize(ctx context.Context) error {
+	err := a.initializeFetchers(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = a.WebsocketFetcher.Init(ctx, websocketfetcher.WithLatestFeedDataMap(a.LatestFeedDataMap), websocketfetcher.WithFeedDataDumpChannel(a.FeedDataDumpChannel))
+	if err != nil {
+		return err
+	}
+
+	go a.LatestFeedDataMap.CleanupJob(ctx)
+
+	return nil
+}
+
+// initializeFetchers sets up everything but the websocket fetcher.
+func (a *App) initializeFetchers(ctx context.Context) error {
 	configs, err := a.getConfigs(ctx)
 	if err != nil {
 		return err
@@ -382,13 +415,6 @@ func (a *App) initialize(ctx context.Context) error {
 	}
 	a.Proxies = proxies
 
-	err = a.WebsocketFetcher.Init(ctx, websocketfetcher.WithLatestFeedDataMap(a.LatestFeedDataMap), websocketfetcher.WithFeedDataDumpChannel(a.FeedDataDumpChannel))
-	if err != nil {
-		return err
-	}
-
-	go a.LatestFeedDataMap.CleanupJob(ctx)
-
 	return nil
 }
 
@@ -3,7 +3,6 @@ package fetcher
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"math"
 	"os"
 	"slices"
@@ -21,6 +20,11 @@ func NewLocalAggregator(
 	bus *bus.MessageBus,
 	latestFeedDataMap *LatestFeedDataMap,
 	localAggregateValueMap *LocalAggregateValueMap) *LocalAggregator {
+	strategy, err := NewAggregationStrategy(config)
+	if err != nil {
+		log.Error().Err(err).Str("Player", "LocalAggregator").Str("config", config.Name).Msg("failed to resolve aggregation strategy, falling back to default")
+	}
+
 	return &LocalAggregator{
 		Config:                 config,
 		Feeds:                  feeds,
@@ -30,6 +34,7 @@ func NewLocalAggregator(
 		localAggregatesChannel: localAggregatesChannel,
 		latestFeedDataMap:      latestFeedDataMap,
 		localAggregateValueMap: localAggregateValueMap,
+		strategy:               strategy,
 	}
 }
 
@@ -39,14 +44,7 @@ func (c *LocalAggregator) Run(ctx context.Context) {
 	c.cancel = cancel
 	c.isRunning = true
 
-	localAggregateIntervalRaw := os.Getenv("LOCAL_AGGREGATE_INTERVAL")
-	localAggregateInterval, err := time.ParseDuration(localAggregateIntervalRaw)
-	if err != nil {
-		localAggregateInterval = DefaultLocalAggregateInterval
-	}
-	localAggregateFrequency := localAggregateInterval
-
-	ticker := time.NewTicker(localAggregateFrequency)
+	ticker := time.NewTicker(getLocalAggregateInterval())
 	go func() {
 		for {
 			select {
@@ -65,6 +63,14 @@ func (c *LocalAggregator) Run(ctx context.Context) {
 	}()
 }
 
+func getLocalAggregateInterval() time.Duration {
+	localAggregateInterval, err := time.ParseDuration(os.Getenv("LOCAL_AGGREGATE_INTERVAL"))
+	if err != nil {
+		return DefaultLocalAggregateInterval
+	}
+	return localAggregateInterval
+}
+
 func (c *LocalAggregator) Job(ctx context.Context) error {
 	feeds, err := c.collect(ctx)
 	if err != nil {
@@ -80,43 +86,25 @@ func (c *LocalAggregator) Job(ctx context.Context) error {
 }
 
 func (c *LocalAggregator) processFeeds(ctx context.Context, feeds []*FeedData) error {
-	if isFXPricePair(c.Name) {
-		return c.processFXPricePair(ctx, feeds)
-	}
-	return c.processVolumeWeightedFeeds(ctx, feeds)
-}
-
-func (c *LocalAggregator) processFXPricePair(ctx context.Context, feeds []*FeedData) error {
-	median, err := calculateMedian(feeds)
+	strategy := c.aggregationStrategy()
+	aggregated, err := strategy.Aggregate(feeds)
 	if err != nil {
-		log.Error().Err(err).Str("Player", "LocalAggregator").Msg("error in calculateMedian in localAggregator")
+		log.Error().Err(err).Str("Player", "LocalAggregator").Str("strategy", strategy.Name()).Msg("error in aggregation strategy in localAggregator")
 		return err
 	}
-	return c.streamLocalAggregate(ctx, median)
+	return c.streamLocalAggregate(ctx, aggregated)
 }
 
-func (c *LocalAggregator) processVolumeWeightedFeeds(ctx context.Context, feeds []*FeedData) error {
-	filtered, err := filterOutliers(feeds)
-	if err != nil {
-		log.Error().Err(err).Str("Player", "LocalAggregator").Msg("error in filterOutliers in localAggregator")
-		return err
-	}
-
-	volumeWeightedFeeds, medianFeeds := partitionFeeds(filtered)
-	vwap, err := calculateVWAP(volumeWeightedFeeds)
-	if err != nil {
-		log.Error().Err(err).Str("Player", "LocalAggregator").Msg("error in calculateVWAP in localAggregator")
-		return err
+// aggregationStrategy returns the strategy resolved at construction, falling
+// back to the stateless legacy default when none could be resolved.
+func (c *LocalAggregator) aggregationStrategy() AggregationStrategy {
+	if c.strategy != nil {
+		return c.strategy
 	}
-
-	median, err := calculateMedian(medianFeeds)
-	if err != nil {
-		log.Error().Err(err).Str("Player", "LocalAggregator").Msg("error in calculateMedian in localAggregator")
-		return err
+	if isFXPricePair(c.Name) {
+		return &medianStrategy{}
 	}
-	log.Debug().Str("Player", "LocalAggregator").Msg(fmt.Sprintf("VWAP: %f Median: %f", vwap, median))
-	aggregated := calculateAggregatedPrice(vwap, median)
-	return c.streamLocalAggregate(ctx, aggregated)
+	return &vwapStrategy{}
 }
 
 func filterOutliers(feeds []*FeedData) ([]*FeedData, error) {
@@ -374,44 +362,75 @@ func (c *LocalAggregator) applyPerFeedMultipliers(feeds []*FeedData) []*FeedData
 	return out
 }
 
-func (c *LocalAggregator) filterStaleFeeds(feeds []*FeedData) []*FeedData {
-	if c.Config.FeedDataFreshness == nil || *c.Config.FeedDataFreshness <= 0 {
-		return feeds
-	}
+const (
+	staleByWatchdog  = "marked stale by watchdog"
+	staleByFreshness = "older than feed data freshness"
+)
 
+// filterStaleFeeds drops the stale feeds from feeds. Exclusions are logged
+// when a feed gets excluded and when it's included again rather than on
+// every aggregation.
+func (c *LocalAggregator) filterStaleFeeds(feeds []*FeedData) []*FeedData {
 	if len(feeds) <= 1 {
 		return feeds
 	}
 
-	freshness := time.Duration(*c.Config.FeedDataFreshness) * time.Millisecond
+	var freshness time.Duration
+	if c.Config.FeedDataFreshness != nil && *c.Config.FeedDataFreshness > 0 {
+		freshness = time.Duration(*c.Config.FeedDataFreshness) * time.Millisecond
+	}
 	now := time.Now()
 	fresh := make([]*FeedData, 0, len(feeds))
+	excluded := map[int32]string{}
 
 	for _, feed := range feeds {
-		// DEX/HTTP feeds (volume == 0) are not subject to freshness filtering
-		if feed.Volume == 0 {
+		reason := c.staleReason(feed, freshness, now)
+		if reason == "" {
+			if previous, ok := c.excludedFeeds[feed.FeedID]; ok {
+				log.Info().Str("Player", "LocalAggregator").
+					Str("config", c.Name).
+					Int32("feedID", feed.FeedID).
+					Str("reason", previous).
+					Msg("including feed in aggregation again")
+			}
 			fresh = append(fresh, feed)
 			continue
 		}
 
-		if feed.Timestamp == nil {
-			fresh = append(fresh, feed)
+		excluded[feed.FeedID] = reason
+		if c.excludedFeeds[feed.FeedID] == reason {
 			continue
 		}
-
-		age := now.Sub(*feed.Timestamp)
-		if age > freshness {
-			log.Warn().Str("Player", "LocalAggregator").
-				Str("config", c.Name).
-				Int32("feedID", feed.FeedID).
-				Dur("age", age).
-				Dur("freshness", freshness).
-				Float64("value", feed.Value).
-				Msg("excluding stale feed from aggregation")
-			continue
+		event := log.Warn().Str("Player", "LocalAggregator").
+			Str("config", c.Name).
+			Int32("feedID", feed.FeedID).
+			Str("reason", reason).
+			Float64("value", feed.Value)
+		if reason == staleByFreshness {
+			event = event.Dur("age", now.Sub(*feed.Timestamp)).Dur("freshness", freshness)
 		}
-		fresh = append(fresh, feed)
+		event.Msg("excluding feed from aggregation")
 	}
+	c.excludedFeeds = excluded
 
 	return fresh
 }
+
+// staleReason returns why feed is stale, or an empty string if it isn't.
+func (c *LocalAggregator) staleReason(feed *FeedData, freshness time.Duration, now time.Time) string {
+	// feeds the websocket watchdog marked stale are excluded regardless
+	// of the configured freshness
+	if c.latestFeedDataMap != nil && c.latestFeedDataMap.IsStale(feed.FeedID) {
+		return staleByWatchdog
+	}
+
+	// DEX/HTTP feeds (volume == 0) are not subject to freshness filtering
+	if freshness == 0 || feed.Volume == 0 || feed.Timestamp == nil {
+		return ""
+	}
+
+	if now.Sub(*feed.Timestamp) > freshness {
+		return staleByFreshness
+	}
+	return ""
+}
@@ -265,3 +265,27 @@ func TestFilterStaleFeeds_IntegrationWithCollectFlow(t *testing.T) {
 		assert.NotEqual(t, int32(12), feed.FeedID, "feed 12 (bitmart stuck) should be excluded")
 	}
 }
+
+func TestFilterStaleFeeds_ExcludesWatchdogStale(t *testing.T) {
+	la := newLocalAggregatorWithFreshness(nil)
+
+	now := time.Now()
+	feedDataMap := &LatestFeedDataMap{FeedDataMap: map[int32]*FeedData{}}
+	feedDataMap.SetStale(2, true)
+	la.latestFeedDataMap = feedDataMap
+
+	feeds := []*FeedData{
+		{FeedID: 1, Value: 100.0, Volume: 10, Timestamp: &now},
+		{FeedID: 2, Value: 90.0, Volume: 10, Timestamp: &now},
+		{FeedID: 3, Value: 101.0, Volume: 10, Timestamp: &now},
+	}
+
+	result := la.filterStaleFeeds(feeds)
+	assert.Equal(t, 2, len(result), "feed marked stale by the watchdog should be excluded without freshness config")
+	assert.Equal(t, map[int32]string{2: staleByWatchdog}, la.excludedFeeds, "exclusion is kept to log it only once")
+
+	feedDataMap.SetStale(2, false)
+	result = la.filterStaleFeeds(feeds)
+	assert.Equal(t, 3, len(result), "feed is included again once fresh")
+	assert.Empty(t, la.excludedFeeds)
+}
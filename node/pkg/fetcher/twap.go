@@ -0,0 +1,124 @@
+package fetcher
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	MinTWAPRingCapacity = 2
+	MaxTWAPRingCapacity = 4096
+)
+
+// sampleRing is a fixed capacity ring buffer of samples ordered by time.
+// Once full, pushing a new sample overwrites the oldest one.
+type sampleRing struct {
+	samples []aggregateSample
+	head    int
+	size    int
+}
+
+func newSampleRing(capacity int) *sampleRing {
+	return &sampleRing{samples: make([]aggregateSample, capacity)}
+}
+
+func (r *sampleRing) push(sample aggregateSample) {
+	idx := (r.head + r.size) % len(r.samples)
+	r.samples[idx] = sample
+	if r.size < len(r.samples) {
+		r.size++
+		return
+	}
+	r.head = (r.head + 1) % len(r.samples)
+}
+
+func (r *sampleRing) last() (aggregateSample, bool) {
+	if r.size == 0 {
+		return aggregateSample{}, false
+	}
+	return r.samples[(r.head+r.size-1)%len(r.samples)], true
+}
+
+// ordered returns the samples from oldest to newest.
+func (r *sampleRing) ordered() []aggregateSample {
+	result := make([]aggregateSample, r.size)
+	for i := 0; i < r.size; i++ {
+		result[i] = r.samples[(r.head+i)%len(r.samples)]
+	}
+	return result
+}
+
+// feedTWAP keeps a rolling ring of samples per feed and replaces each feed's
+// point-in-time value with its time weighted average over the window, so a
+// single tick spike on one exchange can't flow straight into local_aggregates.
+type feedTWAP struct {
+	window   time.Duration
+	capacity int
+
+	mu    sync.Mutex
+	rings map[int32]*sampleRing
+}
+
+func newFeedTWAP(window time.Duration, interval time.Duration) *feedTWAP {
+	capacity := MinTWAPRingCapacity
+	if interval > 0 {
+		capacity = max(int(window/interval)+1, MinTWAPRingCapacity)
+	}
+
+	return &feedTWAP{
+		window:   window,
+		capacity: min(capacity, MaxTWAPRingCapacity),
+		rings:    make(map[int32]*sampleRing),
+	}
+}
+
+// apply records the latest value of every feed and returns copies of the
+// feeds carrying their time weighted value. The original feeds are shared
+// through LatestFeedDataMap and are never mutated.
+func (t *feedTWAP) apply(feeds []*FeedData, now time.Time) []*FeedData {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	start := now.Add(-t.window)
+	result := make([]*FeedData, 0, len(feeds))
+	for _, feed := range feeds {
+		ring, ok := t.rings[feed.FeedID]
+		if !ok {
+			ring = newSampleRing(t.capacity)
+			t.rings[feed.FeedID] = ring
+		}
+
+		sampledAt := now
+		if feed.Timestamp != nil {
+			sampledAt = *feed.Timestamp
+		}
+
+		// the same feed data is read on every tick until the provider sends an
+		// update, so only record samples that are newer than the last one
+		if last, ok := ring.last(); !ok || sampledAt.After(last.timestamp) {
+			ring.push(aggregateSample{value: feed.Value, timestamp: sampledAt})
+		}
+
+		samples := pruneSamples(ring.ordered(), start)
+		feedCopy := *feed
+		feedCopy.Value = timeWeightedAverage(samples, start, now)
+		result = append(result, &feedCopy)
+	}
+
+	return result
+}
+
+// feedTWAPStrategy is the per feed mode of twap, the base strategy combines
+// the time weighted values of the feeds.
+type feedTWAPStrategy struct {
+	base AggregationStrategy
+	twap *feedTWAP
+}
+
+func (s *feedTWAPStrategy) Name() string {
+	return TWAPStrategy
+}
+
+func (s *feedTWAPStrategy) Aggregate(feeds []*FeedData) (float64, error) {
+	return s.base.Aggregate(s.twap.apply(feeds, time.Now()))
+}
@@ -0,0 +1,105 @@
+//nolint:all
+package fetcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampleRing_OverwritesOldest(t *testing.T) {
+	ring := newSampleRing(3)
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		ring.push(aggregateSample{value: float64(i), timestamp: now.Add(time.Duration(i) * time.Second)})
+	}
+
+	ordered := ring.ordered()
+	assert.Equal(t, 3, len(ordered))
+	assert.Equal(t, []float64{2, 3, 4}, []float64{ordered[0].value, ordered[1].value, ordered[2].value})
+
+	last, ok := ring.last()
+	assert.True(t, ok)
+	assert.Equal(t, 4.0, last.value)
+}
+
+func TestNewFeedTWAP_Capacity(t *testing.T) {
+	assert.Equal(t, 51, newFeedTWAP(10*time.Second, 200*time.Millisecond).capacity)
+	assert.Equal(t, MinTWAPRingCapacity, newFeedTWAP(10*time.Millisecond, time.Second).capacity)
+	assert.Equal(t, MaxTWAPRingCapacity, newFeedTWAP(time.Hour, time.Millisecond).capacity)
+}
+
+func TestFeedTWAP_DampensSpike(t *testing.T) {
+	twap := newFeedTWAP(10*time.Second, 200*time.Millisecond)
+	now := time.Now()
+
+	steady := now.Add(-9 * time.Second)
+	result := twap.apply([]*FeedData{{FeedID: 1, Value: 100, Volume: 1, Timestamp: &steady}}, steady)
+	assert.Equal(t, 100.0, result[0].Value)
+
+	spike := now.Add(-500 * time.Millisecond)
+	original := &FeedData{FeedID: 1, Value: 200, Volume: 1, Timestamp: &spike}
+	result = twap.apply([]*FeedData{original}, now)
+
+	// 100 for 8.5s and 200 for 0.5s since the first sample
+	assert.InDelta(t, 105.56, result[0].Value, 0.01)
+	assert.Equal(t, 200.0, original.Value, "shared feed data should not be mutated")
+	assert.Equal(t, &spike, result[0].Timestamp)
+}
+
+func TestFeedTWAP_IgnoresRepeatedSamples(t *testing.T) {
+	twap := newFeedTWAP(10*time.Second, 200*time.Millisecond)
+	now := time.Now()
+	ts := now.Add(-time.Second)
+	feed := &FeedData{FeedID: 1, Value: 100, Timestamp: &ts}
+
+	twap.apply([]*FeedData{feed}, now)
+	twap.apply([]*FeedData{feed}, now.Add(200*time.Millisecond))
+
+	assert.Equal(t, 1, twap.rings[1].size)
+}
+
+func TestLocalAggregatorJob_PerFeedTWAP(t *testing.T) {
+	strategy := TWAPStrategy
+	now := time.Now()
+	steady := now.Add(-9 * time.Second)
+	feedDataMap := &LatestFeedDataMap{FeedDataMap: map[int32]*FeedData{
+		1: {FeedID: 1, Value: 100, Volume: 1, Timestamp: &steady},
+	}}
+	la := NewLocalAggregator(Config{
+		ID:                  1,
+		Name:                "TEST-USDT",
+		AggregationStrategy: &strategy,
+		AggregationParams:   []byte(`{"windowMs": 10000, "perFeed": true}`),
+	}, []Feed{{ID: 1}}, nil, nil, feedDataMap, nil)
+	assert.IsType(t, &feedTWAPStrategy{}, la.strategy)
+
+	feeds, err := la.collect(context.Background())
+	assert.NoError(t, err)
+	value, err := la.strategy.Aggregate(feeds)
+	assert.NoError(t, err)
+	assert.Equal(t, 100.0, value)
+
+	spike := now.Add(-100 * time.Millisecond)
+	feedDataMap.FeedDataMap[1] = &FeedData{FeedID: 1, Value: 200, Volume: 1, Timestamp: &spike}
+	feeds, err = la.collect(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 200.0, feeds[0].Value, "collected feeds keep their latest value")
+	value, err = la.strategy.Aggregate(feeds)
+	assert.NoError(t, err)
+	assert.Less(t, value, 110.0, "single tick spike should be dampened")
+}
+
+func TestNewAggregationStrategy_TWAPModes(t *testing.T) {
+	strategy := TWAPStrategy
+	resolved, err := NewAggregationStrategy(Config{Name: "TEST-USDT", AggregationStrategy: &strategy})
+	assert.NoError(t, err)
+	assert.IsType(t, &twapStrategy{}, resolved, "twap averages the combined value by default")
+
+	resolved, err = NewAggregationStrategy(Config{Name: "TEST-USDT", AggregationStrategy: &strategy, AggregationParams: []byte(`{"perFeed": true, "base": "vwap"}`)})
+	assert.NoError(t, err)
+	assert.IsType(t, &feedTWAPStrategy{}, resolved)
+	assert.IsType(t, &vwapStrategy{}, resolved.(*feedTWAPStrategy).base)
+}
@@ -2,6 +2,7 @@ package fetcher
 
 import (
 	"context"
+	"encoding/json"
 	"math/big"
 	"sync"
 	"time"
@@ -14,7 +15,7 @@ import (
 
 const (
 	SelectAllProxiesQuery                 = `SELECT * FROM proxies`
-	SelectConfigsQuery                    = `SELECT id, name, fetch_interval, decimals, feed_data_freshness, multiply_by, multiply_by_reciprocal FROM configs`
+	SelectConfigsQuery                    = `SELECT id, name, fetch_interval, decimals, feed_data_freshness, multiply_by, multiply_by_reciprocal, aggregation_strategy, aggregation_params FROM configs`
 	SelectHttpRequestFeedsByConfigIdQuery = `SELECT * FROM feeds WHERE config_id = @config_id AND NOT (definition::jsonb ? 'type')`
 	SelectFeedsByConfigIdQuery            = `SELECT * FROM feeds WHERE config_id = @config_id`
 	InsertLocalAggregateQuery             = `INSERT INTO local_aggregates (config_id, value) VALUES (@config_id, @value)`
@@ -35,13 +36,15 @@ type Proxy = types.Proxy
 type LatestFeedDataMap = types.LatestFeedDataMap
 
 type Config struct {
-	ID                    int32   `db:"id"`
-	Name                  string  `db:"name"`
-	FetchInterval         int32   `db:"fetch_interval"`
-	Decimals              *int    `db:"decimals"`
-	FeedDataFreshness     *int    `db:"feed_data_freshness"`
-	MultiplyBy            *string `db:"multiply_by"`
-	MultiplyByReciprocal  bool    `db:"multiply_by_reciprocal"`
+	ID                   int32           `db:"id"`
+	Name                 string          `db:"name"`
+	FetchInterval        int32           `db:"fetch_interval"`
+	Decimals             *int            `db:"decimals"`
+	FeedDataFreshness    *int            `db:"feed_data_freshness"`
+	MultiplyBy           *string         `db:"multiply_by"`
+	MultiplyByReciprocal bool            `db:"multiply_by_reciprocal"`
+	AggregationStrategy  *string         `db:"aggregation_strategy"`
+	AggregationParams    json.RawMessage `db:"aggregation_params"`
 }
 
 // LocalAggregateValueMap is a process-wide cache of the most recent raw
@@ -102,10 +105,14 @@ type LocalAggregator struct {
 	cancel        context.CancelFunc
 	isRunning     bool
 	bus           *bus.MessageBus
+	strategy      AggregationStrategy
 
 	localAggregatesChannel chan *LocalAggregate
 	latestFeedDataMap      *LatestFeedDataMap
 	localAggregateValueMap *LocalAggregateValueMap
+	// excludedFeeds are the feeds excluded as stale by the last aggregation,
+	// with their reason
+	excludedFeeds map[int32]string
 }
 
 type FeedDataBulkWriter struct {
@@ -1,5 +1,7 @@
 package raft
 
+import "time"
+
 func (r *Raft) GetRole() RoleType {
 	r.Mutex.Lock()
 	defer r.Mutex.Unlock()
@@ -14,8 +16,26 @@ func (r *Raft) GetCurrentTerm() int {
 
 func (r *Raft) IncreaseTerm() {
 	r.Mutex.Lock()
-	defer r.Mutex.Unlock()
 	r.Term++
+	r.markStateDirty()
+	r.Mutex.Unlock()
+	r.flushState()
+}
+
+// hasLeaderLease reports whether a majority of the participants, counting
+// this node, acknowledged its leadership within LeaseDuration. It is only
+// meaningful from the leader loop started in becomeLeader.
+func (r *Raft) hasLeaderLease() bool {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	acknowledged := 1
+	for sender, ackedAt := range r.heartbeatAcks {
+		if sender != r.GetHostId() && time.Since(ackedAt) < r.LeaseDuration {
+			acknowledged++
+		}
+	}
+	return acknowledged >= r.majority()
 }
 
 func (r *Raft) GetLeader() string {
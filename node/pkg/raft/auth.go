@@ -0,0 +1,236 @@
+package raft
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	errorSentinel "bisonai.com/miko/node/pkg/error"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+)
+
+var droppedMessagesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "raft_dropped_messages_total",
+	Help: "Total number of raft messages dropped by authentication, by reason",
+}, []string{"reason"})
+
+type DropReason string
+
+const (
+	DropReasonMalformed        DropReason = "malformed"
+	DropReasonMissingSignature DropReason = "missing_signature"
+	DropReasonInvalidSignature DropReason = "invalid_signature"
+	DropReasonSenderMismatch   DropReason = "sender_mismatch"
+	DropReasonUnknownPeer      DropReason = "unknown_peer"
+	DropReasonNotWhitelisted   DropReason = "not_whitelisted"
+	DropReasonStale            DropReason = "stale"
+	DropReasonDuplicate        DropReason = "duplicate"
+)
+
+const (
+	// MessageFreshness is how far a message timestamp may be from the local
+	// clock, in either direction, for the message to be accepted.
+	MessageFreshness = 30 * time.Second
+	// SeenCacheSize bounds the signatures remembered per peer to reject
+	// replays within MessageFreshness.
+	SeenCacheSize = 1024
+)
+
+// signingPayload is what gets signed for a raft message. The timestamp is
+// signed as unix nanoseconds so it doesn't depend on its json encoding.
+type signingPayload struct {
+	Type      MessageType     `json:"type"`
+	SentFrom  string          `json:"sentFrom"`
+	Data      json.RawMessage `json:"data"`
+	Timestamp int64           `json:"timestamp"`
+}
+
+func (m *Message) signingPayload() ([]byte, error) {
+	return json.Marshal(signingPayload{
+		Type:      m.Type,
+		SentFrom:  m.SentFrom,
+		Data:      m.Data,
+		Timestamp: m.Timestamp.UnixNano(),
+	})
+}
+
+func (m *Message) sign(key crypto.PrivKey) error {
+	payload, err := m.signingPayload()
+	if err != nil {
+		return err
+	}
+	signature, err := key.Sign(payload)
+	if err != nil {
+		return err
+	}
+	m.Signature = signature
+	return nil
+}
+
+// LoadPeerWhitelist reads a comma separated list of peer ids from
+// RAFT_PEER_WHITELIST. Peers missing from it are rejected, unless
+// RAFT_ALLOW_ANY_PEER is set, see LoadAllowAnyPeer.
+func LoadPeerWhitelist() map[peer.ID]struct{} {
+	whitelist := make(map[peer.ID]struct{})
+	raw := os.Getenv("RAFT_PEER_WHITELIST")
+	if raw == "" {
+		return whitelist
+	}
+
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, err := peer.Decode(entry)
+		if err != nil {
+			log.Warn().Err(err).Str("Player", "Raft").Str("peer", entry).Msg("invalid peer id in RAFT_PEER_WHITELIST, skipping")
+			continue
+		}
+		whitelist[id] = struct{}{}
+	}
+	return whitelist
+}
+
+// CheckPeerAuth fails when no peer can be accepted, that is when
+// RAFT_PEER_WHITELIST is empty and RAFT_ALLOW_ANY_PEER is not set.
+func CheckPeerAuth() error {
+	if len(LoadPeerWhitelist()) == 0 && !LoadAllowAnyPeer() {
+		return errorSentinel.ErrRaftEmptyPeerWhitelist
+	}
+	return nil
+}
+
+// LoadAllowAnyPeer reads RAFT_ALLOW_ANY_PEER, the explicit opt-out of the
+// whitelist which accepts every authenticated peer.
+func LoadAllowAnyPeer() bool {
+	raw := os.Getenv("RAFT_ALLOW_ANY_PEER")
+	if raw == "" {
+		return false
+	}
+	allow, err := strconv.ParseBool(raw)
+	if err != nil {
+		log.Warn().Err(err).Str("Player", "Raft").Str("value", raw).Msg("invalid RAFT_ALLOW_ANY_PEER, keeping the whitelist")
+		return false
+	}
+	return allow
+}
+
+// verifyMessage authenticates a message received from the pubsub author
+// `from`. The claimed SentFrom has to match the author, the author has to be
+// allowed by the whitelist, the signature has to match the author's key, and
+// the message has to be fresh and not seen before.
+func (r *Raft) verifyMessage(from peer.ID, data []byte) (Message, error) {
+	msg, err := r.unmarshalMessage(data)
+	if err != nil {
+		return Message{}, err
+	}
+
+	if len(msg.Signature) == 0 {
+		return msg, errorSentinel.ErrRaftMissingSignature
+	}
+
+	if msg.SentFrom != from.String() {
+		return msg, errorSentinel.ErrRaftSenderMismatch
+	}
+
+	if from != r.Host.ID() && !r.AllowAnyPeer {
+		if _, ok := r.PeerWhitelist[from]; !ok {
+			return msg, errorSentinel.ErrRaftPeerNotWhitelisted
+		}
+	}
+
+	pubKey := r.Host.Peerstore().PubKey(from)
+	if pubKey == nil {
+		pubKey, err = from.ExtractPublicKey()
+		if err != nil {
+			return msg, errorSentinel.ErrRaftUnknownPeer
+		}
+	}
+
+	payload, err := msg.signingPayload()
+	if err != nil {
+		return msg, err
+	}
+	ok, err := pubKey.Verify(payload, msg.Signature)
+	if err != nil || !ok {
+		return msg, errorSentinel.ErrRaftInvalidSignature
+	}
+
+	now := time.Now()
+	if age := now.Sub(msg.Timestamp); age > MessageFreshness || age < -MessageFreshness {
+		return msg, errorSentinel.ErrRaftStaleMessage
+	}
+	if !r.markSeen(from, msg.Signature, now) {
+		return msg, errorSentinel.ErrRaftDuplicateMessage
+	}
+
+	return msg, nil
+}
+
+// markSeen remembers the signature of a verified message from peer and
+// reports whether it is new. Entries older than MessageFreshness are pruned
+// once the peer's cache is full, then the oldest entry is evicted.
+func (r *Raft) markSeen(from peer.ID, signature []byte, now time.Time) bool {
+	r.seenMu.Lock()
+	defer r.seenMu.Unlock()
+
+	if r.seen == nil {
+		r.seen = make(map[peer.ID]map[string]time.Time)
+	}
+	seen, ok := r.seen[from]
+	if !ok {
+		seen = make(map[string]time.Time)
+		r.seen[from] = seen
+	}
+
+	key := string(signature)
+	if _, ok := seen[key]; ok {
+		return false
+	}
+
+	if len(seen) >= SeenCacheSize {
+		oldestKey, oldest := "", now
+		for k, receivedAt := range seen {
+			if now.Sub(receivedAt) > MessageFreshness {
+				delete(seen, k)
+			} else if receivedAt.Before(oldest) {
+				oldestKey, oldest = k, receivedAt
+			}
+		}
+		if len(seen) >= SeenCacheSize {
+			delete(seen, oldestKey)
+		}
+	}
+	seen[key] = now
+	return true
+}
+
+func (r *Raft) countDroppedMessage(err error) {
+	reason := DropReasonMalformed
+	switch {
+	case errors.Is(err, errorSentinel.ErrRaftMissingSignature):
+		reason = DropReasonMissingSignature
+	case errors.Is(err, errorSentinel.ErrRaftInvalidSignature):
+		reason = DropReasonInvalidSignature
+	case errors.Is(err, errorSentinel.ErrRaftSenderMismatch):
+		reason = DropReasonSenderMismatch
+	case errors.Is(err, errorSentinel.ErrRaftUnknownPeer):
+		reason = DropReasonUnknownPeer
+	case errors.Is(err, errorSentinel.ErrRaftPeerNotWhitelisted):
+		reason = DropReasonNotWhitelisted
+	case errors.Is(err, errorSentinel.ErrRaftStaleMessage):
+		reason = DropReasonStale
+	case errors.Is(err, errorSentinel.ErrRaftDuplicateMessage):
+		reason = DropReasonDuplicate
+	}
+
+	droppedMessagesTotal.WithLabelValues(string(reason)).Inc()
+}
@@ -0,0 +1,196 @@
+//nolint:all
+package raft
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	errorSentinel "bisonai.com/miko/node/pkg/error"
+	libp2psetup "bisonai.com/miko/node/pkg/libp2p/setup"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSignedMessage(t *testing.T, key crypto.PrivKey, sentFrom string) []byte {
+	t.Helper()
+	return newSignedMessageAt(t, key, sentFrom, time.Now())
+}
+
+func newSignedMessageAt(t *testing.T, key crypto.PrivKey, sentFrom string, timestamp time.Time) []byte {
+	t.Helper()
+	msg := Message{
+		Type:      Heartbeat,
+		SentFrom:  sentFrom,
+		Data:      json.RawMessage(`{"leaderID":"test","term":1}`),
+		Timestamp: timestamp,
+	}
+	assert.NoError(t, msg.sign(key))
+	data, err := json.Marshal(msg)
+	assert.NoError(t, err)
+	return data
+}
+
+func TestVerifyMessage(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h, err := libp2psetup.NewHost(ctx)
+	if err != nil {
+		t.Fatalf("error creating host: %v", err)
+	}
+	defer h.Close()
+	r := &Raft{Host: h, AllowAnyPeer: true}
+
+	priv, _, err := crypto.GenerateEd25519Key(nil)
+	assert.NoError(t, err)
+	sender, err := peer.IDFromPrivateKey(priv)
+	assert.NoError(t, err)
+
+	t.Run("valid signature", func(t *testing.T) {
+		msg, err := r.verifyMessage(sender, newSignedMessage(t, priv, sender.String()))
+		assert.NoError(t, err)
+		assert.Equal(t, Heartbeat, msg.Type)
+	})
+
+	t.Run("own message", func(t *testing.T) {
+		data := newSignedMessage(t, h.Peerstore().PrivKey(h.ID()), h.ID().String())
+		_, err := r.verifyMessage(h.ID(), data)
+		assert.NoError(t, err)
+	})
+
+	t.Run("tampered data", func(t *testing.T) {
+		var msg Message
+		assert.NoError(t, json.Unmarshal(newSignedMessage(t, priv, sender.String()), &msg))
+		msg.Data = json.RawMessage(`{"leaderID":"test","term":2}`)
+		data, _ := json.Marshal(msg)
+
+		_, err := r.verifyMessage(sender, data)
+		assert.ErrorIs(t, err, errorSentinel.ErrRaftInvalidSignature)
+	})
+
+	t.Run("spoofed sender", func(t *testing.T) {
+		_, err := r.verifyMessage(sender, newSignedMessage(t, priv, h.ID().String()))
+		assert.ErrorIs(t, err, errorSentinel.ErrRaftSenderMismatch)
+	})
+
+	t.Run("missing signature", func(t *testing.T) {
+		data, _ := json.Marshal(Message{Type: Heartbeat, SentFrom: sender.String(), Timestamp: time.Now()})
+		_, err := r.verifyMessage(sender, data)
+		assert.ErrorIs(t, err, errorSentinel.ErrRaftMissingSignature)
+	})
+
+	t.Run("not whitelisted", func(t *testing.T) {
+		r.AllowAnyPeer = false
+		r.PeerWhitelist = map[peer.ID]struct{}{h.ID(): {}}
+		defer func() { r.AllowAnyPeer, r.PeerWhitelist = true, nil }()
+
+		_, err := r.verifyMessage(sender, newSignedMessage(t, priv, sender.String()))
+		assert.ErrorIs(t, err, errorSentinel.ErrRaftPeerNotWhitelisted)
+	})
+
+	t.Run("empty whitelist fails closed", func(t *testing.T) {
+		r.AllowAnyPeer = false
+		defer func() { r.AllowAnyPeer = true }()
+
+		_, err := r.verifyMessage(sender, newSignedMessage(t, priv, sender.String()))
+		assert.ErrorIs(t, err, errorSentinel.ErrRaftPeerNotWhitelisted)
+
+		data := newSignedMessage(t, h.Peerstore().PrivKey(h.ID()), h.ID().String())
+		_, err = r.verifyMessage(h.ID(), data)
+		assert.NoError(t, err, "own messages are always accepted")
+	})
+
+	t.Run("stale message", func(t *testing.T) {
+		_, err := r.verifyMessage(sender, newSignedMessageAt(t, priv, sender.String(), time.Now().Add(-MessageFreshness-time.Second)))
+		assert.ErrorIs(t, err, errorSentinel.ErrRaftStaleMessage)
+
+		_, err = r.verifyMessage(sender, newSignedMessageAt(t, priv, sender.String(), time.Now().Add(MessageFreshness+time.Second)))
+		assert.ErrorIs(t, err, errorSentinel.ErrRaftStaleMessage, "timestamps from the future are rejected too")
+	})
+
+	t.Run("replayed message", func(t *testing.T) {
+		data := newSignedMessage(t, priv, sender.String())
+		_, err := r.verifyMessage(sender, data)
+		assert.NoError(t, err)
+
+		_, err = r.verifyMessage(sender, data)
+		assert.ErrorIs(t, err, errorSentinel.ErrRaftDuplicateMessage)
+	})
+}
+
+func TestMarkSeen(t *testing.T) {
+	r := &Raft{}
+	now := time.Now()
+
+	assert.True(t, r.markSeen("a", []byte("sig"), now))
+	assert.False(t, r.markSeen("a", []byte("sig"), now))
+	assert.True(t, r.markSeen("b", []byte("sig"), now), "the cache is per peer")
+
+	for i := 0; i < SeenCacheSize; i++ {
+		assert.True(t, r.markSeen("c", []byte{byte(i), byte(i >> 8)}, now.Add(time.Duration(i))))
+	}
+	assert.Equal(t, SeenCacheSize, len(r.seen["c"]))
+	assert.False(t, r.markSeen("c", []byte{1, 0}, now))
+	assert.True(t, r.markSeen("c", []byte("new"), now.Add(time.Minute)))
+	assert.LessOrEqual(t, len(r.seen["c"]), SeenCacheSize, "the cache is bounded")
+}
+
+func TestCheckPeerAuth(t *testing.T) {
+	t.Setenv("RAFT_PEER_WHITELIST", "")
+	t.Setenv("RAFT_ALLOW_ANY_PEER", "")
+	assert.ErrorIs(t, CheckPeerAuth(), errorSentinel.ErrRaftEmptyPeerWhitelist)
+
+	t.Setenv("RAFT_ALLOW_ANY_PEER", "true")
+	assert.NoError(t, CheckPeerAuth())
+
+	priv, _, err := crypto.GenerateEd25519Key(nil)
+	assert.NoError(t, err)
+	id, err := peer.IDFromPrivateKey(priv)
+	assert.NoError(t, err)
+	t.Setenv("RAFT_ALLOW_ANY_PEER", "")
+	t.Setenv("RAFT_PEER_WHITELIST", id.String())
+	assert.NoError(t, CheckPeerAuth())
+}
+
+func TestLoadPeerWhitelist(t *testing.T) {
+	priv, _, err := crypto.GenerateEd25519Key(nil)
+	assert.NoError(t, err)
+	id, err := peer.IDFromPrivateKey(priv)
+	assert.NoError(t, err)
+
+	assert.Empty(t, LoadPeerWhitelist())
+
+	t.Setenv("RAFT_PEER_WHITELIST", id.String()+", invalid-peer-id,")
+	whitelist := LoadPeerWhitelist()
+	assert.Equal(t, 1, len(whitelist))
+	assert.Contains(t, whitelist, id)
+}
+
+func TestLoadAllowAnyPeer(t *testing.T) {
+	assert.False(t, LoadAllowAnyPeer())
+
+	t.Setenv("RAFT_ALLOW_ANY_PEER", "true")
+	assert.True(t, LoadAllowAnyPeer())
+
+	t.Setenv("RAFT_ALLOW_ANY_PEER", "yes please")
+	assert.False(t, LoadAllowAnyPeer(), "invalid values keep the whitelist")
+}
+
+func TestCountDroppedMessage(t *testing.T) {
+	invalidSignature := testutil.ToFloat64(droppedMessagesTotal.WithLabelValues(string(DropReasonInvalidSignature)))
+	notWhitelisted := testutil.ToFloat64(droppedMessagesTotal.WithLabelValues(string(DropReasonNotWhitelisted)))
+	unknownPeer := testutil.ToFloat64(droppedMessagesTotal.WithLabelValues(string(DropReasonUnknownPeer)))
+
+	r := &Raft{}
+	r.countDroppedMessage(errorSentinel.ErrRaftInvalidSignature)
+	r.countDroppedMessage(errorSentinel.ErrRaftInvalidSignature)
+	r.countDroppedMessage(errorSentinel.ErrRaftPeerNotWhitelisted)
+
+	assert.Equal(t, invalidSignature+2, testutil.ToFloat64(droppedMessagesTotal.WithLabelValues(string(DropReasonInvalidSignature))))
+	assert.Equal(t, notWhitelisted+1, testutil.ToFloat64(droppedMessagesTotal.WithLabelValues(string(DropReasonNotWhitelisted))))
+	assert.Equal(t, unknownPeer, testutil.ToFloat64(droppedMessagesTotal.WithLabelValues(string(DropReasonUnknownPeer))))
+}
@@ -36,7 +36,7 @@ func NewRaftNode(
 		Term:          0,
 		Mutex:         sync.Mutex{},
 
-		MessageBuffer:    make(chan *pubsub.Message, messageBuffer),
+		MessageBuffer:    make(chan Message, messageBuffer),
 		Resign:           make(chan interface{}),
 		HeartbeatTimeout: HEARTBEAT_TIMEOUT,
 
@@ -44,24 +44,29 @@ func NewRaftNode(
 		MissedHeartbeats: 0,
 		CooldownPeriod:   DefaultCooldownPeriod,
 		LastElectionTime: time.Time{},
+
+		Store:         NewMemoryStateStore(),
+		LeaseDuration: HEARTBEAT_TIMEOUT * 5,
+		heartbeatAcks: make(map[string]time.Time),
+
+		PeerWhitelist: LoadPeerWhitelist(),
+		AllowAnyPeer:  LoadAllowAnyPeer(),
+	}
+	if len(r.PeerWhitelist) == 0 && !r.AllowAnyPeer {
+		log.Error().Str("Player", "Raft").Msg("RAFT_PEER_WHITELIST is empty, messages from other peers will be dropped")
 	}
 	return r
 }
 
 func (r *Raft) Run(ctx context.Context) {
+	r.restoreState(ctx)
 	go r.subscribe(ctx)
 	r.startElectionTimer()
 	for {
 		select {
-		case rawMsg := <-r.MessageBuffer:
-			go func(*pubsub.Message) {
-				msg, err := r.unmarshalMessage(rawMsg.Data)
-				if err != nil {
-					log.Error().Err(err).Msg("failed to unmarshal message")
-					return
-				}
-
-				err = r.handleMessage(ctx, msg)
+		case msg := <-r.MessageBuffer:
+			go func(msg Message) {
+				err := r.handleMessage(ctx, msg)
 				if err != nil {
 					if errors.Is(err, errorSentinel.ErrAggregatorNonLeaderRaftMessage) {
 						log.Debug().Err(err).Str("Player", "Raft").Msg("failed to handle message")
@@ -69,7 +74,7 @@ func (r *Raft) Run(ctx context.Context) {
 						log.Error().Err(err).Str("Player", "Raft").Msg("failed to handle message")
 					}
 				}
-			}(rawMsg)
+			}(msg)
 		case <-r.ElectionTimer.C:
 			r.startElection(ctx)
 		case <-ctx.Done():
@@ -99,7 +104,13 @@ func (r *Raft) subscribe(ctx context.Context) {
 				log.Error().Err(err).Msg("failed to get message from topic")
 				continue
 			}
-			r.MessageBuffer <- rawMsg
+			msg, err := r.verifyMessage(rawMsg.GetFrom(), rawMsg.Data)
+			if err != nil {
+				r.countDroppedMessage(err)
+				log.Warn().Err(err).Str("Player", "Raft").Str("from", rawMsg.GetFrom().String()).Msg("dropping unauthenticated message")
+				continue
+			}
+			r.MessageBuffer <- msg
 		}
 	}
 }
@@ -109,7 +120,13 @@ func (r *Raft) subscribe(ctx context.Context) {
 func (r *Raft) handleMessage(ctx context.Context, msg Message) error {
 	switch msg.Type {
 	case Heartbeat:
-		return r.handleHeartbeat(msg)
+		return r.handleHeartbeat(ctx, msg)
+	case ReplyHeartbeat:
+		return r.handleReplyHeartbeat(msg)
+	case PreVote:
+		return r.handlePreVote(ctx, msg)
+	case ReplyPreVote:
+		return r.handleReplyPreVote(ctx, msg)
 	case RequestVote:
 		return r.handleRequestVote(ctx, msg)
 	case ReplyVote:
@@ -119,7 +136,7 @@ func (r *Raft) handleMessage(ctx context.Context, msg Message) error {
 	}
 }
 
-func (r *Raft) handleHeartbeat(msg Message) error {
+func (r *Raft) handleHeartbeat(ctx context.Context, msg Message) error {
 	if msg.SentFrom == r.GetHostId() {
 		return nil
 	}
@@ -135,8 +152,20 @@ func (r *Raft) handleHeartbeat(msg Message) error {
 	}
 
 	r.Mutex.Lock()
-	defer r.Mutex.Unlock()
+	accepted := r.acceptHeartbeat(heartbeatMessage)
+	term := r.Term
+	r.Mutex.Unlock()
+	if !accepted {
+		return nil
+	}
 
+	r.flushState()
+	return r.sendReplyHeartbeat(ctx, heartbeatMessage.LeaderID, term)
+}
+
+// acceptHeartbeat follows the leader of the heartbeat unless we are ahead of
+// it. Must be called with the mutex held.
+func (r *Raft) acceptHeartbeat(heartbeatMessage HeartbeatMessage) bool {
 	r.MissedHeartbeats = 0
 
 	currentRole := r.Role
@@ -153,60 +182,148 @@ func (r *Raft) handleHeartbeat(msg Message) error {
 		r.Term = heartbeatMessage.Term
 		r.Role = Follower
 		r.LeaderID = heartbeatMessage.LeaderID
-
-		return nil
+		r.markStateDirty()
 	} else if heartbeatMessage.Term == currentTerm {
 		if currentRole == Leader {
 			if r.GetHostId() < heartbeatMessage.LeaderID {
 				r.ResignLeader()
 				r.LeaderID = heartbeatMessage.LeaderID
 			} else {
-				return nil
+				return false
 			}
 		} else {
 			r.LeaderID = heartbeatMessage.LeaderID
 		}
+	} else {
+		return false
 	}
 
+	r.LastHeartbeat = time.Now()
+	r.PreVoteTerm = 0
+	return true
+}
+
+func (r *Raft) handleReplyHeartbeat(msg Message) error {
+	var replyHeartbeatMessage ReplyHeartbeatMessage
+	err := json.Unmarshal(msg.Data, &replyHeartbeatMessage)
+	if err != nil {
+		return err
+	}
+
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	if r.Role != Leader || replyHeartbeatMessage.LeaderID != r.GetHostId() || replyHeartbeatMessage.Term != r.Term {
+		return nil
+	}
+	r.heartbeatAcks[msg.SentFrom] = time.Now()
 	return nil
 }
 
-func (r *Raft) handleRequestVote(ctx context.Context, msg Message) error {
+// handlePreVote grants a pre-vote only if the proposed term is ahead of ours
+// and we haven't heard from a live leader recently, so a node rejoining after
+// a partition can't force the cluster into a new term.
+func (r *Raft) handlePreVote(ctx context.Context, msg Message) error {
+	var preVoteMessage PreVoteMessage
+	if err := json.Unmarshal(msg.Data, &preVoteMessage); err != nil {
+		log.Error().Err(err).Msg("failed to unmarshal pre-vote message")
+		return err
+	}
+
 	r.Mutex.Lock()
 	defer r.Mutex.Unlock()
-	if r.Role == Leader {
+
+	voteGranted := r.Role != Leader && preVoteMessage.Term > r.Term && !r.hasRecentLeader()
+	return r.sendReplyPreVote(ctx, msg.SentFrom, preVoteMessage.Term, voteGranted)
+}
+
+func (r *Raft) handleReplyPreVote(ctx context.Context, msg Message) error {
+	var replyPreVoteMessage ReplyPreVoteMessage
+	if err := json.Unmarshal(msg.Data, &replyPreVoteMessage); err != nil {
+		return err
+	}
+
+	r.Mutex.Lock()
+	started, term := r.countPreVote(replyPreVoteMessage)
+	r.Mutex.Unlock()
+	if !started {
 		return nil
 	}
 
+	r.flushState()
+	err := r.sendRequestVote(ctx, term)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to send request vote")
+	}
+	return nil
+}
+
+// countPreVote starts the campaign once a strict majority granted the
+// pre-vote, and returns its term. Must be called with the mutex held.
+func (r *Raft) countPreVote(replyPreVoteMessage ReplyPreVoteMessage) (bool, int) {
+	if r.Role == Leader || r.PreVoteTerm == 0 {
+		return false, 0
+	}
+
+	if !replyPreVoteMessage.VoteGranted || replyPreVoteMessage.LeaderID != r.GetHostId() || replyPreVoteMessage.Term != r.PreVoteTerm {
+		return false, 0
+	}
+
+	r.PreVotesReceived++
+	if r.PreVotesReceived < r.majority() {
+		return false, 0
+	}
+	r.PreVoteTerm = 0
+	r.campaign()
+	return true, r.Term
+}
+
+func (r *Raft) handleRequestVote(ctx context.Context, msg Message) error {
 	var requestVoteMessage RequestVoteMessage
 	if err := json.Unmarshal(msg.Data, &requestVoteMessage); err != nil {
 		log.Error().Err(err).Msg("failed to unmarshal request vote message")
 		return err
 	}
 
-	if requestVoteMessage.Term < r.Term {
-		return r.sendReplyVote(ctx, msg.SentFrom, false)
+	r.Mutex.Lock()
+	if r.Role == Leader {
+		r.Mutex.Unlock()
+		return nil
 	}
+	voteGranted := r.grantVote(msg.SentFrom, requestVoteMessage.Term)
+	r.Mutex.Unlock()
+
+	r.flushState()
+	return r.sendReplyVote(ctx, msg.SentFrom, voteGranted)
+}
 
-	if requestVoteMessage.Term > r.Term {
-		r.Term = requestVoteMessage.Term
+// grantVote decides on the vote requested by candidate for term. Must be
+// called with the mutex held.
+func (r *Raft) grantVote(candidate string, term int) bool {
+	if term < r.Term {
+		return false
+	}
+
+	if term > r.Term {
+		r.Term = term
 		r.Role = Follower
 		r.VotedFor = ""
+		r.markStateDirty()
 	}
 
-	if r.Role == Candidate && requestVoteMessage.Term == r.Term && msg.SentFrom != r.GetHostId() {
+	if r.Role == Candidate && term == r.Term && candidate != r.GetHostId() {
 		r.Role = Follower
-		return r.sendReplyVote(ctx, msg.SentFrom, false)
+		return false
 	}
 
-	if r.VotedFor != "" && r.VotedFor != msg.SentFrom {
-		return r.sendReplyVote(ctx, msg.SentFrom, false)
+	if r.VotedFor != "" && r.VotedFor != candidate {
+		return false
 	}
 
-	r.VotedFor = msg.SentFrom
+	r.VotedFor = candidate
+	r.markStateDirty()
 	r.startElectionTimer()
 	log.Debug().Bool("vote granted", true).Msg("voted")
-	return r.sendReplyVote(ctx, msg.SentFrom, true)
+	return true
 }
 
 func (r *Raft) handleReplyVote(ctx context.Context, msg Message) error {
@@ -227,10 +344,12 @@ func (r *Raft) handleReplyVote(ctx context.Context, msg Message) error {
 	}
 
 	if replyVoteMessage.VoteGranted && replyVoteMessage.LeaderID == r.GetHostId() && r.Role == Candidate {
+		// a granted vote also acknowledges us as leader for the lease
+		r.heartbeatAcks[msg.SentFrom] = time.Now()
 		r.VotesReceived++
 		log.Debug().Int("vote received", r.VotesReceived).Msg("vote received")
 		log.Debug().Int("subscribers count", r.SubscribersCount()).Msg("subscribers count")
-		if r.VotesReceived >= (r.SubscribersCount()+1)/2 {
+		if r.VotesReceived >= r.majority() {
 			r.becomeLeader(ctx)
 		}
 	}
@@ -241,6 +360,13 @@ func (r *Raft) handleReplyVote(ctx context.Context, msg Message) error {
 
 func (r *Raft) PublishMessage(ctx context.Context, msg Message) error {
 	msg.Timestamp = time.Now()
+	key := r.Host.Peerstore().PrivKey(r.Host.ID())
+	if key == nil {
+		return errorSentinel.ErrRaftSigningKeyNotFound
+	}
+	if err := msg.sign(key); err != nil {
+		return err
+	}
 	data, err := json.Marshal(msg)
 	if err != nil {
 		return err
@@ -295,9 +421,60 @@ func (r *Raft) sendReplyVote(ctx context.Context, to string, voteGranted bool) e
 	return nil
 }
 
-func (r *Raft) sendRequestVote(ctx context.Context) error {
+func (r *Raft) sendReplyHeartbeat(ctx context.Context, leaderID string, term int) error {
+	replyHeartbeatMessage := ReplyHeartbeatMessage{
+		Term:     term,
+		LeaderID: leaderID,
+	}
+	marshalledReplyHeartbeatMsg, err := json.Marshal(replyHeartbeatMessage)
+	if err != nil {
+		return err
+	}
+	message := Message{
+		Type:     ReplyHeartbeat,
+		SentFrom: r.GetHostId(),
+		Data:     json.RawMessage(marshalledReplyHeartbeatMsg),
+	}
+	return r.PublishMessage(ctx, message)
+}
+
+func (r *Raft) sendPreVote(ctx context.Context) error {
+	preVoteMessage := PreVoteMessage{
+		Term: r.PreVoteTerm,
+	}
+	marshalledPreVoteMsg, err := json.Marshal(preVoteMessage)
+	if err != nil {
+		return err
+	}
+	message := Message{
+		Type:     PreVote,
+		SentFrom: r.GetHostId(),
+		Data:     json.RawMessage(marshalledPreVoteMsg),
+	}
+	return r.PublishMessage(ctx, message)
+}
+
+func (r *Raft) sendReplyPreVote(ctx context.Context, to string, term int, voteGranted bool) error {
+	replyPreVoteMessage := ReplyPreVoteMessage{
+		Term:        term,
+		VoteGranted: voteGranted,
+		LeaderID:    to,
+	}
+	marshalledReplyPreVoteMsg, err := json.Marshal(replyPreVoteMessage)
+	if err != nil {
+		return err
+	}
+	message := Message{
+		Type:     ReplyPreVote,
+		SentFrom: r.GetHostId(),
+		Data:     json.RawMessage(marshalledReplyPreVoteMsg),
+	}
+	return r.PublishMessage(ctx, message)
+}
+
+func (r *Raft) sendRequestVote(ctx context.Context, term int) error {
 	requestVoteMessage := RequestVoteMessage{
-		Term: r.Term,
+		Term: term,
 	}
 	marshalledRequestVoteMsg, err := json.Marshal(requestVoteMessage)
 	if err != nil {
@@ -332,6 +509,7 @@ func (r *Raft) setLeaderState() {
 	r.Resign = make(chan interface{})
 	r.ElectionTimer.Stop()
 	r.Term++
+	r.markStateDirty()
 	r.Role = Leader
 	r.LeaderID = r.GetHostId()
 	r.HeartbeatTicker = time.NewTicker(r.HeartbeatTimeout)
@@ -340,6 +518,7 @@ func (r *Raft) setLeaderState() {
 
 func (r *Raft) becomeLeader(ctx context.Context) {
 	r.setLeaderState()
+	resign := r.Resign
 
 	go func() {
 		defer func() {
@@ -348,18 +527,21 @@ func (r *Raft) becomeLeader(ctx context.Context) {
 			}
 		}()
 
+		r.flushState()
 		err := r.sendHeartbeat(ctx)
 		if err != nil {
 			log.Error().Err(err).Msg("failed to send heartbeat")
 		}
-		err = r.LeaderJob(ctx)
-		if err != nil {
-			log.Error().Err(err).Msg("failed to execute leader job")
+		if r.hasLeaderLease() {
+			err = r.LeaderJob(ctx)
+			if err != nil {
+				log.Error().Err(err).Msg("failed to execute leader job")
+			}
 		}
 
 		for {
 			select {
-			case <-r.Resign:
+			case <-resign:
 				r.Mutex.Lock()
 				r.HeartbeatTicker.Stop()
 				r.LeaderJobTicker.Stop()
@@ -373,6 +555,10 @@ func (r *Raft) becomeLeader(ctx context.Context) {
 				}
 
 			case <-r.LeaderJobTicker.C:
+				if !r.hasLeaderLease() {
+					log.Warn().Str("Player", "Raft").Msg("leader lease expired, skipping leader job")
+					continue
+				}
 				go func() {
 					defer func() {
 						if r := recover(); r != nil {
@@ -398,11 +584,21 @@ func (r *Raft) becomeLeader(ctx context.Context) {
 }
 
 func (r *Raft) getRandomElectionTimeout() time.Duration {
-	baseTimeout := r.HeartbeatTimeout * 5
+	baseTimeout := r.minElectionTimeout()
 	jitter := time.Duration(rand.Int63n(int64(r.HeartbeatTimeout * 5)))
 	return baseTimeout + jitter
 }
 
+func (r *Raft) minElectionTimeout() time.Duration {
+	return r.HeartbeatTimeout * 5
+}
+
+// hasRecentLeader reports whether a heartbeat from another leader arrived
+// within the minimum election timeout. Must be called with the mutex held.
+func (r *Raft) hasRecentLeader() bool {
+	return r.LeaderID != "" && r.LeaderID != r.GetHostId() && time.Since(r.LastHeartbeat) < r.minElectionTimeout()
+}
+
 func (r *Raft) startElectionTimer() {
 	if r.ElectionTimer != nil {
 		if !r.ElectionTimer.Stop() {
@@ -436,20 +632,85 @@ func (r *Raft) startElection(ctx context.Context) {
 		return
 	}
 
+	log.Debug().Msg("start pre-vote")
+	r.PreVoteTerm = r.Term + 1
+	r.PreVotesReceived = 0
+	r.MissedHeartbeats = 0
+
+	r.LastElectionTime = time.Now()
+
+	r.startElectionTimer()
+
+	err := r.sendPreVote(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to send pre-vote")
+	}
+}
+
+// campaign starts the actual election once a pre-vote succeeded, the caller
+// requests the votes once the state is flushed. Must be called with the
+// mutex held.
+func (r *Raft) campaign() {
 	log.Debug().Msg("start election")
 	r.Term++
 	r.VotesReceived = 0
 	r.Role = Candidate
 	r.VotedFor = r.GetHostId()
-	r.MissedHeartbeats = 0
-
-	r.LastElectionTime = time.Now()
+	r.heartbeatAcks = make(map[string]time.Time)
+	r.markStateDirty()
 
 	r.startElectionTimer()
+}
+
+// majority is the strict majority of the cluster, our own vote included.
+func (r *Raft) majority() int {
+	return (r.SubscribersCount()+1)/2 + 1
+}
+
+// markStateDirty snapshots term and vote for flushState. Must be called with
+// the mutex held so the snapshot matches memory.
+func (r *Raft) markStateDirty() {
+	r.pendingState = &PersistentState{Term: r.Term, VotedFor: r.VotedFor}
+}
+
+// flushState saves the last snapshot of markStateDirty before it is acted
+// upon. Must be called without the mutex, saves are serialized so the latest
+// snapshot is always written last.
+func (r *Raft) flushState() {
+	r.persistMu.Lock()
+	defer r.persistMu.Unlock()
 
-	err := r.sendRequestVote(ctx)
+	r.Mutex.Lock()
+	state := r.pendingState
+	r.pendingState = nil
+	r.Mutex.Unlock()
+
+	if state == nil || r.Store == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), PersistStateTimeout)
+	defer cancel()
+	err := r.Store.Save(ctx, *state)
 	if err != nil {
-		log.Error().Err(err).Msg("failed to send request vote")
+		log.Error().Err(err).Str("Player", "Raft").Msg("failed to persist raft state")
+	}
+}
+
+func (r *Raft) restoreState(ctx context.Context) {
+	if r.Store == nil {
+		return
+	}
+	state, err := r.Store.Load(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("Player", "Raft").Msg("failed to load raft state, starting from term 0")
+		return
+	}
+
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	if state.Term > r.Term {
+		r.Term = state.Term
+		r.VotedFor = state.VotedFor
 	}
 }
 
@@ -3,6 +3,7 @@ package raft
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
@@ -102,6 +103,7 @@ func setup(ctx context.Context, cancel context.CancelFunc) (func() error, *TestI
 	raftNodes := []*Raft{}
 	for i := 0; i < 3; i++ {
 		node := NewRaftNode(hosts[i], pss[i], topics[i], 100, time.Second)
+		node.AllowAnyPeer = true
 		node.LeaderJob = func(context.Context) error {
 			log.Debug().Int("subscribers", node.SubscribersCount()).Int("Term", node.GetCurrentTerm()).Msg("Leader job")
 			// node.IncreaseTerm()
@@ -375,6 +377,49 @@ func joinNewNode(ctx context.Context, testItems *TestItems, t *testing.T) *Raft
 	}
 
 	newNode := NewRaftNode(newHost, newPs, newTopic, 100, time.Second)
+	newNode.AllowAnyPeer = true
 	go newNode.Run(ctx)
 	return newNode
 }
+
+func TestRaft_PartitionedFollowerPreVote(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cleanup, testItems := setupRaftCluster(ctx, cancel, t)
+	defer func() {
+		if cleanupErr := cleanup(); cleanupErr != nil {
+			t.Logf("Cleanup failed: %v", cleanupErr)
+		}
+	}()
+
+	// the follower still sees its peers on the topic but none of their
+	// pre-votes get through, only its own
+	for _, topic := range testItems.Topics {
+		sub, err := topic.Subscribe()
+		assert.NoError(t, err)
+		defer sub.Cancel()
+	}
+	node := testItems.RaftNodes[0]
+	WaitForCondition(ctx, t, func() bool {
+		return node.SubscribersCount() == 2
+	})
+
+	node.Mutex.Lock()
+	node.PreVoteTerm = 1
+	node.Mutex.Unlock()
+
+	reply := func(from string) Message {
+		data, err := json.Marshal(ReplyPreVoteMessage{Term: 1, VoteGranted: true, LeaderID: node.GetHostId()})
+		assert.NoError(t, err)
+		return Message{Type: ReplyPreVote, SentFrom: from, Data: data}
+	}
+
+	assert.NoError(t, node.handleReplyPreVote(ctx, reply(node.GetHostId())))
+	assert.Equal(t, Follower, node.GetRole(), "own pre-vote isn't a majority")
+	assert.Equal(t, 0, node.GetCurrentTerm())
+
+	assert.NoError(t, node.handleReplyPreVote(ctx, reply(testItems.Hosts[1].ID().String())))
+	assert.Equal(t, Candidate, node.GetRole(), "campaigns once a majority granted the pre-vote")
+	assert.Equal(t, 1, node.GetCurrentTerm())
+}
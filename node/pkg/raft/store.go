@@ -0,0 +1,73 @@
+package raft
+
+import (
+	"context"
+	"sync"
+
+	"bisonai.com/miko/node/pkg/db"
+)
+
+const (
+	SelectStateQuery = `SELECT term, voted_for FROM raft_states WHERE key = @key`
+	UpsertStateQuery = `INSERT INTO raft_states (key, term, voted_for) VALUES (@key, @term, @voted_for)
+		ON CONFLICT (key) DO UPDATE SET term = EXCLUDED.term, voted_for = EXCLUDED.voted_for, updated_at = NOW()`
+)
+
+// PersistentState is the part of the raft state that has to survive a
+// restart, so a restarted node neither votes twice in a term nor starts over
+// from term 0.
+type PersistentState struct {
+	Term     int    `db:"term"`
+	VotedFor string `db:"voted_for"`
+}
+
+type StateStore interface {
+	Load(ctx context.Context) (PersistentState, error)
+	Save(ctx context.Context, state PersistentState) error
+}
+
+// MemoryStateStore keeps the state in memory only. It is the default store
+// and matches the behavior of a node without persistence.
+type MemoryStateStore struct {
+	mu    sync.Mutex
+	state PersistentState
+}
+
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{}
+}
+
+func (s *MemoryStateStore) Load(ctx context.Context) (PersistentState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state, nil
+}
+
+func (s *MemoryStateStore) Save(ctx context.Context, state PersistentState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = state
+	return nil
+}
+
+// PgsqlStateStore keeps the state in the raft_states table, keyed by the
+// raft topic since a node runs one raft instance per topic.
+type PgsqlStateStore struct {
+	Key string
+}
+
+func NewPgsqlStateStore(key string) *PgsqlStateStore {
+	return &PgsqlStateStore{Key: key}
+}
+
+func (s *PgsqlStateStore) Load(ctx context.Context) (PersistentState, error) {
+	return db.QueryRow[PersistentState](ctx, SelectStateQuery, map[string]any{"key": s.Key})
+}
+
+func (s *PgsqlStateStore) Save(ctx context.Context, state PersistentState) error {
+	return db.QueryWithoutResult(ctx, UpsertStateQuery, map[string]any{
+		"key":       s.Key,
+		"term":      state.Term,
+		"voted_for": state.VotedFor,
+	})
+}
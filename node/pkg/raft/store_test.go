@@ -0,0 +1,179 @@
+//nolint:all
+package raft
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	libp2psetup "bisonai.com/miko/node/pkg/libp2p/setup"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStateStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStateStore()
+
+	state, err := store.Load(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, PersistentState{}, state)
+
+	assert.NoError(t, store.Save(ctx, PersistentState{Term: 3, VotedFor: "peer"}))
+	state, err = store.Load(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, PersistentState{Term: 3, VotedFor: "peer"}, state)
+}
+
+func TestRaft_RestoreState(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStateStore()
+	assert.NoError(t, store.Save(ctx, PersistentState{Term: 5, VotedFor: "peer"}))
+
+	r := &Raft{Store: store}
+	r.restoreState(ctx)
+	assert.Equal(t, 5, r.GetCurrentTerm())
+	assert.Equal(t, "peer", r.VotedFor)
+
+	r.Term = 7
+	r.markStateDirty()
+	r.flushState()
+	state, _ := store.Load(ctx)
+	assert.Equal(t, 7, state.Term)
+}
+
+// blockingStore holds every save until released
+type blockingStore struct {
+	MemoryStateStore
+	saving  chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingStore) Save(ctx context.Context, state PersistentState) error {
+	s.saving <- struct{}{}
+	<-s.release
+	return s.MemoryStateStore.Save(ctx, state)
+}
+
+func TestRaft_PersistsOutsideMutex(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h, err := libp2psetup.NewHost(ctx)
+	if err != nil {
+		t.Fatalf("error creating host: %v", err)
+	}
+	defer h.Close()
+	ps, err := libp2psetup.MakePubsub(ctx, h)
+	if err != nil {
+		t.Fatalf("error making pubsub: %v", err)
+	}
+	topic, err := ps.Join(topicString)
+	if err != nil {
+		t.Fatalf("error joining topic: %v", err)
+	}
+	defer topic.Close()
+
+	store := &blockingStore{saving: make(chan struct{}), release: make(chan struct{})}
+	r := NewRaftNode(h, ps, topic, 1, time.Second)
+	r.Store = store
+
+	msg := Message{Type: RequestVote, SentFrom: "candidate", Data: []byte(`{"term":3}`)}
+	done := make(chan error)
+	go func() { done <- r.handleRequestVote(ctx, msg) }()
+
+	<-store.saving
+	assert.Equal(t, 3, r.GetCurrentTerm(), "mutex is free while the state is saved")
+	select {
+	case <-done:
+		t.Fatal("vote was replied before the state was saved")
+	default:
+	}
+
+	close(store.release)
+	assert.NoError(t, <-done)
+	state, _ := store.Load(ctx)
+	assert.Equal(t, PersistentState{Term: 3, VotedFor: "candidate"}, state)
+}
+
+func TestRaft_HasRecentLeader(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h, err := libp2psetup.NewHost(ctx)
+	if err != nil {
+		t.Fatalf("error creating host: %v", err)
+	}
+	defer h.Close()
+
+	r := NewRaftNode(h, nil, nil, 1, time.Second)
+	assert.False(t, r.hasRecentLeader(), "no leader known")
+
+	r.LeaderID = "leader"
+	r.LastHeartbeat = time.Now()
+	assert.True(t, r.hasRecentLeader())
+
+	r.LastHeartbeat = time.Now().Add(-r.minElectionTimeout())
+	assert.False(t, r.hasRecentLeader(), "leader heartbeat is stale")
+}
+
+func TestRaft_HasLeaderLease(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cleanup, testItems := setupRaftCluster(ctx, cancel, t)
+	defer cleanup()
+
+	// subscribe without running raft so no election takes place
+	for _, topic := range testItems.Topics {
+		sub, err := topic.Subscribe()
+		assert.NoError(t, err)
+		defer sub.Cancel()
+	}
+	WaitForCondition(ctx, t, func() bool {
+		return testItems.RaftNodes[0].SubscribersCount() == 2
+	})
+
+	r := testItems.RaftNodes[0]
+	assert.False(t, r.hasLeaderLease(), "no acknowledgement from other peers")
+
+	r.heartbeatAcks[testItems.Hosts[1].ID().String()] = time.Now()
+	assert.True(t, r.hasLeaderLease(), "majority of three acknowledged")
+
+	r.heartbeatAcks[testItems.Hosts[1].ID().String()] = time.Now().Add(-r.LeaseDuration)
+	assert.False(t, r.hasLeaderLease(), "acknowledgement expired")
+}
+
+func TestRaft_HasLeaderLeaseEvenCluster(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cleanup, testItems := setupRaftCluster(ctx, cancel, t)
+	defer cleanup()
+
+	// a fourth peer joins the topic, without running raft
+	newHost, err := libp2psetup.NewHost(ctx)
+	assert.NoError(t, err)
+	defer newHost.Close()
+	newPs, err := libp2psetup.MakePubsub(ctx, newHost)
+	assert.NoError(t, err)
+	for _, host := range testItems.Hosts {
+		assert.NoError(t, newHost.Connect(ctx, peer.AddrInfo{ID: host.ID(), Addrs: host.Addrs()}))
+	}
+	newTopic, err := newPs.Join(topicString)
+	assert.NoError(t, err)
+	defer newTopic.Close()
+
+	for _, topic := range append(testItems.Topics, newTopic) {
+		sub, err := topic.Subscribe()
+		assert.NoError(t, err)
+		defer sub.Cancel()
+	}
+	WaitForCondition(ctx, t, func() bool {
+		return testItems.RaftNodes[0].SubscribersCount() == 3
+	})
+
+	r := testItems.RaftNodes[0]
+	r.heartbeatAcks[testItems.Hosts[1].ID().String()] = time.Now()
+	assert.False(t, r.hasLeaderLease(), "two of four is not a majority")
+
+	r.heartbeatAcks[newHost.ID().String()] = time.Now()
+	assert.True(t, r.hasLeaderLease(), "majority of four acknowledged")
+}
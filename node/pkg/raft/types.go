@@ -8,6 +8,7 @@ import (
 
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
 )
 
 type MessageType string
@@ -19,6 +20,9 @@ const (
 	ReplyVote          MessageType = "replyVote"
 	AppendEntries      MessageType = "appendEntries"
 	ReplyAppendEntries MessageType = "replyAppendEntries"
+	PreVote            MessageType = "preVote"
+	ReplyPreVote       MessageType = "replyPreVote"
+	ReplyHeartbeat     MessageType = "replyHeartbeat"
 
 	Leader    RoleType = "leader"
 	Candidate RoleType = "candidate"
@@ -26,6 +30,7 @@ const (
 
 	MaxMissedHeartbeats   = 2
 	DefaultCooldownPeriod = 3 * time.Second
+	PersistStateTimeout   = 1 * time.Second
 )
 
 type Message struct {
@@ -33,6 +38,7 @@ type Message struct {
 	SentFrom  string          `json:"sentFrom"`
 	Data      json.RawMessage `json:"data"`
 	Timestamp time.Time       `json:"timestamp"`
+	Signature []byte          `json:"signature,omitempty"`
 }
 
 type RequestVoteMessage struct {
@@ -49,6 +55,23 @@ type ReplyRequestVoteMessage struct {
 	LeaderID    string `json:"leaderID"`
 }
 
+// PreVoteMessage asks whether peers would vote for the sender in Term
+// without anyone changing their term yet.
+type PreVoteMessage struct {
+	Term int `json:"term"`
+}
+
+type ReplyPreVoteMessage struct {
+	Term        int    `json:"term"`
+	VoteGranted bool   `json:"voteGranted"`
+	LeaderID    string `json:"leaderID"`
+}
+
+type ReplyHeartbeatMessage struct {
+	Term     int    `json:"term"`
+	LeaderID string `json:"leaderID"`
+}
+
 type Raft struct {
 	Host  host.Host
 	Ps    *pubsub.PubSub
@@ -64,7 +87,7 @@ type Raft struct {
 	HeartbeatTicker  *time.Ticker
 	ElectionTimer    *time.Timer
 	Resign           chan interface{}
-	MessageBuffer    chan *pubsub.Message
+	MessageBuffer    chan Message
 	HeartbeatTimeout time.Duration
 
 	LeaderJobTimeout    time.Duration
@@ -76,4 +99,19 @@ type Raft struct {
 
 	CooldownPeriod   time.Duration
 	LastElectionTime time.Time
+
+	Store            StateStore
+	pendingState     *PersistentState
+	persistMu        sync.Mutex
+	PreVoteTerm      int
+	PreVotesReceived int
+	LastHeartbeat    time.Time
+	LeaseDuration    time.Duration
+	heartbeatAcks    map[string]time.Time
+
+	PeerWhitelist map[peer.ID]struct{}
+	AllowAnyPeer  bool
+
+	seenMu sync.Mutex
+	seen   map[peer.ID]map[string]time.Time
 }
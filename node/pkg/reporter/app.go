@@ -6,7 +6,7 @@ import (
 	"os"
 	"sync"
 
-	"bisonai.com/miko/node/pkg/chain/helper"
+	"bisonai.com/miko/node/pkg/db"
 	errorSentinel "bisonai.com/miko/node/pkg/error"
 	"bisonai.com/miko/node/pkg/secrets"
 	"bisonai.com/miko/node/pkg/utils/request"
@@ -19,6 +19,7 @@ func New() *App {
 		Reporters:              []*Reporter{},
 		LatestDataMap:          new(sync.Map),
 		LatestSubmittedDataMap: new(sync.Map),
+		LatestSubmittedTimeMap: new(sync.Map),
 	}
 }
 
@@ -50,26 +51,19 @@ func (a *App) setReporters(ctx context.Context) error {
 		return errorSentinel.ErrReporterDalRestEndpointNotFound
 	}
 
-	contractAddress := os.Getenv("SUBMISSION_PROXY_CONTRACT")
-	if contractAddress == "" {
-		return errorSentinel.ErrReporterSubmissionProxyContractNotFound
-	}
-
-	chainHelper, err := helper.NewChainHelper(ctx)
+	configs, err := fetchConfigs()
 	if err != nil {
-		log.Error().Str("Player", "Reporter").Err(err).Msg("failed to create chain helper")
+		log.Error().Str("Player", "Reporter").Err(err).Msg("failed to get reporter configs")
 		return err
 	}
-
-	cachedWhitelist, err := ReadOnchainWhitelist(ctx, chainHelper, contractAddress, GET_ONCHAIN_WHITELIST)
+	configs, err = loadConfigParams(ctx, configs)
 	if err != nil {
-		log.Error().Str("Player", "Reporter").Err(err).Msg("failed to get whitelist, starting with empty whitelist")
-		cachedWhitelist = []common.Address{}
+		log.Error().Str("Player", "Reporter").Err(err).Msg("failed to load reporter config params")
+		return err
 	}
 
-	configs, err := fetchConfigs()
+	targets, err := loadChainTargets(ctx)
 	if err != nil {
-		log.Error().Str("Player", "Reporter").Err(err).Msg("failed to get reporter configs")
 		return err
 	}
 
@@ -79,55 +73,106 @@ func (a *App) setReporters(ctx context.Context) error {
 	}
 	a.WsHelper = dalWsHelper
 
-	groupedConfigs := groupConfigsBySubmitIntervals(configs)
+	for _, target := range targets {
+		latestSubmittedDataMap, latestSubmittedTimeMap := new(sync.Map), new(sync.Map)
+		if target.Name == DefaultChainTargetName {
+			latestSubmittedDataMap, latestSubmittedTimeMap = a.LatestSubmittedDataMap, a.LatestSubmittedTimeMap
+		}
+
+		reporters, errChainReporters := a.newChainReporters(ctx, target, configs, dalRestEndpoint, latestSubmittedDataMap, latestSubmittedTimeMap)
+		if errChainReporters != nil {
+			// a misconfigured or unreachable chain shouldn't stop the other chains
+			log.Error().Str("Player", "Reporter").Str("chain", target.Name).Err(errChainReporters).Msg("failed to set reporters for chain")
+			continue
+		}
+		a.Reporters = append(a.Reporters, reporters...)
+	}
+	if len(a.Reporters) == 0 {
+		log.Error().Str("Player", "Reporter").Msg("no reporters set")
+		return errorSentinel.ErrReporterNotFound
+	}
+
+	log.Info().Str("Player", "Reporter").Msgf("%d reporters set for %d chains", len(a.Reporters), len(targets))
+	return nil
+}
+
+// newChainReporters sets up the interval reporters and the deviation reporter
+// for a single chain target, sharing the DAL data with the other targets.
+func (a *App) newChainReporters(ctx context.Context, target ChainTarget, configs []Config, dalRestEndpoint string, latestSubmittedDataMap *sync.Map, latestSubmittedTimeMap *sync.Map) ([]*Reporter, error) {
+	chainHelper, err := newChainHelper(ctx, target)
+	if err != nil {
+		log.Error().Str("Player", "Reporter").Str("chain", target.Name).Err(err).Msg("failed to create chain helper")
+		return nil, err
+	}
+
+	cachedWhitelist, err := ReadOnchainWhitelist(ctx, chainHelper, target.ContractAddress, GET_ONCHAIN_WHITELIST)
+	if err != nil {
+		log.Error().Str("Player", "Reporter").Str("chain", target.Name).Err(err).Msg("failed to get whitelist, starting with empty whitelist")
+		cachedWhitelist = []common.Address{}
+	}
+
+	var deviationThreshold float64
+	if target.DeviationThreshold != nil {
+		deviationThreshold = *target.DeviationThreshold
+	}
+
+	reporters := []*Reporter{}
+	groupedConfigs := groupConfigsForChain(configs, target)
 	for groupInterval, configs := range groupedConfigs {
 		reporter, errNewReporter := NewReporter(
 			ctx,
+			WithChain(target.Name),
 			WithConfigs(configs),
 			WithInterval(groupInterval),
-			WithContractAddress(contractAddress),
+			WithContractAddress(target.ContractAddress),
 			WithCachedWhitelist(cachedWhitelist),
 			WithKaiaHelper(chainHelper),
 			WithLatestDataMap(a.LatestDataMap),
-			WithLatestSubmittedDataMap(a.LatestSubmittedDataMap),
+			WithLatestSubmittedDataMap(latestSubmittedDataMap),
+			WithLatestSubmittedTimeMap(latestSubmittedTimeMap),
 			WithDalRestEndpoint(dalRestEndpoint),
+			WithDeviationThreshold(deviationThreshold),
 		)
 		if errNewReporter != nil {
-			log.Error().Str("Player", "Reporter").Err(errNewReporter).Msg("failed to set reporter")
-			return errNewReporter
+			log.Error().Str("Player", "Reporter").Str("chain", target.Name).Err(errNewReporter).Msg("failed to set reporter")
+			chainHelper.Close()
+			return nil, errNewReporter
 		}
-		a.Reporters = append(a.Reporters, reporter)
-	}
-	if len(a.Reporters) == 0 {
-		log.Error().Str("Player", "Reporter").Msg("no reporters set")
-		return errorSentinel.ErrReporterNotFound
+		reporters = append(reporters, reporter)
 	}
 
 	deviationReporter, errNewDeviationReporter := NewReporter(
 		ctx,
+		WithChain(target.Name),
 		WithConfigs(configs),
 		WithInterval(DEVIATION_INTERVAL),
-		WithContractAddress(contractAddress),
+		WithContractAddress(target.ContractAddress),
 		WithCachedWhitelist(cachedWhitelist),
 		WithJobType(DeviationJob),
 		WithKaiaHelper(chainHelper),
 		WithLatestDataMap(a.LatestDataMap),
-		WithLatestSubmittedDataMap(a.LatestSubmittedDataMap),
+		WithLatestSubmittedDataMap(latestSubmittedDataMap),
+		WithLatestSubmittedTimeMap(latestSubmittedTimeMap),
 		WithDalRestEndpoint(dalRestEndpoint),
+		WithDeviationThreshold(deviationThreshold),
 	)
 	if errNewDeviationReporter != nil {
-		log.Error().Str("Player", "Reporter").Err(errNewDeviationReporter).Msg("failed to set deviation reporter")
-		return errNewDeviationReporter
+		log.Error().Str("Player", "Reporter").Str("chain", target.Name).Err(errNewDeviationReporter).Msg("failed to set deviation reporter")
+		chainHelper.Close()
+		return nil, errNewDeviationReporter
 	}
-	a.Reporters = append(a.Reporters, deviationReporter)
 
-	log.Info().Str("Player", "Reporter").Msgf("%d reporters set", len(a.Reporters))
-	return nil
+	a.ChainHelpers = append(a.ChainHelpers, chainHelper)
+	return append(reporters, deviationReporter), nil
 }
 
 func (a *App) startReporters(ctx context.Context) {
 	go a.WsHelper.Run(ctx, a.HandleWsMessage)
 
+	for _, chainHelper := range a.ChainHelpers {
+		go chainHelper.TrackTransactions(ctx)
+	}
+
 	for _, reporter := range a.Reporters {
 		go reporter.Run(ctx)
 	}
@@ -147,6 +192,40 @@ func fetchConfigs() ([]Config, error) {
 	return configs, nil
 }
 
+// loadConfigParams sets the per-feed deviation threshold and heartbeat of the
+// configs table on the published configs.
+func loadConfigParams(ctx context.Context, configs []Config) ([]Config, error) {
+	rows, err := db.QueryRows[Config](ctx, GET_REPORTER_CONFIGS, nil)
+	if err != nil {
+		return nil, err
+	}
+	return mergeConfigParams(configs, rows), nil
+}
+
+func mergeConfigParams(configs []Config, rows []Config) []Config {
+	params := make(map[string]Config, len(rows))
+	for _, row := range rows {
+		params[row.Name] = row
+	}
+
+	for i, config := range configs {
+		if row, ok := params[config.Name]; ok {
+			configs[i].DeviationThreshold = row.DeviationThreshold
+			configs[i].Heartbeat = row.Heartbeat
+		}
+	}
+	return configs
+}
+
+// groupConfigsForChain groups configs by their submit interval unless the
+// chain target overrides the interval for all of its configs.
+func groupConfigsForChain(reporterConfigs []Config, target ChainTarget) map[int][]Config {
+	if target.SubmitInterval != nil && *target.SubmitInterval > 0 {
+		return map[int][]Config{*target.SubmitInterval: reporterConfigs}
+	}
+	return groupConfigsBySubmitIntervals(reporterConfigs)
+}
+
 func groupConfigsBySubmitIntervals(reporterConfigs []Config) map[int][]Config {
 	grouped := make(map[int][]Config)
 	for _, sa := range reporterConfigs {
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"bisonai.com/miko/node/pkg/chain/helper"
 	"bisonai.com/miko/node/pkg/common/keys"
 	"bisonai.com/miko/node/pkg/dal/hub"
 	"bisonai.com/miko/node/pkg/db"
@@ -92,3 +93,40 @@ func TestWsDataHandling(t *testing.T) {
 		}
 	}
 }
+
+func TestGroupConfigsForChain(t *testing.T) {
+	fast, slow := 5000, 15000
+	configs := []Config{
+		{Name: "BTC-USDT", SubmitInterval: &fast},
+		{Name: "ETH-USDT", SubmitInterval: &slow},
+		{Name: "KAIA-USDT"},
+	}
+
+	grouped := groupConfigsForChain(configs, ChainTarget{Name: "kaia"})
+	if len(grouped) != 2 || len(grouped[5000]) != 2 || len(grouped[15000]) != 1 {
+		t.Fatalf("unexpected grouping without override: %v", grouped)
+	}
+
+	override := 60000
+	grouped = groupConfigsForChain(configs, ChainTarget{Name: "l2", SubmitInterval: &override})
+	if len(grouped) != 1 || len(grouped[override]) != len(configs) {
+		t.Fatalf("unexpected grouping with chain override: %v", grouped)
+	}
+}
+
+func TestBlockchainTypeOf(t *testing.T) {
+	for chainType, expected := range map[string]helper.BlockchainType{
+		"":         helper.Kaia,
+		"kaia":     helper.Kaia,
+		"Ethereum": helper.Ethereum,
+	} {
+		blockchainType, err := blockchainTypeOf(chainType)
+		if err != nil || blockchainType != expected {
+			t.Fatalf("unexpected blockchain type for %q: %v, %v", chainType, blockchainType, err)
+		}
+	}
+
+	if _, err := blockchainTypeOf("solana"); err == nil {
+		t.Fatal("expected error for unsupported chain type")
+	}
+}
@@ -0,0 +1,113 @@
+package reporter
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"bisonai.com/miko/node/pkg/chain/helper"
+	"bisonai.com/miko/node/pkg/db"
+	errorSentinel "bisonai.com/miko/node/pkg/error"
+	"bisonai.com/miko/node/pkg/secrets"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	KaiaChainType     = "kaia"
+	EthereumChainType = "ethereum"
+
+	DefaultChainTargetName = "default"
+)
+
+// ChainTarget is a chain the reporter submits the same DAL data to. Every
+// target gets its own chain helper (and so its own nonce pool) so a failing
+// chain can't hold back the others.
+type ChainTarget struct {
+	Name            string   `db:"name"`
+	ChainType       string   `db:"chain_type"`
+	ProviderUrls    []string `db:"provider_urls"`
+	ContractAddress string   `db:"contract_address"`
+	// ReporterPkSecret is the name of the secret holding the reporter key, the
+	// key itself is never stored in the table
+	ReporterPkSecret   *string  `db:"reporter_pk_secret"`
+	SubmitInterval     *int     `db:"submit_interval"`
+	DeviationThreshold *float64 `db:"deviation_threshold"`
+}
+
+// loadChainTargets reads enabled targets from reporter_chains and falls back
+// to the single SUBMISSION_PROXY_CONTRACT target when none are configured.
+// Failing to read the table fails, rather than silently dropping the other
+// chains.
+func loadChainTargets(ctx context.Context) ([]ChainTarget, error) {
+	targets, err := db.QueryRows[ChainTarget](ctx, GET_REPORTER_CHAINS, nil)
+	if err != nil {
+		log.Error().Str("Player", "Reporter").Err(err).Msg("failed to load reporter chains")
+		return nil, err
+	}
+	if len(targets) > 0 {
+		return targets, nil
+	}
+
+	target, err := defaultChainTarget()
+	if err != nil {
+		return nil, err
+	}
+	return []ChainTarget{target}, nil
+}
+
+func defaultChainTarget() (ChainTarget, error) {
+	contractAddress := os.Getenv("SUBMISSION_PROXY_CONTRACT")
+	if contractAddress == "" {
+		return ChainTarget{}, errorSentinel.ErrReporterSubmissionProxyContractNotFound
+	}
+
+	return ChainTarget{
+		Name:            DefaultChainTargetName,
+		ChainType:       KaiaChainType,
+		ContractAddress: contractAddress,
+	}, nil
+}
+
+func blockchainTypeOf(chainType string) (helper.BlockchainType, error) {
+	switch strings.ToLower(chainType) {
+	case "", KaiaChainType:
+		return helper.Kaia, nil
+	case EthereumChainType:
+		return helper.Ethereum, nil
+	default:
+		return 0, errorSentinel.ErrChainReporterUnsupportedChain
+	}
+}
+
+// newChainHelper dials the target's provider urls in order and returns the
+// first helper that connects. Without provider urls the chain type's default
+// provider and reporter secrets are used.
+func newChainHelper(ctx context.Context, target ChainTarget) (*helper.ChainHelper, error) {
+	blockchainType, err := blockchainTypeOf(target.ChainType)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []helper.ChainHelperOption{helper.WithBlockchainType(blockchainType), helper.WithTxStore(helper.NewPgsqlTxStore())}
+	if target.ReporterPkSecret != nil && *target.ReporterPkSecret != "" {
+		reporterPk := secrets.GetSecret(*target.ReporterPkSecret)
+		if reporterPk == "" {
+			return nil, errorSentinel.ErrReporterChainReporterPkNotFound
+		}
+		opts = append(opts, helper.WithReporterPk(reporterPk))
+	}
+
+	if len(target.ProviderUrls) == 0 {
+		return helper.NewChainHelper(ctx, opts...)
+	}
+
+	for _, providerUrl := range target.ProviderUrls {
+		chainHelper, err := helper.NewChainHelper(ctx, append(opts, helper.WithProviderUrl(providerUrl))...)
+		if err != nil {
+			log.Warn().Str("Player", "Reporter").Str("chain", target.Name).Err(err).Msg("failed to connect to provider, trying next one")
+			continue
+		}
+		return chainHelper, nil
+	}
+	return nil, errorSentinel.ErrReporterChainProviderUnavailable
+}
@@ -0,0 +1,156 @@
+package reporter
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	DEFAULT_BATCH_GAS_LIMIT  = uint64(10_000_000)
+	GAS_LIMIT_MARGIN_PERCENT = 20
+)
+
+type batchPlan struct {
+	pairs []string
+	data  []SubmissionData
+	// gas is the estimated gas of the batch, zero when it couldn't be estimated
+	gas uint64
+}
+
+type gasEstimator func(ctx context.Context, batch []SubmissionData) (uint64, error)
+
+// batchSubmitter submits a batch and waits until it is mined
+type batchSubmitter func(ctx context.Context, batch batchPlan, gasLimit uint64) error
+
+// batchPlanner orders pairs by priority and splits them into batches that
+// stay below the gas limit, so a single oversized batch doesn't fail every
+// pair in it.
+type batchPlanner struct {
+	maxBatchSize int
+	gasLimit     uint64
+	estimate     gasEstimator
+}
+
+type prioritizedPair struct {
+	pair     string
+	data     SubmissionData
+	priority float64
+}
+
+func newBatchPlanner(maxBatchSize int, gasLimit uint64, estimate gasEstimator) *batchPlanner {
+	return &batchPlanner{
+		maxBatchSize: maxBatchSize,
+		gasLimit:     gasLimit,
+		estimate:     estimate,
+	}
+}
+
+func getBatchGasLimit() uint64 {
+	if raw := os.Getenv("REPORTER_BATCH_GAS_LIMIT"); raw != "" {
+		if gasLimit, err := strconv.ParseUint(raw, 10, 64); err == nil && gasLimit > 0 {
+			return gasLimit
+		}
+	}
+	return DEFAULT_BATCH_GAS_LIMIT
+}
+
+// plan returns batches with the highest priority pairs first.
+func (p *batchPlanner) plan(ctx context.Context, pairs map[string]SubmissionData, priority func(string, SubmissionData) float64) []batchPlan {
+	ordered := make([]prioritizedPair, 0, len(pairs))
+	for pair, submissionData := range pairs {
+		ordered = append(ordered, prioritizedPair{pair: pair, data: submissionData, priority: priority(pair, submissionData)})
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if ordered[i].priority != ordered[j].priority {
+			return ordered[i].priority > ordered[j].priority
+		}
+		return ordered[i].pair < ordered[j].pair
+	})
+
+	batches := []batchPlan{}
+	for start := 0; start < len(ordered); start += p.maxBatchSize {
+		end := min(start+p.maxBatchSize, len(ordered))
+		batches = append(batches, p.fit(ctx, ordered[start:end])...)
+	}
+	return batches
+}
+
+// fit halves a batch until its estimated gas is within the limit. A batch
+// whose gas can't be estimated is kept as is and left to the tx builder.
+func (p *batchPlanner) fit(ctx context.Context, items []prioritizedPair) []batchPlan {
+	batch := batchPlan{
+		pairs: make([]string, 0, len(items)),
+		data:  make([]SubmissionData, 0, len(items)),
+	}
+	for _, item := range items {
+		batch.pairs = append(batch.pairs, item.pair)
+		batch.data = append(batch.data, item.data)
+	}
+
+	if p.estimate == nil {
+		return []batchPlan{batch}
+	}
+
+	gas, err := p.estimate(ctx, batch.data)
+	if err != nil {
+		log.Debug().Str("Player", "Reporter").Err(err).Msg("failed to estimate batch gas")
+		return []batchPlan{batch}
+	}
+
+	if gas <= p.gasLimit {
+		batch.gas = gas
+		return []batchPlan{batch}
+	}
+
+	if len(items) == 1 {
+		log.Warn().Str("Player", "Reporter").Str("pair", items[0].pair).Uint64("gas", gas).Uint64("gasLimit", p.gasLimit).Msg("pair exceeds batch gas limit, skipping")
+		return nil
+	}
+
+	mid := len(items) / 2
+	return append(p.fit(ctx, items[:mid]), p.fit(ctx, items[mid:])...)
+}
+
+// txGasLimit adds a safety margin to the estimate without exceeding the
+// batch gas limit. Zero lets the tx builder estimate on its own.
+func (p *batchPlanner) txGasLimit(batch batchPlan) uint64 {
+	if batch.gas == 0 {
+		return 0
+	}
+	return min(batch.gas*(100+GAS_LIMIT_MARGIN_PERCENT)/100, p.gasLimit)
+}
+
+func batchArgs(batch []SubmissionData) ([][32]byte, []*big.Int, []*big.Int, [][]byte) {
+	feedHashes := make([][32]byte, 0, len(batch))
+	values := make([]*big.Int, 0, len(batch))
+	timestamps := make([]*big.Int, 0, len(batch))
+	proofs := make([][]byte, 0, len(batch))
+	for _, submissionData := range batch {
+		feedHashes = append(feedHashes, submissionData.FeedHash)
+		values = append(values, big.NewInt(submissionData.Value))
+		timestamps = append(timestamps, big.NewInt(submissionData.AggregateTime))
+		proofs = append(proofs, submissionData.Proof)
+	}
+	return feedHashes, values, timestamps, proofs
+}
+
+// deviationOf is the relative change since the last submission, pairs that
+// were never submitted come first.
+func deviationOf(latestSubmittedData *sync.Map, pair string, submissionData SubmissionData) float64 {
+	rawOldValue, ok := latestSubmittedData.Load(pair)
+	if !ok {
+		return math.Inf(1)
+	}
+	oldValue, ok := rawOldValue.(int64)
+	if !ok || oldValue == 0 {
+		return math.Inf(1)
+	}
+	return math.Abs(float64(submissionData.Value-oldValue)) / math.Abs(float64(oldValue))
+}
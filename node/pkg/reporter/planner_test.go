@@ -0,0 +1,164 @@
+//nolint:all
+
+package reporter
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testGasPerPair = uint64(100_000)
+
+func perPairEstimator() gasEstimator {
+	return func(ctx context.Context, batch []SubmissionData) (uint64, error) {
+		gas := uint64(0)
+		for _, submissionData := range batch {
+			if submissionData.Value < 0 {
+				gas += math.MaxUint32
+				continue
+			}
+			gas += testGasPerPair
+		}
+		return gas, nil
+	}
+}
+
+func testPairs(n int) map[string]SubmissionData {
+	pairs := make(map[string]SubmissionData, n)
+	for i := 0; i < n; i++ {
+		pairs[string(rune('a'+i))] = SubmissionData{Value: int64(i + 1)}
+	}
+	return pairs
+}
+
+func flatten(batches []batchPlan) []string {
+	result := []string{}
+	for _, batch := range batches {
+		result = append(result, batch.pairs...)
+	}
+	return result
+}
+
+func TestBatchPlannerSplitsOverGasLimit(t *testing.T) {
+	planner := newBatchPlanner(MAX_REPORT_BATCH_SIZE, 3*testGasPerPair, perPairEstimator())
+	batches := planner.plan(context.Background(), testPairs(8), func(string, SubmissionData) float64 { return 0 })
+
+	assert.Equal(t, 8, len(flatten(batches)))
+	for _, batch := range batches {
+		assert.LessOrEqual(t, batch.gas, 3*testGasPerPair)
+		assert.LessOrEqual(t, planner.txGasLimit(batch), 3*testGasPerPair)
+	}
+}
+
+func TestBatchPlannerPriority(t *testing.T) {
+	planner := newBatchPlanner(2, DEFAULT_BATCH_GAS_LIMIT, nil)
+	priorities := map[string]float64{"a": 0.1, "b": math.Inf(1), "c": 0.5}
+	batches := planner.plan(context.Background(), testPairs(3), func(pair string, _ SubmissionData) float64 {
+		return priorities[pair]
+	})
+
+	assert.Equal(t, 2, len(batches))
+	assert.Equal(t, []string{"b", "c", "a"}, flatten(batches))
+	assert.Equal(t, uint64(0), planner.txGasLimit(batches[0]))
+}
+
+func TestBatchPlannerSkipsOversizedPair(t *testing.T) {
+	planner := newBatchPlanner(MAX_REPORT_BATCH_SIZE, DEFAULT_BATCH_GAS_LIMIT, perPairEstimator())
+	pairs := testPairs(3)
+	pairs["huge"] = SubmissionData{Value: -1}
+
+	batches := planner.plan(context.Background(), pairs, func(string, SubmissionData) float64 { return 0 })
+	planned := flatten(batches)
+	assert.Equal(t, 3, len(planned))
+	assert.NotContains(t, planned, "huge")
+}
+
+func TestBatchPlannerKeepsBatchOnEstimationError(t *testing.T) {
+	planner := newBatchPlanner(MAX_REPORT_BATCH_SIZE, DEFAULT_BATCH_GAS_LIMIT, func(context.Context, []SubmissionData) (uint64, error) {
+		return 0, errors.New("estimation failed")
+	})
+	batches := planner.plan(context.Background(), testPairs(4), func(string, SubmissionData) float64 { return 0 })
+
+	assert.Equal(t, 1, len(batches))
+	assert.Equal(t, 4, len(batches[0].pairs))
+	assert.Equal(t, uint64(0), planner.txGasLimit(batches[0]))
+}
+
+func TestDeviationOf(t *testing.T) {
+	latest := &sync.Map{}
+	assert.True(t, math.IsInf(deviationOf(latest, "a", SubmissionData{Value: 10}), 1))
+
+	latest.Store("a", int64(100))
+	assert.InDelta(t, 0.1, deviationOf(latest, "a", SubmissionData{Value: 110}), 1e-9)
+}
+
+// recordingSubmitter records the first pair of every submitted batch and
+// fails the batches starting with a pair in failures
+func recordingSubmitter(submitted *[]string, failures map[string]error) batchSubmitter {
+	return func(ctx context.Context, batch batchPlan, gasLimit uint64) error {
+		*submitted = append(*submitted, batch.pairs[0])
+		return failures[batch.pairs[0]]
+	}
+}
+
+func TestReportSubmitsInPriorityOrder(t *testing.T) {
+	submitted := []string{}
+	reporter := &Reporter{
+		planner:                newBatchPlanner(1, DEFAULT_BATCH_GAS_LIMIT, nil),
+		submit:                 recordingSubmitter(&submitted, map[string]error{"b": errors.New("execution reverted")}),
+		LatestSubmittedDataMap: new(sync.Map),
+		LatestSubmittedTimeMap: new(sync.Map),
+	}
+	reporter.LatestSubmittedDataMap.Store("a", int64(100))
+	reporter.LatestSubmittedDataMap.Store("b", int64(100))
+	reporter.LatestSubmittedDataMap.Store("c", int64(100))
+
+	err := reporter.report(context.Background(), map[string]SubmissionData{"a": {Value: 101}, "b": {Value: 150}, "c": {Value: 120}})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"b", "c", "a"}, submitted, "most deviated first, other errors don't stop the round")
+
+	value, _ := reporter.LatestSubmittedDataMap.Load("b")
+	assert.Equal(t, int64(100), value, "failed batch is retried next round")
+	value, _ = reporter.LatestSubmittedDataMap.Load("c")
+	assert.Equal(t, int64(120), value)
+}
+
+func TestReportStopsOnGasError(t *testing.T) {
+	submitted := []string{}
+	reporter := &Reporter{
+		planner:                newBatchPlanner(1, DEFAULT_BATCH_GAS_LIMIT, nil),
+		submit:                 recordingSubmitter(&submitted, map[string]error{"b": errors.New("insufficient funds for gas * price + value")}),
+		LatestSubmittedDataMap: new(sync.Map),
+	}
+	reporter.LatestSubmittedDataMap.Store("a", int64(100))
+	reporter.LatestSubmittedDataMap.Store("b", int64(100))
+
+	err := reporter.report(context.Background(), map[string]SubmissionData{"a": {Value: 101}, "b": {Value: 150}})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"b"}, submitted, "lower priority batches are dropped")
+}
+
+func TestReportSkipsPairsWithinHeartbeat(t *testing.T) {
+	submitted := []string{}
+	heartbeat := 60000
+	reporter, err := NewReporter(
+		context.Background(),
+		WithConfigs([]Config{{Name: "a", Heartbeat: &heartbeat}}),
+		WithInterval(5000),
+		WithLatestSubmittedDataMap(new(sync.Map)),
+		WithLatestSubmittedTimeMap(new(sync.Map)),
+	)
+	assert.NoError(t, err)
+	reporter.submit = recordingSubmitter(&submitted, nil)
+	reporter.LatestSubmittedDataMap.Store("a", int64(100000000))
+	reporter.LatestSubmittedTimeMap.Store("a", time.Now())
+
+	assert.NoError(t, reporter.report(context.Background(), map[string]SubmissionData{"a": {Value: 100000001}}))
+	assert.Empty(t, submitted)
+}
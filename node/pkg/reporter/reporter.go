@@ -3,8 +3,6 @@ package reporter
 import (
 	"context"
 	"errors"
-	"math/big"
-	"sync"
 	"time"
 
 	"bisonai.com/miko/node/pkg/chain/utils"
@@ -32,8 +30,12 @@ func NewReporter(ctx context.Context, opts ...ReporterOption) (*Reporter, error)
 	groupInterval := time.Duration(config.Interval) * time.Millisecond
 
 	deviationThreshold := GetDeviationThreshold(groupInterval)
+	if config.DeviationThreshold > 0 {
+		deviationThreshold = config.DeviationThreshold
+	}
 
 	reporter := &Reporter{
+		Chain:                  config.Chain,
 		DalRestEndpoint:        config.DalRestEndpoint,
 		contractAddress:        config.ContractAddress,
 		SubmissionInterval:     groupInterval,
@@ -42,13 +44,35 @@ func NewReporter(ctx context.Context, opts ...ReporterOption) (*Reporter, error)
 		KaiaHelper:             config.KaiaHelper,
 		LatestDataMap:          config.LatestDataMap,
 		LatestSubmittedDataMap: config.LatestSubmittedDataMap,
+		LatestSubmittedTimeMap: config.LatestSubmittedTimeMap,
+		deviationThresholds:    map[string]float64{},
+		heartbeats:             map[string]time.Duration{},
 	}
 
 	reporter.Pairs = make([]string, 0, len(config.Configs))
 	for _, sa := range config.Configs {
 		reporter.Pairs = append(reporter.Pairs, sa.Name)
+		if sa.DeviationThreshold != nil && *sa.DeviationThreshold > 0 {
+			reporter.deviationThresholds[sa.Name] = *sa.DeviationThreshold
+		}
+		if sa.Heartbeat != nil && *sa.Heartbeat > 0 {
+			reporter.heartbeats[sa.Name] = time.Duration(*sa.Heartbeat) * time.Millisecond
+		}
 	}
 
+	var estimate gasEstimator
+	if config.KaiaHelper != nil {
+		estimate = func(ctx context.Context, batch []SubmissionData) (uint64, error) {
+			feedHashes, values, timestamps, proofs := batchArgs(batch)
+			return config.KaiaHelper.EstimateGas(ctx, config.ContractAddress, SUBMIT_WITH_PROOFS, feedHashes, values, timestamps, proofs)
+		}
+		reporter.submit = func(ctx context.Context, batch batchPlan, gasLimit uint64) error {
+			feedHashes, values, timestamps, proofs := batchArgs(batch.data)
+			return config.KaiaHelper.SubmitTracked(ctx, batch.pairs, config.ContractAddress, SUBMIT_WITH_PROOFS, gasLimit, feedHashes, values, timestamps, proofs)
+		}
+	}
+	reporter.planner = newBatchPlanner(MAX_REPORT_BATCH_SIZE, getBatchGasLimit(), estimate)
+
 	if config.JobType == ReportJob {
 		reporter.Job = func() error {
 			return reporter.regularReporterJob(ctx)
@@ -75,7 +99,7 @@ func (r *Reporter) Run(ctx context.Context) {
 			go func() {
 				err := r.Job()
 				if err != nil {
-					log.Error().Str("Player", "Reporter").Err(err).Msg("ReporterJob")
+					log.Error().Str("Player", "Reporter").Str("chain", r.Chain).Err(err).Msg("ReporterJob")
 				}
 			}()
 		}
@@ -96,7 +120,10 @@ func (r *Reporter) regularReporterJob(ctx context.Context) error {
 }
 
 func (r *Reporter) deviationJob(ctx context.Context) error {
-	deviatingAggregates := GetDeviatingAggregates(r.LatestSubmittedDataMap, r.LatestDataMap, r.deviationThreshold)
+	deviatingAggregates := GetDeviatingAggregatesWithThresholds(r.LatestSubmittedDataMap, r.LatestDataMap, r.deviationThresholds, r.deviationThreshold)
+	for pair, submissionData := range r.heartbeatDue(time.Now()) {
+		deviatingAggregates[pair] = submissionData
+	}
 	if len(deviatingAggregates) == 0 {
 		return nil
 	}
@@ -109,61 +136,105 @@ func (r *Reporter) deviationJob(ctx context.Context) error {
 	return nil
 }
 
-func (r *Reporter) report(ctx context.Context, pairs map[string]SubmissionData) error {
-	var feedHashes [][32]byte
-	var values []*big.Int
-	var timestamps []*big.Int
-	var proofs [][]byte
-	var submittedPairs []string
+// filterByHeartbeat drops pairs that were submitted within their heartbeat
+// and haven't deviated since. Pairs without a heartbeat are always kept.
+func (r *Reporter) filterByHeartbeat(pairs map[string]SubmissionData, now time.Time) map[string]SubmissionData {
+	if len(r.heartbeats) == 0 || r.LatestSubmittedTimeMap == nil {
+		return pairs
+	}
 
+	result := make(map[string]SubmissionData, len(pairs))
 	for pair, submissionData := range pairs {
-		feedHashes = append(feedHashes, submissionData.FeedHash)
-		values = append(values, big.NewInt(submissionData.Value))
-		timestamps = append(timestamps, big.NewInt(submissionData.AggregateTime))
-		proofs = append(proofs, submissionData.Proof)
-		submittedPairs = append(submittedPairs, pair)
-	}
-
-	dataLen := len(feedHashes)
-	wg := sync.WaitGroup{}
-
-	errorsChan := make(chan error, 5)
-	for start := 0; start < dataLen; start += MAX_REPORT_BATCH_SIZE {
-		end := min(start+MAX_REPORT_BATCH_SIZE, dataLen)
-
-		batchFeedHashes := feedHashes[start:end]
-		batchValues := values[start:end]
-		batchTimestamps := timestamps[start:end]
-		batchProofs := proofs[start:end]
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			err := r.KaiaHelper.SubmitDelegatedFallbackDirect(ctx, r.contractAddress, SUBMIT_WITH_PROOFS, batchFeedHashes, batchValues, batchTimestamps, batchProofs)
-			if err != nil {
-				errorsChan <- err
-			}
-		}()
-	}
-	wg.Wait()
-	close(errorsChan)
+		heartbeat, ok := r.heartbeats[pair]
+		if !ok {
+			result[pair] = submissionData
+			continue
+		}
+
+		rawSubmittedAt, timeOk := r.LatestSubmittedTimeMap.Load(pair)
+		rawSubmittedValue, valueOk := r.LatestSubmittedDataMap.Load(pair)
+		if !timeOk || !valueOk {
+			result[pair] = submissionData
+			continue
+		}
+
+		submittedAt, timeOk := rawSubmittedAt.(time.Time)
+		submittedValue, valueOk := rawSubmittedValue.(int64)
+		if !timeOk || !valueOk || now.Sub(submittedAt) >= heartbeat || ShouldReportDeviation(submittedValue, submissionData.Value, r.thresholdFor(pair)) {
+			result[pair] = submissionData
+		}
+	}
+	return result
+}
+
+// heartbeatDue returns the latest data of the pairs whose heartbeat elapsed
+// since their last submission, so they are submitted even without deviating.
+func (r *Reporter) heartbeatDue(now time.Time) map[string]SubmissionData {
+	result := map[string]SubmissionData{}
+	if r.LatestSubmittedTimeMap == nil || r.LatestDataMap == nil {
+		return result
+	}
+
+	for pair, heartbeat := range r.heartbeats {
+		rawSubmittedAt, ok := r.LatestSubmittedTimeMap.Load(pair)
+		if !ok {
+			continue
+		}
+		submittedAt, ok := rawSubmittedAt.(time.Time)
+		if !ok || now.Sub(submittedAt) < heartbeat {
+			continue
+		}
+		if submissionData, ok := GetLatestData(r.LatestDataMap, pair); ok {
+			result[pair] = submissionData
+		}
+	}
+	return result
+}
+
+func (r *Reporter) thresholdFor(pair string) float64 {
+	if threshold, ok := r.deviationThresholds[pair]; ok {
+		return threshold
+	}
+	return r.deviationThreshold
+}
+
+// report submits the pairs that deviated or whose heartbeat elapsed. Batches
+// are submitted one at a time in priority order, and the lower priority ones
+// are dropped on nonce or gas errors since they would fail the same way.
+// Pairs of batches that weren't mined are retried in the next round.
+func (r *Reporter) report(ctx context.Context, pairs map[string]SubmissionData) error {
+	pairs = r.filterByHeartbeat(pairs, time.Now())
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	batches := r.planner.plan(ctx, pairs, func(pair string, submissionData SubmissionData) float64 {
+		return deviationOf(r.LatestSubmittedDataMap, pair, submissionData)
+	})
 
 	shouldRefreshNonce := false
 
 	tmp := []error{}
-	for err := range errorsChan {
+	for b, batch := range batches {
+		err := r.submit(ctx, batch, r.planner.txGasLimit(batch))
+		if err == nil {
+			r.storeSubmitted(batch, time.Now())
+			continue
+		}
+
 		tmp = append(tmp, err)
 		if utils.IsNonceError(err) || errors.Is(err, context.DeadlineExceeded) {
 			log.Debug().Err(err).Str("Player", "Reporter").Msg("should refresh nonce")
 			shouldRefreshNonce = true
 		}
-	}
-
-	for i, pair := range submittedPairs {
-		r.LatestSubmittedDataMap.Store(pair, values[i].Int64())
+		if shouldRefreshNonce || utils.IsGasError(err) {
+			log.Warn().Err(err).Str("Player", "Reporter").Str("chain", r.Chain).Int("skipped", len(batches)-b-1).Msg("stopping lower priority batches")
+			break
+		}
 	}
 
 	if shouldRefreshNonce {
-		log.Debug().Str("Player", "Reporter").Msg("refreshing nonce pool")
+		log.Debug().Str("Player", "Reporter").Str("chain", r.Chain).Msg("refreshing nonce pool")
 		return r.KaiaHelper.FlushNoncePool(ctx)
 	}
 
@@ -176,6 +247,15 @@ func (r *Reporter) report(ctx context.Context, pairs map[string]SubmissionData)
 	return nil
 }
 
+func (r *Reporter) storeSubmitted(batch batchPlan, submittedAt time.Time) {
+	for i, pair := range batch.pairs {
+		r.LatestSubmittedDataMap.Store(pair, batch.data[i].Value)
+		if r.LatestSubmittedTimeMap != nil {
+			r.LatestSubmittedTimeMap.Store(pair, submittedAt)
+		}
+	}
+}
+
 func mergeErrors(errs []error) error {
 	if len(errs) == 0 {
 		return nil
@@ -0,0 +1,81 @@
+package reporter
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"bisonai.com/miko/node/pkg/chain/helper"
+	"bisonai.com/miko/node/pkg/db"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	DEFAULT_TRANSACTIONS_LIMIT = 100
+	MAX_TRANSACTIONS_LIMIT     = 1000
+
+	transactionColumns = `hash, chain, nonce, gas_price, status, pairs, replaced_by, block_number, gas_used, submitted_at, mined_at`
+
+	GET_TRANSACTION  = `SELECT ` + transactionColumns + ` FROM reporter_transactions WHERE hash = @hash`
+	GET_TRANSACTIONS = `SELECT ` + transactionColumns + ` FROM reporter_transactions
+		WHERE (@pair = '' OR @pair = ANY(pairs)) AND (@status = '' OR status = @status) AND (@chain = '' OR chain = @chain)
+		ORDER BY submitted_at DESC LIMIT @limit`
+)
+
+// RegisterTransactionRoutes serves the tx records of the reporter:
+// GET /api/v1/transactions?pair=&status=&chain=&limit= lists the latest txs,
+// GET /api/v1/transactions/{hash} returns a single tx.
+func RegisterTransactionRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/v1/transactions", getTransactions)
+	mux.HandleFunc("GET /api/v1/transactions/{hash}", getTransaction)
+}
+
+func getTransactions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit := DEFAULT_TRANSACTIONS_LIMIT
+	if raw := query.Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(parsed, MAX_TRANSACTIONS_LIMIT)
+	}
+
+	transactions, err := db.QueryRows[helper.TrackedTx](r.Context(), GET_TRANSACTIONS, map[string]any{
+		"pair":   query.Get("pair"),
+		"status": query.Get("status"),
+		"chain":  query.Get("chain"),
+		"limit":  limit,
+	})
+	if err != nil {
+		log.Error().Str("Player", "Reporter").Err(err).Msg("failed to query transactions")
+		http.Error(w, "failed to query transactions", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, transactions)
+}
+
+func getTransaction(w http.ResponseWriter, r *http.Request) {
+	transaction, err := db.QueryRow[helper.TrackedTx](r.Context(), GET_TRANSACTION, map[string]any{"hash": r.PathValue("hash")})
+	if err != nil {
+		log.Error().Str("Player", "Reporter").Err(err).Msg("failed to query transaction")
+		http.Error(w, "failed to query transaction", http.StatusInternalServerError)
+		return
+	}
+	if transaction.Hash == "" {
+		http.Error(w, "transaction not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, transaction)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Error().Str("Player", "Reporter").Err(err).Msg("failed to write response")
+	}
+}
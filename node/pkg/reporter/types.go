@@ -14,7 +14,8 @@ const (
 	SUBMIT_WITH_PROOFS    = "submit(bytes32[] calldata _feedHashes, int256[] calldata _answers, uint256[] calldata _timestamps, bytes[] calldata _proofs)"
 	GET_ONCHAIN_WHITELIST = "getAllOracles() public view returns (address[] memory)"
 
-	GET_REPORTER_CONFIGS = `SELECT name, id, submit_interval, aggregate_interval FROM configs;`
+	GET_REPORTER_CONFIGS = `SELECT name, submit_interval, deviation_threshold, heartbeat FROM configs;`
+	GET_REPORTER_CHAINS  = `SELECT name, chain_type, provider_urls, contract_address, reporter_pk_secret, submit_interval, deviation_threshold FROM reporter_chains WHERE enabled = true ORDER BY id;`
 
 	MAX_REPORT_BATCH_SIZE = 50
 	DEVIATION_INTERVAL    = 2000
@@ -31,16 +32,24 @@ const (
 type GlobalAggregate = types.GlobalAggregate
 
 type Config struct {
-	Name           string `json:"name"`
-	SubmitInterval *int   `json:"submitInterval"`
+	Name           string `json:"name" db:"name"`
+	SubmitInterval *int   `json:"submitInterval" db:"submit_interval"`
+	// DeviationThreshold overrides the interval based threshold, as a ratio
+	// (0.001 for 0.1%)
+	DeviationThreshold *float64 `json:"deviationThreshold" db:"deviation_threshold"`
+	// Heartbeat is the max staleness in milliseconds, pairs that haven't
+	// deviated are only resubmitted once it passes
+	Heartbeat *int `json:"heartbeat" db:"heartbeat"`
 }
 
 type App struct {
-	Reporters []*Reporter
+	Reporters    []*Reporter
+	ChainHelpers []*helper.ChainHelper
 
 	WsHelper               *wss.WebsocketHelper
 	LatestDataMap          *sync.Map // map[symbol]SubmissionData
 	LatestSubmittedDataMap *sync.Map // map[symbol]int64
+	LatestSubmittedTimeMap *sync.Map // map[symbol]time.Time
 }
 
 type JobType int
@@ -51,6 +60,7 @@ const (
 )
 
 type ReporterConfig struct {
+	Chain                  string
 	Configs                []Config
 	Interval               int
 	ContractAddress        string
@@ -61,6 +71,8 @@ type ReporterConfig struct {
 	KaiaHelper             *helper.ChainHelper
 	LatestDataMap          *sync.Map // map[symbol]SubmissionData
 	LatestSubmittedDataMap *sync.Map // map[symbol]int64
+	LatestSubmittedTimeMap *sync.Map // map[symbol]time.Time
+	DeviationThreshold     float64
 }
 
 type ReporterOption func(*ReporterConfig)
@@ -70,6 +82,12 @@ type Subscription struct {
 	Params []string `json:"params"`
 }
 
+func WithChain(chain string) ReporterOption {
+	return func(c *ReporterConfig) {
+		c.Chain = chain
+	}
+}
+
 func WithConfigs(configs []Config) ReporterOption {
 	return func(c *ReporterConfig) {
 		c.Configs = configs
@@ -118,24 +136,44 @@ func WithLatestSubmittedDataMap(latestSubmittedDataMap *sync.Map) ReporterOption
 	}
 }
 
+func WithLatestSubmittedTimeMap(latestSubmittedTimeMap *sync.Map) ReporterOption {
+	return func(c *ReporterConfig) {
+		c.LatestSubmittedTimeMap = latestSubmittedTimeMap
+	}
+}
+
 func WithDalRestEndpoint(endpoint string) ReporterOption {
 	return func(c *ReporterConfig) {
 		c.DalRestEndpoint = endpoint
 	}
 }
 
+// WithDeviationThreshold overrides the threshold derived from the interval,
+// zero keeps the derived one.
+func WithDeviationThreshold(threshold float64) ReporterOption {
+	return func(c *ReporterConfig) {
+		c.DeviationThreshold = threshold
+	}
+}
+
 type Reporter struct {
+	Chain              string
 	DalRestEndpoint    string
 	KaiaHelper         *helper.ChainHelper
 	Pairs              []string
 	SubmissionInterval time.Duration
 	CachedWhitelist    []common.Address
 
-	contractAddress    string
-	deviationThreshold float64
+	contractAddress     string
+	deviationThreshold  float64
+	deviationThresholds map[string]float64
+	heartbeats          map[string]time.Duration
+	planner             *batchPlanner
+	submit              batchSubmitter
 
 	LatestDataMap          *sync.Map
 	LatestSubmittedDataMap *sync.Map
+	LatestSubmittedTimeMap *sync.Map
 	Job                    func() error
 }
 
@@ -21,6 +21,12 @@ import (
 )
 
 func GetDeviatingAggregates(latestSubmittedData *sync.Map, latestData *sync.Map, threshold float64) map[string]SubmissionData {
+	return GetDeviatingAggregatesWithThresholds(latestSubmittedData, latestData, nil, threshold)
+}
+
+// GetDeviatingAggregatesWithThresholds checks each pair against its own
+// threshold, pairs without one use defaultThreshold.
+func GetDeviatingAggregatesWithThresholds(latestSubmittedData *sync.Map, latestData *sync.Map, thresholds map[string]float64, defaultThreshold float64) map[string]SubmissionData {
 	deviatingSubmissionPairs := map[string]SubmissionData{}
 	latestSubmittedData.Range(func(key, value any) bool {
 		pair := key.(string)
@@ -32,6 +38,11 @@ func GetDeviatingAggregates(latestSubmittedData *sync.Map, latestData *sync.Map,
 			return true
 		}
 
+		threshold, ok := thresholds[pair]
+		if !ok {
+			threshold = defaultThreshold
+		}
+
 		if ShouldReportDeviation(oldValue, newValue.Value, threshold) {
 			deviatingSubmissionPairs[pair] = newValue
 		}
@@ -3,7 +3,9 @@
 package reporter
 
 import (
+	"context"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
@@ -122,3 +124,99 @@ func TestGetDeviationThreshold(t *testing.T) {
 	assert.Equal(t, 0.01, GetDeviationThreshold(2*time.Hour))
 	assert.Less(t, GetDeviationThreshold(30*time.Second), 0.05)
 }
+
+func TestGetDeviatingAggregatesWithThresholds(t *testing.T) {
+	latestSubmittedData := new(sync.Map)
+	latestData := new(sync.Map)
+
+	// both pairs moved by 0.5%
+	latestSubmittedData.Store("USDT-USD", int64(100000000))
+	latestData.Store("USDT-USD", SubmissionData{Symbol: "USDT-USD", Value: 100500000})
+	latestSubmittedData.Store("PEPE-USDT", int64(100000000))
+	latestData.Store("PEPE-USDT", SubmissionData{Symbol: "PEPE-USDT", Value: 100500000})
+
+	deviating := GetDeviatingAggregatesWithThresholds(latestSubmittedData, latestData, map[string]float64{"USDT-USD": 0.001}, 0.01)
+	assert.Contains(t, deviating, "USDT-USD")
+	assert.NotContains(t, deviating, "PEPE-USDT")
+}
+
+func TestFilterByHeartbeat(t *testing.T) {
+	heartbeat := 60000
+	threshold := 0.001
+	reporter, err := NewReporter(
+		context.Background(),
+		WithConfigs([]Config{
+			{Name: "USDT-USD", Heartbeat: &heartbeat, DeviationThreshold: &threshold},
+			{Name: "BTC-USDT"},
+		}),
+		WithInterval(5000),
+		WithLatestSubmittedDataMap(new(sync.Map)),
+		WithLatestSubmittedTimeMap(new(sync.Map)),
+	)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	pairs := map[string]SubmissionData{
+		"USDT-USD": {Symbol: "USDT-USD", Value: 100000000},
+		"BTC-USDT": {Symbol: "BTC-USDT", Value: 100000000},
+	}
+	assert.Equal(t, 2, len(reporter.filterByHeartbeat(pairs, now)), "never submitted pairs should be reported")
+
+	reporter.LatestSubmittedDataMap.Store("USDT-USD", int64(100000000))
+	reporter.LatestSubmittedTimeMap.Store("USDT-USD", now.Add(-time.Second))
+	filtered := reporter.filterByHeartbeat(pairs, now)
+	assert.NotContains(t, filtered, "USDT-USD", "fresh and unchanged pair should be skipped")
+	assert.Contains(t, filtered, "BTC-USDT", "pairs without heartbeat are always reported")
+
+	pairs["USDT-USD"] = SubmissionData{Symbol: "USDT-USD", Value: 100200000}
+	assert.Contains(t, reporter.filterByHeartbeat(pairs, now), "USDT-USD", "deviation above the pair threshold should be reported")
+
+	pairs["USDT-USD"] = SubmissionData{Symbol: "USDT-USD", Value: 100000000}
+	reporter.LatestSubmittedTimeMap.Store("USDT-USD", now.Add(-2*time.Minute))
+	assert.Contains(t, reporter.filterByHeartbeat(pairs, now), "USDT-USD", "heartbeat elapsed")
+}
+
+func TestHeartbeatDue(t *testing.T) {
+	heartbeat := 60000
+	reporter, err := NewReporter(
+		context.Background(),
+		WithConfigs([]Config{
+			{Name: "USDT-USD", Heartbeat: &heartbeat},
+			{Name: "BTC-USDT"},
+		}),
+		WithInterval(DEVIATION_INTERVAL),
+		WithJobType(DeviationJob),
+		WithLatestDataMap(new(sync.Map)),
+		WithLatestSubmittedDataMap(new(sync.Map)),
+		WithLatestSubmittedTimeMap(new(sync.Map)),
+	)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	reporter.LatestDataMap.Store("USDT-USD", SubmissionData{Symbol: "USDT-USD", Value: 100000000})
+	reporter.LatestDataMap.Store("BTC-USDT", SubmissionData{Symbol: "BTC-USDT", Value: 100000000})
+	assert.Empty(t, reporter.heartbeatDue(now), "never submitted pairs are left to the regular job")
+
+	reporter.LatestSubmittedTimeMap.Store("USDT-USD", now.Add(-time.Second))
+	reporter.LatestSubmittedTimeMap.Store("BTC-USDT", now.Add(-time.Hour))
+	assert.Empty(t, reporter.heartbeatDue(now), "within heartbeat, pairs without heartbeat are never due")
+
+	reporter.LatestSubmittedTimeMap.Store("USDT-USD", now.Add(-2*time.Minute))
+	due := reporter.heartbeatDue(now)
+	assert.Equal(t, 1, len(due))
+	assert.Equal(t, int64(100000000), due["USDT-USD"].Value)
+}
+
+func TestMergeConfigParams(t *testing.T) {
+	heartbeat := 60000
+	threshold := 0.001
+	configs := mergeConfigParams(
+		[]Config{{Name: "USDT-USD"}, {Name: "BTC-USDT"}},
+		[]Config{{Name: "USDT-USD", Heartbeat: &heartbeat, DeviationThreshold: &threshold}, {Name: "ETH-USDT", Heartbeat: &heartbeat}},
+	)
+
+	assert.Equal(t, 2, len(configs), "only published configs are reported")
+	assert.Equal(t, heartbeat, *configs[0].Heartbeat)
+	assert.Equal(t, threshold, *configs[0].DeviationThreshold)
+	assert.Nil(t, configs[1].Heartbeat)
+}
@@ -2,6 +2,7 @@ package request
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -21,6 +22,7 @@ type RequestConfig struct {
 	Headers  map[string]string
 	Proxy    string
 	Method   string
+	Context  context.Context
 }
 
 type RequestOption func(*RequestConfig)
@@ -61,6 +63,13 @@ func WithMethod(method string) RequestOption {
 	}
 }
 
+// WithContext cancels the request along with ctx.
+func WithContext(ctx context.Context) RequestOption {
+	return func(config *RequestConfig) {
+		config.Context = ctx
+	}
+}
+
 func Request[T any](opts ...RequestOption) (T, error) {
 	var result T
 
@@ -136,7 +145,13 @@ func requestRaw(config RequestConfig) (*http.Response, error) {
 		return nil, errorSentinel.ErrRequestInvalidMethod
 	}
 
-	req, err := http.NewRequest(
+	ctx := config.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
 		config.Method,
 		url.String(),
 		body,
@@ -3,7 +3,9 @@ package websocketfetcher
 import (
 	"context"
 	"errors"
+	"maps"
 	"os"
+	"slices"
 	"sync"
 	"time"
 
@@ -42,6 +44,7 @@ import (
 	"bisonai.com/miko/node/pkg/websocketfetcher/providers/uniswapv4"
 	"bisonai.com/miko/node/pkg/websocketfetcher/providers/upbit"
 	"bisonai.com/miko/node/pkg/websocketfetcher/providers/xt"
+	"bisonai.com/miko/node/pkg/wss"
 	"github.com/rs/zerolog/log"
 )
 
@@ -59,6 +62,8 @@ type AppConfig struct {
 	StoreInterval       time.Duration
 	LatestFeedDataMap   *types.LatestFeedDataMap
 	FeedDataDumpChannel chan *types.FeedData
+	// ChainReader is dialed from the *_WEBSOCKET_URL secrets when not set
+	ChainReader *websocketchainreader.ChainReader
 }
 
 type AppOption func(*AppConfig)
@@ -81,6 +86,18 @@ func WithCexFactories(factories map[string]func(context.Context, ...common.Fetch
 	}
 }
 
+func WithDexFactories(factories map[string]func(...common.DexFetcherOption) common.FetcherInterface) AppOption {
+	return func(c *AppConfig) {
+		c.DexFactories = factories
+	}
+}
+
+func WithChainReader(chainReader *websocketchainreader.ChainReader) AppOption {
+	return func(c *AppConfig) {
+		c.ChainReader = chainReader
+	}
+}
+
 func WithBufferSize(size int) AppOption {
 	return func(c *AppConfig) {
 		c.BufferSize = size
@@ -105,13 +122,37 @@ func WithFeedDataDumpChannel(feedDataDumpChannel chan *types.FeedData) AppOption
 	}
 }
 
+// cexFetcher is a running CEX fetcher with the feeds it was given.
+type cexFetcher struct {
+	fetcher  common.FetcherInterface
+	feedMaps common.FeedMaps
+	cancel   context.CancelFunc
+}
+
+// dexFetcher is a running DEX fetcher with the pool feeds it was given.
+type dexFetcher struct {
+	fetcher common.FetcherInterface
+	feeds   []common.Feed
+	cancel  context.CancelFunc
+}
+
 type App struct {
-	fetchers            []common.FetcherInterface
+	cexFetchers         map[string]*cexFetcher
+	cexFactories        map[string]func(context.Context, ...common.FetcherOption) (common.FetcherInterface, error)
+	dexFetchers         map[string]*dexFetcher
+	dexFactories        map[string]func(...common.DexFetcherOption) common.FetcherInterface
+	proxy               string
+	recording           wss.Recording
+	orderBookProviders  map[string]struct{}
+	depthPercent        float64
+	runCtx              context.Context
+	mu                  sync.Mutex
 	buffer              chan *common.FeedData
 	storeInterval       time.Duration
 	chainReader         *websocketchainreader.ChainReader
 	latestFeedDataMap   *types.LatestFeedDataMap
 	feedDataDumpChannel chan *common.FeedData
+	watchdog            *Watchdog
 	cancel              context.CancelFunc
 }
 
@@ -176,25 +217,24 @@ func (a *App) Init(ctx context.Context, opts ...AppOption) error {
 
 	a.latestFeedDataMap = appConfig.LatestFeedDataMap
 	a.feedDataDumpChannel = appConfig.FeedDataDumpChannel
-
-	if err := a.initializeCex(ctx, *appConfig); err != nil {
-		return err
+	if a.watchdog != nil {
+		a.watchdog.Clear()
 	}
+	a.watchdog = NewWatchdog(a.latestFeedDataMap)
+	a.buffer = make(chan *common.FeedData, appConfig.BufferSize)
+	a.storeInterval = appConfig.StoreInterval
 
-	if err := a.initializeDex(ctx, *appConfig); err != nil {
+	a.initializeCex(*appConfig)
+
+	if err := a.initializeDex(*appConfig); err != nil {
 		return err
 	}
 
-	return nil
-}
-
-func (a *App) initializeCex(ctx context.Context, appConfig AppConfig) error {
 	var feeds []common.Feed
 	if appConfig.SetFromDB {
 		var err error
-		feeds, err = db.QueryRows[common.Feed](ctx, common.GetAllWebsocketFeedsQuery, nil)
+		feeds, err = a.queryFeeds(ctx)
 		if err != nil {
-			log.Error().Err(err).Msg("error in fetching feeds")
 			return err
 		}
 	}
@@ -202,34 +242,243 @@ func (a *App) initializeCex(ctx context.Context, appConfig AppConfig) error {
 	if len(appConfig.Feeds) > 0 {
 		feeds = appConfig.Feeds
 	}
-	feedMap := common.GetWssFeedMap(feeds)
 
-	a.buffer = make(chan *common.FeedData, appConfig.BufferSize)
-	a.storeInterval = appConfig.StoreInterval
+	return a.ApplyFeeds(ctx, feeds)
+}
 
-	wsProxy := os.Getenv("WS_PROXY")
+func (a *App) initializeCex(appConfig AppConfig) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.cexFactories = appConfig.CexFactories
+	a.cexFetchers = map[string]*cexFetcher{}
+	a.proxy = os.Getenv("WS_PROXY")
+	a.recording = common.GetRecording()
+	a.orderBookProviders = common.OrderBookProviders()
+	a.depthPercent = common.GetDepthPercent()
+}
 
-	for name, factory := range appConfig.CexFactories {
-		if _, ok := feedMap[name]; !ok {
-			log.Warn().Msgf("no feeds for %s", name)
-			continue
-		}
-		fetcher, err := factory(
-			ctx,
-			common.WithFeedDataBuffer(a.buffer),
-			common.WithFeedMaps(feedMap[name]),
-			common.WithProxy(wsProxy),
-		)
+// queryFeeds returns the websocket feeds and the pool feeds of the DEX
+// fetchers currently in the db.
+func (a *App) queryFeeds(ctx context.Context) ([]common.Feed, error) {
+	feeds, err := db.QueryRows[common.Feed](ctx, common.GetAllWebsocketFeedsQuery, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("error in fetching feeds")
+		return nil, err
+	}
+
+	a.mu.Lock()
+	names := slices.Collect(maps.Keys(a.dexFactories))
+	a.mu.Unlock()
+	for _, name := range names {
+		dexFeeds, err := db.QueryRows[common.Feed](ctx, common.GetDexFeedsQuery(name), nil)
 		if err != nil {
-			log.Error().Err(err).Msgf("error in creating %s fetcher", name)
-			return err
+			log.Error().Err(err).Msg("error in fetching feeds")
+			return nil, err
+		}
+		feeds = append(feeds, dexFeeds...)
+	}
+	return feeds, nil
+}
+
+// SyncFeeds applies the websocket and DEX pool feeds currently in the db, see
+// ApplyFeeds.
+func (a *App) SyncFeeds(ctx context.Context) error {
+	feeds, err := a.queryFeeds(ctx)
+	if err != nil {
+		return err
+	}
+	return a.ApplyFeeds(ctx, feeds)
+}
+
+// ApplyFeeds brings the fetchers in line with feeds without dropping the
+// connections of unaffected providers. CEX fetchers with changed feeds update
+// their subscriptions in place, and are restarted alone when they can't. DEX
+// fetchers are restarted when their pool feeds change.
+func (a *App) ApplyFeeds(ctx context.Context, feeds []common.Feed) error {
+	feedMap := common.GetWssFeedMap(feeds)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	errs := []error{}
+	for name, factory := range a.cexFactories {
+		current, running := a.cexFetchers[name]
+		feedMaps, wanted := feedMap[name]
+		switch {
+		case !wanted && !running:
+			log.Debug().Str("Player", "WebsocketFetcher").Msgf("no feeds for %s", name)
+		case !wanted:
+			log.Info().Str("Player", "WebsocketFetcher").Msgf("no feeds left for %s, stopping fetcher", name)
+			a.stopCex(name, current)
+		case !running:
+			if err := a.startCex(ctx, name, factory, feedMaps); err != nil {
+				errs = append(errs, err)
+			}
+		case sameFeeds(current.feedMaps, feedMaps):
+		default:
+			if updater, ok := current.fetcher.(common.FeedUpdater); ok {
+				err := updater.UpdateFeeds(ctx, feedMaps)
+				if err == nil {
+					log.Info().Str("Player", "WebsocketFetcher").Msgf("updated %s feeds in place", name)
+					a.watchdog.Unwatch(removedFeedIDs(current.feedMaps, feedMaps))
+					a.watchdog.Watch(name, current.fetcher, feedIDs(feedMaps))
+					current.feedMaps = feedMaps
+					continue
+				}
+				log.Warn().Str("Player", "WebsocketFetcher").Err(err).Msgf("failed to update %s feeds, restarting fetcher", name)
+			}
+			a.stopCex(name, current)
+			if err := a.startCex(ctx, name, factory, feedMaps); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	a.applyDexFeeds(common.GetDexFeedMap(feeds))
+	return errors.Join(errs...)
+}
+
+func (a *App) applyDexFeeds(feedMap map[string][]common.Feed) {
+	for name, factory := range a.dexFactories {
+		current, running := a.dexFetchers[name]
+		feeds := feedMap[common.DexPoolType(name)]
+		switch {
+		case len(feeds) == 0 && !running:
+			log.Debug().Str("Player", "WebsocketFetcher").Msgf("no feeds for %s", name)
+		case len(feeds) == 0:
+			log.Info().Str("Player", "WebsocketFetcher").Msgf("no feeds left for %s, stopping fetcher", name)
+			a.stopDex(name, current)
+		case !running:
+			a.startDex(name, factory, feeds)
+		case sameDexFeeds(current.feeds, feeds):
+		default:
+			log.Info().Str("Player", "WebsocketFetcher").Msgf("%s feeds changed, restarting fetcher", name)
+			a.stopDex(name, current)
+			a.startDex(name, factory, feeds)
 		}
-		a.fetchers = append(a.fetchers, fetcher)
 	}
+}
+
+func (a *App) startCex(ctx context.Context, name string, factory func(context.Context, ...common.FetcherOption) (common.FetcherInterface, error), feedMaps common.FeedMaps) error {
+	fetcherOptions := []common.FetcherOption{
+		common.WithFeedDataBuffer(a.buffer),
+		common.WithFeedMaps(feedMaps),
+		common.WithProxy(a.proxy),
+		common.WithRecording(a.recording),
+	}
+	if _, ok := a.orderBookProviders[name]; ok {
+		fetcherOptions = append(fetcherOptions, common.WithOrderBook(a.depthPercent))
+	}
+	fetcher, err := factory(ctx, fetcherOptions...)
+	if err != nil {
+		log.Error().Err(err).Msgf("error in creating %s fetcher", name)
+		return err
+	}
+
+	entry := &cexFetcher{fetcher: fetcher, feedMaps: feedMaps}
+	a.cexFetchers[name] = entry
+	if a.running() {
+		entry.cancel = a.run(fetcher)
+	}
+	a.watchdog.Watch(name, fetcher, feedIDs(feedMaps))
 	return nil
 }
 
-func (a *App) initializeDex(ctx context.Context, appConfig AppConfig) error {
+func (a *App) running() bool {
+	return a.runCtx != nil && a.runCtx.Err() == nil
+}
+
+func (a *App) run(fetcher common.FetcherInterface) context.CancelFunc {
+	fetcherCtx, cancel := context.WithCancel(a.runCtx)
+	go fetcher.Run(fetcherCtx)
+	return cancel
+}
+
+func (a *App) stopCex(name string, entry *cexFetcher) {
+	if entry.cancel != nil {
+		entry.cancel()
+	}
+	delete(a.cexFetchers, name)
+	a.watchdog.Unwatch(feedIDs(entry.feedMaps))
+}
+
+// symbolsByFeedID maps each feed id of feedMap to its symbol.
+func symbolsByFeedID(feedMap map[string][]int32) map[int32]string {
+	result := map[int32]string{}
+	for symbol, ids := range feedMap {
+		for _, id := range ids {
+			result[id] = symbol
+		}
+	}
+	return result
+}
+
+func sameFeeds(prev common.FeedMaps, next common.FeedMaps) bool {
+	return maps.Equal(symbolsByFeedID(prev.Combined), symbolsByFeedID(next.Combined)) &&
+		maps.Equal(symbolsByFeedID(prev.Separated), symbolsByFeedID(next.Separated))
+}
+
+func feedIDs(feedMaps common.FeedMaps) []int32 {
+	return slices.Collect(maps.Keys(symbolsByFeedID(feedMaps.Combined)))
+}
+
+func removedFeedIDs(prev common.FeedMaps, next common.FeedMaps) []int32 {
+	nextSymbols := symbolsByFeedID(next.Combined)
+	result := []int32{}
+	for id := range symbolsByFeedID(prev.Combined) {
+		if _, ok := nextSymbols[id]; !ok {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+func (a *App) startDex(name string, factory func(...common.DexFetcherOption) common.FetcherInterface, feeds []common.Feed) {
+	fetcher := factory(
+		common.WithFeeds(feeds),
+		common.WithDexFeedDataBuffer(a.buffer),
+		common.WithWebsocketChainReader(a.chainReader),
+	)
+
+	entry := &dexFetcher{fetcher: fetcher, feeds: feeds}
+	a.dexFetchers[name] = entry
+	if a.running() {
+		entry.cancel = a.run(fetcher)
+	}
+}
+
+func (a *App) stopDex(name string, entry *dexFetcher) {
+	if entry.cancel != nil {
+		entry.cancel()
+	}
+	delete(a.dexFetchers, name)
+}
+
+func sameDexFeeds(prev []common.Feed, next []common.Feed) bool {
+	definitions := func(feeds []common.Feed) map[int32]string {
+		result := make(map[int32]string, len(feeds))
+		for _, feed := range feeds {
+			result[feed.ID] = string(feed.Definition)
+		}
+		return result
+	}
+	return maps.Equal(definitions(prev), definitions(next))
+}
+
+func (a *App) initializeDex(appConfig AppConfig) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.dexFactories = appConfig.DexFactories
+	a.dexFetchers = map[string]*dexFetcher{}
+	if len(appConfig.DexFactories) == 0 {
+		return nil
+	}
+
+	if appConfig.ChainReader != nil {
+		a.chainReader = appConfig.ChainReader
+		return nil
+	}
+
 	kaiaWebsocketUrl := secrets.GetSecret("KAIA_WEBSOCKET_URL")
 	ethWebsocketUrl := secrets.GetSecret("ETH_WEBSOCKET_URL")
 	bscWebsocketUrl := secrets.GetSecret("BSC_WEBSOCKET_URL")
@@ -258,32 +507,29 @@ func (a *App) initializeDex(ctx context.Context, appConfig AppConfig) error {
 		return err
 	}
 	a.chainReader = chainReader
-
-	for name, factory := range appConfig.DexFactories {
-		feeds, err := db.QueryRows[common.Feed](ctx, common.GetDexFeedsQuery(name), nil)
-		if err != nil {
-			log.Error().Err(err).Msg("error in fetching feeds")
-			return err
-		}
-		fetcher := factory(
-			common.WithFeeds(feeds),
-			common.WithDexFeedDataBuffer(a.buffer),
-			common.WithWebsocketChainReader(a.chainReader),
-		)
-
-		a.fetchers = append(a.fetchers, fetcher)
-	}
-
 	return nil
 }
 
+// Start runs the fetchers until Stop, it returns right away if the app is
+// already running.
 func (a *App) Start(ctx context.Context) {
+	a.mu.Lock()
+	if a.running() {
+		a.mu.Unlock()
+		return
+	}
 	ctxWithCancel, cancel := context.WithCancel(ctx)
 	a.cancel = cancel
-
-	for _, fetcher := range a.fetchers {
-		go fetcher.Run(ctxWithCancel)
+	a.runCtx = ctxWithCancel
+	for _, entry := range a.cexFetchers {
+		entry.cancel = a.run(entry.fetcher)
+	}
+	for _, entry := range a.dexFetchers {
+		entry.cancel = a.run(entry.fetcher)
 	}
+	a.mu.Unlock()
+
+	go a.watchdog.Run(ctxWithCancel)
 
 	ticker := time.NewTicker(a.storeInterval)
 	for {
@@ -325,7 +571,16 @@ func (a *App) storeFeedData(ctx context.Context) {
 		if err != nil {
 			log.Error().Err(err).Msg("error in setting latest feed data")
 		}
+		a.watchdog.Observe(batch)
 	default:
 		return
 	}
 }
+
+// FeedStatus returns the watchdog's view of the websocket feeds.
+func (a *App) FeedStatus() []FeedStatus {
+	if a.watchdog == nil {
+		return nil
+	}
+	return a.watchdog.Status()
+}
@@ -0,0 +1,374 @@
+package common
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bisonai.com/miko/node/pkg/common/types"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultDepthPercent is the distance from the mid price, in percent,
+	// within which the depth of a book is summed
+	DefaultDepthPercent = 1.0
+	// BookMaxAge is how long a book may go without updates before it's
+	// no longer attached to feed data
+	BookMaxAge = 30 * time.Second
+	// MaxPendingDiffs caps the diffs buffered per book while its snapshot
+	// is fetched, the oldest are dropped beyond it
+	MaxPendingDiffs = 1000
+	// SnapshotBackoff is the delay before fetching the snapshot of a book
+	// again after a failure, doubled on every failure up to MaxSnapshotBackoff
+	SnapshotBackoff    = time.Second
+	MaxSnapshotBackoff = time.Minute
+)
+
+// Diff applies a diff to a synced book, failing on a gap.
+type Diff func(*OrderBook) error
+
+// SnapshotFunc fetches the snapshot of symbol and returns the func building
+// the book from it.
+type SnapshotFunc func(ctx context.Context, symbol string) (func(*OrderBook) error, error)
+
+type BookData = types.BookData
+
+// Level is a price level of an order book.
+type Level struct {
+	Price float64
+	Size  float64
+}
+
+// OrderBook is a local L2 book kept from a snapshot and the diffs following
+// it. It isn't safe for concurrent use, OrderBooks guards the books it holds.
+type OrderBook struct {
+	bids map[float64]float64
+	asks map[float64]float64
+	// Sequence is the exchange's id of the last snapshot or diff applied
+	Sequence  int64
+	UpdatedAt time.Time
+	synced    bool
+	// firstDiff is set until a diff is applied on top of the snapshot
+	firstDiff bool
+
+	// pending holds the diffs received while the snapshot is fetched
+	pending  []Diff
+	fetching bool
+	failures int
+	retryAt  time.Time
+}
+
+func NewOrderBook() *OrderBook {
+	return &OrderBook{
+		bids: map[float64]float64{},
+		asks: map[float64]float64{},
+	}
+}
+
+// Snapshot replaces the book with bids and asks.
+func (b *OrderBook) Snapshot(bids []Level, asks []Level, sequence int64) {
+	b.bids = make(map[float64]float64, len(bids))
+	b.asks = make(map[float64]float64, len(asks))
+	b.synced = true
+	b.Update(bids, asks, sequence)
+	b.firstDiff = true
+}
+
+// Update applies a diff to the book, levels of size 0 are removed.
+func (b *OrderBook) Update(bids []Level, asks []Level, sequence int64) {
+	apply(b.bids, bids)
+	apply(b.asks, asks)
+	b.Sequence = sequence
+	b.UpdatedAt = time.Now()
+	b.firstDiff = false
+}
+
+// FirstDiff reports whether no diff was applied since the snapshot, for
+// exchanges whose first diff has to overlap the snapshot. The gaps between
+// diffs are the SequenceTracker's to detect.
+func (b *OrderBook) FirstDiff() bool {
+	return b.firstDiff
+}
+
+func apply(side map[float64]float64, levels []Level) {
+	for _, level := range levels {
+		if level.Size == 0 {
+			delete(side, level.Price)
+			continue
+		}
+		side[level.Price] = level.Size
+	}
+}
+
+// Reset empties the book until its next snapshot, after a gap in its diffs.
+func (b *OrderBook) Reset() {
+	b.bids = map[float64]float64{}
+	b.asks = map[float64]float64{}
+	b.Sequence = 0
+	b.synced = false
+	b.firstDiff = false
+	b.pending = nil
+}
+
+// Synced reports whether the book was built from a snapshot.
+func (b *OrderBook) Synced() bool {
+	return b.synced
+}
+
+// Truncate keeps the depth best levels of each side, for exchanges expecting
+// the book to be cut at the subscribed depth and to keep books fed by
+// unbounded diffs at the depth of their snapshot.
+func (b *OrderBook) Truncate(depth int) {
+	truncate(b.bids, depth, func(a, c float64) int { return cmp.Compare(c, a) })
+	truncate(b.asks, depth, cmp.Compare[float64])
+}
+
+// truncate deletes the levels of side past depth, best first by compare.
+func truncate(side map[float64]float64, depth int, compare func(float64, float64) int) {
+	if len(side) <= depth {
+		return
+	}
+	prices := slices.SortedFunc(maps.Keys(side), compare)
+	for _, price := range prices[depth:] {
+		delete(side, price)
+	}
+}
+
+func (b *OrderBook) BestBid() (Level, bool) {
+	best := Level{}
+	for price, size := range b.bids {
+		if price > best.Price {
+			best = Level{Price: price, Size: size}
+		}
+	}
+	return best, best.Price > 0
+}
+
+func (b *OrderBook) BestAsk() (Level, bool) {
+	best := Level{}
+	for price, size := range b.asks {
+		if best.Price == 0 || price < best.Price {
+			best = Level{Price: price, Size: size}
+		}
+	}
+	return best, best.Price > 0
+}
+
+// Stats returns the mid price, spread and depth within depthPercent of the
+// mid. It fails for books that aren't synced, miss a side or are crossed.
+func (b *OrderBook) Stats(depthPercent float64) (*BookData, bool) {
+	if !b.synced {
+		return nil, false
+	}
+	bid, ok := b.BestBid()
+	if !ok {
+		return nil, false
+	}
+	ask, ok := b.BestAsk()
+	if !ok || bid.Price >= ask.Price {
+		return nil, false
+	}
+
+	mid := (bid.Price + ask.Price) / 2
+	result := &BookData{
+		Mid:          mid,
+		Spread:       ask.Price - bid.Price,
+		DepthPercent: depthPercent,
+	}
+	lowest := mid * (1 - depthPercent/100)
+	highest := mid * (1 + depthPercent/100)
+	for price, size := range b.bids {
+		if price >= lowest {
+			result.BidDepth += size
+		}
+	}
+	for price, size := range b.asks {
+		if price <= highest {
+			result.AskDepth += size
+		}
+	}
+	return result, true
+}
+
+// OrderBooks holds the books of a fetcher by the symbol the exchange uses.
+type OrderBooks struct {
+	books        map[string]*OrderBook
+	depthPercent float64
+	mu           sync.Mutex
+}
+
+func NewOrderBooks(depthPercent float64) *OrderBooks {
+	if depthPercent <= 0 {
+		depthPercent = DefaultDepthPercent
+	}
+	return &OrderBooks{
+		books:        map[string]*OrderBook{},
+		depthPercent: depthPercent,
+	}
+}
+
+// Apply runs fn on the book of symbol, creating it if needed.
+func (o *OrderBooks) Apply(symbol string, fn func(*OrderBook)) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	book, ok := o.books[symbol]
+	if !ok {
+		book = NewOrderBook()
+		o.books[symbol] = book
+	}
+	fn(book)
+}
+
+// ApplyDiff applies diff to the book of symbol once it's synced. Until then
+// diffs are buffered and the snapshot is fetched with fetch off the caller's
+// goroutine, one fetch at a time per symbol and not before its backoff after
+// a failure. A gap resets the book, the next diff fetches a new snapshot.
+func (o *OrderBooks) ApplyDiff(ctx context.Context, symbol string, diff Diff, fetch SnapshotFunc) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	book, ok := o.books[symbol]
+	if !ok {
+		book = NewOrderBook()
+		o.books[symbol] = book
+	}
+
+	if book.synced {
+		if err := diff(book); err != nil {
+			book.Reset()
+			return err
+		}
+		return nil
+	}
+
+	if len(book.pending) >= MaxPendingDiffs {
+		book.pending = book.pending[1:]
+	}
+	book.pending = append(book.pending, diff)
+	if !book.fetching && !time.Now().Before(book.retryAt) {
+		book.fetching = true
+		go o.fetchSnapshot(ctx, symbol, fetch)
+	}
+	return nil
+}
+
+func (o *OrderBooks) fetchSnapshot(ctx context.Context, symbol string, fetch SnapshotFunc) {
+	build, err := fetch(ctx, symbol)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	book, ok := o.books[symbol]
+	if !ok {
+		return
+	}
+	book.fetching = false
+	if err == nil {
+		err = book.sync(build)
+	}
+	if err != nil {
+		book.failures++
+		backoff := min(SnapshotBackoff<<min(book.failures-1, 6), MaxSnapshotBackoff)
+		book.retryAt = time.Now().Add(backoff)
+		log.Warn().Str("Player", "OrderBook").Err(err).Str("symbol", symbol).Dur("backoff", backoff).Msg("failed to sync order book")
+		return
+	}
+	book.failures = 0
+}
+
+// sync builds the book with build and replays the pending diffs on it.
+func (b *OrderBook) sync(build func(*OrderBook) error) error {
+	pending := b.pending
+	b.pending = nil
+	if err := build(b); err != nil {
+		b.Reset()
+		return err
+	}
+	for _, diff := range pending {
+		if err := diff(b); err != nil {
+			b.Reset()
+			return err
+		}
+	}
+	return nil
+}
+
+// Stats returns the stats of the book of symbol if it's synced and recent.
+func (o *OrderBooks) Stats(symbol string) (*BookData, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	book, ok := o.books[symbol]
+	if !ok || time.Since(book.UpdatedAt) > BookMaxAge {
+		return nil, false
+	}
+	return book.Stats(o.depthPercent)
+}
+
+// Attach sets the book stats of symbol on feedDataList. It's a no-op for
+// fetchers without books.
+func (o *OrderBooks) Attach(symbol string, feedDataList []*FeedData) {
+	if o == nil {
+		return
+	}
+	stats, ok := o.Stats(symbol)
+	if !ok {
+		return
+	}
+	for _, feedData := range feedDataList {
+		feedData.Book = stats
+	}
+}
+
+// ParseLevels parses the [price, size, ...] string arrays most exchanges send
+// their levels as.
+func ParseLevels(raw [][]string) ([]Level, error) {
+	levels := make([]Level, 0, len(raw))
+	for _, entry := range raw {
+		if len(entry) < 2 {
+			continue
+		}
+		price, err := strconv.ParseFloat(entry[0], 64)
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.ParseFloat(entry[1], 64)
+		if err != nil {
+			return nil, err
+		}
+		levels = append(levels, Level{Price: price, Size: size})
+	}
+	return levels, nil
+}
+
+// OrderBookProviders returns the providers to keep order books for, read
+// from WS_ORDERBOOK_PROVIDERS as comma separated names.
+func OrderBookProviders() map[string]struct{} {
+	result := map[string]struct{}{}
+	for _, name := range strings.Split(os.Getenv("WS_ORDERBOOK_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" {
+			result[name] = struct{}{}
+		}
+	}
+	return result
+}
+
+// GetDepthPercent returns WS_ORDERBOOK_DEPTH_PERCENT, falling back to
+// DefaultDepthPercent if it's unset or invalid.
+func GetDepthPercent() float64 {
+	raw := os.Getenv("WS_ORDERBOOK_DEPTH_PERCENT")
+	if raw == "" {
+		return DefaultDepthPercent
+	}
+	percent, err := strconv.ParseFloat(raw, 64)
+	if err != nil || percent <= 0 {
+		log.Warn().Str("WS_ORDERBOOK_DEPTH_PERCENT", raw).Msg("invalid depth percent, using default")
+		return DefaultDepthPercent
+	}
+	return percent
+}
@@ -0,0 +1,166 @@
+//nolint:all
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderBook(t *testing.T) {
+	book := NewOrderBook()
+	_, ok := book.Stats(1)
+	assert.False(t, ok, "unsynced book has no stats")
+
+	book.Snapshot(
+		[]Level{{Price: 99, Size: 1}, {Price: 98.5, Size: 2}, {Price: 90, Size: 5}},
+		[]Level{{Price: 101, Size: 1}, {Price: 101.5, Size: 3}, {Price: 110, Size: 5}},
+		10)
+
+	stats, ok := book.Stats(2)
+	assert.True(t, ok)
+	assert.Equal(t, 100.0, stats.Mid)
+	assert.Equal(t, 2.0, stats.Spread)
+	assert.Equal(t, 3.0, stats.BidDepth)
+	assert.Equal(t, 4.0, stats.AskDepth)
+	assert.Equal(t, 2.0, stats.DepthPercent)
+
+	book.Update([]Level{{Price: 99, Size: 0}, {Price: 99.5, Size: 4}}, []Level{{Price: 101, Size: 0}}, 11)
+	bid, _ := book.BestBid()
+	ask, _ := book.BestAsk()
+	assert.Equal(t, Level{Price: 99.5, Size: 4}, bid)
+	assert.Equal(t, Level{Price: 101.5, Size: 3}, ask)
+	assert.Equal(t, int64(11), book.Sequence)
+
+	book.Truncate(1)
+	stats, _ = book.Stats(100)
+	assert.Equal(t, 4.0, stats.BidDepth)
+	assert.Equal(t, 3.0, stats.AskDepth)
+
+	book.Update([]Level{{Price: 102, Size: 1}}, nil, 12)
+	_, ok = book.Stats(1)
+	assert.False(t, ok, "crossed book has no stats")
+
+	book.Reset()
+	assert.False(t, book.Synced())
+	assert.Equal(t, int64(0), book.Sequence)
+}
+
+func TestOrderBooksAttach(t *testing.T) {
+	var none *OrderBooks
+	feedData := []*FeedData{{FeedID: 1}, {FeedID: 2}}
+	none.Attach("BTCUSDT", feedData)
+	assert.Nil(t, feedData[0].Book)
+
+	books := NewOrderBooks(0)
+	books.Attach("BTCUSDT", feedData)
+	assert.Nil(t, feedData[0].Book, "no book yet")
+
+	books.Apply("BTCUSDT", func(book *OrderBook) {
+		book.Snapshot([]Level{{Price: 99, Size: 1}}, []Level{{Price: 101, Size: 1}}, 1)
+	})
+	books.Attach("BTCUSDT", feedData)
+	assert.NotNil(t, feedData[1].Book)
+	assert.Equal(t, 100.0, feedData[1].Book.Mid)
+	assert.Equal(t, DefaultDepthPercent, feedData[1].Book.DepthPercent)
+
+	books.Apply("BTCUSDT", func(book *OrderBook) {
+		book.UpdatedAt = time.Now().Add(-BookMaxAge - time.Second)
+	})
+	_, ok := books.Stats("BTCUSDT")
+	assert.False(t, ok, "stale book has no stats")
+}
+
+func TestParseLevels(t *testing.T) {
+	levels, err := ParseLevels([][]string{{"100.5", "2"}, {"99", "0", "0", "1"}, {"1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []Level{{Price: 100.5, Size: 2}, {Price: 99, Size: 0}}, levels)
+
+	_, err = ParseLevels([][]string{{"abc", "1"}})
+	assert.Error(t, err)
+}
+
+func TestOrderBooksApplyDiff(t *testing.T) {
+	ctx := context.Background()
+	diff := func(first, last int64) Diff {
+		return func(book *OrderBook) error {
+			if last <= book.Sequence {
+				return nil
+			}
+			if first > book.Sequence+1 {
+				return fmt.Errorf("gap: expected %d, got %d", book.Sequence+1, first)
+			}
+			book.Update(nil, nil, last)
+			return nil
+		}
+	}
+	sequence := func(books *OrderBooks) (int64, bool) {
+		var result int64
+		var synced bool
+		books.Apply("BTCUSDT", func(book *OrderBook) {
+			result, synced = book.Sequence, book.Synced()
+		})
+		return result, synced
+	}
+
+	fetches := atomic.Int32{}
+	release := make(chan error)
+	fetch := func(ctx context.Context, symbol string) (func(*OrderBook) error, error) {
+		fetches.Add(1)
+		if err := <-release; err != nil {
+			return nil, err
+		}
+		return func(book *OrderBook) error {
+			book.Snapshot([]Level{{Price: 99, Size: 1}}, []Level{{Price: 101, Size: 1}}, 5)
+			return nil
+		}, nil
+	}
+
+	books := NewOrderBooks(0)
+	assert.NoError(t, books.ApplyDiff(ctx, "BTCUSDT", diff(1, 4), fetch), "doesn't wait for the snapshot")
+	assert.NoError(t, books.ApplyDiff(ctx, "BTCUSDT", diff(5, 7), fetch))
+	assert.NoError(t, books.ApplyDiff(ctx, "BTCUSDT", diff(8, 8), fetch))
+	assert.Eventually(t, func() bool { return fetches.Load() == 1 }, time.Second, time.Millisecond)
+
+	release <- nil
+	assert.Eventually(t, func() bool {
+		result, synced := sequence(books)
+		return synced && result == 8
+	}, time.Second, time.Millisecond, "buffered diffs are replayed on the snapshot")
+	assert.Equal(t, int32(1), fetches.Load(), "a single fetch while pending")
+
+	assert.Error(t, books.ApplyDiff(ctx, "BTCUSDT", diff(20, 21), fetch))
+	_, synced := sequence(books)
+	assert.False(t, synced, "gap resets the book")
+
+	assert.NoError(t, books.ApplyDiff(ctx, "BTCUSDT", diff(22, 23), fetch))
+	release <- errors.New("rate limited")
+	assert.Eventually(t, func() bool {
+		fetching := true
+		books.Apply("BTCUSDT", func(book *OrderBook) { fetching = book.fetching })
+		return !fetching
+	}, time.Second, time.Millisecond)
+	assert.NoError(t, books.ApplyDiff(ctx, "BTCUSDT", diff(24, 25), fetch))
+	assert.Equal(t, int32(2), fetches.Load(), "failed snapshot backs off")
+
+	books.Apply("BTCUSDT", func(book *OrderBook) {
+		assert.Equal(t, 1, book.failures)
+		book.retryAt = time.Now()
+	})
+	assert.NoError(t, books.ApplyDiff(ctx, "BTCUSDT", diff(26, 27), fetch))
+	assert.Eventually(t, func() bool { return fetches.Load() == 3 }, time.Second, time.Millisecond, "retried after the backoff")
+	release <- errors.New("rate limited")
+	assert.Eventually(t, func() bool {
+		var failures int
+		books.Apply("BTCUSDT", func(book *OrderBook) { failures = book.failures })
+		return failures == 2
+	}, time.Second, time.Millisecond)
+	books.Apply("BTCUSDT", func(book *OrderBook) {
+		assert.InDelta(t, float64(2*SnapshotBackoff), float64(time.Until(book.retryAt)), float64(100*time.Millisecond))
+	})
+}
@@ -0,0 +1,151 @@
+package common
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"bisonai.com/miko/node/pkg/wss"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	sequenceGapsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "websocketfetcher_sequence_gaps_total",
+		Help: "Total number of gaps detected in the sequence ids of a stream",
+	}, []string{"provider", "stream"})
+	sequenceStaleTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "websocketfetcher_sequence_stale_total",
+		Help: "Total number of messages dropped for a sequence id at or behind the last one of their stream",
+	}, []string{"provider", "stream"})
+	resubscriptionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "websocketfetcher_resubscriptions_total",
+		Help: "Total number of resubscriptions forced by sequence gaps, by result",
+	}, []string{"provider", "stream", "result"})
+)
+
+// Sequence is the range of sequence ids a message covers on its stream.
+type Sequence struct {
+	Stream string
+	First  int64
+	Last   int64
+	// Snapshot messages restart their stream at Last
+	Snapshot bool
+	// Contiguous streams number every update, so a First past the previous
+	// Last means updates were lost. Other streams only have to increase.
+	Contiguous bool
+}
+
+// Sequencer is implemented by fetchers whose streams carry sequence ids.
+type Sequencer interface {
+	// Sequence returns the sequence of message, ok is false for messages
+	// without one
+	Sequence(message map[string]any) (Sequence, bool)
+	// Resubscribe gets the stream back in sync after a gap, usually by
+	// subscribing to it again so the exchange resends its snapshot
+	Resubscribe(ctx context.Context, stream string) error
+}
+
+type sequenceResult int
+
+const (
+	sequenceOk sequenceResult = iota
+	sequenceStale
+	sequenceGap
+)
+
+// SequenceTracker checks the sequence ids of the messages of a fetcher before
+// they're routed. Messages behind their stream are dropped, a gap drops the
+// message and resubscribes its stream, falling back to a reconnect.
+type SequenceTracker struct {
+	provider  string
+	sequencer Sequencer
+	ws        *wss.WebsocketHelper
+	last      map[string]int64
+	mu        sync.Mutex
+}
+
+func NewSequenceTracker(provider string, sequencer Sequencer, ws *wss.WebsocketHelper) *SequenceTracker {
+	return &SequenceTracker{
+		provider:  provider,
+		sequencer: sequencer,
+		ws:        ws,
+		last:      map[string]int64{},
+	}
+}
+
+// Route wraps router with the sequence checks.
+func (t *SequenceTracker) Route(router func(context.Context, map[string]any) error) func(context.Context, map[string]any) error {
+	return func(ctx context.Context, message map[string]any) error {
+		sequence, ok := t.sequencer.Sequence(message)
+		if !ok {
+			return router(ctx, message)
+		}
+
+		switch t.check(sequence) {
+		case sequenceStale:
+			sequenceStaleTotal.WithLabelValues(t.provider, sequence.Stream).Inc()
+			return nil
+		case sequenceGap:
+			sequenceGapsTotal.WithLabelValues(t.provider, sequence.Stream).Inc()
+			t.resubscribe(ctx, sequence.Stream)
+			return nil
+		}
+		return router(ctx, message)
+	}
+}
+
+func (t *SequenceTracker) check(sequence Sequence) sequenceResult {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	last, ok := t.last[sequence.Stream]
+	switch {
+	case sequence.Snapshot || !ok:
+	case sequence.Last <= last:
+		return sequenceStale
+	case sequence.Contiguous && sequence.First > last+1:
+		log.Warn().Str("Player", t.provider).Str("stream", sequence.Stream).Int64("expected", last+1).Int64("got", sequence.First).Msg("gap in stream sequence")
+		delete(t.last, sequence.Stream)
+		return sequenceGap
+	}
+	t.last[sequence.Stream] = sequence.Last
+	return sequenceOk
+}
+
+func (t *SequenceTracker) resubscribe(ctx context.Context, stream string) {
+	err := t.sequencer.Resubscribe(ctx, stream)
+	if err == nil {
+		resubscriptionsTotal.WithLabelValues(t.provider, stream, "ok").Inc()
+		return
+	}
+
+	resubscriptionsTotal.WithLabelValues(t.provider, stream, "failed").Inc()
+	log.Warn().Str("Player", t.provider).Err(err).Str("stream", stream).Msg("failed to resubscribe, reconnecting")
+	t.Reset()
+	if t.ws != nil {
+		t.ws.Reconnect()
+	}
+}
+
+// Reset forgets the sequences of all streams.
+func (t *SequenceTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.last = map[string]int64{}
+}
+
+// SequenceID reads a sequence id from a decoded json value, which exchanges
+// send either as a number or a string.
+func SequenceID(value any) (int64, bool) {
+	switch casted := value.(type) {
+	case float64:
+		return int64(casted), true
+	case string:
+		id, err := strconv.ParseInt(casted, 10, 64)
+		return id, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,97 @@
+//nolint:all
+package common
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testSequencer struct {
+	resubscribed []string
+	err          error
+}
+
+func (s *testSequencer) Sequence(message map[string]any) (Sequence, bool) {
+	first, ok := SequenceID(message["first"])
+	if !ok {
+		return Sequence{}, false
+	}
+	last, _ := SequenceID(message["last"])
+	return Sequence{
+		Stream:     message["stream"].(string),
+		First:      first,
+		Last:       last,
+		Snapshot:   message["snapshot"] == true,
+		Contiguous: message["contiguous"] != false,
+	}, true
+}
+
+func (s *testSequencer) Resubscribe(ctx context.Context, stream string) error {
+	s.resubscribed = append(s.resubscribed, stream)
+	return s.err
+}
+
+func TestSequenceTracker(t *testing.T) {
+	sequencer := &testSequencer{}
+	routed := []float64{}
+	route := NewSequenceTracker("Test", sequencer, nil).Route(func(ctx context.Context, message map[string]any) error {
+		if last, ok := message["last"].(float64); ok {
+			routed = append(routed, last)
+		}
+		return nil
+	})
+	send := func(message map[string]any) {
+		assert.NoError(t, route(context.Background(), message))
+	}
+
+	send(map[string]any{"stream": "a", "first": 1.0, "last": 3.0})
+	send(map[string]any{"stream": "a", "first": 2.0, "last": 5.0})
+	send(map[string]any{"stream": "a", "first": 4.0, "last": 5.0})
+	send(map[string]any{"stream": "b", "first": 100.0, "last": 100.0})
+	send(map[string]any{"stream": "a", "first": 8.0, "last": 9.0})
+	assert.Equal(t, []float64{3, 5, 100}, routed, "stale and gapped messages are dropped")
+	assert.Equal(t, []string{"a"}, sequencer.resubscribed)
+
+	send(map[string]any{"stream": "a", "first": 20.0, "last": 21.0})
+	send(map[string]any{"stream": "a", "first": 1.0, "last": 2.0, "snapshot": true})
+	send(map[string]any{"stream": "a", "first": 3.0, "last": 3.0})
+	assert.Equal(t, []float64{3, 5, 100, 21, 2, 3}, routed, "gapped streams and snapshots restart the sequence")
+
+	send(map[string]any{"stream": "c", "first": 10.0, "last": 10.0, "contiguous": false})
+	send(map[string]any{"stream": "c", "first": 50.0, "last": 50.0, "contiguous": false})
+	send(map[string]any{"stream": "c", "first": 40.0, "last": 40.0, "contiguous": false})
+	send(map[string]any{"unsequenced": true})
+	assert.Equal(t, []float64{3, 5, 100, 21, 2, 3, 10, 50}, routed)
+	assert.Equal(t, []string{"a"}, sequencer.resubscribed)
+}
+
+func TestSequenceTrackerResubscribeFailure(t *testing.T) {
+	sequencer := &testSequencer{err: errors.New("closed")}
+	tracker := NewSequenceTracker("Test", sequencer, nil)
+	route := tracker.Route(func(ctx context.Context, message map[string]any) error { return nil })
+
+	route(context.Background(), map[string]any{"stream": "a", "first": 1.0, "last": 1.0})
+	route(context.Background(), map[string]any{"stream": "b", "first": 1.0, "last": 1.0})
+	route(context.Background(), map[string]any{"stream": "a", "first": 5.0, "last": 5.0})
+
+	assert.Equal(t, []string{"a"}, sequencer.resubscribed)
+	assert.Empty(t, tracker.last, "failed resubscription forgets every stream before reconnecting")
+}
+
+func TestSequenceID(t *testing.T) {
+	id, ok := SequenceID(1234567890123.0)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1234567890123), id)
+
+	id, ok = SequenceID("42")
+	assert.True(t, ok)
+	assert.Equal(t, int64(42), id)
+
+	_, ok = SequenceID("abc")
+	assert.False(t, ok)
+	_, ok = SequenceID(nil)
+	assert.False(t, ok)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strconv"
 	"sync"
 	"time"
 	"unicode"
@@ -11,6 +12,7 @@ import (
 	"bisonai.com/miko/node/pkg/chain/websocketchainreader"
 	"bisonai.com/miko/node/pkg/common/types"
 	"bisonai.com/miko/node/pkg/wss"
+	"github.com/rs/zerolog/log"
 )
 
 const (
@@ -29,6 +31,15 @@ const (
 	// while the poll guarantees a fresh timestamp (and value, for low
 	// volume pools that rarely swap) on a predictable cadence.
 	DefaultDexPollInterval = 30 * time.Second
+
+	// RecordDirEnv records the frames of every CEX fetcher to a file per
+	// endpoint in the directory, see wss.RecordingFile
+	RecordDirEnv = "WSS_RECORD_DIR"
+	// ReplayDirEnv replays the recordings in the directory instead of dialing
+	// the endpoints that have one
+	ReplayDirEnv = "WSS_REPLAY_DIR"
+	// ReplaySpeedEnv scales the replay speed, 0 replays without delays
+	ReplaySpeedEnv = "WSS_REPLAY_SPEED"
 )
 
 // GetDexPollInterval returns the configured DEX poll interval, falling back to
@@ -45,12 +56,38 @@ func GetDexPollInterval() time.Duration {
 	return d
 }
 
+// GetRecording returns the recording the CEX fetchers are created with, read
+// from WSS_RECORD_DIR, WSS_REPLAY_DIR and WSS_REPLAY_SPEED. The speed falls
+// back to wss.DefaultReplaySpeed if it's unset or invalid.
+func GetRecording() wss.Recording {
+	recording := wss.Recording{
+		RecordDir:   os.Getenv(RecordDirEnv),
+		ReplayDir:   os.Getenv(ReplayDirEnv),
+		ReplaySpeed: wss.DefaultReplaySpeed,
+	}
+	raw := os.Getenv(ReplaySpeedEnv)
+	if raw == "" {
+		return recording
+	}
+	speed, err := strconv.ParseFloat(raw, 64)
+	if err != nil || speed < 0 {
+		log.Warn().Str(ReplaySpeedEnv, raw).Msg("invalid replay speed, using default")
+		return recording
+	}
+	recording.ReplaySpeed = speed
+	return recording
+}
+
 type Feed = types.Feed
 type FeedData = types.FeedData
 
 func GetDexFeedsQuery(name string) string {
-	name = capitalizeFirstLetter(name)
-	return fmt.Sprintf(`SELECT * FROM public.feeds WHERE definition::jsonb @> '{"type": "%sPool"}'::jsonb;`, name)
+	return fmt.Sprintf(`SELECT * FROM public.feeds WHERE definition::jsonb @> '{"type": "%s"}'::jsonb;`, DexPoolType(name))
+}
+
+// DexPoolType is the definition type of the feeds of the DEX fetcher name.
+func DexPoolType(name string) string {
+	return capitalizeFirstLetter(name) + "Pool"
 }
 
 type FeedDefinition struct {
@@ -88,6 +125,12 @@ type FetcherConfig struct {
 	FeedMaps       FeedMaps
 	Proxy          string
 	FeedDataBuffer chan *FeedData
+	// OrderBook subscribes to the order books of the feeds, on the providers
+	// supporting it
+	OrderBook    bool
+	DepthPercent float64
+	// Recording records the frames of the fetcher or replays them
+	Recording wss.Recording
 }
 
 type DexFetcherConfig struct {
@@ -121,6 +164,21 @@ func WithFeedDataBuffer(feedDataBuffer chan *FeedData) FetcherOption {
 	}
 }
 
+// WithOrderBook keeps the order books of the feeds and attaches their stats,
+// with depth summed within depthPercent of the mid price.
+func WithOrderBook(depthPercent float64) FetcherOption {
+	return func(c *FetcherConfig) {
+		c.OrderBook = true
+		c.DepthPercent = depthPercent
+	}
+}
+
+func WithRecording(recording wss.Recording) FetcherOption {
+	return func(c *FetcherConfig) {
+		c.Recording = recording
+	}
+}
+
 type DexFetcherOption func(*DexFetcherConfig)
 
 func WithFeeds(feeds []Feed) DexFetcherOption {
@@ -142,10 +200,16 @@ func WithDexFeedDataBuffer(feedDataBuffer chan *FeedData) DexFetcherOption {
 }
 
 type Fetcher struct {
-	FeedMap        map[string][]int32
+	FeedMap map[string][]int32
+	// FeedMapMu guards FeedMap for fetchers updating their feeds in place,
+	// which replace the map instead of modifying it
+	FeedMapMu      sync.RWMutex
 	Ws             *wss.WebsocketHelper
 	FeedDataBuffer chan *FeedData
 	VolumeCacheMap VolumeCacheMap
+	// Books is nil unless the fetcher was created WithOrderBook
+	Books *OrderBooks
+	Proxy string
 }
 
 type DexFetcher struct {
@@ -160,6 +224,19 @@ type FetcherInterface interface {
 	Run(context.Context)
 }
 
+// FeedUpdater is implemented by fetchers that can subscribe to the feeds of
+// feedMaps they don't have yet and drop the ones no longer listed, keeping
+// their connection. Other fetchers are restarted with the new feeds.
+type FeedUpdater interface {
+	UpdateFeeds(ctx context.Context, feedMaps FeedMaps) error
+}
+
+// FeedResubscriber is implemented by fetchers that can resubscribe the symbol
+// of a single feed, without dropping the connection the other symbols use.
+type FeedResubscriber interface {
+	ResubscribeFeed(ctx context.Context, feedID int32) error
+}
+
 type VolumeCache struct {
 	UpdatedAt time.Time
 	Volume    float64
@@ -5,12 +5,33 @@ import (
 	"compress/gzip"
 	"encoding/json"
 	"io"
+	"maps"
+	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/rs/zerolog/log"
 )
 
+// GetDexFeedMap groups the DEX pool feeds of feeds by their definition type,
+// see DexPoolType.
+func GetDexFeedMap(feeds []Feed) map[string][]Feed {
+	result := map[string][]Feed{}
+	for _, feed := range feeds {
+		var def DexFeedDefinition
+		err := json.Unmarshal(feed.Definition, &def)
+		if err != nil {
+			log.Warn().Err(err).Msg("failed to unmarshal definition")
+			continue
+		}
+		if !strings.HasSuffix(def.Type, "Pool") {
+			continue
+		}
+		result[def.Type] = append(result[def.Type], feed)
+	}
+	return result
+}
+
 /*
 Generates two types of maps with different keys for the same feed ID.
 The combined map has keys like "BTCUSD", and the separated map has keys like "BTC-USD".
@@ -48,6 +69,39 @@ func GetWssFeedMap(feeds []Feed) map[string]FeedMaps {
 	return feedMaps
 }
 
+// FeedSymbol returns the key of feedMap holding feedID.
+func FeedSymbol(feedMap map[string][]int32, feedID int32) (string, bool) {
+	for symbol, feedIDs := range feedMap {
+		if slices.Contains(feedIDs, feedID) {
+			return symbol, true
+		}
+	}
+	return "", false
+}
+
+// FeedSymbols returns the sorted keys of feedMap.
+func FeedSymbols(feedMap map[string][]int32) []string {
+	return slices.Sorted(maps.Keys(feedMap))
+}
+
+// DiffFeedMap returns the sorted symbols of next missing from prev and the
+// ones of prev missing from next.
+func DiffFeedMap(prev map[string][]int32, next map[string][]int32) (added []string, removed []string) {
+	for symbol := range next {
+		if _, ok := prev[symbol]; !ok {
+			added = append(added, symbol)
+		}
+	}
+	for symbol := range prev {
+		if _, ok := next[symbol]; !ok {
+			removed = append(removed, symbol)
+		}
+	}
+	slices.Sort(added)
+	slices.Sort(removed)
+	return added, removed
+}
+
 func PriceStringToFloat64(price string) (float64, error) {
 	return strconv.ParseFloat(price, 64)
 }
@@ -2,8 +2,12 @@ package binance
 
 import (
 	"context"
+	"fmt"
 	"strings"
+	"time"
 
+	errorSentinel "bisonai.com/miko/node/pkg/error"
+	"bisonai.com/miko/node/pkg/utils/request"
 	"bisonai.com/miko/node/pkg/websocketfetcher/common"
 	"bisonai.com/miko/node/pkg/wss"
 	"github.com/rs/zerolog/log"
@@ -21,17 +25,22 @@ func New(ctx context.Context, opts ...common.FetcherOption) (common.FetcherInter
 	fetcher := &BinanceFetcher{}
 	fetcher.FeedMap = config.FeedMaps.Combined
 	fetcher.FeedDataBuffer = config.FeedDataBuffer
+	fetcher.Proxy = config.Proxy
 
-	streams := []Stream{}
-	for feed := range fetcher.FeedMap {
-		streams = append(streams, Stream(strings.ToLower(feed)+"@miniTicker"))
-	}
-	subscription := Subscription{"SUBSCRIBE", streams, 1}
+	subscription := Subscription{"SUBSCRIBE", Streams(common.FeedSymbols(fetcher.FeedMap), config.OrderBook), 1}
 
-	ws, err := wss.NewWebsocketHelper(ctx,
+	wsOptions := []wss.ConnectionOption{
 		wss.WithEndpoint(URL),
 		wss.WithSubscriptions([]any{subscription}),
-		wss.WithProxyUrl(config.Proxy))
+		wss.WithProxyUrl(config.Proxy),
+		wss.WithRecording(config.Recording),
+	}
+	if config.OrderBook {
+		fetcher.Books = common.NewOrderBooks(config.DepthPercent)
+		wsOptions = append(wsOptions, wss.WithSequentialRouting())
+	}
+
+	ws, err := wss.NewWebsocketHelper(ctx, wsOptions...)
 	if err != nil {
 		log.Error().Str("Player", "Binance").Err(err).Msg("error in binance.New")
 		return nil, err
@@ -42,6 +51,10 @@ func New(ctx context.Context, opts ...common.FetcherOption) (common.FetcherInter
 }
 
 func (b *BinanceFetcher) handleMessage(ctx context.Context, message map[string]any) error {
+	if message["e"] == "depthUpdate" {
+		return b.handleDepthUpdate(ctx, message)
+	}
+
 	ticker, err := common.MessageToStruct[MiniTicker](message)
 	if err != nil {
 		log.Error().Str("Player", "Binance").Err(err).Msg("error in MessageToTicker")
@@ -52,12 +65,13 @@ func (b *BinanceFetcher) handleMessage(ctx context.Context, message map[string]a
 		return nil
 	}
 
-	feedDataList, err := TickerToFeedData(ticker, b.FeedMap)
+	feedDataList, err := TickerToFeedData(ticker, b.feedMap())
 	if err != nil {
 		log.Error().Str("Player", "Binance").Err(err).Msg("error in MiniTickerToFeedData")
 		return err
 	}
 
+	b.Books.Attach(ticker.Symbol, feedDataList)
 	for _, feedData := range feedDataList {
 		b.FeedDataBuffer <- feedData
 	}
@@ -65,6 +79,120 @@ func (b *BinanceFetcher) handleMessage(ctx context.Context, message map[string]a
 	return nil
 }
 
+func (b *BinanceFetcher) handleDepthUpdate(ctx context.Context, message map[string]any) error {
+	if b.Books == nil {
+		return nil
+	}
+
+	update, err := common.MessageToStruct[DepthUpdate](message)
+	if err != nil {
+		log.Error().Str("Player", "Binance").Err(err).Msg("error in MessageToDepthUpdate")
+		return err
+	}
+
+	err = b.Books.ApplyDiff(ctx, update.Symbol, func(book *common.OrderBook) error {
+		return ApplyDepthUpdate(book, update)
+	}, b.fetchDepthSnapshot)
+	if err != nil {
+		log.Warn().Str("Player", "Binance").Err(err).Str("symbol", update.Symbol).Msg("resyncing order book")
+	}
+	return nil
+}
+
+func (b *BinanceFetcher) fetchDepthSnapshot(ctx context.Context, symbol string) (func(*common.OrderBook) error, error) {
+	options := []request.RequestOption{
+		request.WithEndpoint(fmt.Sprintf("%s?symbol=%s&limit=%d", DepthURL, symbol, DepthLimit)),
+		request.WithTimeout(5 * time.Second),
+		request.WithContext(ctx),
+	}
+	if b.Proxy != "" {
+		options = append(options, request.WithProxy(b.Proxy))
+	}
+	snapshot, err := request.Request[DepthSnapshot](options...)
+	if err != nil {
+		return nil, err
+	}
+	return func(book *common.OrderBook) error {
+		return ApplySnapshot(book, snapshot)
+	}, nil
+}
+
+// Sequence returns the update ids of depth diffs, which follow each other
+// without gaps on a stream.
+func (b *BinanceFetcher) Sequence(message map[string]any) (common.Sequence, bool) {
+	if b.Books == nil || message["e"] != "depthUpdate" {
+		return common.Sequence{}, false
+	}
+	symbol, ok := message["s"].(string)
+	if !ok {
+		return common.Sequence{}, false
+	}
+	first, ok := common.SequenceID(message["U"])
+	if !ok {
+		return common.Sequence{}, false
+	}
+	last, ok := common.SequenceID(message["u"])
+	if !ok {
+		return common.Sequence{}, false
+	}
+	return common.Sequence{
+		Stream:     strings.ToLower(symbol) + DepthStream,
+		First:      first,
+		Last:       last,
+		Contiguous: true,
+	}, true
+}
+
+// Resubscribe resets the book of stream, the next diff fetches a new snapshot.
+func (b *BinanceFetcher) Resubscribe(ctx context.Context, stream string) error {
+	symbol := strings.ToUpper(strings.TrimSuffix(stream, DepthStream))
+	b.Books.Apply(symbol, func(book *common.OrderBook) {
+		book.Reset()
+	})
+	return nil
+}
+
+// ResubscribeFeed subscribes to the ticker of feedID again.
+func (b *BinanceFetcher) ResubscribeFeed(ctx context.Context, feedID int32) error {
+	symbol, ok := common.FeedSymbol(b.feedMap(), feedID)
+	if !ok {
+		return errorSentinel.ErrFetcherFeedNotFound
+	}
+	stream := Stream(strings.ToLower(symbol) + "@miniTicker")
+	if err := b.Ws.Write(ctx, Subscription{"UNSUBSCRIBE", []Stream{stream}, 1}); err != nil {
+		return err
+	}
+	return b.Ws.Write(ctx, Subscription{"SUBSCRIBE", []Stream{stream}, 1})
+}
+
+// UpdateFeeds subscribes to the streams of added symbols and unsubscribes from
+// the ones of removed symbols.
+func (b *BinanceFetcher) UpdateFeeds(ctx context.Context, feedMaps common.FeedMaps) error {
+	added, removed := common.DiffFeedMap(b.feedMap(), feedMaps.Combined)
+	orderBook := b.Books != nil
+
+	b.FeedMapMu.Lock()
+	b.FeedMap = feedMaps.Combined
+	b.FeedMapMu.Unlock()
+	b.Ws.SetSubscriptions([]any{Subscription{"SUBSCRIBE", Streams(common.FeedSymbols(feedMaps.Combined), orderBook), 1}})
+
+	if len(removed) > 0 {
+		if err := b.Ws.Write(ctx, Subscription{"UNSUBSCRIBE", Streams(removed, orderBook), 1}); err != nil {
+			return err
+		}
+	}
+	if len(added) > 0 {
+		return b.Ws.Write(ctx, Subscription{"SUBSCRIBE", Streams(added, orderBook), 1})
+	}
+	return nil
+}
+
+func (b *BinanceFetcher) feedMap() map[string][]int32 {
+	b.FeedMapMu.RLock()
+	defer b.FeedMapMu.RUnlock()
+	return b.FeedMap
+}
+
 func (b *BinanceFetcher) Run(ctx context.Context) {
-	b.Ws.Run(ctx, b.handleMessage)
+	b.Ws.Run(ctx, common.NewSequenceTracker("Binance", b, b.Ws).Route(b.handleMessage))
 }
@@ -2,6 +2,12 @@ package binance
 
 const (
 	URL = "wss://stream.binance.com:443/ws"
+	// DepthURL serves the snapshots the depth diffs are applied to
+	// https://developers.binance.com/docs/binance-spot-api-docs/web-socket-streams#how-to-manage-a-local-order-book-correctly
+	DepthURL   = "https://api.binance.com/api/v3/depth"
+	DepthLimit = 1000
+	// DepthStream is the suffix of the depth diff streams after the symbol
+	DepthStream = "@depth@100ms"
 )
 
 type Stream string
@@ -23,3 +29,19 @@ type MiniTicker struct {
 	Volume      string `json:"v"`
 	QuoteVolume string `json:"q"`
 }
+
+type DepthUpdate struct {
+	EventType     string     `json:"e"`
+	EventTime     int64      `json:"E"`
+	Symbol        string     `json:"s"`
+	FirstUpdateID int64      `json:"U"`
+	FinalUpdateID int64      `json:"u"`
+	Bids          [][]string `json:"b"`
+	Asks          [][]string `json:"a"`
+}
+
+type DepthSnapshot struct {
+	LastUpdateID int64      `json:"lastUpdateId"`
+	Bids         [][]string `json:"bids"`
+	Asks         [][]string `json:"asks"`
+}
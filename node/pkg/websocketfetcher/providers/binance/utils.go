@@ -2,6 +2,7 @@ package binance
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"bisonai.com/miko/node/pkg/websocketfetcher/common"
@@ -37,3 +38,58 @@ func TickerToFeedData(miniTicker MiniTicker, feedMap map[string][]int32) ([]*com
 
 	return result, nil
 }
+
+// ApplySnapshot builds book from a snapshot of the depth endpoint.
+func ApplySnapshot(book *common.OrderBook, snapshot DepthSnapshot) error {
+	bids, err := common.ParseLevels(snapshot.Bids)
+	if err != nil {
+		return err
+	}
+	asks, err := common.ParseLevels(snapshot.Asks)
+	if err != nil {
+		return err
+	}
+	book.Snapshot(bids, asks, snapshot.LastUpdateID)
+	return nil
+}
+
+// ApplyDepthUpdate applies a diff of the depth stream to a synced book. Diffs
+// already part of the snapshot are skipped, a first diff not overlapping the
+// snapshot fails so the book gets synced again. The gaps between diffs are
+// caught by the SequenceTracker before they get here. The book is kept at the
+// depth of the snapshot, past which the diffs aren't bounded.
+func ApplyDepthUpdate(book *common.OrderBook, update DepthUpdate) error {
+	// already part of the snapshot
+	if update.FinalUpdateID <= book.Sequence {
+		return nil
+	}
+	if book.FirstDiff() && update.FirstUpdateID > book.Sequence+1 {
+		expected := book.Sequence + 1
+		return fmt.Errorf("depth updates of %s don't follow the snapshot: expected %d, got %d", update.Symbol, expected, update.FirstUpdateID)
+	}
+
+	bids, err := common.ParseLevels(update.Bids)
+	if err != nil {
+		return err
+	}
+	asks, err := common.ParseLevels(update.Asks)
+	if err != nil {
+		return err
+	}
+	book.Update(bids, asks, update.FinalUpdateID)
+	book.Truncate(DepthLimit)
+	return nil
+}
+
+// Streams returns the streams of symbols, with their depth diffs if orderBook
+// is set.
+func Streams(symbols []string, orderBook bool) []Stream {
+	streams := make([]Stream, 0, len(symbols))
+	for _, symbol := range symbols {
+		streams = append(streams, Stream(strings.ToLower(symbol)+"@miniTicker"))
+		if orderBook {
+			streams = append(streams, Stream(strings.ToLower(symbol)+DepthStream))
+		}
+	}
+	return streams
+}
@@ -37,7 +37,8 @@ func New(ctx context.Context, opts ...common.FetcherOption) (common.FetcherInter
 		wss.WithCustomReadFunc(fetcher.customReadFunc),
 		wss.WithEndpoint(URL),
 		wss.WithSubscriptions(subscriptions),
-		wss.WithProxyUrl(config.Proxy))
+		wss.WithProxyUrl(config.Proxy),
+		wss.WithRecording(config.Recording))
 	if err != nil {
 		log.Error().Str("Player", "Bingx").Err(err).Msg("error in bingx.New")
 		return nil, err
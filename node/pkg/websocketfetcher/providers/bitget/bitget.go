@@ -41,7 +41,8 @@ func New(ctx context.Context, opts ...common.FetcherOption) (common.FetcherInter
 		wss.WithCustomReadFunc(fetcher.customReadFunc),
 		wss.WithEndpoint(URL),
 		wss.WithSubscriptions([]any{subscription}),
-		wss.WithProxyUrl(config.Proxy))
+		wss.WithProxyUrl(config.Proxy),
+		wss.WithRecording(config.Recording))
 	if err != nil {
 		log.Error().Str("Player", "Bitget").Err(err).Msg("error in bitget.New")
 		return nil, err
@@ -44,7 +44,8 @@ func New(ctx context.Context, opts ...common.FetcherOption) (common.FetcherInter
 		wss.WithCustomReadFunc(fetcher.customReadFunc),
 		wss.WithEndpoint(URL),
 		wss.WithSubscriptions(subscriptions),
-		wss.WithProxyUrl(config.Proxy))
+		wss.WithProxyUrl(config.Proxy),
+		wss.WithRecording(config.Recording))
 	if err != nil {
 		log.Error().Str("Player", "Bitmart").Err(err).Msg("error in bitmart.New")
 		return nil, err
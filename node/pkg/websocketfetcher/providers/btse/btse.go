@@ -56,6 +56,7 @@ func New(ctx context.Context, opts ...common.FetcherOption) (common.FetcherInter
 		wss.WithEndpoint(URL),
 		wss.WithSubscriptions(subscriptions),
 		wss.WithProxyUrl(config.Proxy),
+		wss.WithRecording(config.Recording),
 		wss.WithReadLimit(IncreasedReadLimit),
 	)
 
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	errorSentinel "bisonai.com/miko/node/pkg/error"
 	"bisonai.com/miko/node/pkg/websocketfetcher/common"
 	"bisonai.com/miko/node/pkg/wss"
 	"github.com/rs/zerolog/log"
@@ -22,27 +23,20 @@ func New(ctx context.Context, opts ...common.FetcherOption) (common.FetcherInter
 	fetcher.FeedMap = config.FeedMaps.Combined
 	fetcher.FeedDataBuffer = config.FeedDataBuffer
 
-	pairList := []string{}
+	subscriptions := Subscriptions("subscribe", common.FeedSymbols(fetcher.FeedMap), config.OrderBook)
 
-	for feed := range fetcher.FeedMap {
-		pairList = append(pairList, "tickers."+feed)
+	wsOptions := []wss.ConnectionOption{
+		wss.WithEndpoint(URL),
+		wss.WithSubscriptions(subscriptions),
+		wss.WithProxyUrl(config.Proxy),
+		wss.WithRecording(config.Recording),
 	}
-
-	subscriptions := []any{}
-	// bybit allows maximum 10 pairs per subscription
-	// https://bybit-exchange.github.io/docs/v5/ws/connect#public-channel---args-limits
-	for i := 0; i < len(pairList); i += 10 {
-		end := common.Min(i+10, len(pairList))
-		subscriptions = append(subscriptions, Subscription{
-			Op:   "subscribe",
-			Args: pairList[i:end],
-		})
+	if config.OrderBook {
+		fetcher.Books = common.NewOrderBooks(config.DepthPercent)
+		wsOptions = append(wsOptions, wss.WithSequentialRouting())
 	}
 
-	ws, err := wss.NewWebsocketHelper(ctx,
-		wss.WithEndpoint(URL),
-		wss.WithSubscriptions(subscriptions),
-		wss.WithProxyUrl(config.Proxy))
+	ws, err := wss.NewWebsocketHelper(ctx, wsOptions...)
 	if err != nil {
 		log.Error().Str("Player", "Bybit").Err(err).Msg("error in bybit.New")
 		return nil, err
@@ -52,6 +46,10 @@ func New(ctx context.Context, opts ...common.FetcherOption) (common.FetcherInter
 }
 
 func (f *BybitFetcher) handleMessage(ctx context.Context, message map[string]any) error {
+	if topic, ok := message["topic"].(string); ok && strings.HasPrefix(topic, BookTopicPrefix) {
+		return f.handleBook(ctx, message)
+	}
+
 	response, err := common.MessageToStruct[Response](message)
 	if err != nil {
 		log.Error().Str("Player", "Bybit").Err(err).Msg("error in bybit.handleMessage")
@@ -62,12 +60,13 @@ func (f *BybitFetcher) handleMessage(ctx context.Context, message map[string]any
 		return nil
 	}
 
-	feedDataList, err := ResponseToFeedData(response, f.FeedMap)
+	feedDataList, err := ResponseToFeedData(response, f.feedMap())
 	if err != nil {
 		log.Error().Str("Player", "Bybit").Err(err).Msg("error in bybit.handleMessage")
 		return err
 	}
 
+	f.Books.Attach(response.Data.Symbol, feedDataList)
 	for _, feedData := range feedDataList {
 		f.FeedDataBuffer <- feedData
 	}
@@ -75,9 +74,108 @@ func (f *BybitFetcher) handleMessage(ctx context.Context, message map[string]any
 	return nil
 }
 
+func (f *BybitFetcher) handleBook(ctx context.Context, message map[string]any) error {
+	if f.Books == nil {
+		return nil
+	}
+
+	response, err := common.MessageToStruct[BookResponse](message)
+	if err != nil {
+		log.Error().Str("Player", "Bybit").Err(err).Msg("error in bybit.handleBook")
+		return err
+	}
+
+	f.Books.Apply(response.Data.Symbol, func(book *common.OrderBook) {
+		err = ApplyBook(book, response)
+	})
+	if err == nil {
+		return nil
+	}
+
+	log.Warn().Str("Player", "Bybit").Err(err).Str("symbol", response.Data.Symbol).Msg("resubscribing to order book")
+	return f.Resubscribe(ctx, response.Topic)
+}
+
+// Sequence returns the update ids of order book messages, which increase by
+// one on each delta of a topic.
+func (f *BybitFetcher) Sequence(message map[string]any) (common.Sequence, bool) {
+	topic, ok := message["topic"].(string)
+	if f.Books == nil || !ok || !strings.HasPrefix(topic, BookTopicPrefix) {
+		return common.Sequence{}, false
+	}
+	data, ok := message["data"].(map[string]any)
+	if !ok {
+		return common.Sequence{}, false
+	}
+	updateID, ok := common.SequenceID(data["u"])
+	if !ok {
+		return common.Sequence{}, false
+	}
+	return common.Sequence{
+		Stream: topic,
+		First:  updateID,
+		Last:   updateID,
+		// bybit restarts the ids at 1 with a snapshot when its service restarts
+		Snapshot:   message["type"] == "snapshot" || updateID == 1,
+		Contiguous: true,
+	}, true
+}
+
+// Resubscribe resets the book of stream and subscribes to it again, bybit
+// only sends snapshots on subscription.
+func (f *BybitFetcher) Resubscribe(ctx context.Context, stream string) error {
+	f.Books.Apply(strings.TrimPrefix(stream, BookTopicPrefix), func(book *common.OrderBook) {
+		book.Reset()
+	})
+
+	if err := f.Ws.Write(ctx, Subscription{Op: "unsubscribe", Args: []string{stream}}); err != nil {
+		return err
+	}
+	return f.Ws.Write(ctx, Subscription{Op: "subscribe", Args: []string{stream}})
+}
+
+// ResubscribeFeed subscribes to the ticker of feedID again.
+func (f *BybitFetcher) ResubscribeFeed(ctx context.Context, feedID int32) error {
+	symbol, ok := common.FeedSymbol(f.feedMap(), feedID)
+	if !ok {
+		return errorSentinel.ErrFetcherFeedNotFound
+	}
+	topic := "tickers." + symbol
+	if err := f.Ws.Write(ctx, Subscription{Op: "unsubscribe", Args: []string{topic}}); err != nil {
+		return err
+	}
+	return f.Ws.Write(ctx, Subscription{Op: "subscribe", Args: []string{topic}})
+}
+
+// UpdateFeeds subscribes to the topics of added symbols and unsubscribes from
+// the ones of removed symbols.
+func (f *BybitFetcher) UpdateFeeds(ctx context.Context, feedMaps common.FeedMaps) error {
+	added, removed := common.DiffFeedMap(f.feedMap(), feedMaps.Combined)
+	orderBook := f.Books != nil
+
+	f.FeedMapMu.Lock()
+	f.FeedMap = feedMaps.Combined
+	f.FeedMapMu.Unlock()
+	f.Ws.SetSubscriptions(Subscriptions("subscribe", common.FeedSymbols(feedMaps.Combined), orderBook))
+
+	messages := append(Subscriptions("unsubscribe", removed, orderBook), Subscriptions("subscribe", added, orderBook)...)
+	for _, message := range messages {
+		if err := f.Ws.Write(ctx, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *BybitFetcher) feedMap() map[string][]int32 {
+	f.FeedMapMu.RLock()
+	defer f.FeedMapMu.RUnlock()
+	return f.FeedMap
+}
+
 func (f *BybitFetcher) Run(ctx context.Context) {
 	go f.ping(ctx)
-	f.Ws.Run(ctx, f.handleMessage)
+	f.Ws.Run(ctx, common.NewSequenceTracker("Bybit", f, f.Ws).Route(f.handleMessage))
 }
 
 func (f *BybitFetcher) ping(ctx context.Context) {
@@ -2,6 +2,11 @@ package bybit
 
 const URL = "wss://stream.bybit.com/v5/public/spot"
 
+// BookTopicPrefix is followed by the symbol, the book sends a snapshot on
+// subscription and deltas after it
+// https://bybit-exchange.github.io/docs/v5/websocket/public/orderbook
+const BookTopicPrefix = "orderbook.50."
+
 type Subscription struct {
 	Op   string   `json:"op"`
 	Args []string `json:"args"`
@@ -20,3 +25,16 @@ type Response struct {
 type Heartbeat struct {
 	Op string `json:"op"`
 }
+
+type BookResponse struct {
+	Topic     string `json:"topic"`
+	Type      string `json:"type"`
+	Timestamp int64  `json:"ts"`
+	Data      struct {
+		Symbol   string     `json:"s"`
+		Bids     [][]string `json:"b"`
+		Asks     [][]string `json:"a"`
+		UpdateID int64      `json:"u"`
+		Seq      int64      `json:"seq"`
+	} `json:"data"`
+}
@@ -37,3 +37,51 @@ func ResponseToFeedData(data Response, feedMap map[string][]int32) ([]*common.Fe
 
 	return result, nil
 }
+
+// ApplyBook applies a snapshot or delta of the order book topic to book.
+// Deltas are dropped until a snapshot arrives, the ones not following the
+// last delta are caught by the SequenceTracker before they get here.
+func ApplyBook(book *common.OrderBook, response BookResponse) error {
+	bids, err := common.ParseLevels(response.Data.Bids)
+	if err != nil {
+		return err
+	}
+	asks, err := common.ParseLevels(response.Data.Asks)
+	if err != nil {
+		return err
+	}
+
+	if response.Type == "snapshot" {
+		book.Snapshot(bids, asks, response.Data.UpdateID)
+		return nil
+	}
+	if !book.Synced() || response.Data.UpdateID <= book.Sequence {
+		return nil
+	}
+	book.Update(bids, asks, response.Data.UpdateID)
+	return nil
+}
+
+// Subscriptions returns the op messages for the ticker topics of symbols, with
+// their order books if orderBook is set.
+func Subscriptions(op string, symbols []string, orderBook bool) []any {
+	topics := []string{}
+	for _, symbol := range symbols {
+		topics = append(topics, "tickers."+symbol)
+		if orderBook {
+			topics = append(topics, BookTopicPrefix+symbol)
+		}
+	}
+
+	subscriptions := []any{}
+	// bybit allows maximum 10 pairs per subscription
+	// https://bybit-exchange.github.io/docs/v5/ws/connect#public-channel---args-limits
+	for i := 0; i < len(topics); i += 10 {
+		end := common.Min(i+10, len(topics))
+		subscriptions = append(subscriptions, Subscription{
+			Op:   op,
+			Args: topics[i:end],
+		})
+	}
+	return subscriptions
+}
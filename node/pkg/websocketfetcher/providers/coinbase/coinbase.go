@@ -2,7 +2,9 @@ package coinbase
 
 import (
 	"context"
+	"strings"
 
+	errorSentinel "bisonai.com/miko/node/pkg/error"
 	"bisonai.com/miko/node/pkg/websocketfetcher/common"
 	"bisonai.com/miko/node/pkg/wss"
 	"github.com/rs/zerolog/log"
@@ -21,20 +23,24 @@ func New(ctx context.Context, opts ...common.FetcherOption) (common.FetcherInter
 	fetcher.FeedMap = config.FeedMaps.Separated
 	fetcher.FeedDataBuffer = config.FeedDataBuffer
 
-	pairListString := []string{}
-	for feed := range fetcher.FeedMap {
-		pairListString = append(pairListString, feed)
-	}
 	subscription := Subscription{
 		Type:       "subscribe",
-		ProductIds: pairListString,
-		Channels:   []string{"ticker"},
+		ProductIds: common.FeedSymbols(fetcher.FeedMap),
+		Channels:   Channels(config.OrderBook),
 	}
 
-	ws, err := wss.NewWebsocketHelper(ctx,
+	wsOptions := []wss.ConnectionOption{
 		wss.WithEndpoint(URL),
+		wss.WithProxyUrl(config.Proxy),
+		wss.WithRecording(config.Recording),
 		wss.WithSubscriptions([]any{subscription}),
-		wss.WithProxyUrl(config.Proxy))
+	}
+	if config.OrderBook {
+		fetcher.Books = common.NewOrderBooks(config.DepthPercent)
+		wsOptions = append(wsOptions, wss.WithSequentialRouting())
+	}
+
+	ws, err := wss.NewWebsocketHelper(ctx, wsOptions...)
 	if err != nil {
 		log.Error().Str("Player", "Coinbase").Err(err).Msg("error in coinbase.New")
 		return nil, err
@@ -44,6 +50,10 @@ func New(ctx context.Context, opts ...common.FetcherOption) (common.FetcherInter
 }
 
 func (c *CoinbaseFetcher) handleMessage(ctx context.Context, message map[string]any) error {
+	if message["type"] == "snapshot" || message["type"] == "l2update" {
+		return c.handleLevel2(message)
+	}
+
 	ticker, err := common.MessageToStruct[Ticker](message)
 	if err != nil {
 		return err
@@ -53,11 +63,12 @@ func (c *CoinbaseFetcher) handleMessage(ctx context.Context, message map[string]
 		return nil
 	}
 
-	feedDataList, err := TickerToFeedData(ticker, c.FeedMap)
+	feedDataList, err := TickerToFeedData(ticker, c.feedMap())
 	if err != nil {
 		return err
 	}
 
+	c.Books.Attach(strings.ToUpper(ticker.ProductID), feedDataList)
 	for _, feedData := range feedDataList {
 		c.FeedDataBuffer <- feedData
 	}
@@ -65,6 +76,88 @@ func (c *CoinbaseFetcher) handleMessage(ctx context.Context, message map[string]
 	return nil
 }
 
+func (c *CoinbaseFetcher) handleLevel2(message map[string]any) error {
+	if c.Books == nil {
+		return nil
+	}
+
+	level2, err := common.MessageToStruct[Level2](message)
+	if err != nil {
+		return err
+	}
+
+	c.Books.Apply(strings.ToUpper(level2.ProductID), func(book *common.OrderBook) {
+		err = ApplyLevel2(book, level2)
+	})
+	return err
+}
+
+// Sequence returns the sequence of ticker messages. It counts every message
+// of a product, so tickers skip ids and are only checked to increase.
+func (c *CoinbaseFetcher) Sequence(message map[string]any) (common.Sequence, bool) {
+	if message["type"] != "ticker" {
+		return common.Sequence{}, false
+	}
+	productID, ok := message["product_id"].(string)
+	if !ok {
+		return common.Sequence{}, false
+	}
+	sequence, ok := common.SequenceID(message["sequence"])
+	if !ok {
+		return common.Sequence{}, false
+	}
+	return common.Sequence{
+		Stream: "ticker:" + productID,
+		First:  sequence,
+		Last:   sequence,
+	}, true
+}
+
+// Resubscribe is never needed, tickers aren't contiguous.
+func (c *CoinbaseFetcher) Resubscribe(ctx context.Context, stream string) error {
+	return nil
+}
+
+// ResubscribeFeed subscribes to the ticker of feedID again.
+func (c *CoinbaseFetcher) ResubscribeFeed(ctx context.Context, feedID int32) error {
+	productID, ok := common.FeedSymbol(c.feedMap(), feedID)
+	if !ok {
+		return errorSentinel.ErrFetcherFeedNotFound
+	}
+	if err := c.Ws.Write(ctx, Subscription{Type: "unsubscribe", ProductIds: []string{productID}, Channels: []string{"ticker"}}); err != nil {
+		return err
+	}
+	return c.Ws.Write(ctx, Subscription{Type: "subscribe", ProductIds: []string{productID}, Channels: []string{"ticker"}})
+}
+
+// UpdateFeeds subscribes to the channels of added products and unsubscribes
+// from the ones of removed products.
+func (c *CoinbaseFetcher) UpdateFeeds(ctx context.Context, feedMaps common.FeedMaps) error {
+	added, removed := common.DiffFeedMap(c.feedMap(), feedMaps.Separated)
+	channels := Channels(c.Books != nil)
+
+	c.FeedMapMu.Lock()
+	c.FeedMap = feedMaps.Separated
+	c.FeedMapMu.Unlock()
+	c.Ws.SetSubscriptions([]any{Subscription{Type: "subscribe", ProductIds: common.FeedSymbols(feedMaps.Separated), Channels: channels}})
+
+	if len(removed) > 0 {
+		if err := c.Ws.Write(ctx, Subscription{Type: "unsubscribe", ProductIds: removed, Channels: channels}); err != nil {
+			return err
+		}
+	}
+	if len(added) > 0 {
+		return c.Ws.Write(ctx, Subscription{Type: "subscribe", ProductIds: added, Channels: channels})
+	}
+	return nil
+}
+
+func (c *CoinbaseFetcher) feedMap() map[string][]int32 {
+	c.FeedMapMu.RLock()
+	defer c.FeedMapMu.RUnlock()
+	return c.FeedMap
+}
+
 func (k *CoinbaseFetcher) Run(ctx context.Context) {
-	k.Ws.Run(ctx, k.handleMessage)
+	k.Ws.Run(ctx, common.NewSequenceTracker("Coinbase", k, k.Ws).Route(k.handleMessage))
 }
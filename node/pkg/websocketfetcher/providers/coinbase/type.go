@@ -2,6 +2,10 @@ package coinbase
 
 const (
 	URL = "wss://ws-feed.exchange.coinbase.com"
+	// Level2Channel sends a snapshot on subscription and batched l2update
+	// messages after it, without authentication
+	// https://docs.cdp.coinbase.com/exchange/docs/websocket-channels#level2-batch-channel
+	Level2Channel = "level2_batch"
 )
 
 type Ticker struct {
@@ -29,3 +33,14 @@ type Subscription struct {
 	ProductIds []string `json:"product_ids"`
 	Channels   []string `json:"channels"`
 }
+
+// Level2 is either a snapshot or an l2update of the level2 channels
+type Level2 struct {
+	Type      string     `json:"type"`
+	ProductID string     `json:"product_id"`
+	Bids      [][]string `json:"bids"`
+	Asks      [][]string `json:"asks"`
+	// Changes are [side, price, size] entries, side being buy or sell
+	Changes [][]string `json:"changes"`
+	Time    string     `json:"time"`
+}
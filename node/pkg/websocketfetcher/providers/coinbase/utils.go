@@ -43,3 +43,50 @@ func TickerToFeedData(ticker Ticker, feedMap map[string][]int32) ([]*common.Feed
 
 	return result, nil
 }
+
+// ApplyLevel2 applies a snapshot or l2update to book. Updates are dropped
+// until a snapshot arrives.
+func ApplyLevel2(book *common.OrderBook, level2 Level2) error {
+	if level2.Type == "snapshot" {
+		bids, err := common.ParseLevels(level2.Bids)
+		if err != nil {
+			return err
+		}
+		asks, err := common.ParseLevels(level2.Asks)
+		if err != nil {
+			return err
+		}
+		book.Snapshot(bids, asks, 0)
+		return nil
+	}
+	if !book.Synced() {
+		return nil
+	}
+
+	bids := []common.Level{}
+	asks := []common.Level{}
+	for _, change := range level2.Changes {
+		if len(change) < 3 {
+			continue
+		}
+		levels, err := common.ParseLevels([][]string{change[1:]})
+		if err != nil {
+			return err
+		}
+		if change[0] == "buy" {
+			bids = append(bids, levels...)
+		} else {
+			asks = append(asks, levels...)
+		}
+	}
+	book.Update(bids, asks, 0)
+	return nil
+}
+
+// Channels returns the channels subscribed for each product.
+func Channels(orderBook bool) []string {
+	if orderBook {
+		return []string{"ticker", Level2Channel}
+	}
+	return []string{"ticker"}
+}
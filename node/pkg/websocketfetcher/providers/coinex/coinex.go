@@ -40,6 +40,7 @@ func New(ctx context.Context, opts ...common.FetcherOption) (common.FetcherInter
 		wss.WithEndpoint(URL),
 		wss.WithSubscriptions([]any{subscription}),
 		wss.WithProxyUrl(config.Proxy),
+		wss.WithRecording(config.Recording),
 		wss.WithCustomReadFunc(fetcher.customReadFunc),
 	)
 	if err != nil {
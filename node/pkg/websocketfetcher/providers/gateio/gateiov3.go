@@ -68,6 +68,7 @@ func NewV3(ctx context.Context, opts ...common.FetcherOption) (common.FetcherInt
 		wss.WithEndpoint(v3Url),
 		wss.WithSubscriptions(subscriptions),
 		wss.WithProxyUrl(config.Proxy),
+		wss.WithRecording(config.Recording),
 	)
 	if err != nil {
 		return nil, err
@@ -35,7 +35,8 @@ func New(ctx context.Context, opts ...common.FetcherOption) (common.FetcherInter
 	ws, err := wss.NewWebsocketHelper(ctx,
 		wss.WithEndpoint(URL+strings.Join(symbols, ",")),
 		wss.WithSubscriptions([]any{}),
-		wss.WithProxyUrl(config.Proxy))
+		wss.WithProxyUrl(config.Proxy),
+		wss.WithRecording(config.Recording))
 	if err != nil {
 		log.Error().Str("Player", "Gemini").Err(err).Msg("error in gemini.New")
 		return nil, err
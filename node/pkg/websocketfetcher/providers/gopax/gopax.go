@@ -36,6 +36,7 @@ func New(ctx context.Context, opts ...common.FetcherOption) (common.FetcherInter
 		wss.WithEndpoint(URL),
 		wss.WithSubscriptions(subscription),
 		wss.WithProxyUrl(config.Proxy),
+		wss.WithRecording(config.Recording),
 		wss.WithReadLimit(IncreasedReadLimit),
 		wss.WithCustomReadFunc(fetcher.customReadFunc),
 	)
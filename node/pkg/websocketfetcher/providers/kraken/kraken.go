@@ -37,10 +37,23 @@ func New(ctx context.Context, opts ...common.FetcherOption) (common.FetcherInter
 		Params: params,
 	}
 
-	ws, err := wss.NewWebsocketHelper(ctx,
+	subscriptions := []any{subscription}
+	wsOptions := []wss.ConnectionOption{
 		wss.WithEndpoint(URL),
-		wss.WithSubscriptions([]any{subscription}),
-		wss.WithProxyUrl(config.Proxy))
+		wss.WithProxyUrl(config.Proxy),
+		wss.WithRecording(config.Recording),
+	}
+	if config.OrderBook {
+		fetcher.Books = common.NewOrderBooks(config.DepthPercent)
+		subscriptions = append(subscriptions, Subscription{
+			Method: "subscribe",
+			Params: Params{Channel: "book", Symbol: symbols, Depth: BookDepth},
+		})
+		wsOptions = append(wsOptions, wss.WithSequentialRouting())
+	}
+	wsOptions = append(wsOptions, wss.WithSubscriptions(subscriptions))
+
+	ws, err := wss.NewWebsocketHelper(ctx, wsOptions...)
 	if err != nil {
 		log.Error().Str("Player", "Kraken").Err(err).Msg("error in kraken.New")
 		return nil, err
@@ -50,6 +63,10 @@ func New(ctx context.Context, opts ...common.FetcherOption) (common.FetcherInter
 }
 
 func (f *KrakenFetcher) handleMessage(ctx context.Context, message map[string]any) error {
+	if message["channel"] == "book" {
+		return f.handleBook(message)
+	}
+
 	raw, err := common.MessageToStruct[Response](message)
 	if err != nil {
 		log.Error().Str("Player", "Kraken").Err(err).Msg("error in kraken.handleMessage")
@@ -60,10 +77,34 @@ func (f *KrakenFetcher) handleMessage(ctx context.Context, message map[string]an
 		return nil
 	}
 
-	feedDataList := ResponseToFeedData(raw, f.FeedMap)
+	for i := range raw.Data {
+		single := raw
+		single.Data = raw.Data[i : i+1]
+		feedDataList := ResponseToFeedData(single, f.FeedMap)
+		f.Books.Attach(raw.Data[i].Symbol, feedDataList)
+
+		for _, feedData := range feedDataList {
+			f.FeedDataBuffer <- feedData
+		}
+	}
+	return nil
+}
+
+func (f *KrakenFetcher) handleBook(message map[string]any) error {
+	if f.Books == nil {
+		return nil
+	}
+
+	response, err := common.MessageToStruct[BookResponse](message)
+	if err != nil {
+		log.Error().Str("Player", "Kraken").Err(err).Msg("error in kraken.handleBook")
+		return err
+	}
 
-	for _, feedData := range feedDataList {
-		f.FeedDataBuffer <- feedData
+	for i := range response.Data {
+		f.Books.Apply(response.Data[i].Symbol, func(book *common.OrderBook) {
+			ApplyBook(book, response.Type, response.Data[i].Bids, response.Data[i].Asks)
+		})
 	}
 	return nil
 }
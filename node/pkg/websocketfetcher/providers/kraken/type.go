@@ -2,9 +2,15 @@ package kraken
 
 const URL = "wss://ws.kraken.com/v2"
 
+// BookDepth is the depth of the book channel, the book is expected to be
+// truncated to it after every update
+// https://docs.kraken.com/api/docs/websocket-v2/book
+const BookDepth = 25
+
 type Params struct {
 	Channel string   `json:"channel"`
 	Symbol  []string `json:"symbol"`
+	Depth   int      `json:"depth,omitempty"`
 }
 
 type Subscription struct {
@@ -21,3 +27,18 @@ type Response struct {
 		Volume float64 `json:"volume"`
 	} `json:"data"`
 }
+
+type BookLevel struct {
+	Price float64 `json:"price"`
+	Qty   float64 `json:"qty"`
+}
+
+type BookResponse struct {
+	Channel string `json:"channel"`
+	Type    string `json:"type"`
+	Data    []struct {
+		Symbol string      `json:"symbol"`
+		Bids   []BookLevel `json:"bids"`
+		Asks   []BookLevel `json:"asks"`
+	} `json:"data"`
+}
@@ -31,3 +31,25 @@ func ResponseToFeedData(response Response, feedMap map[string][]int32) []*common
 	}
 	return feedDataList
 }
+
+// ApplyBook applies a snapshot or update of the book channel to book.
+// Updates are dropped until a snapshot arrives.
+func ApplyBook(book *common.OrderBook, updateType string, bids []BookLevel, asks []BookLevel) {
+	if updateType == "snapshot" {
+		book.Snapshot(toLevels(bids), toLevels(asks), 0)
+		return
+	}
+	if !book.Synced() {
+		return
+	}
+	book.Update(toLevels(bids), toLevels(asks), 0)
+	book.Truncate(BookDepth)
+}
+
+func toLevels(levels []BookLevel) []common.Level {
+	result := make([]common.Level, len(levels))
+	for i, level := range levels {
+		result[i] = common.Level{Price: level.Price, Size: level.Qty}
+	}
+	return result
+}
@@ -39,7 +39,8 @@ func New(ctx context.Context, opts ...common.FetcherOption) (common.FetcherInter
 		wss.WithSubscriptions([]any{subscription}),
 		wss.WithCustomReadFunc(readFrame),
 		wss.WithReadLimit(ReadLimit),
-		wss.WithProxyUrl(config.Proxy))
+		wss.WithProxyUrl(config.Proxy),
+		wss.WithRecording(config.Recording))
 	if err != nil {
 		log.Error().Str("Player", "Mexc").Err(err).Msg("error in mexc.New")
 		return nil, err
@@ -2,7 +2,9 @@ package okx
 
 import (
 	"context"
+	"strings"
 
+	errorSentinel "bisonai.com/miko/node/pkg/error"
 	"bisonai.com/miko/node/pkg/websocketfetcher/common"
 	"bisonai.com/miko/node/pkg/wss"
 	"github.com/rs/zerolog/log"
@@ -20,24 +22,23 @@ func New(ctx context.Context, opts ...common.FetcherOption) (common.FetcherInter
 	fetcher.FeedMap = config.FeedMaps.Separated
 	fetcher.FeedDataBuffer = config.FeedDataBuffer
 
-	args := []Arg{}
-	for feed := range fetcher.FeedMap {
-		arg := Arg{
-			Channel: "tickers",
-			InstId:  feed,
-		}
-		args = append(args, arg)
-	}
-
 	subscription := Subscription{
 		Operation: "subscribe",
-		Args:      args,
+		Args:      Args(common.FeedSymbols(fetcher.FeedMap), config.OrderBook),
 	}
 
-	ws, err := wss.NewWebsocketHelper(ctx,
+	wsOptions := []wss.ConnectionOption{
 		wss.WithEndpoint(URL),
 		wss.WithSubscriptions([]any{subscription}),
-		wss.WithProxyUrl(config.Proxy))
+		wss.WithProxyUrl(config.Proxy),
+		wss.WithRecording(config.Recording),
+	}
+	if config.OrderBook {
+		fetcher.Books = common.NewOrderBooks(config.DepthPercent)
+		wsOptions = append(wsOptions, wss.WithSequentialRouting())
+	}
+
+	ws, err := wss.NewWebsocketHelper(ctx, wsOptions...)
 	if err != nil {
 		log.Error().Str("Player", "Okx").Err(err).Msg("error in okx.New")
 		return nil, err
@@ -48,6 +49,10 @@ func New(ctx context.Context, opts ...common.FetcherOption) (common.FetcherInter
 }
 
 func (f *OkxFetcher) handleMessage(ctx context.Context, message map[string]any) error {
+	if arg, ok := message["arg"].(map[string]any); ok && arg["channel"] == BooksChannel {
+		return f.handleBook(ctx, message)
+	}
+
 	raw, err := common.MessageToStruct[Response](message)
 	if err != nil {
 		log.Error().Str("Player", "Okx").Err(err).Msg("error in okx.handleMessage")
@@ -58,7 +63,8 @@ func (f *OkxFetcher) handleMessage(ctx context.Context, message map[string]any)
 		return nil
 	}
 
-	feedDataList := ResponseToFeedData(raw, f.FeedMap)
+	feedDataList := ResponseToFeedData(raw, f.feedMap())
+	f.Books.Attach(raw.Arg.InstId, feedDataList)
 
 	for _, feedData := range feedDataList {
 		f.FeedDataBuffer <- feedData
@@ -67,6 +73,123 @@ func (f *OkxFetcher) handleMessage(ctx context.Context, message map[string]any)
 	return nil
 }
 
+func (f *OkxFetcher) handleBook(ctx context.Context, message map[string]any) error {
+	if f.Books == nil {
+		return nil
+	}
+
+	response, err := common.MessageToStruct[BookResponse](message)
+	if err != nil {
+		log.Error().Str("Player", "Okx").Err(err).Msg("error in okx.handleBook")
+		return err
+	}
+	if len(response.Data) == 0 {
+		return nil
+	}
+
+	f.Books.Apply(response.Arg.InstId, func(book *common.OrderBook) {
+		err = ApplyBook(book, response)
+	})
+	if err == nil {
+		return nil
+	}
+
+	log.Warn().Str("Player", "Okx").Err(err).Str("instId", response.Arg.InstId).Msg("resubscribing to order book")
+	return f.Resubscribe(ctx, BookStream(response.Arg.InstId))
+}
+
+// Sequence returns the sequence ids of order book messages, each linking to
+// the previous one of its stream through prevSeqId.
+func (f *OkxFetcher) Sequence(message map[string]any) (common.Sequence, bool) {
+	arg, ok := message["arg"].(map[string]any)
+	if f.Books == nil || !ok || arg["channel"] != BooksChannel {
+		return common.Sequence{}, false
+	}
+	instId, ok := arg["instId"].(string)
+	if !ok {
+		return common.Sequence{}, false
+	}
+	data, ok := message["data"].([]any)
+	if !ok || len(data) == 0 {
+		return common.Sequence{}, false
+	}
+	entry, ok := data[0].(map[string]any)
+	if !ok {
+		return common.Sequence{}, false
+	}
+	seqId, ok := common.SequenceID(entry["seqId"])
+	if !ok {
+		return common.Sequence{}, false
+	}
+	prevSeqId, ok := common.SequenceID(entry["prevSeqId"])
+	if !ok {
+		return common.Sequence{}, false
+	}
+	return common.Sequence{
+		Stream:     BookStream(instId),
+		First:      prevSeqId + 1,
+		Last:       seqId,
+		Snapshot:   message["action"] == "snapshot",
+		Contiguous: true,
+	}, true
+}
+
+// Resubscribe resets the book of stream and subscribes to it again, okx only
+// sends snapshots on subscription.
+func (f *OkxFetcher) Resubscribe(ctx context.Context, stream string) error {
+	instId := strings.TrimPrefix(stream, BooksChannel+":")
+	f.Books.Apply(instId, func(book *common.OrderBook) {
+		book.Reset()
+	})
+
+	arg := Arg{Channel: BooksChannel, InstId: instId}
+	if err := f.Ws.Write(ctx, Subscription{Operation: "unsubscribe", Args: []Arg{arg}}); err != nil {
+		return err
+	}
+	return f.Ws.Write(ctx, Subscription{Operation: "subscribe", Args: []Arg{arg}})
+}
+
+// ResubscribeFeed subscribes to the ticker of feedID again.
+func (f *OkxFetcher) ResubscribeFeed(ctx context.Context, feedID int32) error {
+	instId, ok := common.FeedSymbol(f.feedMap(), feedID)
+	if !ok {
+		return errorSentinel.ErrFetcherFeedNotFound
+	}
+	arg := Arg{Channel: "tickers", InstId: instId}
+	if err := f.Ws.Write(ctx, Subscription{Operation: "unsubscribe", Args: []Arg{arg}}); err != nil {
+		return err
+	}
+	return f.Ws.Write(ctx, Subscription{Operation: "subscribe", Args: []Arg{arg}})
+}
+
+// UpdateFeeds subscribes to the channels of added instruments and unsubscribes
+// from the ones of removed instruments.
+func (f *OkxFetcher) UpdateFeeds(ctx context.Context, feedMaps common.FeedMaps) error {
+	added, removed := common.DiffFeedMap(f.feedMap(), feedMaps.Separated)
+	orderBook := f.Books != nil
+
+	f.FeedMapMu.Lock()
+	f.FeedMap = feedMaps.Separated
+	f.FeedMapMu.Unlock()
+	f.Ws.SetSubscriptions([]any{Subscription{Operation: "subscribe", Args: Args(common.FeedSymbols(feedMaps.Separated), orderBook)}})
+
+	if len(removed) > 0 {
+		if err := f.Ws.Write(ctx, Subscription{Operation: "unsubscribe", Args: Args(removed, orderBook)}); err != nil {
+			return err
+		}
+	}
+	if len(added) > 0 {
+		return f.Ws.Write(ctx, Subscription{Operation: "subscribe", Args: Args(added, orderBook)})
+	}
+	return nil
+}
+
+func (f *OkxFetcher) feedMap() map[string][]int32 {
+	f.FeedMapMu.RLock()
+	defer f.FeedMapMu.RUnlock()
+	return f.FeedMap
+}
+
 func (f *OkxFetcher) Run(ctx context.Context) {
-	f.Ws.Run(ctx, f.handleMessage)
+	f.Ws.Run(ctx, common.NewSequenceTracker("Okx", f, f.Ws).Route(f.handleMessage))
 }
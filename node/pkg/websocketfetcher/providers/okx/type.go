@@ -3,6 +3,10 @@ package okx
 // rate limits to 3 request / sec
 const URL = "wss://ws.okx.com:8443/ws/v5/public"
 
+// BooksChannel sends a 400 level snapshot on subscription and diffs after it
+// https://www.okx.com/docs-v5/en/#order-book-trading-market-data-ws-order-book-channel
+const BooksChannel = "books"
+
 type Arg struct {
 	Channel string `json:"channel"`
 	InstId  string `json:"instId"`
@@ -23,3 +27,15 @@ type Response struct {
 		Timestamp string `json:"ts"`
 	} `json:"data"`
 }
+
+type BookResponse struct {
+	Arg    Arg    `json:"arg"`
+	Action string `json:"action"`
+	Data   []struct {
+		Asks      [][]string `json:"asks"`
+		Bids      [][]string `json:"bids"`
+		Timestamp string     `json:"ts"`
+		SeqID     int64      `json:"seqId"`
+		PrevSeqID int64      `json:"prevSeqId"`
+	} `json:"data"`
+}
@@ -46,3 +46,47 @@ func ResponseToFeedData(response Response, feedMap map[string][]int32) []*common
 	}
 	return feedDataList
 }
+
+// ApplyBook applies a snapshot or diff of the books channel to book. Diffs
+// are dropped until a snapshot arrives, the ones not following the last diff
+// are caught by the SequenceTracker before they get here.
+func ApplyBook(book *common.OrderBook, response BookResponse) error {
+	for _, data := range response.Data {
+		bids, err := common.ParseLevels(data.Bids)
+		if err != nil {
+			return err
+		}
+		asks, err := common.ParseLevels(data.Asks)
+		if err != nil {
+			return err
+		}
+
+		if response.Action == "snapshot" {
+			book.Snapshot(bids, asks, data.SeqID)
+			continue
+		}
+		if !book.Synced() {
+			return nil
+		}
+		book.Update(bids, asks, data.SeqID)
+	}
+	return nil
+}
+
+// BookStream names the order book stream of instId for sequence tracking.
+func BookStream(instId string) string {
+	return BooksChannel + ":" + instId
+}
+
+// Args returns the ticker args of instIds, with their order books if
+// orderBook is set.
+func Args(instIds []string, orderBook bool) []Arg {
+	args := make([]Arg, 0, len(instIds))
+	for _, instId := range instIds {
+		args = append(args, Arg{Channel: "tickers", InstId: instId})
+		if orderBook {
+			args = append(args, Arg{Channel: BooksChannel, InstId: instId})
+		}
+	}
+	return args
+}
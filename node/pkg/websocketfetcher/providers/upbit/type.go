@@ -41,3 +41,17 @@ type Response struct {
 	StreamType         string   `json:"st"`
 	SequentialId       *int64   `json:"sid"`
 }
+
+// OrderBook is the SIMPLE format of the orderbook type, every message is a
+// snapshot of the top of the book
+type OrderBook struct {
+	Type      string `json:"ty"`
+	Code      string `json:"cd"`
+	Timestamp int64  `json:"tms"`
+	Units     []struct {
+		AskPrice float64 `json:"ap"`
+		BidPrice float64 `json:"bp"`
+		AskSize  float64 `json:"as"`
+		BidSize  float64 `json:"bs"`
+	} `json:"obu"`
+}
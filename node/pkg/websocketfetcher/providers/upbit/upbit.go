@@ -34,12 +34,18 @@ func New(ctx context.Context, opts ...common.FetcherOption) (common.FetcherInter
 		map[string]string{"ticket": uuid.New().String()},
 		// map[string]interface{}{"type": "trade", "codes": codes, "isOnlyRealtime": true},
 		map[string]interface{}{"type": "ticker", "codes": codes, "isOnlyRealtime": true},
-		map[string]string{"format": "SIMPLE"},
 	}
+	if config.OrderBook {
+		fetcher.Books = common.NewOrderBooks(config.DepthPercent)
+		subscription = append(subscription, map[string]interface{}{"type": "orderbook", "codes": codes})
+	}
+	subscription = append(subscription, map[string]string{"format": "SIMPLE"})
+
 	ws, err := wss.NewWebsocketHelper(ctx,
 		wss.WithEndpoint(URL),
 		wss.WithSubscriptions([]interface{}{subscription}),
-		wss.WithProxyUrl(config.Proxy))
+		wss.WithProxyUrl(config.Proxy),
+		wss.WithRecording(config.Recording))
 	if err != nil {
 		log.Error().Str("Player", "Upbit").Err(err).Msg("error in upbit.New")
 		return nil, err
@@ -50,6 +56,10 @@ func New(ctx context.Context, opts ...common.FetcherOption) (common.FetcherInter
 }
 
 func (f *UpbitFetcher) handleMessage(ctx context.Context, message map[string]interface{}) error {
+	if message["ty"] == "orderbook" {
+		return f.handleOrderBook(message)
+	}
+
 	response, err := common.MessageToStruct[Response](message)
 	if err != nil {
 		log.Error().Str("Player", "Upbit").Err(err).Msg("error in upbit.handleMessage")
@@ -61,6 +71,7 @@ func (f *UpbitFetcher) handleMessage(ctx context.Context, message map[string]int
 		return err
 	}
 
+	f.Books.Attach(response.Code, feedDataList)
 	for _, feedData := range feedDataList {
 		f.FeedDataBuffer <- feedData
 	}
@@ -68,6 +79,24 @@ func (f *UpbitFetcher) handleMessage(ctx context.Context, message map[string]int
 	return nil
 }
 
+func (f *UpbitFetcher) handleOrderBook(message map[string]interface{}) error {
+	if f.Books == nil {
+		return nil
+	}
+
+	orderBook, err := common.MessageToStruct[OrderBook](message)
+	if err != nil {
+		log.Error().Str("Player", "Upbit").Err(err).Msg("error in upbit.handleOrderBook")
+		return err
+	}
+
+	// snapshots can be applied in any order as long as older ones are dropped
+	f.Books.Apply(orderBook.Code, func(book *common.OrderBook) {
+		ApplyOrderBook(book, orderBook)
+	})
+	return nil
+}
+
 func (f *UpbitFetcher) Run(ctx context.Context) {
 	f.Ws.Run(ctx, f.handleMessage)
 }
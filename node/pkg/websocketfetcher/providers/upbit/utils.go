@@ -41,3 +41,19 @@ func ResponseToFeedData(data Response, feedMap map[string][]int32) ([]*common.Fe
 
 	return result, nil
 }
+
+// ApplyOrderBook replaces book with orderBook unless it's older than the
+// book.
+func ApplyOrderBook(book *common.OrderBook, orderBook OrderBook) {
+	if orderBook.Timestamp < book.Sequence {
+		return
+	}
+
+	bids := make([]common.Level, 0, len(orderBook.Units))
+	asks := make([]common.Level, 0, len(orderBook.Units))
+	for _, unit := range orderBook.Units {
+		bids = append(bids, common.Level{Price: unit.BidPrice, Size: unit.BidSize})
+		asks = append(asks, common.Level{Price: unit.AskPrice, Size: unit.AskSize})
+	}
+	book.Snapshot(bids, asks, orderBook.Timestamp)
+}
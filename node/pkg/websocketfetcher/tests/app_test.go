@@ -0,0 +1,185 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"bisonai.com/miko/node/pkg/chain/websocketchainreader"
+	"bisonai.com/miko/node/pkg/websocketfetcher"
+	"bisonai.com/miko/node/pkg/websocketfetcher/common"
+	"github.com/stretchr/testify/assert"
+)
+
+type feedsFetcher struct {
+	running atomic.Bool
+	mu      sync.Mutex
+	symbols []string
+	updates int
+}
+
+func (f *feedsFetcher) Run(ctx context.Context) {
+	f.running.Store(true)
+	<-ctx.Done()
+	f.running.Store(false)
+}
+
+// updatableFetcher is a feedsFetcher implementing common.FeedUpdater
+type updatableFetcher struct {
+	*feedsFetcher
+}
+
+func (f *updatableFetcher) UpdateFeeds(ctx context.Context, feedMaps common.FeedMaps) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.symbols = common.FeedSymbols(feedMaps.Combined)
+	f.updates++
+	return nil
+}
+
+type feedsFactory struct {
+	updatable bool
+	mu        sync.Mutex
+	created   []*feedsFetcher
+}
+
+func (f *feedsFactory) New(ctx context.Context, opts ...common.FetcherOption) (common.FetcherInterface, error) {
+	config := &common.FetcherConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	fetcher := &feedsFetcher{symbols: common.FeedSymbols(config.FeedMaps.Combined)}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.created = append(f.created, fetcher)
+	if f.updatable {
+		return &updatableFetcher{fetcher}, nil
+	}
+	return fetcher, nil
+}
+
+func (f *feedsFactory) NewDex(opts ...common.DexFetcherOption) common.FetcherInterface {
+	config := &common.DexFetcherConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	fetcher := &feedsFetcher{}
+	for _, feed := range config.Feeds {
+		fetcher.symbols = append(fetcher.symbols, feed.Name)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.created = append(f.created, fetcher)
+	return fetcher
+}
+
+func (f *feedsFactory) latest() *feedsFetcher {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.created[len(f.created)-1]
+}
+
+func (f *feedsFactory) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.created)
+}
+
+func wssFeed(id int32, provider string, base string) common.Feed {
+	return quotedFeed(id, provider, base, "usdt")
+}
+
+func quotedFeed(id int32, provider string, base string, quote string) common.Feed {
+	return common.Feed{
+		ID:         id,
+		Name:       fmt.Sprintf("%s-wss-%s-%s", provider, strings.ToUpper(base), strings.ToUpper(quote)),
+		Definition: json.RawMessage(fmt.Sprintf(`{"type": "wss", "provider": "%s", "base": "%s", "quote": "%s"}`, provider, base, quote)),
+	}
+}
+
+func dexFeed(id int32, dex string, address string) common.Feed {
+	return common.Feed{
+		ID:         id,
+		Name:       fmt.Sprintf("%s-%s", dex, address),
+		Definition: json.RawMessage(fmt.Sprintf(`{"type": "%s", "address": "%s", "chainId": "1"}`, common.DexPoolType(dex), address)),
+	}
+}
+
+func TestApplyFeeds(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	binanceFactory := &feedsFactory{updatable: true}
+	geminiFactory := &feedsFactory{}
+	uniswapFactory := &feedsFactory{}
+	app := websocketfetcher.New()
+	err := app.Init(ctx,
+		websocketfetcher.WithSetFromDB(false),
+		websocketfetcher.WithFeeds([]common.Feed{wssFeed(1, "binance", "btc"), wssFeed(2, "gemini", "btc"), dexFeed(10, "uniswap", "0x1")}),
+		websocketfetcher.WithCexFactories(map[string]func(context.Context, ...common.FetcherOption) (common.FetcherInterface, error){
+			"binance": binanceFactory.New,
+			"gemini":  geminiFactory.New,
+		}),
+		websocketfetcher.WithDexFactories(map[string]func(...common.DexFetcherOption) common.FetcherInterface{
+			"uniswap": uniswapFactory.NewDex,
+		}),
+		websocketfetcher.WithChainReader(&websocketchainreader.ChainReader{}),
+	)
+	assert.NoError(t, err)
+	go app.Start(ctx)
+	defer app.Stop()
+
+	binanceFetcher := binanceFactory.latest()
+	geminiFetcher := geminiFactory.latest()
+	uniswapFetcher := uniswapFactory.latest()
+	assert.Eventually(t, func() bool {
+		return binanceFetcher.running.Load() && geminiFetcher.running.Load() && uniswapFetcher.running.Load()
+	}, time.Second, 10*time.Millisecond)
+
+	err = app.ApplyFeeds(ctx, []common.Feed{wssFeed(1, "binance", "btc"), wssFeed(3, "binance", "eth"), wssFeed(4, "gemini", "eth"), dexFeed(10, "uniswap", "0x1")})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, uniswapFactory.count(), "DEX fetcher with unchanged feeds is kept")
+
+	assert.Equal(t, 1, binanceFactory.count(), "updatable fetcher is kept")
+	assert.Equal(t, 1, binanceFetcher.updates)
+	assert.Equal(t, []string{"BTCUSDT", "ETHUSDT"}, binanceFetcher.symbols)
+	assert.True(t, binanceFetcher.running.Load())
+
+	assert.Equal(t, 2, geminiFactory.count(), "fetcher without UpdateFeeds is restarted")
+	assert.Equal(t, []string{"ETHUSDT"}, geminiFactory.latest().symbols)
+	assert.Eventually(t, func() bool {
+		return !geminiFetcher.running.Load() && geminiFactory.latest().running.Load()
+	}, time.Second, 10*time.Millisecond)
+
+	feedIDs := []int32{}
+	for _, status := range app.FeedStatus() {
+		feedIDs = append(feedIDs, status.FeedID)
+	}
+	assert.Equal(t, []int32{1, 3, 4}, feedIDs)
+
+	err = app.ApplyFeeds(ctx, []common.Feed{wssFeed(1, "binance", "btc"), wssFeed(3, "binance", "eth"), wssFeed(4, "gemini", "eth"), dexFeed(10, "uniswap", "0x1")})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, binanceFetcher.updates, "unchanged feeds are left alone")
+	assert.Equal(t, 2, geminiFactory.count())
+
+	err = app.ApplyFeeds(ctx, []common.Feed{wssFeed(1, "binance", "btc"), wssFeed(3, "binance", "eth"), wssFeed(4, "gemini", "eth"), dexFeed(10, "uniswap", "0x1"), dexFeed(11, "uniswap", "0x2")})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, uniswapFactory.count(), "DEX fetcher is restarted with its new feeds")
+	assert.Equal(t, []string{"uniswap-0x1", "uniswap-0x2"}, uniswapFactory.latest().symbols)
+	assert.Eventually(t, func() bool {
+		return !uniswapFetcher.running.Load() && uniswapFactory.latest().running.Load()
+	}, time.Second, 10*time.Millisecond)
+	uniswapFetcher = uniswapFactory.latest()
+
+	err = app.ApplyFeeds(ctx, []common.Feed{wssFeed(4, "gemini", "eth")})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return !binanceFetcher.running.Load() && !uniswapFetcher.running.Load()
+	}, time.Second, 10*time.Millisecond, "providers without feeds are stopped")
+	assert.Len(t, app.FeedStatus(), 1)
+}
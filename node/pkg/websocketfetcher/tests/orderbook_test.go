@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"strconv"
+	"testing"
+
+	"bisonai.com/miko/node/pkg/websocketfetcher/common"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/binance"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/bybit"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/coinbase"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/kraken"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/okx"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/upbit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderBookBinance(t *testing.T) {
+	book := common.NewOrderBook()
+	err := binance.ApplySnapshot(book, binance.DepthSnapshot{
+		LastUpdateID: 100,
+		Bids:         [][]string{{"99", "1"}},
+		Asks:         [][]string{{"101", "1"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), book.Sequence)
+
+	// older than the snapshot
+	err = binance.ApplyDepthUpdate(book, binance.DepthUpdate{Symbol: "BTCUSDT", FirstUpdateID: 90, FinalUpdateID: 95, Bids: [][]string{{"99", "0"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), book.Sequence)
+
+	err = binance.ApplyDepthUpdate(book, binance.DepthUpdate{Symbol: "BTCUSDT", FirstUpdateID: 98, FinalUpdateID: 102, Bids: [][]string{{"99.5", "2"}}})
+	assert.NoError(t, err)
+	stats, ok := book.Stats(1)
+	assert.True(t, ok)
+	assert.Equal(t, 100.25, stats.Mid)
+
+	// gaps between diffs are left to the sequence tracker
+	err = binance.ApplyDepthUpdate(book, binance.DepthUpdate{Symbol: "BTCUSDT", FirstUpdateID: 110, FinalUpdateID: 112})
+	assert.NoError(t, err)
+
+	// the book is kept at the depth of the snapshot
+	bids := make([][]string, 0, binance.DepthLimit)
+	for i := 1; i <= binance.DepthLimit; i++ {
+		bids = append(bids, []string{strconv.FormatFloat(float64(i)*0.05, 'f', -1, 64), "1"})
+	}
+	err = binance.ApplyDepthUpdate(book, binance.DepthUpdate{Symbol: "BTCUSDT", FirstUpdateID: 113, FinalUpdateID: 113, Bids: bids})
+	assert.NoError(t, err)
+	stats, ok = book.Stats(100)
+	assert.True(t, ok)
+	// the lowest new level is dropped, 99.5 has a size of 2
+	assert.Equal(t, float64(binance.DepthLimit+1), stats.BidDepth)
+	bid, _ := book.BestBid()
+	assert.Equal(t, 99.5, bid.Price)
+
+	err = binance.ApplySnapshot(book, binance.DepthSnapshot{LastUpdateID: 200, Bids: [][]string{{"99", "1"}}, Asks: [][]string{{"101", "1"}}})
+	assert.NoError(t, err)
+	err = binance.ApplyDepthUpdate(book, binance.DepthUpdate{Symbol: "BTCUSDT", FirstUpdateID: 205, FinalUpdateID: 210})
+	assert.Error(t, err, "the first diff has to overlap the snapshot")
+}
+
+func TestOrderBookOkx(t *testing.T) {
+	message := func(action string, prev int64, seq int64, bid string) okx.BookResponse {
+		response := okx.BookResponse{Arg: okx.Arg{Channel: okx.BooksChannel, InstId: "BTC-USDT"}, Action: action}
+		response.Data = append(response.Data, struct {
+			Asks      [][]string `json:"asks"`
+			Bids      [][]string `json:"bids"`
+			Timestamp string     `json:"ts"`
+			SeqID     int64      `json:"seqId"`
+			PrevSeqID int64      `json:"prevSeqId"`
+		}{Asks: [][]string{{"101", "1", "0", "1"}}, Bids: [][]string{{bid, "1", "0", "1"}}, SeqID: seq, PrevSeqID: prev})
+		return response
+	}
+
+	book := common.NewOrderBook()
+	assert.NoError(t, okx.ApplyBook(book, message("update", 1, 2, "99")))
+	assert.False(t, book.Synced(), "updates before the snapshot are dropped")
+
+	assert.NoError(t, okx.ApplyBook(book, message("snapshot", -1, 10, "99")))
+	assert.NoError(t, okx.ApplyBook(book, message("update", 10, 11, "100")))
+	bid, _ := book.BestBid()
+	assert.Equal(t, 100.0, bid.Price)
+
+	assert.NoError(t, okx.ApplyBook(book, message("snapshot", -1, 20, "98")))
+	bid, _ = book.BestBid()
+	assert.Equal(t, 98.0, bid.Price, "snapshots replace the book")
+}
+
+func TestOrderBookBybit(t *testing.T) {
+	message := func(kind string, id int64, bid string) bybit.BookResponse {
+		response := bybit.BookResponse{Topic: bybit.BookTopicPrefix + "BTCUSDT", Type: kind}
+		response.Data.Symbol = "BTCUSDT"
+		response.Data.UpdateID = id
+		response.Data.Bids = [][]string{{bid, "1"}}
+		response.Data.Asks = [][]string{{"101", "1"}}
+		return response
+	}
+
+	book := common.NewOrderBook()
+	assert.NoError(t, bybit.ApplyBook(book, message("snapshot", 5, "99")))
+	assert.NoError(t, bybit.ApplyBook(book, message("delta", 6, "100")))
+	assert.NoError(t, bybit.ApplyBook(book, message("delta", 6, "98")), "replayed delta is dropped")
+	bid, _ := book.BestBid()
+	assert.Equal(t, 100.0, bid.Price)
+
+	book.Reset()
+	assert.NoError(t, bybit.ApplyBook(book, message("delta", 8, "100")))
+	assert.False(t, book.Synced(), "deltas before the snapshot are dropped")
+}
+
+func TestOrderBookUpbit(t *testing.T) {
+	orderBook := func(timestamp int64, bid float64) upbit.OrderBook {
+		result := upbit.OrderBook{Type: "orderbook", Code: "KRW-BTC", Timestamp: timestamp}
+		result.Units = append(result.Units, struct {
+			AskPrice float64 `json:"ap"`
+			BidPrice float64 `json:"bp"`
+			AskSize  float64 `json:"as"`
+			BidSize  float64 `json:"bs"`
+		}{AskPrice: 101, BidPrice: bid, AskSize: 1, BidSize: 1})
+		return result
+	}
+
+	book := common.NewOrderBook()
+	upbit.ApplyOrderBook(book, orderBook(20, 99))
+	upbit.ApplyOrderBook(book, orderBook(10, 98))
+	bid, _ := book.BestBid()
+	assert.Equal(t, 99.0, bid.Price, "older snapshot is dropped")
+}
+
+func TestOrderBookCoinbase(t *testing.T) {
+	book := common.NewOrderBook()
+	assert.NoError(t, coinbase.ApplyLevel2(book, coinbase.Level2{Type: "l2update", Changes: [][]string{{"buy", "100", "1"}}}))
+	assert.False(t, book.Synced())
+
+	assert.NoError(t, coinbase.ApplyLevel2(book, coinbase.Level2{Type: "snapshot", Bids: [][]string{{"99", "1"}}, Asks: [][]string{{"101", "1"}}}))
+	assert.NoError(t, coinbase.ApplyLevel2(book, coinbase.Level2{Type: "l2update", Changes: [][]string{{"buy", "99", "0"}, {"buy", "98", "2"}, {"sell", "100.5", "1"}}}))
+	bid, _ := book.BestBid()
+	ask, _ := book.BestAsk()
+	assert.Equal(t, common.Level{Price: 98, Size: 2}, bid)
+	assert.Equal(t, common.Level{Price: 100.5, Size: 1}, ask)
+}
+
+func TestOrderBookKraken(t *testing.T) {
+	book := common.NewOrderBook()
+	asks := make([]kraken.BookLevel, kraken.BookDepth)
+	for i := range asks {
+		asks[i] = kraken.BookLevel{Price: 101 + float64(i), Qty: 1}
+	}
+	kraken.ApplyBook(book, "snapshot", []kraken.BookLevel{{Price: 99, Qty: 1}}, asks)
+	kraken.ApplyBook(book, "update", nil, []kraken.BookLevel{{Price: 100.5, Qty: 1}})
+
+	ask, _ := book.BestAsk()
+	assert.Equal(t, 100.5, ask.Price)
+	stats, _ := book.Stats(100)
+	assert.Equal(t, float64(kraken.BookDepth), stats.AskDepth, "book is truncated to its depth")
+}
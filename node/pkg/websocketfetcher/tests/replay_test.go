@@ -0,0 +1,195 @@
+package tests
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"bisonai.com/miko/node/pkg/websocketfetcher/common"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/binance"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/bingx"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/bitget"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/bithumb"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/bitmart"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/bitstamp"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/btse"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/bybit"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/coinbase"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/coinex"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/coinone"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/crypto"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/gateio"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/gemini"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/gopax"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/hashkey"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/huobi"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/korbit"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/kraken"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/kucoin"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/lbank"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/mexc"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/okx"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/orangex"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/upbit"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/xt"
+	"bisonai.com/miko/node/pkg/wss"
+	"github.com/stretchr/testify/assert"
+)
+
+// record captures the recordings in testdata/<provider> from the live
+// endpoints before replaying them:
+//
+//	go test ./pkg/websocketfetcher/tests -run TestReplay -record
+var record = flag.Bool("record", false, "record the replayed frames from the live endpoints")
+
+const recordTimeout = 30 * time.Second
+
+type replayCase struct {
+	provider string
+	factory  func(context.Context, ...common.FetcherOption) (common.FetcherInterface, error)
+	quote    string
+}
+
+var replayCases = []replayCase{
+	{"binance", binance.New, "usdt"},
+	{"bingx", bingx.New, "usdt"},
+	{"bitget", bitget.New, "usdt"},
+	{"bithumb", bithumb.New, "krw"},
+	{"bitmart", bitmart.New, "usdt"},
+	{"bitstamp", bitstamp.New, "usd"},
+	{"btse", btse.New, "usdt"},
+	{"bybit", bybit.New, "usdt"},
+	{"coinbase", coinbase.New, "usd"},
+	{"coinex", coinex.New, "usdt"},
+	{"coinone", coinone.New, "krw"},
+	{"crypto", crypto.New, "usdt"},
+	{"gateio", gateio.New, "usdt"},
+	{"gemini", gemini.New, "usd"},
+	{"gopax", gopax.New, "krw"},
+	{"hashkey", hashkey.New, "usdt"},
+	{"huobi", huobi.New, "usdt"},
+	{"korbit", korbit.New, "krw"},
+	{"kraken", kraken.New, "usd"},
+	{"kucoin", kucoin.New, "usdt"},
+	{"lbank", lbank.New, "usdt"},
+	{"mexc", mexc.New, "usdt"},
+	{"okx", okx.New, "usdt"},
+	{"orangex", orangex.New, "usdt"},
+	{"upbit", upbit.New, "krw"},
+	{"xt", xt.New, "usdt"},
+}
+
+func (c replayCase) feeds() []common.Feed {
+	return []common.Feed{quotedFeed(1, c.provider, "btc", c.quote), quotedFeed(2, c.provider, "eth", c.quote)}
+}
+
+// runFetcher runs the fetcher of factory with recording and returns the first
+// count feed data it produces, or what it produced until timeout.
+func runFetcher(t *testing.T, factory func(context.Context, ...common.FetcherOption) (common.FetcherInterface, error), feeds []common.Feed, provider string, recording wss.Recording, count int, timeout time.Duration) []*common.FeedData {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	buffer := make(chan *common.FeedData, 100)
+	fetcher, err := factory(ctx,
+		common.WithFeedMaps(common.GetWssFeedMap(feeds)[provider]),
+		common.WithFeedDataBuffer(buffer),
+		common.WithRecording(recording),
+	)
+	assert.NoError(t, err)
+	go fetcher.Run(ctx)
+
+	result := []*common.FeedData{}
+	deadline := time.After(timeout)
+	for len(result) < count {
+		select {
+		case feedData := <-buffer:
+			result = append(result, feedData)
+		case <-deadline:
+			return result
+		}
+	}
+	return result
+}
+
+// replayFeedData replays the recording of provider in testdata and returns
+// the first count feed data it produces. Providers without a recording are
+// skipped, unless recording.
+func replayFeedData(t *testing.T, factory func(context.Context, ...common.FetcherOption) (common.FetcherInterface, error), feeds []common.Feed, provider string, count int) []*common.FeedData {
+	dir := filepath.Join("testdata", provider)
+	if *record {
+		assert.NoError(t, os.RemoveAll(dir))
+		assert.NoError(t, os.MkdirAll(dir, 0755))
+		recorded := runFetcher(t, factory, feeds, provider, wss.Recording{RecordDir: dir}, count, recordTimeout)
+		if len(recorded) < count {
+			t.Fatalf("recorded %d feed data, expected %d", len(recorded), count)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) == 0 {
+		t.Skipf("no recording of %s, capture it with -record", provider)
+	}
+
+	result := runFetcher(t, factory, feeds, provider, wss.Recording{ReplayDir: dir, ReplaySpeed: 0}, count, 10*time.Second)
+	if len(result) < count {
+		t.Fatalf("replay produced %d feed data, expected %d", len(result), count)
+	}
+	return result
+}
+
+// recordedFrames returns the frames recorded in dir.
+func recordedFrames(t *testing.T, dir string) []wss.Frame {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	assert.NoError(t, err)
+
+	result := []wss.Frame{}
+	for _, path := range paths {
+		frames, err := wss.ReadFrames(path)
+		assert.NoError(t, err)
+		result = append(result, frames...)
+	}
+	return result
+}
+
+func TestReplay(t *testing.T) {
+	for _, c := range replayCases {
+		t.Run(c.provider, func(t *testing.T) {
+			start := time.Now()
+			result := replayFeedData(t, c.factory, c.feeds(), c.provider, 1)
+
+			var first, last time.Time
+			text, binary := "", false
+			for _, frame := range recordedFrames(t, filepath.Join("testdata", c.provider)) {
+				if first.IsZero() || frame.Time.Before(first) {
+					first = frame.Time
+				}
+				if frame.Time.After(last) {
+					last = frame.Time
+				}
+				text += frame.Text
+				binary = binary || frame.Binary != nil
+			}
+
+			for _, feedData := range result {
+				assert.Contains(t, []int32{1, 2}, feedData.FeedID)
+				assert.Greater(t, feedData.Value, 0.0)
+				if !binary {
+					// compressed frames can't be searched
+					assert.Contains(t, text, strconv.FormatFloat(feedData.Value, 'f', -1, 64), "value is parsed from the recording")
+				}
+				if !assert.NotNil(t, feedData.Timestamp) {
+					continue
+				}
+				// providers without timestamps in their payloads use the time
+				// they received the message at
+				recorded := !feedData.Timestamp.Before(first.Add(-time.Minute)) && !feedData.Timestamp.After(last.Add(time.Minute))
+				replayed := !feedData.Timestamp.Before(start)
+				assert.True(t, recorded || replayed, "timestamp %s is neither from the recording nor the replay", feedData.Timestamp)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"bisonai.com/miko/node/pkg/websocketfetcher/common"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/binance"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/bybit"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/coinbase"
+	"bisonai.com/miko/node/pkg/websocketfetcher/providers/okx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSequenceBinance(t *testing.T) {
+	fetcher, err := binance.New(context.Background(), common.WithOrderBook(1))
+	assert.NoError(t, err)
+
+	sequence, ok := fetcher.(*binance.BinanceFetcher).Sequence(map[string]any{"e": "depthUpdate", "s": "BTCUSDT", "U": 157.0, "u": 160.0})
+	assert.True(t, ok)
+	assert.Equal(t, common.Sequence{Stream: "btcusdt" + binance.DepthStream, First: 157, Last: 160, Contiguous: true}, sequence)
+
+	_, ok = fetcher.(*binance.BinanceFetcher).Sequence(map[string]any{"e": "24hrMiniTicker", "s": "BTCUSDT"})
+	assert.False(t, ok)
+}
+
+func TestSequenceOkx(t *testing.T) {
+	fetcher, err := okx.New(context.Background(), common.WithOrderBook(1))
+	assert.NoError(t, err)
+
+	sequence, ok := fetcher.(*okx.OkxFetcher).Sequence(map[string]any{
+		"arg":    map[string]any{"channel": okx.BooksChannel, "instId": "BTC-USDT"},
+		"action": "update",
+		"data":   []any{map[string]any{"seqId": 123.0, "prevSeqId": 120.0}},
+	})
+	assert.True(t, ok)
+	assert.Equal(t, common.Sequence{Stream: okx.BookStream("BTC-USDT"), First: 121, Last: 123, Contiguous: true}, sequence)
+
+	withoutBooks, err := okx.New(context.Background())
+	assert.NoError(t, err)
+	_, ok = withoutBooks.(*okx.OkxFetcher).Sequence(map[string]any{"arg": map[string]any{"channel": okx.BooksChannel}})
+	assert.False(t, ok)
+}
+
+func TestSequenceBybit(t *testing.T) {
+	fetcher, err := bybit.New(context.Background(), common.WithOrderBook(1))
+	assert.NoError(t, err)
+
+	sequence, ok := fetcher.(*bybit.BybitFetcher).Sequence(map[string]any{"topic": bybit.BookTopicPrefix + "BTCUSDT", "type": "delta", "data": map[string]any{"u": 18521288.0}})
+	assert.True(t, ok)
+	assert.Equal(t, common.Sequence{Stream: bybit.BookTopicPrefix + "BTCUSDT", First: 18521288, Last: 18521288, Contiguous: true}, sequence)
+
+	sequence, ok = fetcher.(*bybit.BybitFetcher).Sequence(map[string]any{"topic": bybit.BookTopicPrefix + "BTCUSDT", "type": "delta", "data": map[string]any{"u": 1.0}})
+	assert.True(t, ok)
+	assert.True(t, sequence.Snapshot)
+}
+
+func TestSequenceCoinbase(t *testing.T) {
+	fetcher, err := coinbase.New(context.Background())
+	assert.NoError(t, err)
+
+	sequence, ok := fetcher.(*coinbase.CoinbaseFetcher).Sequence(map[string]any{"type": "ticker", "product_id": "BTC-USD", "sequence": 37475248783.0})
+	assert.True(t, ok)
+	assert.Equal(t, common.Sequence{Stream: "ticker:BTC-USD", First: 37475248783, Last: 37475248783}, sequence)
+}
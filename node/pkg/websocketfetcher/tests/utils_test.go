@@ -691,3 +691,17 @@ func TestMessageToStruct(t *testing.T) {
 
 	})
 }
+
+func TestDiffFeedMap(t *testing.T) {
+	prev := map[string][]int32{"BTCUSDT": {1}, "ETHUSDT": {2}}
+	next := map[string][]int32{"BTCUSDT": {1}, "XRPUSDT": {3}, "ADAUSDT": {4}}
+
+	added, removed := common.DiffFeedMap(prev, next)
+	assert.Equal(t, []string{"ADAUSDT", "XRPUSDT"}, added)
+	assert.Equal(t, []string{"ETHUSDT"}, removed)
+	assert.Equal(t, []string{"ADAUSDT", "BTCUSDT", "XRPUSDT"}, common.FeedSymbols(next))
+
+	added, removed = common.DiffFeedMap(prev, prev)
+	assert.Empty(t, added)
+	assert.Empty(t, removed)
+}
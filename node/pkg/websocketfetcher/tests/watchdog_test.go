@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"bisonai.com/miko/node/pkg/common/types"
+	"bisonai.com/miko/node/pkg/websocketfetcher"
+	"bisonai.com/miko/node/pkg/websocketfetcher/common"
+	"github.com/stretchr/testify/assert"
+)
+
+type resubscribingFetcher struct {
+	resubscribed atomic.Int32
+}
+
+func (f *resubscribingFetcher) Run(ctx context.Context) {}
+
+func (f *resubscribingFetcher) ResubscribeFeed(ctx context.Context, feedID int32) error {
+	f.resubscribed.Store(feedID)
+	return nil
+}
+
+type plainFetcher struct{}
+
+func (f *plainFetcher) Run(ctx context.Context) {}
+
+func TestWatchdog(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	latestFeedDataMap := &types.LatestFeedDataMap{FeedDataMap: map[int32]*types.FeedData{}}
+	watchdog := websocketfetcher.NewWatchdog(
+		latestFeedDataMap,
+		websocketfetcher.WithWatchdogInterval(10*time.Millisecond),
+		websocketfetcher.WithStaleAfter(200*time.Millisecond, 300*time.Millisecond),
+		websocketfetcher.WithResubscribeInterval(time.Hour),
+	)
+	resubscribing := &resubscribingFetcher{}
+	watchdog.Watch("binance", resubscribing, []int32{1, 2})
+	watchdog.Watch("gemini", &plainFetcher{}, []int32{3})
+	go watchdog.Run(ctx)
+
+	// feed 1 keeps updating, 2 and 3 go silent
+	deadline := time.Now().Add(600 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		watchdog.Observe([]*common.FeedData{{FeedID: 1}})
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	assert.False(t, latestFeedDataMap.IsStale(1))
+	assert.True(t, latestFeedDataMap.IsStale(2))
+	assert.True(t, latestFeedDataMap.IsStale(3))
+	assert.Equal(t, int32(2), resubscribing.resubscribed.Load())
+
+	status := watchdog.Status()
+	assert.Len(t, status, 3)
+	assert.Equal(t, int32(2), status[0].FeedID, "stale feeds are listed first")
+	assert.True(t, status[0].Stale)
+	assert.Equal(t, 1, status[0].Resubscribes)
+	assert.True(t, status[0].Resubscribable)
+	assert.Equal(t, int32(3), status[1].FeedID)
+	assert.False(t, status[1].Resubscribable)
+	assert.Equal(t, 0, status[1].Resubscribes)
+	assert.False(t, status[2].Stale)
+	assert.Equal(t, int64(200), status[2].StaleAfterMs, "busy feeds use the min stale duration")
+
+	watchdog.Observe([]*common.FeedData{{FeedID: 2}})
+	assert.False(t, latestFeedDataMap.IsStale(2), "feed updating again is fresh")
+
+	watchdog.Clear()
+	assert.False(t, latestFeedDataMap.IsStale(3))
+}
@@ -0,0 +1,318 @@
+package websocketfetcher
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"bisonai.com/miko/node/pkg/common/types"
+	"bisonai.com/miko/node/pkg/websocketfetcher/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultStaleFactor is how many of its usual intervals a feed may go
+	// without updates before it's stale
+	DefaultStaleFactor = 10
+	// DefaultMinStaleAfter keeps busy feeds from turning stale on short pauses
+	DefaultMinStaleAfter = 30 * time.Second
+	// DefaultMaxStaleAfter applies to feeds without a known cadence yet
+	DefaultMaxStaleAfter = 5 * time.Minute
+	// DefaultResubscribeInterval is the least time between resubscriptions
+	// of the same stale feed
+	DefaultResubscribeInterval = time.Minute
+	DefaultWatchdogInterval    = 5 * time.Second
+
+	// cadenceWeight is the weight of the latest interval in the cadence
+	cadenceWeight = 0.2
+)
+
+var (
+	watchdogStaleFeeds = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "websocketfetcher_stale_feeds",
+		Help: "Current number of websocket feeds marked stale by the watchdog",
+	}, []string{"provider"})
+	watchdogResubscriptionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "websocketfetcher_watchdog_resubscriptions_total",
+		Help: "Total number of resubscriptions of stale feeds, by result",
+	}, []string{"provider", "result"})
+)
+
+type WatchdogConfig struct {
+	StaleFactor         float64
+	MinStaleAfter       time.Duration
+	MaxStaleAfter       time.Duration
+	ResubscribeInterval time.Duration
+	Interval            time.Duration
+}
+
+type WatchdogOption func(*WatchdogConfig)
+
+func WithStaleFactor(factor float64) WatchdogOption {
+	return func(c *WatchdogConfig) {
+		c.StaleFactor = factor
+	}
+}
+
+func WithStaleAfter(min time.Duration, max time.Duration) WatchdogOption {
+	return func(c *WatchdogConfig) {
+		c.MinStaleAfter = min
+		c.MaxStaleAfter = max
+	}
+}
+
+func WithResubscribeInterval(interval time.Duration) WatchdogOption {
+	return func(c *WatchdogConfig) {
+		c.ResubscribeInterval = interval
+	}
+}
+
+func WithWatchdogInterval(interval time.Duration) WatchdogOption {
+	return func(c *WatchdogConfig) {
+		c.Interval = interval
+	}
+}
+
+// FeedStatus is the state of a feed as the watchdog sees it.
+type FeedStatus struct {
+	FeedID         int32      `json:"feedId"`
+	Provider       string     `json:"provider"`
+	LastUpdate     time.Time  `json:"lastUpdate"`
+	CadenceMs      int64      `json:"cadenceMs"`
+	StaleAfterMs   int64      `json:"staleAfterMs"`
+	Stale          bool       `json:"stale"`
+	StaleSince     *time.Time `json:"staleSince,omitempty"`
+	Resubscribes   int        `json:"resubscribes"`
+	Resubscribable bool       `json:"resubscribable"`
+}
+
+type watchedFeed struct {
+	provider        string
+	lastUpdate      time.Time
+	cadence         time.Duration
+	staleSince      *time.Time
+	lastResubscribe time.Time
+	resubscribes    int
+}
+
+// Watchdog tracks when each websocket feed last updated against its usual
+// cadence. Feeds that stop updating while their connection is still busy are
+// marked stale in the latest feed data map, keeping them out of the local
+// aggregates, and the owning fetcher is asked to resubscribe them.
+type Watchdog struct {
+	config            WatchdogConfig
+	feeds             map[int32]*watchedFeed
+	fetchers          map[string]common.FetcherInterface
+	latestFeedDataMap *types.LatestFeedDataMap
+	mu                sync.Mutex
+}
+
+func NewWatchdog(latestFeedDataMap *types.LatestFeedDataMap, opts ...WatchdogOption) *Watchdog {
+	config := WatchdogConfig{
+		StaleFactor:         DefaultStaleFactor,
+		MinStaleAfter:       DefaultMinStaleAfter,
+		MaxStaleAfter:       DefaultMaxStaleAfter,
+		ResubscribeInterval: DefaultResubscribeInterval,
+		Interval:            DefaultWatchdogInterval,
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return &Watchdog{
+		config:            config,
+		feeds:             map[int32]*watchedFeed{},
+		fetchers:          map[string]common.FetcherInterface{},
+		latestFeedDataMap: latestFeedDataMap,
+	}
+}
+
+// Watch starts tracking feedIDs of provider, served by fetcher. Feeds already
+// watched keep their cadence and stale state.
+func (w *Watchdog) Watch(provider string, fetcher common.FetcherInterface, feedIDs []int32) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.fetchers[provider] = fetcher
+	now := time.Now()
+	for _, feedID := range feedIDs {
+		if feed, ok := w.feeds[feedID]; ok && feed.provider == provider {
+			continue
+		}
+		w.unwatch(feedID)
+		w.feeds[feedID] = &watchedFeed{provider: provider, lastUpdate: now}
+	}
+}
+
+// Unwatch stops tracking feedIDs, unmarking the stale ones.
+func (w *Watchdog) Unwatch(feedIDs []int32) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, feedID := range feedIDs {
+		w.unwatch(feedID)
+	}
+}
+
+func (w *Watchdog) unwatch(feedID int32) {
+	feed, ok := w.feeds[feedID]
+	if !ok {
+		return
+	}
+	if feed.staleSince != nil {
+		watchdogStaleFeeds.WithLabelValues(feed.provider).Dec()
+		w.setStale(feedID, false)
+	}
+	delete(w.feeds, feedID)
+}
+
+// Observe records the updates in batch, feeds that update again are fresh.
+func (w *Watchdog) Observe(batch []*common.FeedData) {
+	w.observe(batch, time.Now())
+}
+
+func (w *Watchdog) observe(batch []*common.FeedData, now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, feedData := range batch {
+		if feedData == nil {
+			continue
+		}
+		feed, ok := w.feeds[feedData.FeedID]
+		if !ok || !now.After(feed.lastUpdate) {
+			continue
+		}
+
+		interval := now.Sub(feed.lastUpdate)
+		if feed.cadence == 0 {
+			feed.cadence = interval
+		} else {
+			feed.cadence = time.Duration(cadenceWeight*float64(interval) + (1-cadenceWeight)*float64(feed.cadence))
+		}
+		feed.lastUpdate = now
+
+		if feed.staleSince != nil {
+			log.Info().Str("Player", "Watchdog").Str("provider", feed.provider).Int32("feedID", feedData.FeedID).Msg("feed updating again")
+			feed.staleSince = nil
+			watchdogStaleFeeds.WithLabelValues(feed.provider).Dec()
+			w.setStale(feedData.FeedID, false)
+		}
+	}
+}
+
+func (w *Watchdog) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.check(ctx, time.Now())
+		}
+	}
+}
+
+type resubscription struct {
+	feedID   int32
+	provider string
+	fetcher  common.FeedResubscriber
+}
+
+func (w *Watchdog) check(ctx context.Context, now time.Time) {
+	resubscriptions := []resubscription{}
+
+	w.mu.Lock()
+	for feedID, feed := range w.feeds {
+		if now.Sub(feed.lastUpdate) <= w.staleAfter(feed) {
+			continue
+		}
+
+		if feed.staleSince == nil {
+			log.Warn().Str("Player", "Watchdog").Str("provider", feed.provider).Int32("feedID", feedID).Time("lastUpdate", feed.lastUpdate).Msg("feed stopped updating, marking stale")
+			staleSince := now
+			feed.staleSince = &staleSince
+			watchdogStaleFeeds.WithLabelValues(feed.provider).Inc()
+			w.setStale(feedID, true)
+		}
+
+		resubscriber, ok := w.fetchers[feed.provider].(common.FeedResubscriber)
+		if !ok || now.Sub(feed.lastResubscribe) < w.config.ResubscribeInterval {
+			continue
+		}
+		feed.lastResubscribe = now
+		feed.resubscribes++
+		resubscriptions = append(resubscriptions, resubscription{feedID: feedID, provider: feed.provider, fetcher: resubscriber})
+	}
+	w.mu.Unlock()
+
+	for _, r := range resubscriptions {
+		err := r.fetcher.ResubscribeFeed(ctx, r.feedID)
+		if err != nil {
+			log.Warn().Str("Player", "Watchdog").Err(err).Str("provider", r.provider).Int32("feedID", r.feedID).Msg("failed to resubscribe stale feed")
+			watchdogResubscriptionsTotal.WithLabelValues(r.provider, "failed").Inc()
+			continue
+		}
+		watchdogResubscriptionsTotal.WithLabelValues(r.provider, "ok").Inc()
+	}
+}
+
+// staleAfter is StaleFactor times the cadence of feed, within the min and
+// max stale durations.
+func (w *Watchdog) staleAfter(feed *watchedFeed) time.Duration {
+	if feed.cadence == 0 {
+		return w.config.MaxStaleAfter
+	}
+	staleAfter := time.Duration(w.config.StaleFactor * float64(feed.cadence))
+	return min(max(staleAfter, w.config.MinStaleAfter), w.config.MaxStaleAfter)
+}
+
+// Clear unmarks the stale feeds, for a watchdog being replaced.
+func (w *Watchdog) Clear() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for feedID, feed := range w.feeds {
+		if feed.staleSince == nil {
+			continue
+		}
+		feed.staleSince = nil
+		watchdogStaleFeeds.WithLabelValues(feed.provider).Dec()
+		w.setStale(feedID, false)
+	}
+}
+
+func (w *Watchdog) setStale(feedID int32, stale bool) {
+	if w.latestFeedDataMap != nil {
+		w.latestFeedDataMap.SetStale(feedID, stale)
+	}
+}
+
+// Status returns the state of every watched feed, stale feeds first.
+func (w *Watchdog) Status() []FeedStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	result := make([]FeedStatus, 0, len(w.feeds))
+	for feedID, feed := range w.feeds {
+		_, resubscribable := w.fetchers[feed.provider].(common.FeedResubscriber)
+		result = append(result, FeedStatus{
+			FeedID:         feedID,
+			Provider:       feed.provider,
+			LastUpdate:     feed.lastUpdate,
+			CadenceMs:      feed.cadence.Milliseconds(),
+			StaleAfterMs:   w.staleAfter(feed).Milliseconds(),
+			Stale:          feed.staleSince != nil,
+			StaleSince:     feed.staleSince,
+			Resubscribes:   feed.resubscribes,
+			Resubscribable: resubscribable,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Stale != result[j].Stale {
+			return result[i].Stale
+		}
+		return result[i].FeedID < result[j].FeedID
+	})
+	return result
+}
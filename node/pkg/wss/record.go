@@ -0,0 +1,317 @@
+package wss
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"nhooyr.io/websocket"
+)
+
+const DefaultReplaySpeed = 1.0
+
+var (
+	nonFileNameChars = regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
+
+	// recorders shares the recorder of a file between the helpers writing to it
+	recorders   = map[string]*Recorder{}
+	recordersMu sync.Mutex
+)
+
+// Frame is a message received from a websocket, as recorded. Text frames are
+// kept readable, binary ones (e.g. gzipped) are base64 encoded.
+type Frame struct {
+	Time   time.Time `json:"time"`
+	Text   string    `json:"text,omitempty"`
+	Binary []byte    `json:"binary,omitempty"`
+}
+
+func (f Frame) message() (websocket.MessageType, []byte) {
+	if f.Binary != nil {
+		return websocket.MessageBinary, f.Binary
+	}
+	return websocket.MessageText, []byte(f.Text)
+}
+
+// Recorder appends frames to a file, one json object per line.
+type Recorder struct {
+	file    *os.File
+	encoder *json.Encoder
+	mu      sync.Mutex
+}
+
+func NewRecorder(path string) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{file: file, encoder: json.NewEncoder(file)}, nil
+}
+
+func openRecorder(path string) (*Recorder, error) {
+	recordersMu.Lock()
+	defer recordersMu.Unlock()
+	if recorder, ok := recorders[path]; ok {
+		return recorder, nil
+	}
+	recorder, err := NewRecorder(path)
+	if err != nil {
+		return nil, err
+	}
+	recorders[path] = recorder
+	return recorder, nil
+}
+
+func (r *Recorder) Record(messageType websocket.MessageType, data []byte) error {
+	frame := Frame{Time: time.Now()}
+	if messageType == websocket.MessageBinary {
+		frame.Binary = data
+	} else {
+		frame.Text = string(data)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.encoder.Encode(frame)
+}
+
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// ReadFrames loads a recording made by Recorder.
+func ReadFrames(path string) ([]Frame, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	frames := []Frame{}
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	for decoder.More() {
+		var frame Frame
+		if err := decoder.Decode(&frame); err != nil {
+			return nil, err
+		}
+		frames = append(frames, frame)
+	}
+	return frames, nil
+}
+
+// Recording records the frames of a helper to, or replays them from, a file
+// per endpoint in a directory, see RecordingFile and WithRecording.
+type Recording struct {
+	RecordDir string
+	// ReplayDir replays the recordings in the directory instead of dialing
+	// the endpoints that have one
+	ReplayDir string
+	// ReplaySpeed scales the replay speed, 0 replays without delays
+	ReplaySpeed float64
+}
+
+// RecordingFile is the file in dir holding the recording of endpoint.
+func RecordingFile(dir string, endpoint string) string {
+	name := endpoint
+	if parsed, err := url.Parse(endpoint); err == nil && parsed.Host != "" {
+		name = parsed.Host + parsed.Path
+	}
+	return filepath.Join(dir, nonFileNameChars.ReplaceAllString(name, "_")+".jsonl")
+}
+
+// RecordingDialFunc dials with dialFunc and relays the connection through a
+// local websocket server that records every frame received from the endpoint.
+// The connection returned is the local one, so custom readers see the frames
+// exactly as the endpoint sent them.
+func RecordingDialFunc(dialFunc func(context.Context, string, *websocket.DialOptions) (*websocket.Conn, *http.Response, error), recorder *Recorder) func(context.Context, string, *websocket.DialOptions) (*websocket.Conn, *http.Response, error) {
+	return func(ctx context.Context, endpoint string, opts *websocket.DialOptions) (*websocket.Conn, *http.Response, error) {
+		upstream, resp, err := dialFunc(ctx, endpoint, opts)
+		if err != nil {
+			return nil, resp, err
+		}
+		// the helper applies its read limit to the local connection
+		upstream.SetReadLimit(-1)
+
+		conn, err := dialLocal(ctx, func(ctx context.Context, client *websocket.Conn) {
+			relay(ctx, upstream, client, recorder)
+		})
+		if err != nil {
+			upstream.Close(websocket.StatusNormalClosure, "")
+			return nil, resp, err
+		}
+		return conn, resp, nil
+	}
+}
+
+type ReplayConfig struct {
+	Speed float64
+}
+
+type ReplayOption func(*ReplayConfig)
+
+// WithReplaySpeed scales the delays between frames by 1/speed, 0 sends the
+// frames without delays.
+func WithReplaySpeed(speed float64) ReplayOption {
+	return func(c *ReplayConfig) {
+		c.Speed = speed
+	}
+}
+
+// ReplayDialFunc serves the frames recorded in path from a local websocket
+// server, for WithCustomDialFunc. Every connection replays the recording from
+// the start, messages sent by the client are ignored.
+func ReplayDialFunc(path string, opts ...ReplayOption) (func(context.Context, string, *websocket.DialOptions) (*websocket.Conn, *http.Response, error), error) {
+	config := &ReplayConfig{Speed: DefaultReplaySpeed}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	frames, err := ReadFrames(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, endpoint string, _ *websocket.DialOptions) (*websocket.Conn, *http.Response, error) {
+		conn, err := dialLocal(ctx, func(ctx context.Context, client *websocket.Conn) {
+			replay(ctx, client, frames, config.Speed)
+		})
+		return conn, nil, err
+	}, nil
+}
+
+func replay(ctx context.Context, client *websocket.Conn, frames []Frame, speed float64) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer client.Close(websocket.StatusNormalClosure, "")
+
+	// drains the subscriptions, until the client closes
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := client.Read(ctx); err != nil {
+				return
+			}
+		}
+	}()
+
+	for i, frame := range frames {
+		if speed > 0 && i > 0 {
+			delay := time.Duration(float64(frame.Time.Sub(frames[i-1].Time)) / speed)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+		}
+		messageType, data := frame.message()
+		if err := client.Write(ctx, messageType, data); err != nil {
+			return
+		}
+	}
+	// keeps the connection open so the recording isn't replayed again
+	<-ctx.Done()
+}
+
+func relay(ctx context.Context, upstream *websocket.Conn, client *websocket.Conn, recorder *Recorder) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer upstream.Close(websocket.StatusNormalClosure, "")
+	defer client.Close(websocket.StatusNormalClosure, "")
+
+	go func() {
+		defer cancel()
+		for {
+			messageType, data, err := client.Read(ctx)
+			if err != nil {
+				return
+			}
+			if err := upstream.Write(ctx, messageType, data); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		messageType, data, err := upstream.Read(ctx)
+		if err != nil {
+			return
+		}
+		if err := recorder.Record(messageType, data); err != nil {
+			log.Warn().Err(err).Msg("error recording websocket frame")
+		}
+		if err := client.Write(ctx, messageType, data); err != nil {
+			return
+		}
+	}
+}
+
+// dialLocal serves a single websocket connection on a loopback port with
+// handle and dials it.
+func dialLocal(ctx context.Context, handle func(context.Context, *websocket.Conn)) (*websocket.Conn, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	var once sync.Once
+	server := &http.Server{
+		ReadHeaderTimeout: 10 * time.Second,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			accepted := false
+			once.Do(func() { accepted = true })
+			if !accepted {
+				http.Error(w, "already connected", http.StatusConflict)
+				return
+			}
+			listener.Close()
+
+			conn, err := websocket.Accept(w, r, nil)
+			if err != nil {
+				log.Warn().Err(err).Msg("error accepting local websocket connection")
+				return
+			}
+			conn.SetReadLimit(-1)
+			handle(context.Background(), conn)
+		}),
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, net.ErrClosed) && !errors.Is(err, http.ErrServerClosed) {
+			log.Warn().Err(err).Msg("error serving local websocket")
+		}
+	}()
+
+	conn, _, err := websocket.Dial(ctx, "ws://"+listener.Addr().String(), nil)
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// replayDialFunc returns the replay dial func of endpoint, nil if there's no
+// recording of it in recording.ReplayDir.
+func replayDialFunc(recording Recording, endpoint string) (func(context.Context, string, *websocket.DialOptions) (*websocket.Conn, *http.Response, error), error) {
+	if recording.ReplayDir == "" {
+		return nil, nil
+	}
+
+	path := RecordingFile(recording.ReplayDir, endpoint)
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		log.Warn().Str("endpoint", endpoint).Str("path", path).Msg("no websocket recording, dialing endpoint")
+		return nil, nil
+	}
+	return ReplayDialFunc(path, WithReplaySpeed(recording.ReplaySpeed))
+}
@@ -0,0 +1,152 @@
+//nolint:all
+package wss
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"nhooyr.io/websocket"
+)
+
+// frameReader decodes text and binary frames alike, like the providers with
+// compressed streams do
+func frameReader(ctx context.Context, conn *websocket.Conn) (map[string]interface{}, error) {
+	_, data, err := conn.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]interface{}
+	err = json.Unmarshal(data, &result)
+	return result, err
+}
+
+// collect runs ws until count messages were routed
+func collect(t *testing.T, ws *WebsocketHelper, count int) []float64 {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	result := []float64{}
+	go ws.Run(ctx, func(ctx context.Context, data map[string]any) error {
+		mu.Lock()
+		defer mu.Unlock()
+		result = append(result, data["n"].(float64))
+		return nil
+	})
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(result) >= count
+	}, 10*time.Second, 10*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	return result
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	subscribed := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close(websocket.StatusNormalClosure, "")
+
+		_, subscription, err := conn.Read(r.Context())
+		if err != nil {
+			return
+		}
+		subscribed <- string(subscription)
+		conn.Write(r.Context(), websocket.MessageText, []byte(`{"n":1}`))
+		conn.Write(r.Context(), websocket.MessageBinary, []byte(`{"n":2}`))
+		conn.Write(r.Context(), websocket.MessageText, []byte(`{"n":3}`))
+		conn.Read(r.Context())
+	}))
+	defer server.Close()
+	wsURL := "ws" + server.URL[len("http"):] + "/ws"
+	path := filepath.Join(t.TempDir(), "recording.jsonl")
+
+	recording, err := NewWebsocketHelper(context.Background(),
+		WithEndpoint(wsURL),
+		WithSubscriptions([]any{map[string]any{"op": "subscribe"}}),
+		WithCustomReadFunc(frameReader),
+		WithSequentialRouting(),
+		WithRecordFile(path),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1, 2, 3}, collect(t, recording, 3))
+	assert.JSONEq(t, `{"op":"subscribe"}`, <-subscribed, "subscriptions are relayed to the endpoint")
+
+	frames, err := ReadFrames(path)
+	assert.NoError(t, err)
+	assert.Len(t, frames, 3)
+	assert.Equal(t, `{"n":1}`, frames[0].Text)
+	assert.Equal(t, []byte(`{"n":2}`), frames[1].Binary)
+	assert.False(t, frames[2].Time.Before(frames[0].Time))
+
+	dialFunc, err := ReplayDialFunc(path, WithReplaySpeed(0))
+	assert.NoError(t, err)
+	replaying, err := NewWebsocketHelper(context.Background(),
+		WithEndpoint("ws://replay"),
+		WithSubscriptions([]any{map[string]any{"op": "subscribe"}}),
+		WithCustomReadFunc(frameReader),
+		WithSequentialRouting(),
+		WithCustomDialFunc(dialFunc),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1, 2, 3}, collect(t, replaying, 3))
+}
+
+func TestReplaySpeed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recording.jsonl")
+	recorder, err := NewRecorder(path)
+	assert.NoError(t, err)
+	assert.NoError(t, recorder.Record(websocket.MessageText, []byte(`{"n":1}`)))
+	time.Sleep(400 * time.Millisecond)
+	assert.NoError(t, recorder.Record(websocket.MessageText, []byte(`{"n":2}`)))
+	assert.NoError(t, recorder.Close())
+
+	dialFunc, err := ReplayDialFunc(path, WithReplaySpeed(4))
+	assert.NoError(t, err)
+	conn, _, err := dialFunc(context.Background(), "ws://replay", nil)
+	assert.NoError(t, err)
+	defer conn.Close(websocket.StatusNormalClosure, "")
+
+	_, _, err = conn.Read(context.Background())
+	assert.NoError(t, err)
+	start := time.Now()
+	_, data, err := conn.Read(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, `{"n":2}`, string(data))
+	assert.InDelta(t, 100*time.Millisecond, time.Since(start), float64(80*time.Millisecond), "delays are scaled by the speed")
+}
+
+func TestReplayFromDir(t *testing.T) {
+	dir := t.TempDir()
+	endpoint := "wss://stream.example.com:443/ws"
+	assert.Equal(t, filepath.Join(dir, "stream.example.com_443_ws.jsonl"), RecordingFile(dir, endpoint))
+
+	ws, err := NewWebsocketHelper(context.Background(), WithEndpoint(endpoint), WithRecording(Recording{ReplayDir: dir}))
+	assert.NoError(t, err)
+	assert.Nil(t, ws.CustomDialFunc, "endpoint without recording is dialed")
+
+	recorder, err := NewRecorder(RecordingFile(dir, endpoint))
+	assert.NoError(t, err)
+	assert.NoError(t, recorder.Close())
+	ws, err = NewWebsocketHelper(context.Background(), WithEndpoint(endpoint), WithRecording(Recording{ReplayDir: dir}))
+	assert.NoError(t, err)
+	assert.NotNil(t, ws.CustomDialFunc)
+
+	ws, err = NewWebsocketHelper(context.Background(), WithEndpoint(endpoint))
+	assert.NoError(t, err)
+	assert.Nil(t, ws.CustomDialFunc, "only replays when asked to")
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -23,7 +24,12 @@ type WebsocketHelper struct {
 	ReadLimit         int64
 	ReconnectInterval time.Duration
 	InactivityTimeout time.Duration
+	SequentialRouting bool
 	lastMessageTime   time.Time
+	recorder          *Recorder
+	reconnect         chan struct{}
+	// mu guards Subscriptions once Run started
+	mu sync.Mutex
 }
 
 type ConnectionConfig struct {
@@ -37,6 +43,9 @@ type ConnectionConfig struct {
 	ReadLimit         int64
 	ReconnectInterval time.Duration
 	InactivityTimeout time.Duration
+	SequentialRouting bool
+	RecordFile        string
+	Recording         Recording
 }
 
 type ConnectionOption func(*ConnectionConfig)
@@ -107,6 +116,31 @@ func WithInactivityTimeout(duration time.Duration) ConnectionOption {
 	}
 }
 
+// WithSequentialRouting routes messages one at a time in the order they were
+// read, for streams like order book diffs that can't be applied out of order.
+func WithSequentialRouting() ConnectionOption {
+	return func(c *ConnectionConfig) {
+		c.SequentialRouting = true
+	}
+}
+
+// WithRecordFile appends every frame received to path, to be served later
+// with ReplayDialFunc.
+func WithRecordFile(path string) ConnectionOption {
+	return func(c *ConnectionConfig) {
+		c.RecordFile = path
+	}
+}
+
+// WithRecording records the frames of the helper to recording.RecordDir, or
+// replays them from recording.ReplayDir when it holds a recording of the
+// endpoint.
+func WithRecording(recording Recording) ConnectionOption {
+	return func(c *ConnectionConfig) {
+		c.Recording = recording
+	}
+}
+
 func NewWebsocketHelper(ctx context.Context, opts ...ConnectionOption) (*WebsocketHelper, error) {
 	config := &ConnectionConfig{
 		ReconnectInterval: DefaultReconnectInterval,
@@ -125,6 +159,19 @@ func NewWebsocketHelper(ctx context.Context, opts ...ConnectionOption) (*Websock
 		log.Warn().Msg("no subscriptions provided")
 	}
 
+	if dir := config.Recording.RecordDir; dir != "" && config.RecordFile == "" {
+		config.RecordFile = RecordingFile(dir, config.Endpoint)
+	}
+
+	dialFunc, err := replayDialFunc(config.Recording, config.Endpoint)
+	if err != nil {
+		log.Error().Err(err).Str("endpoint", config.Endpoint).Msg("error loading websocket replay")
+		return nil, err
+	}
+	if dialFunc != nil {
+		config.DialFunc = dialFunc
+	}
+
 	ws := &WebsocketHelper{
 		Endpoint:          config.Endpoint,
 		Subscriptions:     config.Subscriptions,
@@ -133,6 +180,8 @@ func NewWebsocketHelper(ctx context.Context, opts ...ConnectionOption) (*Websock
 		RequestHeaders:    config.RequestHeaders,
 		ReconnectInterval: config.ReconnectInterval,
 		InactivityTimeout: config.InactivityTimeout,
+		SequentialRouting: config.SequentialRouting,
+		reconnect:         make(chan struct{}, 1),
 	}
 
 	if config.DialFunc != nil {
@@ -147,5 +196,14 @@ func NewWebsocketHelper(ctx context.Context, opts ...ConnectionOption) (*Websock
 		ws.ReadLimit = config.ReadLimit
 	}
 
+	if config.RecordFile != "" {
+		recorder, err := openRecorder(config.RecordFile)
+		if err != nil {
+			log.Error().Err(err).Str("endpoint", config.Endpoint).Msg("error opening websocket recording")
+			return nil, err
+		}
+		ws.recorder = recorder
+	}
+
 	return ws, nil
 }
@@ -52,6 +52,9 @@ func (ws *WebsocketHelper) Dial(ctx context.Context) error {
 	if ws.CustomDialFunc != nil {
 		dialFunc = *ws.CustomDialFunc
 	}
+	if ws.recorder != nil {
+		dialFunc = RecordingDialFunc(dialFunc, ws.recorder)
+	}
 	conn, _, err := dialFunc(ctx, ws.Endpoint, dialOption)
 	if err != nil {
 		log.Warn().Err(err).Str("endpoint", ws.Endpoint).Msg("error opening websocket connection")
@@ -103,6 +106,9 @@ func (ws *WebsocketHelper) Run(ctx context.Context, router func(context.Context,
 			case <-reconnectTicker.C:
 				log.Info().Str("endpoint", ws.Endpoint).Msg("reconnect interval exceeded during read, closing websocket")
 				break innerLoop
+			case <-ws.reconnect:
+				log.Info().Str("endpoint", ws.Endpoint).Msg("reconnect requested, closing websocket")
+				break innerLoop
 			case <-inactivityTimer.C:
 				if time.Since(ws.lastMessageTime) > ws.InactivityTimeout {
 					log.Info().Str("endpoint", ws.Endpoint).Msg("inactivity timeout exceeded, closing websocket")
@@ -121,7 +127,11 @@ func (ws *WebsocketHelper) Run(ctx context.Context, router func(context.Context,
 
 				ws.lastMessageTime = time.Now()
 
-				if len(data) != 0 {
+				if len(data) != 0 && ws.SequentialRouting {
+					if routerErr := router(ctx, data); routerErr != nil {
+						log.Warn().Err(routerErr).Str("endpoint", ws.Endpoint).Msg("error processing websocket message")
+					}
+				} else if len(data) != 0 {
 					go func(context.Context, map[string]any) {
 						routerErr := router(ctx, data)
 						if routerErr != nil {
@@ -135,13 +145,33 @@ func (ws *WebsocketHelper) Run(ctx context.Context, router func(context.Context,
 	}
 }
 
+// SetSubscriptions replaces the subscriptions sent on the next connection,
+// for fetchers that changed their streams on the current one.
+func (ws *WebsocketHelper) SetSubscriptions(subscriptions []any) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.Subscriptions = subscriptions
+}
+
+// Reconnect makes Run close the connection and dial again once the message
+// being routed is done, for streams that can't be recovered otherwise.
+func (ws *WebsocketHelper) Reconnect() {
+	select {
+	case ws.reconnect <- struct{}{}:
+	default:
+	}
+}
+
 func (ws *WebsocketHelper) dialAndSubscribe(ctx context.Context) error {
 	dialJob := func() error {
 		return ws.Dial(ctx)
 	}
 
 	subscribeJob := func() error {
-		for _, subscription := range ws.Subscriptions {
+		ws.mu.Lock()
+		subscriptions := ws.Subscriptions
+		ws.mu.Unlock()
+		for _, subscription := range subscriptions {
 			switch casted := subscription.(type) {
 			case []byte:
 				if err := ws.RawWrite(ctx, string(casted)); err != nil {
@@ -174,6 +204,10 @@ func (ws *WebsocketHelper) dialAndSubscribe(ctx context.Context) error {
 }
 
 func (ws *WebsocketHelper) Write(ctx context.Context, message interface{}) error {
+	if ws.Conn == nil {
+		return errors.New("websocket is not running")
+	}
+
 	err := wsjson.Write(ctx, ws.Conn, message)
 	if err != nil {
 		return err
@@ -5,6 +5,7 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -130,3 +131,32 @@ func TestInactivityTimer(t *testing.T) {
 	err = conn.Close()
 	assert.NoError(t, err)
 }
+
+func TestReconnect(t *testing.T) {
+	var connections atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close(websocket.StatusNormalClosure, "")
+		connections.Add(1)
+		_ = wsjson.Write(r.Context(), conn, map[string]any{"n": 1})
+		var v interface{}
+		_ = wsjson.Read(r.Context(), conn, &v)
+	}))
+	defer server.Close()
+	wsURL := "ws" + server.URL[len("http"):] + "/ws"
+
+	conn, err := NewWebsocketHelper(context.Background(), WithEndpoint(wsURL), WithSequentialRouting())
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go conn.Run(ctx, func(ctx context.Context, data map[string]any) error {
+		conn.Reconnect()
+		return nil
+	})
+
+	assert.Eventually(t, func() bool { return connections.Load() >= 2 }, 10*time.Second, 100*time.Millisecond)
+}